@@ -0,0 +1,66 @@
+// auditは、Todoの変更を追記専用の監査ログとして記録するパッケージ
+package audit
+
+import (
+	"backend/app/model"
+	"backend/app/requestctx"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	ActionRevert Action = "revert"
+)
+
+// Recordは、Todoの変更を監査ログに記録する。
+// 変更と同じトランザクションで記録することで、変更と監査ログの不整合を防ぐ。
+// 実行者、ワークスペース、リクエストIDはcontextから取得する。
+func Record(ctx context.Context, tx *sql.Tx, action Action, todoID int, before, after *model.Todo) error {
+	beforeJSON, err := marshalTodo(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalTodo(after)
+	if err != nil {
+		return err
+	}
+
+	// 匿名ユーザーの場合はNULLとして記録する
+	var actorID any
+	if id := requestctx.UserID(ctx); id != 0 {
+		actorID = id
+	}
+
+	query := "INSERT INTO audit_logs (workspace_id, actor_id, action, todo_id, before_json, after_json, request_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err = tx.Exec(
+		query,
+		requestctx.WorkspaceID(ctx),
+		actorID,
+		string(action),
+		todoID,
+		beforeJSON,
+		afterJSON,
+		requestctx.RequestID(ctx),
+		time.Now(),
+	)
+	return err
+}
+
+// 変更前後のTodoをJSON文字列に変換する。存在しない場合はNULLとして記録する。
+func marshalTodo(todo *model.Todo) (any, error) {
+	if todo == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(todo)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
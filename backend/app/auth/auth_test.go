@@ -0,0 +1,50 @@
+package auth_test
+
+import (
+	"backend/app/auth"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignerVerify(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	signer := auth.NewSigner([]byte("secret"))
+	signer.Now = func() time.Time { return now }
+	token := signer.Issue(7, time.Hour)
+
+	other := auth.NewSigner([]byte("other-secret"))
+	other.Now = signer.Now
+
+	cases := map[string]struct {
+		token      string
+		signer     *auth.Signer
+		after      time.Duration
+		wantUserID int
+		wantErr    error
+	}{
+		"正常系":        {token: token, signer: signer, wantUserID: 7},
+		"有効期限の直前":    {token: token, signer: signer, after: time.Hour - time.Second, wantUserID: 7},
+		"有効期限切れ":     {token: token, signer: signer, after: time.Hour, wantErr: auth.ErrExpiredToken},
+		"別の鍵で署名":     {token: token, signer: other, wantErr: auth.ErrInvalidToken},
+		"ユーザーIDを改ざん": {token: "8" + strings.TrimPrefix(token, "7"), signer: signer, wantErr: auth.ErrInvalidToken},
+		"形式が不正":      {token: "abc", signer: signer, wantErr: auth.ErrInvalidToken},
+		"空文字":        {token: "", signer: signer, wantErr: auth.ErrInvalidToken},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			c.signer.Now = func() time.Time { return now.Add(c.after) }
+			defer func() { c.signer.Now = func() time.Time { return now } }()
+
+			userID, err := c.signer.Verify(c.token)
+			if !errors.Is(err, c.wantErr) {
+				t.Fatalf("期待したエラー: %v, 実際のエラー: %v", c.wantErr, err)
+			}
+			if userID != c.wantUserID {
+				t.Errorf("期待したユーザーID: %d, 実際のユーザーID: %d", c.wantUserID, userID)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Package blobは、添付ファイルなどの内容を保存するストレージを扱う。
+//
+// 内容はSHA-256のダイジェストをキーとして保存するため、同じ内容のファイルは1つの保存先を共有する。
+// 保存先を参照しているレコードの管理は利用側で行い、参照がなくなった内容のみ削除すること。
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+)
+
+// ErrNotFoundは、指定したキーの内容が存在しない場合に返す
+var ErrNotFound = errors.New("blob: not found")
+
+// ErrInvalidKeyは、キーがSHA-256のダイジェストの形式でない場合に返す
+var ErrInvalidKey = errors.New("blob: invalid key")
+
+// キーの形式。SHA-256のダイジェストを小文字の16進数で表したもの
+var keyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Storeは、内容をダイジェストで識別して保存するストレージ
+type Store interface {
+	// Stageは、rの内容を最後まで読み込んで一時的に保存する。内容はStaged.Commitを呼ぶまで参照できない。
+	// 読み込みに失敗した場合は何も保存しない
+	Stage(ctx context.Context, r io.Reader) (Staged, error)
+	// Openは、キーを指定して内容を開く。存在しない場合はErrNotFoundを返す
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Deleteは、キーを指定して内容を削除する。存在しない場合は何もしない
+	Delete(ctx context.Context, key string) error
+}
+
+// Stagedは、一時的に保存した内容。
+// 参照を数えて削除する処理と並行しても内容が消えないよう、参照するレコードを登録してからCommitすること
+type Staged interface {
+	// Keyは、内容のキーを返す
+	Key() string
+	// Sizeは、内容のサイズを返す
+	Size() int64
+	// Commitは、内容をキーで参照できるようにする。同じ内容がすでにある場合は置き換える
+	Commit(ctx context.Context) error
+	// Discardは、一時的に保存した内容を削除する。Commitした後は何もしない
+	Discard() error
+}
+
+// ValidKeyは、キーがSHA-256のダイジェストの形式かどうかを返す
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
+
+var defaultStore Store
+
+// Defaultは、アプリケーション全体で使用するストレージを返す
+func Default() Store {
+	return defaultStore
+}
+
+// SetDefaultは、アプリケーション全体で使用するストレージを設定する
+func SetDefault(s Store) {
+	defaultStore = s
+}
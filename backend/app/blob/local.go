@@ -0,0 +1,153 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LocalStoreは、ローカルディスクのディレクトリに内容を保存するストレージ。
+// 内容はDir/<キーの先頭2文字>/<キー>に保存し、書き込み中のファイルはDir/tmpに置く
+type LocalStore struct {
+	Dir string
+}
+
+// NewLocalStoreは、ディレクトリを作成してLocalStoreを返す
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "tmp"), 0o755); err != nil {
+		return nil, fmt.Errorf("blob: create directory: %w", err)
+	}
+	return &LocalStore{Dir: dir}, nil
+}
+
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.Dir, key[:2], key)
+}
+
+// Stageは、一時ファイルに書き込みながらダイジェストを求める
+func (s *LocalStore) Stage(ctx context.Context, r io.Reader) (Staged, error) {
+	tmp, err := os.CreateTemp(filepath.Join(s.Dir, "tmp"), "upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("blob: create temp file: %w", err)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	return &localStaged{store: s, tmp: tmp.Name(), key: hex.EncodeToString(hash.Sum(nil)), size: size}, nil
+}
+
+// Putは、内容を保存してすぐに参照できるようにし、キーとサイズを返す。
+// 参照するレコードを登録する前に内容が見えるため、参照を数えて削除する処理と並行する場合はStageを使うこと
+func (s *LocalStore) Put(ctx context.Context, r io.Reader) (string, int64, error) {
+	staged, err := s.Stage(ctx, r)
+	if err != nil {
+		return "", 0, err
+	}
+	// Commitに成功した後のDiscardは何もしない
+	defer staged.Discard()
+
+	if err := staged.Commit(ctx); err != nil {
+		return "", 0, err
+	}
+	return staged.Key(), staged.Size(), nil
+}
+
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("blob: open: %w", err)
+	}
+	return f, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+
+	err := os.Remove(s.path(key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("blob: delete: %w", err)
+	}
+	return nil
+}
+
+// localStagedは、LocalStoreのDir/tmpに書き込んだ内容
+type localStaged struct {
+	store *LocalStore
+	tmp   string
+	key   string
+	size  int64
+}
+
+func (s *localStaged) Key() string {
+	return s.key
+}
+
+func (s *localStaged) Size() int64 {
+	return s.size
+}
+
+// Commitは、一時ファイルを所定の場所に移動する。
+// 同じ内容がすでにある場合も移動で置き換え、直前に削除された場合でも内容が残るようにする
+func (s *localStaged) Commit(ctx context.Context) error {
+	if s.tmp == "" {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	dst := s.store.path(s.key)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("blob: create directory: %w", err)
+	}
+	if err := os.Rename(s.tmp, dst); err != nil {
+		return fmt.Errorf("blob: rename: %w", err)
+	}
+	s.tmp = ""
+	return nil
+}
+
+func (s *localStaged) Discard() error {
+	if s.tmp == "" {
+		return nil
+	}
+
+	err := os.Remove(s.tmp)
+	s.tmp = ""
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("blob: discard: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package blob_test
+
+import (
+	"backend/app/blob"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := blob.NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("ストレージの作成に失敗しました: %s", err)
+	}
+
+	// "hello"のSHA-256
+	const wantKey = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	key, size, err := store.Put(ctx, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("保存に失敗しました: %s", err)
+	}
+	if key != wantKey || size != 5 {
+		t.Errorf("want: %s (5), got: %s (%d)", wantKey, key, size)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2c", wantKey)); err != nil {
+		t.Errorf("キーの先頭2文字のディレクトリに保存されていません: %s", err)
+	}
+
+	// 同じ内容は同じキーで保存され、一時ファイルは残らない
+	again, _, err := store.Put(ctx, strings.NewReader("hello"))
+	if err != nil || again != key {
+		t.Errorf("同じ内容のキーが一致しません: %s, %v", again, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "tmp")); len(entries) != 0 {
+		t.Errorf("一時ファイルが残っています: %v", entries)
+	}
+
+	f, err := store.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %s", err)
+	}
+	if _, err := f.Seek(1, io.SeekStart); err != nil {
+		t.Fatalf("シークに失敗しました: %s", err)
+	}
+	body, _ := io.ReadAll(f)
+	f.Close()
+	if string(body) != "ello" {
+		t.Errorf("want: ello, got: %s", body)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("削除に失敗しました: %s", err)
+	}
+	if _, err := store.Open(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("削除後はErrNotFoundを返すこと: %v", err)
+	}
+	// 存在しない内容の削除はエラーにしない
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("存在しない内容の削除に失敗しました: %s", err)
+	}
+}
+
+// 読み込みに失敗した場合は何も保存しないことを確認する
+func TestLocalStorePutReadError(t *testing.T) {
+	dir := t.TempDir()
+	store, err := blob.NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("ストレージの作成に失敗しました: %s", err)
+	}
+
+	readErr := errors.New("too large")
+	_, _, err = store.Put(context.Background(), io.MultiReader(strings.NewReader("partial"), &errReader{readErr}))
+	if !errors.Is(err, readErr) {
+		t.Fatalf("読み込みのエラーを返すこと: %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "tmp" {
+		t.Errorf("内容が保存されています: %v", entries)
+	}
+	if tmp, _ := os.ReadDir(filepath.Join(dir, "tmp")); len(tmp) != 0 {
+		t.Errorf("一時ファイルが残っています: %v", tmp)
+	}
+}
+
+// 一時的に保存した内容は、Commitするまで参照できず、Discardすると残らないことを確認する
+func TestLocalStoreStage(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := blob.NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("ストレージの作成に失敗しました: %s", err)
+	}
+
+	key, _, err := store.Put(ctx, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("保存に失敗しました: %s", err)
+	}
+
+	// 同じ内容を一時的に保存している間に既存の内容が削除されても、Commitで配置し直される
+	staged, err := store.Stage(ctx, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("一時的な保存に失敗しました: %s", err)
+	}
+	if staged.Key() != key || staged.Size() != 5 {
+		t.Errorf("want: %s (5), got: %s (%d)", key, staged.Key(), staged.Size())
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("削除に失敗しました: %s", err)
+	}
+	if _, err := store.Open(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Commitするまで参照できないこと: %v", err)
+	}
+	if err := staged.Commit(ctx); err != nil {
+		t.Fatalf("Commitに失敗しました: %s", err)
+	}
+	if err := staged.Discard(); err != nil {
+		t.Errorf("Commit後のDiscardは何もしないこと: %s", err)
+	}
+	f, err := store.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Commit後に参照できません: %s", err)
+	}
+	f.Close()
+
+	discarded, err := store.Stage(ctx, strings.NewReader("discarded"))
+	if err != nil {
+		t.Fatalf("一時的な保存に失敗しました: %s", err)
+	}
+	if err := discarded.Discard(); err != nil {
+		t.Fatalf("Discardに失敗しました: %s", err)
+	}
+	if _, err := store.Open(ctx, discarded.Key()); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Discardした内容を参照できます: %v", err)
+	}
+	if tmp, _ := os.ReadDir(filepath.Join(dir, "tmp")); len(tmp) != 0 {
+		t.Errorf("一時ファイルが残っています: %v", tmp)
+	}
+}
+
+// キーの形式でない場合は、ディレクトリの外を参照しないことを確認する
+func TestLocalStoreInvalidKey(t *testing.T) {
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("ストレージの作成に失敗しました: %s", err)
+	}
+
+	for _, key := range []string{"", "../../etc/passwd", strings.Repeat("A", 64)} {
+		if _, err := store.Open(context.Background(), key); !errors.Is(err, blob.ErrInvalidKey) {
+			t.Errorf("%q: want: ErrInvalidKey, got: %v", key, err)
+		}
+		if err := store.Delete(context.Background(), key); !errors.Is(err, blob.ErrInvalidKey) {
+			t.Errorf("%q: want: ErrInvalidKey, got: %v", key, err)
+		}
+	}
+}
+
+type errReader struct{ err error }
+
+func (r *errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,51 @@
+// burndownは、Todoの変更の記録からマイルストーンの残りの見積もりを日ごとに求めるパッケージ
+package burndown
+
+import (
+	"backend/app/model"
+	"sort"
+	"time"
+)
+
+// Snapshotは、ある時点で記録したTodoの状態。Todoがnilの場合は、その時点で削除されたことを表す
+type Snapshot struct {
+	TodoID int
+	At     time.Time
+	Todo   *model.Todo
+}
+
+// Computeは、startからendまでの各日の終わり（UTC）時点で、マイルストーンに属するTodoの見積もりを集計する。
+// 各Todoはその日の終わりより前の最後の記録の状態とし、見積もりのないTodoは0ポイントとして扱う
+func Compute(milestoneID int, start, end time.Time, snapshots []Snapshot) []model.BurndownPoint {
+	sorted := make([]Snapshot, len(snapshots))
+	copy(sorted, snapshots)
+	// 同じ時刻の記録は、渡された順序（リビジョンの順）を保つ
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At.Before(sorted[j].At) })
+
+	points := []model.BurndownPoint{}
+	states := map[int]*model.Todo{}
+	next := 0
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		cutoff := day.AddDate(0, 0, 1)
+		for ; next < len(sorted) && sorted[next].At.Before(cutoff); next++ {
+			states[sorted[next].TodoID] = sorted[next].Todo
+		}
+
+		point := model.BurndownPoint{Date: day.Format(time.DateOnly)}
+		for _, todo := range states {
+			if todo == nil || todo.MilestoneID == nil || *todo.MilestoneID != milestoneID {
+				continue
+			}
+			estimate := 0
+			if todo.Estimate != nil {
+				estimate = *todo.Estimate
+			}
+			point.TotalPoints += estimate
+			if !todo.IsComplete {
+				point.RemainingPoints += estimate
+			}
+		}
+		points = append(points, point)
+	}
+	return points
+}
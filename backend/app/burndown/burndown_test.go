@@ -0,0 +1,58 @@
+package burndown_test
+
+import (
+	"backend/app/burndown"
+	"backend/app/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestCompute(t *testing.T) {
+	day := func(d, hour int) time.Time {
+		return time.Date(2024, 5, d, hour, 0, 0, 0, time.UTC)
+	}
+	todo := func(estimate int, milestoneID int, complete bool) *model.Todo {
+		return &model.Todo{Estimate: intPtr(estimate), MilestoneID: intPtr(milestoneID), IsComplete: complete}
+	}
+
+	snapshots := []burndown.Snapshot{
+		// 1: 開始前に5ポイントで追加し、2日に完了
+		{TodoID: 1, At: day(1, 0).Add(-time.Hour), Todo: todo(5, 7, false)},
+		{TodoID: 1, At: day(2, 15), Todo: todo(5, 7, true)},
+		// 2: 1日に3ポイントで追加し、同じ日に8ポイントに見積もり直す。3日に別のマイルストーンへ移す
+		{TodoID: 2, At: day(1, 9), Todo: todo(3, 7, false)},
+		{TodoID: 2, At: day(1, 10), Todo: todo(8, 7, false)},
+		{TodoID: 2, At: day(3, 9), Todo: todo(8, 9, false)},
+		// 3: 2日に見積もりなしで追加し、4日に削除
+		{TodoID: 3, At: day(2, 9), Todo: &model.Todo{MilestoneID: intPtr(7)}},
+		{TodoID: 3, At: day(4, 9)},
+		// 4: 4日に2ポイントで追加。日の終わりちょうどの記録は翌日に含める
+		{TodoID: 4, At: day(5, 0), Todo: todo(2, 7, false)},
+	}
+
+	got := burndown.Compute(7, day(1, 0), day(5, 0), snapshots)
+	want := []model.BurndownPoint{
+		{Date: "2024-05-01", RemainingPoints: 13, TotalPoints: 13},
+		{Date: "2024-05-02", RemainingPoints: 8, TotalPoints: 13},
+		{Date: "2024-05-03", RemainingPoints: 0, TotalPoints: 5},
+		{Date: "2024-05-04", RemainingPoints: 0, TotalPoints: 5},
+		{Date: "2024-05-05", RemainingPoints: 2, TotalPoints: 7},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+}
+
+// 終了日が開始日より前の場合は、空の系列を返すことを確認する
+func TestComputeEmptyRange(t *testing.T) {
+	start := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	got := burndown.Compute(7, start, start.AddDate(0, 0, -1), nil)
+	if len(got) != 0 {
+		t.Errorf("want: [], got: %+v", got)
+	}
+}
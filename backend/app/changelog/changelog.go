@@ -0,0 +1,70 @@
+// changelogは、差分同期のためにTodoの変更を連番付きで記録するパッケージ。
+// 削除したTodoは削除済みの記録（トゥームストーン）として残し、同期時にクライアントへ伝える。
+//
+// 連番はワークスペースごとにchange_sequencesの行を更新して採番する。行ロックはコミットまで保持されるため、
+// 連番の順序はコミットの順序と一致し、小さい連番の変更が後からコミットされて同期で読み飛ばされることはない。
+package changelog
+
+import (
+	"backend/app/requestctx"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 変更トークンの形式のバージョン。形式を変えた場合は古いトークンを受け付けない。
+// v1はtodo_changesのidを、v2はコミット順の連番（seq）を表す
+const tokenVersion = "v2"
+
+var ErrInvalidToken = errors.New("invalid change token")
+
+// Recordは、Todoの変更を記録する。deletedがtrueの場合はトゥームストーンとして記録する。
+// Todoの変更と同じトランザクションで呼び出すこと。
+func Record(ctx context.Context, tx *sql.Tx, todoID int, deleted bool) error {
+	workspaceID := requestctx.WorkspaceID(ctx)
+
+	// LAST_INSERT_IDに採番した値を設定し、結果から受け取る
+	seqQuery := "INSERT INTO change_sequences (workspace_id, seq) VALUES (?, LAST_INSERT_ID(1)) ON DUPLICATE KEY UPDATE seq = LAST_INSERT_ID(seq + 1)"
+	result, err := tx.Exec(seqQuery, workspaceID)
+	if err != nil {
+		return err
+	}
+	seq, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO todo_changes (workspace_id, seq, todo_id, deleted, changed_at) VALUES (?, ?, ?, ?, ?)"
+	_, err = tx.Exec(query, workspaceID, seq, todoID, deleted, time.Now())
+	return err
+}
+
+// EncodeTokenは、変更の連番をクライアントに渡す不透明なトークンに変換する
+func EncodeToken(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(tokenVersion + "." + strconv.FormatInt(seq, 10)))
+}
+
+// DecodeTokenは、トークンを変更の連番に戻す。空のトークンは最初からの同期として0を返す。
+func DecodeToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	version, seqStr, ok := strings.Cut(string(b), ".")
+	if !ok || version != tokenVersion {
+		return 0, ErrInvalidToken
+	}
+	seq, err := strconv.ParseInt(seqStr, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, ErrInvalidToken
+	}
+	return seq, nil
+}
@@ -0,0 +1,84 @@
+package changelog_test
+
+import (
+	"backend/app/changelog"
+	"backend/app/requestctx"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 変更はワークスペースごとに採番した連番で記録されることを確認する
+func TestRecord(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("モックDBの作成に失敗しました: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`^INSERT INTO change_sequences \(workspace_id, seq\) VALUES \(\?, LAST_INSERT_ID\(1\)\) ON DUPLICATE KEY UPDATE seq = LAST_INSERT_ID\(seq \+ 1\)$`).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(42, 2))
+	mock.ExpectExec(`^INSERT INTO todo_changes \(workspace_id, seq, todo_id, deleted, changed_at\) VALUES \(\?, \?, \?, \?, \?\)$`).
+		WithArgs(3, 42, 7, true, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := requestctx.WithWorkspaceID(context.Background(), 3)
+	if err := changelog.Record(ctx, tx, 7, true); err != nil {
+		t.Fatalf("記録に失敗しました: %s", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+func TestToken(t *testing.T) {
+	for _, seq := range []int64{0, 1, 1 << 40} {
+		got, err := changelog.DecodeToken(changelog.EncodeToken(seq))
+		if err != nil {
+			t.Fatalf("トークンの復元に失敗しました: %s", err)
+		}
+		if got != seq {
+			t.Errorf("期待した連番: %d, 実際: %d", seq, got)
+		}
+	}
+
+	if got, err := changelog.DecodeToken(""); err != nil || got != 0 {
+		t.Errorf("空のトークンは最初からの同期として扱う: %d, %v", got, err)
+	}
+}
+
+func TestDecodeTokenInvalid(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	cases := map[string]string{
+		"base64ではない": "!!!",
+		"区切りがない":     encode("v2"),
+		"バージョンが異なる":  encode("v0.10"),
+		"旧形式のトークン":   encode("v1.10"),
+		"連番が数値ではない":  encode("v2.abc"),
+		"連番が負":       encode("v2.-1"),
+	}
+
+	for name, token := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := changelog.DecodeToken(token); err != changelog.ErrInvalidToken {
+				t.Errorf("期待したエラー: %v, 実際: %v", changelog.ErrInvalidToken, err)
+			}
+		})
+	}
+}
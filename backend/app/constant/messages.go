@@ -2,8 +2,17 @@ package constant
 
 // 入力関連のエラーメッセージ
 const (
-	INPUT_ERR_INVALID_INPUT = "入力が不正です。"
-	INPUT_ERR_INVALID_ID    = "IDが不正です。"
+	INPUT_ERR_INVALID_INPUT         = "入力が不正です。"
+	INPUT_ERR_INVALID_ID            = "IDが不正です。"
+	INPUT_ERR_INVALID_EXPIRES       = "有効期限が不正です。"
+	INPUT_ERR_INVALID_RENDER        = "renderの指定が不正です。"
+	INPUT_ERR_INVALID_INCLUDE       = "includeの指定が不正です。"
+	INPUT_ERR_INVALID_ASSIGNEE      = "assigneeにはmeかユーザーIDを指定してください。"
+	INPUT_ERR_INVALID_ALLOW_BLOCKED = "allow_blockedの指定が不正です。"
+	INPUT_ERR_INVALID_DATE_RANGE    = "期間はfromとtoにYYYY-MM-DD形式で、fromからtoまで366日以内で指定してください。"
+	INPUT_ERR_INVALID_GROUP_BY      = "group_byにはlist、tag、dayのいずれかを指定してください。"
+	INPUT_ERR_INVALID_FORMAT        = "formatにはjsonかcsvを指定してください。"
+	INPUT_ERR_INVALID_DAYS          = "daysには1から366までの整数を指定してください。"
 )
 
 // DB操作関連のエラーメッセージ
@@ -16,4 +25,231 @@ const (
 	DB_ERR_NOT_UPDATED_TODO    = "更新したTODOがありません。"
 	DB_ERR_FAILED_DELETE_TODO  = "TODOの削除に失敗しました。"
 	DB_ERR_DELETED_TODO        = "指定のTODOは削除済みです。"
+	DB_ERR_CONFLICT_TODO       = "TODOは他の操作によって更新されています。最新の状態を取得してください。"
+)
+
+// リスト関連のエラーメッセージ
+const (
+	LIST_ERR_NOT_FOUND_LIST  = "リストが見つかりません。"
+	LIST_ERR_FAILED_GET_LIST = "リストの取得に失敗しました。"
+)
+
+// 共有リンク関連のエラーメッセージ
+const (
+	SHARE_ERR_NOT_LIST_OWNER        = "共有リンクはリストの所有者のみ操作できます。"
+	SHARE_ERR_FAILED_ADD_LINK       = "共有リンクの作成に失敗しました。"
+	SHARE_ERR_FAILED_REVOKE_LINK    = "共有リンクの無効化に失敗しました。"
+	SHARE_ERR_NOT_FOUND_LINK        = "共有リンクが見つかりません。"
+	SHARE_ERR_EXPIRED_LINK          = "共有リンクの有効期限が切れています。"
+	SHARE_ERR_FAILED_GENERATE_TOKEN = "共有トークンの生成に失敗しました。"
+)
+
+// ワークスペース関連のエラーメッセージ
+const (
+	WORKSPACE_ERR_INVALID_WORKSPACE    = "ワークスペースの指定が不正です。"
+	WORKSPACE_ERR_NOT_FOUND_WORKSPACE  = "ワークスペースが見つかりません。"
+	WORKSPACE_ERR_FAILED_GET_WORKSPACE = "ワークスペースの取得に失敗しました。"
+	WORKSPACE_ERR_NOT_MEMBER           = "ワークスペースへのアクセス権がありません。"
+)
+
+// ユーザー関連のエラーメッセージ
+const (
+	USER_ERR_INVALID_TOKEN  = "認証トークンが不正です。"
+	USER_ERR_UNAUTHORIZED   = "ユーザーの認証が必要です。"
+	USER_ERR_INVALID_ORIGIN = "許可されていないオリジンです。"
+)
+
+// 監査ログ関連のエラーメッセージ
+const (
+	AUDIT_ERR_FAILED_GET_LOG     = "監査ログの取得に失敗しました。"
+	AUDIT_ERR_FAILED_GET_LOG_ROW = "監査ログの読み込みに失敗しました。"
+)
+
+// 変更履歴関連のエラーメッセージ
+const (
+	HISTORY_ERR_INVALID_REVISION   = "リビジョンの指定が不正です。"
+	HISTORY_ERR_NOT_FOUND_REVISION = "指定のリビジョンが見つかりません。"
+	HISTORY_ERR_FAILED_GET_HISTORY = "変更履歴の取得に失敗しました。"
+	HISTORY_ERR_FAILED_REVERT_TODO = "TODOを元に戻せませんでした。"
+)
+
+// イベント配信関連のエラーメッセージ
+const (
+	EVENT_ERR_INVALID_LAST_EVENT_ID = "Last-Event-IDが不正です。"
+)
+
+// WebSocket関連のエラーメッセージ
+const (
+	WS_ERR_BAD_HANDSHAKE   = "WebSocketのハンドシェイクが不正です。"
+	WS_ERR_INVALID_MESSAGE = "メッセージの形式が不正です。"
+	WS_ERR_UNKNOWN_TYPE    = "不明なメッセージの種類です。"
+	WS_ERR_NOT_SUBSCRIBED  = "購読していないリストです。"
+)
+
+// Webhook関連のエラーメッセージ
+const (
+	WEBHOOK_ERR_FAILED_GET_WEBHOOK    = "Webhookの取得に失敗しました。"
+	WEBHOOK_ERR_FAILED_ADD_WEBHOOK    = "Webhookの追加に失敗しました。"
+	WEBHOOK_ERR_FAILED_DELETE_WEBHOOK = "Webhookの削除に失敗しました。"
+	WEBHOOK_ERR_NOT_FOUND_WEBHOOK     = "Webhookが見つかりません。"
+	WEBHOOK_ERR_FAILED_GET_DELIVERY   = "Webhookの配信履歴の取得に失敗しました。"
+	WEBHOOK_ERR_FORBIDDEN_URL         = "URLに内部のアドレスは指定できません。"
+	WEBHOOK_ERR_UNRESOLVABLE_URL      = "URLのホストを解決できません。"
+)
+
+// 同期関連のエラーメッセージ
+const (
+	SYNC_ERR_INVALID_TOKEN     = "変更トークンが不正です。"
+	SYNC_ERR_FAILED_GET_CHANGE = "変更の取得に失敗しました。"
+	SYNC_ERR_FAILED_SYNC       = "変更の同期に失敗しました。"
+)
+
+// 繰り返し関連のエラーメッセージ
+const (
+	RECURRENCE_ERR_INVALID_RULE      = "繰り返しのルールが不正です。"
+	RECURRENCE_ERR_INVALID_START     = "開始日時が不正です。"
+	RECURRENCE_ERR_INVALID_TIME_ZONE = "タイムゾーンが不正です。"
+	RECURRENCE_ERR_INVALID_COUNT     = "件数は1から100の範囲で指定してください。"
+)
+
+// リマインダー関連のエラーメッセージ
+const (
+	REMINDER_ERR_FAILED_GET_REMINDER    = "リマインダーの取得に失敗しました。"
+	REMINDER_ERR_FAILED_ADD_REMINDER    = "リマインダーの追加に失敗しました。"
+	REMINDER_ERR_FAILED_DELETE_REMINDER = "リマインダーの削除に失敗しました。"
+	REMINDER_ERR_NOT_FOUND_REMINDER     = "リマインダーが見つかりません。"
+	REMINDER_ERR_UNVERIFIED_EMAIL       = "メールで通知するには、確認済みのメールアドレスが必要です。"
+	REMINDER_ERR_FORBIDDEN_TARGET       = "メールの宛先には、自分の確認済みのメールアドレスのみ指定できます。"
+)
+
+// ダイジェスト関連のエラーメッセージ
+const (
+	DIGEST_ERR_FAILED_GET_PREFERENCE    = "ダイジェストの配信設定の取得に失敗しました。"
+	DIGEST_ERR_FAILED_UPDATE_PREFERENCE = "ダイジェストの配信設定の更新に失敗しました。"
+	DIGEST_ERR_UNVERIFIED_EMAIL         = "ダイジェストを配信するには、確認済みのメールアドレスが必要です。"
+	DIGEST_ERR_FORBIDDEN_EMAIL          = "配信先には、自分の確認済みのメールアドレスのみ指定できます。"
+)
+
+// 検索関連のエラーメッセージ
+const (
+	SEARCH_ERR_INVALID_QUERY = "検索語は100文字以内、10語以内で入力してください。"
+	SEARCH_ERR_INVALID_LIMIT = "件数は1から100の範囲で指定してください。"
+	SEARCH_ERR_FAILED_SEARCH = "TODOの検索に失敗しました。"
+)
+
+// フィルター関連のエラーメッセージ
+const (
+	FILTER_ERR_INVALID_FILTER    = "フィルターが不正です。"
+	FILTER_ERR_INVALID_TIME_ZONE = "フィルターのタイムゾーンが不正です。"
+)
+
+// スマートリスト関連のエラーメッセージ
+const (
+	SMART_LIST_ERR_FAILED_GET_LIST    = "スマートリストの取得に失敗しました。"
+	SMART_LIST_ERR_FAILED_ADD_LIST    = "スマートリストの追加に失敗しました。"
+	SMART_LIST_ERR_FAILED_DELETE_LIST = "スマートリストの削除に失敗しました。"
+	SMART_LIST_ERR_NOT_FOUND_LIST     = "スマートリストが見つかりません。"
+	SMART_LIST_ERR_DUPLICATE_NAME     = "同じ名前のスマートリストがあります。"
+)
+
+// タグ関連のエラーメッセージ
+const (
+	TAG_ERR_FAILED_GET_TAG    = "タグの取得に失敗しました。"
+	TAG_ERR_FAILED_UPDATE_TAG = "タグの更新に失敗しました。"
+)
+
+// チェックリスト関連のエラーメッセージ
+const (
+	CHECKLIST_ERR_FAILED_GET_CHECKLIST = "チェックリストの取得に失敗しました。"
+	CHECKLIST_ERR_FAILED_ADD_ITEM      = "チェックリストの項目の追加に失敗しました。"
+	CHECKLIST_ERR_FAILED_UPDATE_ITEM   = "チェックリストの項目の更新に失敗しました。"
+	CHECKLIST_ERR_FAILED_DELETE_ITEM   = "チェックリストの項目の削除に失敗しました。"
+	CHECKLIST_ERR_FAILED_REORDER       = "チェックリストの並べ替えに失敗しました。"
+	CHECKLIST_ERR_NOT_FOUND_ITEM       = "チェックリストの項目が見つかりません。"
+	CHECKLIST_ERR_TOO_MANY_ITEMS       = "チェックリストの項目は100件までです。"
+	CHECKLIST_ERR_INVALID_ORDER        = "並べ替えにはすべての項目のIDを1回ずつ指定してください。"
+)
+
+// 添付ファイル関連のエラーメッセージ
+const (
+	ATTACHMENT_ERR_FAILED_GET_ATTACHMENT    = "添付ファイルの取得に失敗しました。"
+	ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT    = "添付ファイルの追加に失敗しました。"
+	ATTACHMENT_ERR_FAILED_DELETE_ATTACHMENT = "添付ファイルの削除に失敗しました。"
+	ATTACHMENT_ERR_NOT_FOUND_ATTACHMENT     = "添付ファイルが見つかりません。"
+	ATTACHMENT_ERR_INVALID_FORM             = "ファイルをfileという名前のフォームの項目で送信してください。"
+	ATTACHMENT_ERR_UNSUPPORTED_TYPE         = "添付できないファイルの形式です。"
+	ATTACHMENT_ERR_TOO_LARGE                = "添付ファイルが大きすぎます。"
+	ATTACHMENT_ERR_QUOTA_EXCEEDED           = "ワークスペースの添付ファイルの容量を超えています。"
+	ATTACHMENT_ERR_TOO_MANY_ATTACHMENTS     = "1つのTODOに添付できるファイルは20件までです。"
+	ATTACHMENT_ERR_EMPTY_FILE               = "空のファイルは添付できません。"
+	ATTACHMENT_ERR_NOT_FOUND_THUMBNAIL      = "縮小画像が見つかりません。"
+)
+
+// コメント関連のエラーメッセージ
+const (
+	COMMENT_ERR_FAILED_GET_COMMENT    = "コメントの取得に失敗しました。"
+	COMMENT_ERR_FAILED_ADD_COMMENT    = "コメントの追加に失敗しました。"
+	COMMENT_ERR_FAILED_UPDATE_COMMENT = "コメントの更新に失敗しました。"
+	COMMENT_ERR_FAILED_DELETE_COMMENT = "コメントの削除に失敗しました。"
+	COMMENT_ERR_NOT_FOUND_COMMENT     = "コメントが見つかりません。"
+	COMMENT_ERR_NOT_AUTHOR            = "コメントを編集・削除できるのは投稿したユーザーのみです。"
+	COMMENT_ERR_FAILED_GET_ACTIVITY   = "アクティビティの取得に失敗しました。"
+)
+
+// 担当者関連のエラーメッセージ
+const (
+	ASSIGNEE_ERR_FAILED_GET_ASSIGNEE    = "担当者の取得に失敗しました。"
+	ASSIGNEE_ERR_FAILED_UPDATE_ASSIGNEE = "担当者の更新に失敗しました。"
+	ASSIGNEE_ERR_NOT_MEMBER             = "ワークスペースのメンバーではないユーザーは担当者にできません（ユーザーID: %s）。"
+)
+
+// 依存関係のエラーメッセージ
+const (
+	DEPENDENCY_ERR_FAILED_GET_DEPENDENCY    = "依存関係の取得に失敗しました。"
+	DEPENDENCY_ERR_FAILED_UPDATE_DEPENDENCY = "依存関係の更新に失敗しました。"
+	DEPENDENCY_ERR_NOT_FOUND_BLOCKER        = "存在しないTODOには依存できません（ID: %s）。"
+	DEPENDENCY_ERR_CYCLE                    = "依存関係が循環するため設定できません（%s）。"
+	DEPENDENCY_ERR_BLOCKED                  = "未完了のTODOに依存しているため完了できません（ID: %s）。"
+	DEPENDENCY_ERR_FAILED_GET_CRITICAL_PATH = "クリティカルパスの取得に失敗しました。"
+)
+
+// ワークフロー関連のエラーメッセージ
+const (
+	WORKFLOW_ERR_FAILED_GET_WORKFLOW    = "ワークフローの取得に失敗しました。"
+	WORKFLOW_ERR_FAILED_UPDATE_WORKFLOW = "ワークフローの更新に失敗しました。"
+	WORKFLOW_ERR_STATUS_IN_USE          = "TODOが使用しているステータスは削除できません（%s）。"
+	WORKFLOW_ERR_DONE_IN_USE            = "TODOが使用しているステータスの完了の扱いは変更できません（%s）。"
+	WORKFLOW_ERR_FAILED_GET_BOARD       = "ボードの取得に失敗しました。"
+)
+
+// 作業時間関連のエラーメッセージ
+const (
+	TIME_ERR_FAILED_GET_ENTRY        = "作業時間の取得に失敗しました。"
+	TIME_ERR_FAILED_ADD_ENTRY        = "作業時間の記録に失敗しました。"
+	TIME_ERR_FAILED_DELETE_ENTRY     = "作業時間の削除に失敗しました。"
+	TIME_ERR_NOT_FOUND_ENTRY         = "作業時間の記録が見つかりません。"
+	TIME_ERR_NOT_OWNER               = "作業時間の記録を削除できるのは記録したユーザーのみです。"
+	TIME_ERR_FAILED_START_TIMER      = "タイマーの開始に失敗しました。"
+	TIME_ERR_FAILED_STOP_TIMER       = "タイマーの停止に失敗しました。"
+	TIME_ERR_TIMER_RUNNING           = "実行中のタイマーがあります。先に停止してください（TODO ID: %d）。"
+	TIME_ERR_TIMER_RUNNING_ELSEWHERE = "別のワークスペースで実行中のタイマーがあります。先に停止してください。"
+	TIME_ERR_TIMER_ALREADY_RUNNING   = "実行中のタイマーがあります。先に停止してください。"
+	TIME_ERR_NOT_FOUND_TIMER         = "このTODOで実行中のタイマーがありません。"
+	TIME_ERR_FAILED_GET_REPORT       = "作業時間の集計に失敗しました。"
+)
+
+// マイルストーン関連のエラーメッセージ
+const (
+	MILESTONE_ERR_FAILED_GET_MILESTONE    = "マイルストーンの取得に失敗しました。"
+	MILESTONE_ERR_FAILED_ADD_MILESTONE    = "マイルストーンの追加に失敗しました。"
+	MILESTONE_ERR_FAILED_UPDATE_MILESTONE = "マイルストーンの更新に失敗しました。"
+	MILESTONE_ERR_FAILED_DELETE_MILESTONE = "マイルストーンの削除に失敗しました。"
+	MILESTONE_ERR_NOT_FOUND_MILESTONE     = "マイルストーンが見つかりません。"
+	MILESTONE_ERR_IN_USE                  = "TODOが属しているマイルストーンは削除できません。"
+	MILESTONE_ERR_FAILED_GET_BURNDOWN     = "バーンダウンの取得に失敗しました。"
+)
+
+// 統計関連のエラーメッセージ
+const (
+	STATS_ERR_FAILED_GET_STATS = "統計の取得に失敗しました。"
 )
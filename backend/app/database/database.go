@@ -10,7 +10,7 @@ var db *sql.DB
 func Init() error {
 	var err error
 	// dsn -> ユーザー名:パスワード@tcp(ホスト名:ポート番号)/データベース名?オプション
-	dsn := "todo_user:todo_password@tcp(mysql-container:3306)/todo_db"
+	dsn := "todo_user:todo_password@tcp(mysql-container:3306)/todo_db?parseTime=true"
 	db, err = sql.Open("mysql", dsn) // DBとの接続を準備
 	if err != nil {
 		return fmt.Errorf("failed to connect to DB: %w", err)
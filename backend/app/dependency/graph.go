@@ -0,0 +1,114 @@
+// dependencyは、Todoの依存関係（どのTodoがどのTodoの完了を待つか）のグラフを扱うパッケージ
+package dependency
+
+import "slices"
+
+// Graphは、TodoのIDから、そのTodoをブロックしているTodoのIDへの辺を持つ有向グラフ
+type Graph map[int][]int
+
+// Addは、todoIDがblockerIDにブロックされることを表す辺を追加する
+func (g Graph) Add(todoID, blockerID int) {
+	g[todoID] = append(g[todoID], blockerID)
+}
+
+// Pathは、fromからブロックしているTodoをたどってtoに到達する最短の経路を返す。
+// 経路はfromとtoを含み、到達できない場合はnilを返す
+func (g Graph) Path(from, to int) []int {
+	if from == to {
+		return []int{from}
+	}
+
+	// 幅優先探索で、たどってきた元のTodoを記録する
+	prev := map[int]int{from: from}
+	queue := []int{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, blocker := range g[id] {
+			if _, ok := prev[blocker]; ok {
+				continue
+			}
+			prev[blocker] = id
+			if blocker == to {
+				path := []int{to}
+				for id := to; id != from; {
+					id = prev[id]
+					path = append(path, id)
+				}
+				slices.Reverse(path)
+				return path
+			}
+			queue = append(queue, blocker)
+		}
+	}
+	return nil
+}
+
+// Cycleは、todoIDのブロックをblockerIDsに置き換えた場合にできる循環を返す。
+// 循環はtodoIDから始まってtodoIDで終わり、循環しない場合はnilを返す
+func (g Graph) Cycle(todoID int, blockerIDs []int) []int {
+	replaced := Graph{}
+	for id, blockers := range g {
+		replaced[id] = blockers
+	}
+	replaced[todoID] = blockerIDs
+
+	var shortest []int
+	for _, blocker := range blockerIDs {
+		path := replaced.Path(blocker, todoID)
+		if path != nil && (shortest == nil || len(path) < len(shortest)) {
+			shortest = path
+		}
+	}
+	if shortest == nil {
+		return nil
+	}
+	return append([]int{todoID}, shortest...)
+}
+
+// LongestChainは、todoIDをブロックしているTodoを最も長くたどった経路を、
+// 最初に完了すべきTodoからtodoIDの順に返す。同じ長さの経路はIDの小さいTodoを優先する。
+// 保存済みのグラフに循環がある場合は、循環に入る辺を無視する
+func (g Graph) LongestChain(todoID int) []int {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[int]int{}
+	// 各Todoから最も長くたどった場合の、次にたどるTodoと経路の長さ
+	next := map[int]int{}
+	length := map[int]int{}
+
+	var visit func(id int)
+	visit = func(id int) {
+		state[id] = visiting
+		blockers := slices.Clone(g[id])
+		slices.Sort(blockers)
+		for _, blocker := range blockers {
+			switch state[blocker] {
+			case visiting:
+				continue
+			case 0:
+				visit(blocker)
+			}
+			if _, ok := next[id]; !ok || length[blocker]+1 > length[id] {
+				next[id] = blocker
+				length[id] = length[blocker] + 1
+			}
+		}
+		state[id] = done
+	}
+	visit(todoID)
+
+	chain := []int{todoID}
+	for id := todoID; ; {
+		blocker, ok := next[id]
+		if !ok {
+			break
+		}
+		chain = append(chain, blocker)
+		id = blocker
+	}
+	slices.Reverse(chain)
+	return chain
+}
@@ -0,0 +1,99 @@
+package dependency_test
+
+import (
+	"backend/app/dependency"
+	"reflect"
+	"testing"
+)
+
+// 1は2と3に、2は4に、3は4に、4は5にブロックされている
+func newGraph() dependency.Graph {
+	g := dependency.Graph{}
+	g.Add(1, 2)
+	g.Add(1, 3)
+	g.Add(2, 4)
+	g.Add(3, 4)
+	g.Add(4, 5)
+	return g
+}
+
+func TestPath(t *testing.T) {
+	cases := map[string]struct {
+		from, to int
+		want     []int
+	}{
+		"直接ブロック":    {1, 2, []int{1, 2}},
+		"間接ブロック":    {1, 5, []int{1, 2, 4, 5}},
+		"同じTodo":    {3, 3, []int{3}},
+		"逆向きはたどらない": {5, 1, nil},
+	}
+
+	g := newGraph()
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := g.Path(c.from, c.to); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want: %v, got: %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestCycle(t *testing.T) {
+	cases := map[string]struct {
+		todoID   int
+		blockers []int
+		want     []int
+	}{
+		"循環しない":       {5, []int{6}, nil},
+		"自分自身":        {5, []int{5}, []int{5, 5}},
+		"間接的な循環":      {5, []int{6, 1}, []int{5, 1, 2, 4, 5}},
+		"置き換える辺は無視する": {2, []int{5}, nil},
+		"置き換えた辺で循環する": {4, []int{5, 1}, []int{4, 1, 2, 4}},
+	}
+
+	g := newGraph()
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := g.Cycle(c.todoID, c.blockers); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want: %v, got: %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestLongestChain(t *testing.T) {
+	g := newGraph()
+	g.Add(3, 6)
+	g.Add(6, 7)
+	g.Add(7, 4)
+
+	cases := map[string]struct {
+		todoID int
+		want   []int
+	}{
+		"最も長い経路":     {1, []int{5, 4, 7, 6, 3, 1}},
+		"ブロックされていない": {5, []int{5}},
+		"途中のTodoから":  {2, []int{5, 4, 2}},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := g.LongestChain(c.todoID); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want: %v, got: %v", c.want, got)
+			}
+		})
+	}
+}
+
+// 保存済みのグラフに循環があっても、終了することを確認する
+func TestLongestChainWithCycle(t *testing.T) {
+	g := dependency.Graph{}
+	g.Add(1, 2)
+	g.Add(2, 3)
+	g.Add(3, 1)
+
+	want := []int{3, 2, 1}
+	if got := g.LongestChain(1); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
@@ -0,0 +1,240 @@
+// digestは、期限切れ・今日が期限・最近完了したTodoをまとめたメールを、
+// ユーザーが指定した時刻に1日1回送信するパッケージ。
+package digest
+
+import (
+	"backend/app/mailer"
+	"backend/app/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// 送信済みの日付の形式
+const dateLayout = "2006-01-02"
+
+// Jobは、送信時刻を迎えたユーザーにダイジェストを送信する
+type Job struct {
+	db     *sql.DB
+	sender mailer.Sender
+	from   string
+
+	// 最近完了したTodoとみなす期間
+	CompletedWithin time.Duration
+	// 現在時刻を返す関数（テスト用に差し替え可能）
+	Now func() time.Time
+}
+
+// Jobのコンストラクタ。fromは送信元のメールアドレス
+func NewJob(db *sql.DB, sender mailer.Sender, from string) *Job {
+	return &Job{
+		db:              db,
+		sender:          sender,
+		from:            from,
+		CompletedWithin: 24 * time.Hour,
+		Now:             time.Now,
+	}
+}
+
+// Runは、一定間隔でダイジェストを送信し続ける。ctxがキャンセルされると戻る。
+func (j *Job) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := j.RunOnce(ctx); err != nil {
+				log.Printf("failed to send digests: %v", err)
+			}
+		}
+	}
+}
+
+// 配信設定1件
+type recipient struct {
+	pref     model.DigestPreference
+	lastSent sql.NullString
+	location *time.Location
+	// ユーザーのタイムゾーンでの今日の日付
+	today string
+}
+
+// RunOnceは、送信時刻を過ぎていて今日まだ送信していないユーザーにダイジェストを送信し、送信した件数を返す。
+// 送信前に送信済みの日付を記録するため、複数のプロセスが動作していても同じ日に重複して送信しない。
+func (j *Job) RunOnce(ctx context.Context) (int, error) {
+	now := j.Now()
+	recipients, err := j.dueRecipients(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, r := range recipients {
+		ok, err := j.send(ctx, r, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("workspace %d user %d: %w", r.pref.WorkspaceID, r.pref.UserID, err))
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// 送信時刻を迎えた配信設定を取得する
+func (j *Job) dueRecipients(ctx context.Context, now time.Time) ([]recipient, error) {
+	// 配信先は設定した時点の値ではなく、送信時点のユーザー自身の確認済みのアドレスとする。確認済みのアドレスがないユーザーには送信しない
+	query := "SELECT p.workspace_id, p.user_id, e.email, p.send_time, p.time_zone, p.last_sent_on FROM digest_preferences p " +
+		"JOIN user_emails e ON e.user_id = p.user_id AND e.verified_at IS NOT NULL WHERE p.enabled = ?"
+	rows, err := j.db.QueryContext(ctx, query, true)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []recipient
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.pref.WorkspaceID, &r.pref.UserID, &r.pref.Email, &r.pref.SendTime, &r.pref.TimeZone, &r.lastSent); err != nil {
+			return nil, err
+		}
+		r.pref.Enabled = true
+
+		r.location, err = time.LoadLocation(r.pref.TimeZone)
+		if err != nil {
+			r.location = time.UTC
+		}
+		local := now.In(r.location)
+		r.today = local.Format(dateLayout)
+		if r.lastSent.Valid && r.lastSent.String == r.today {
+			continue
+		}
+
+		sendAt, err := time.ParseInLocation(dateLayout+" 15:04", r.today+" "+r.pref.SendTime, r.location)
+		if err != nil || local.Before(sendAt) {
+			continue
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, rows.Err()
+}
+
+// ユーザー1人にダイジェストを送信する。通知する内容がない場合は送信せずにfalseを返す。
+func (j *Job) send(ctx context.Context, r recipient, now time.Time) (bool, error) {
+	// 送信済みの日付を先に記録し、記録できたプロセスだけが送信する
+	claimQuery := "UPDATE digest_preferences SET last_sent_on = ? WHERE workspace_id = ? AND user_id = ? AND enabled = ? AND (last_sent_on IS NULL OR last_sent_on <> ?)"
+	result, err := j.db.ExecContext(ctx, claimQuery, r.today, r.pref.WorkspaceID, r.pref.UserID, true, r.today)
+	if err != nil {
+		return false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	sent, err := j.deliver(ctx, r, now)
+	if err != nil {
+		// 次回の実行で再送できるよう、送信済みの日付を元に戻す
+		releaseQuery := "UPDATE digest_preferences SET last_sent_on = ? WHERE workspace_id = ? AND user_id = ? AND last_sent_on = ?"
+		var previous any
+		if r.lastSent.Valid {
+			previous = r.lastSent.String
+		}
+		if _, releaseErr := j.db.Exec(releaseQuery, previous, r.pref.WorkspaceID, r.pref.UserID, r.today); releaseErr != nil {
+			return false, errors.Join(err, releaseErr)
+		}
+		return false, err
+	}
+	return sent, nil
+}
+
+// ダイジェストを組み立てて送信する
+func (j *Job) deliver(ctx context.Context, r recipient, now time.Time) (bool, error) {
+	d, err := j.build(ctx, r, now)
+	if err != nil {
+		return false, err
+	}
+	if d.Empty() {
+		return false, nil
+	}
+
+	text, html, err := Render(d)
+	if err != nil {
+		return false, err
+	}
+
+	err = j.sender.Send(mailer.Message{
+		From:      j.from,
+		To:        r.pref.Email,
+		Subject:   d.Subject(),
+		Text:      text,
+		HTML:      html,
+		MessageID: fmt.Sprintf("digest-%d-%d-%s@todo", r.pref.WorkspaceID, r.pref.UserID, r.today),
+		Date:      now,
+	})
+	return err == nil, err
+}
+
+// 受信者が担当するTodoの条件。担当者のいないTodoは、ワークスペースの全員の対象とする
+const assignedToRecipient = "(EXISTS (SELECT 1 FROM todo_assignees ta WHERE ta.workspace_id = t.workspace_id AND ta.todo_id = t.id AND ta.user_id = ?) " +
+	"OR NOT EXISTS (SELECT 1 FROM todo_assignees ta WHERE ta.workspace_id = t.workspace_id AND ta.todo_id = t.id))"
+
+// ダイジェストの内容を組み立てる。
+// 受信者が担当するTodoと、担当者のいないTodoを対象にする。
+func (j *Job) build(ctx context.Context, r recipient, now time.Time) (Digest, error) {
+	d := Digest{Date: r.today, Location: r.location}
+
+	local := now.In(r.location)
+	endOfDay := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, r.location)
+
+	openQuery := "SELECT t.id, t.title, t.due_at FROM todos t WHERE t.workspace_id = ? AND t.is_complete = ? AND t.due_at IS NOT NULL AND t.due_at < ? AND " +
+		assignedToRecipient + " ORDER BY t.due_at, t.id"
+	open, err := j.queryTodos(ctx, openQuery, r.pref.WorkspaceID, false, endOfDay.UTC(), r.pref.UserID)
+	if err != nil {
+		return Digest{}, err
+	}
+	for _, todo := range open {
+		if todo.DueAt.Before(now) {
+			d.Overdue = append(d.Overdue, todo)
+		} else {
+			d.DueToday = append(d.DueToday, todo)
+		}
+	}
+
+	// 監査ログから、未完了から完了に変更されたTodoを取得する
+	completedQuery := "SELECT t.id, t.title, t.due_at FROM todos t WHERE t.workspace_id = ? AND t.is_complete = ? AND " + assignedToRecipient + " AND EXISTS (" +
+		"SELECT 1 FROM audit_logs a WHERE a.workspace_id = t.workspace_id AND a.todo_id = t.id AND a.created_at >= ? " +
+		"AND JSON_UNQUOTE(JSON_EXTRACT(a.before_json, '$.is_complete')) = 'false' " +
+		"AND JSON_UNQUOTE(JSON_EXTRACT(a.after_json, '$.is_complete')) = 'true') ORDER BY t.id"
+	d.Completed, err = j.queryTodos(ctx, completedQuery, r.pref.WorkspaceID, true, r.pref.UserID, now.Add(-j.CompletedWithin))
+	if err != nil {
+		return Digest{}, err
+	}
+
+	return d, nil
+}
+
+func (j *Job) queryTodos(ctx context.Context, query string, args ...any) ([]model.Todo, error) {
+	rows, err := j.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var todos []model.Todo
+	for rows.Next() {
+		var todo model.Todo
+		if err := rows.Scan(&todo.ID, &todo.Title, &todo.DueAt); err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
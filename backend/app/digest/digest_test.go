@@ -0,0 +1,199 @@
+package digest_test
+
+import (
+	"backend/app/digest"
+	"backend/app/mailer"
+	"backend/app/mailer/mailertest"
+	"backend/app/model"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 東京では2026-01-01 09:30
+var now = time.Date(2026, 1, 1, 0, 30, 0, 0, time.UTC)
+
+var preferenceColumns = []string{"workspace_id", "user_id", "email", "send_time", "time_zone", "last_sent_on"}
+
+// newJobは、モックDBと固定時刻を使用するJobを作成します。
+func newJob(t *testing.T, sender mailer.Sender) (*digest.Job, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("モックDBの作成に失敗しました: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	j := digest.NewJob(db, sender, "todo@example.com")
+	j.Now = func() time.Time { return now }
+
+	return j, mock
+}
+
+// expectPreferencesは、配信設定の取得を期待値として設定します。
+// 送信対象はユーザー7のみで、ユーザー8は送信時刻前、ユーザー9は送信済み
+func expectPreferences(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`^SELECT p\.workspace_id, p\.user_id, e\.email, p\.send_time, p\.time_zone, p\.last_sent_on FROM digest_preferences p JOIN user_emails e ON e\.user_id = p\.user_id AND e\.verified_at IS NOT NULL WHERE p\.enabled = \?$`).
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows(preferenceColumns).
+			AddRow(1, 7, "user7@example.com", "08:00", "Asia/Tokyo", "2025-12-31").
+			AddRow(1, 8, "user8@example.com", "08:00", "UTC", nil).
+			AddRow(1, 9, "user9@example.com", "08:00", "Asia/Tokyo", "2026-01-01"))
+}
+
+// expectClaimは、送信済みの日付の記録を期待値として設定します。
+func expectClaim(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`^UPDATE digest_preferences SET last_sent_on = \? WHERE workspace_id = \? AND user_id = \? AND enabled = \? AND \(last_sent_on IS NULL OR last_sent_on <> \?\)$`).
+		WithArgs("2026-01-01", 1, 7, true, "2026-01-01").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectTodosは、ダイジェストに含めるTodoの取得を期待値として設定します。
+func expectTodos(mock sqlmock.Sqlmock, open, completed *sqlmock.Rows) {
+	// 東京の1月1日の終わり
+	endOfDay := time.Date(2026, 1, 1, 15, 0, 0, 0, time.UTC)
+	// 受信者のユーザー7が担当するTodoと、担当者のいないTodoに限る
+	const assigned = `\(EXISTS \(SELECT 1 FROM todo_assignees ta WHERE ta\.workspace_id = t\.workspace_id AND ta\.todo_id = t\.id AND ta\.user_id = \?\) ` +
+		`OR NOT EXISTS \(SELECT 1 FROM todo_assignees ta WHERE ta\.workspace_id = t\.workspace_id AND ta\.todo_id = t\.id\)\)`
+	mock.ExpectQuery(`^SELECT t\.id, t\.title, t\.due_at FROM todos t WHERE t\.workspace_id = \? AND t\.is_complete = \? AND t\.due_at IS NOT NULL AND t\.due_at < \? AND `+assigned+` ORDER BY t\.due_at, t\.id$`).
+		WithArgs(1, false, endOfDay, 7).
+		WillReturnRows(open)
+	mock.ExpectQuery(`^SELECT t\.id, t\.title, t\.due_at FROM todos t WHERE t\.workspace_id = \? AND t\.is_complete = \? AND `+assigned+` AND EXISTS \(SELECT 1 FROM audit_logs a`).
+		WithArgs(1, true, 7, now.Add(-24*time.Hour)).
+		WillReturnRows(completed)
+}
+
+func checkExpectations(t *testing.T, mock sqlmock.Sqlmock) {
+	t.Helper()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	todoColumns := []string{"id", "title", "due_at"}
+
+	t.Run("送信時刻を迎えたユーザーに送信する", func(t *testing.T) {
+		server := mailertest.NewServer(t)
+		j, mock := newJob(t, &mailer.SMTPSender{Addr: server.Addr})
+
+		expectPreferences(mock)
+		expectClaim(mock)
+		expectTodos(mock,
+			sqlmock.NewRows(todoColumns).
+				AddRow(1, "請求書を送る", now.Add(-time.Hour)).
+				AddRow(2, "<b>会議</b>", now.Add(3*time.Hour)),
+			sqlmock.NewRows(todoColumns).
+				AddRow(3, "牛乳を買う", nil))
+
+		n, err := j.RunOnce(context.Background())
+		if err != nil {
+			t.Fatalf("エラーが発生しました: %s", err)
+		}
+		if n != 1 {
+			t.Errorf("送信件数が不正です: %d", n)
+		}
+		checkExpectations(t, mock)
+
+		mails := server.Mails()
+		if len(mails) != 1 {
+			t.Fatalf("受信件数が不正です: %d", len(mails))
+		}
+		if mails[0].To[0] != "user7@example.com" {
+			t.Errorf("宛先が不正です: %v", mails[0].To)
+		}
+		if !strings.Contains(mails[0].Data, "Message-ID: <digest-1-7-2026-01-01@todo>") {
+			t.Errorf("Message-IDが不正です:\n%s", mails[0].Data)
+		}
+		if !strings.Contains(mails[0].Data, "multipart/alternative") {
+			t.Errorf("HTML形式の本文が含まれていません:\n%s", mails[0].Data)
+		}
+	})
+
+	t.Run("通知する内容がない場合は送信しない", func(t *testing.T) {
+		server := mailertest.NewServer(t)
+		j, mock := newJob(t, &mailer.SMTPSender{Addr: server.Addr})
+
+		expectPreferences(mock)
+		expectClaim(mock)
+		expectTodos(mock, sqlmock.NewRows(todoColumns), sqlmock.NewRows(todoColumns))
+
+		n, err := j.RunOnce(context.Background())
+		if err != nil || n != 0 {
+			t.Errorf("want: 0, nil, got: %d, %v", n, err)
+		}
+		checkExpectations(t, mock)
+		if len(server.Mails()) != 0 {
+			t.Error("メールが送信されています")
+		}
+	})
+
+	t.Run("送信に失敗した場合は送信済みの日付を元に戻す", func(t *testing.T) {
+		server := mailertest.NewServer(t)
+		server.FailData(true)
+		j, mock := newJob(t, &mailer.SMTPSender{Addr: server.Addr})
+
+		expectPreferences(mock)
+		expectClaim(mock)
+		expectTodos(mock,
+			sqlmock.NewRows(todoColumns).AddRow(1, "請求書を送る", now.Add(-time.Hour)),
+			sqlmock.NewRows(todoColumns))
+		mock.ExpectExec(`^UPDATE digest_preferences SET last_sent_on = \? WHERE workspace_id = \? AND user_id = \? AND last_sent_on = \?$`).
+			WithArgs("2025-12-31", 1, 7, "2026-01-01").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if _, err := j.RunOnce(context.Background()); err == nil {
+			t.Error("エラーが返却されていません")
+		}
+		checkExpectations(t, mock)
+	})
+
+	t.Run("他のプロセスが送信済み", func(t *testing.T) {
+		server := mailertest.NewServer(t)
+		j, mock := newJob(t, &mailer.SMTPSender{Addr: server.Addr})
+
+		expectPreferences(mock)
+		mock.ExpectExec(`^UPDATE digest_preferences SET last_sent_on = \?`).
+			WithArgs("2026-01-01", 1, 7, true, "2026-01-01").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		n, err := j.RunOnce(context.Background())
+		if err != nil || n != 0 {
+			t.Errorf("want: 0, nil, got: %d, %v", n, err)
+		}
+		checkExpectations(t, mock)
+	})
+}
+
+func TestRender(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dueAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := digest.Digest{
+		Date:     "2026-01-01",
+		Overdue:  []model.Todo{{ID: 1, Title: "<script>alert(1)</script>", DueAt: &dueAt}},
+		Location: tokyo,
+	}
+
+	text, html, err := digest.Render(d)
+	if err != nil {
+		t.Fatalf("描画に失敗しました: %s", err)
+	}
+
+	if !strings.Contains(text, "- <script>alert(1)</script>（期限: 01/01 09:00）") {
+		t.Errorf("テキスト形式の本文が不正です:\n%s", text)
+	}
+	if strings.Contains(text, "今日が期限") || strings.Contains(text, "最近完了") {
+		t.Errorf("該当のない見出しが含まれています:\n%s", text)
+	}
+	if strings.Contains(html, "<script>") || !strings.Contains(html, "&lt;script&gt;alert(1)&lt;/script&gt;") {
+		t.Errorf("HTML形式の本文でタイトルがエスケープされていません:\n%s", html)
+	}
+}
@@ -0,0 +1,68 @@
+package digest
+
+import (
+	"backend/app/model"
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Digestは、ユーザー1人分のダイジェストの内容
+type Digest struct {
+	// ユーザーのタイムゾーンでの日付
+	Date      string
+	Overdue   []model.Todo
+	DueToday  []model.Todo
+	Completed []model.Todo
+	// 期限の表示に使用するタイムゾーン
+	Location *time.Location
+}
+
+// Emptyは、通知する内容がない場合にtrueを返す
+func (d Digest) Empty() bool {
+	return len(d.Overdue) == 0 && len(d.DueToday) == 0 && len(d.Completed) == 0
+}
+
+// Subjectは、メールの件名を返す
+func (d Digest) Subject() string {
+	return "TODOのまとめ (" + d.Date + ")"
+}
+
+// FormatDueは、期限をユーザーのタイムゾーンで表示する
+func (d Digest) FormatDue(dueAt *time.Time) string {
+	if dueAt == nil {
+		return ""
+	}
+	loc := d.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return dueAt.In(loc).Format("01/02 15:04")
+}
+
+// テンプレートは起動時に一度だけ解析する
+var (
+	textTemplate = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/digest.txt.tmpl"))
+	htmlTemplate = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/digest.html.tmpl"))
+)
+
+// Renderは、ダイジェストをテキスト形式とHTML形式の本文に変換する。
+// HTML形式ではTodoのタイトルをエスケープする。
+func Render(d Digest) (text, html string, err error) {
+	var tb bytes.Buffer
+	if err := textTemplate.Execute(&tb, d); err != nil {
+		return "", "", err
+	}
+
+	var hb bytes.Buffer
+	if err := htmlTemplate.Execute(&hb, d); err != nil {
+		return "", "", err
+	}
+
+	return tb.String(), hb.String(), nil
+}
@@ -0,0 +1,208 @@
+// eventは、Todoの変更イベントをプロセス内の購読者に配信するパッケージ
+package event
+
+import (
+	"backend/app/model"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	TodoCreated Type = "todo.created"
+	TodoUpdated Type = "todo.updated"
+	TodoDeleted Type = "todo.deleted"
+	// 未完了から完了に変わった場合に、todo.updatedに加えて発行する
+	TodoCompleted Type = "todo.completed"
+	// リマインダーの通知。リマインダーを設定したユーザーにのみ配信する
+	TodoReminder Type = "todo.reminder"
+	// コメントでの言及。言及されたユーザーにのみ配信する
+	TodoMentioned Type = "todo.mentioned"
+	// 担当者の追加と解除。追加・解除されたユーザーにのみ配信する
+	TodoAssigned   Type = "todo.assigned"
+	TodoUnassigned Type = "todo.unassigned"
+)
+
+const (
+	// 再接続時の再送用に保持するイベント数
+	defaultReplaySize = 256
+	// 購読者ごとのバッファサイズ。溢れた購読者は切断し、再接続時に再送で追いつかせる
+	subscriberBufferSize = 64
+)
+
+type Event struct {
+	ID uint64 `json:"id"`
+	// 重複排除用のID。同じ変更を再配信した場合も同じ値になる
+	DedupID     string      `json:"dedup_id,omitempty"`
+	Type        Type        `json:"type"`
+	WorkspaceID int         `json:"-"`
+	ListID      *int        `json:"list_id"`
+	TodoID      int         `json:"todo_id"`
+	Todo        *model.Todo `json:"todo"`
+	// 言及の場合に、言及したコメント
+	Comment *model.Comment `json:"comment,omitempty"`
+	// 特定のユーザー宛てのイベントの場合に指定する
+	UserID     int       `json:"user_id,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// VisibleToは、ユーザーがイベントを受信できるかを返す
+func (e Event) VisibleTo(userID int) bool {
+	return e.UserID == 0 || e.UserID == userID
+}
+
+// Brokerは、イベントを購読者に配信し、直近のイベントを再送用に保持する
+type Broker struct {
+	mu          sync.Mutex
+	nextID      uint64
+	replay      []Event
+	replaySize  int
+	subscribers map[*Subscription]struct{}
+	// 再送用に保持しているイベントの重複排除用ID
+	seen map[string]Event
+}
+
+// Subscriptionは、1つのワークスペースのイベントの購読
+type Subscription struct {
+	workspaceID int
+	// すべてのワークスペースのイベントを受信する（バックグラウンド処理用）
+	all       bool
+	events    chan Event
+	broker    *Broker
+	closeOnce sync.Once
+}
+
+var defaultBroker = NewBroker(defaultReplaySize)
+
+// Defaultは、アプリケーション全体で共有するBrokerを返す
+func Default() *Broker {
+	return defaultBroker
+}
+
+// Brokerのコンストラクタ。
+// イベントIDはプロセスの起動時刻（マイクロ秒）から採番し、再起動前のIDを新しいIDと区別できるようにする
+func NewBroker(replaySize int) *Broker {
+	return &Broker{
+		nextID:      uint64(time.Now().UnixMicro()),
+		replaySize:  replaySize,
+		subscribers: make(map[*Subscription]struct{}),
+		seen:        make(map[string]Event),
+	}
+}
+
+// Publishは、イベントにIDを採番して購読者に配信する。
+// 再送用に保持しているイベントと重複排除用IDが一致する場合は配信せず、配信済みのイベントを返す。
+func (b *Broker) Publish(e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e.DedupID != "" {
+		if published, ok := b.seen[e.DedupID]; ok {
+			return published
+		}
+	}
+
+	e.ID = b.nextID
+	b.nextID++
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+
+	b.replay = append(b.replay, e)
+	if e.DedupID != "" {
+		b.seen[e.DedupID] = e
+	}
+	if len(b.replay) > b.replaySize {
+		trimmed := len(b.replay) - b.replaySize
+		for _, old := range b.replay[:trimmed] {
+			delete(b.seen, old.DedupID)
+		}
+		b.replay = b.replay[trimmed:]
+	}
+
+	for s := range b.subscribers {
+		if !s.all && s.workspaceID != e.WorkspaceID {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			// 受信が追いつかない購読者は切断する
+			delete(b.subscribers, s)
+			s.closeOnce.Do(func() { close(s.events) })
+		}
+	}
+
+	return e
+}
+
+// Subscribeは、ワークスペースのイベントを購読する。
+// lastEventIDを指定した場合は、それ以降のイベントを再送用に返す。
+// 再送に必要なイベントがすでに破棄されている場合や、再起動前など別のプロセスが採番したIDの場合、okはfalseになる。
+func (b *Broker) Subscribe(workspaceID int, lastEventID uint64) (sub *Subscription, missed []Event, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub = &Subscription{
+		workspaceID: workspaceID,
+		events:      make(chan Event, subscriberBufferSize),
+		broker:      b,
+	}
+	b.subscribers[sub] = struct{}{}
+
+	ok = true
+	if lastEventID == 0 {
+		return sub, nil, ok
+	}
+
+	// 保持している最古のイベントより前から再開する場合は、取りこぼしがある
+	oldest := b.nextID
+	if len(b.replay) > 0 {
+		oldest = b.replay[0].ID
+	}
+	if lastEventID+1 < oldest {
+		ok = false
+	}
+	// まだ採番していないIDは、このプロセスのものではない
+	if lastEventID >= b.nextID {
+		return sub, nil, false
+	}
+
+	for _, e := range b.replay {
+		if e.ID > lastEventID && e.WorkspaceID == workspaceID {
+			missed = append(missed, e)
+		}
+	}
+	return sub, missed, ok
+}
+
+// SubscribeAllは、すべてのワークスペースのイベントを購読する。
+// リクエストに紐づかないバックグラウンド処理での利用を想定している。
+func (b *Broker) SubscribeAll() *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription{
+		all:    true,
+		events: make(chan Event, subscriberBufferSize),
+		broker: b,
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Eventsは、購読したイベントを受信するチャネルを返す。
+// 購読が解除された場合、チャネルはクローズされる。
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Closeは、購読を解除する
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	delete(s.broker.subscribers, s)
+	s.closeOnce.Do(func() { close(s.events) })
+}
@@ -0,0 +1,145 @@
+package event_test
+
+import (
+	"backend/app/event"
+	"testing"
+)
+
+func TestBrokerPublish(t *testing.T) {
+	b := event.NewBroker(10)
+	sub, _, _ := b.Subscribe(1, 0)
+	defer sub.Close()
+	other, _, _ := b.Subscribe(2, 0)
+	defer other.Close()
+
+	published := b.Publish(event.Event{Type: event.TodoCreated, WorkspaceID: 1, TodoID: 10})
+
+	select {
+	case e := <-sub.Events():
+		if e.ID != published.ID || e.TodoID != 10 || e.Type != event.TodoCreated {
+			t.Errorf("想定外のイベントです: %+v", e)
+		}
+	default:
+		t.Fatal("イベントが配信されていません")
+	}
+
+	select {
+	case e := <-other.Events():
+		t.Errorf("他のワークスペースのイベントが配信されました: %+v", e)
+	default:
+	}
+}
+
+func TestBrokerSubscribeReplay(t *testing.T) {
+	// IDは1件目のイベントのIDからの相対位置（1始まり）で指定する
+	cases := map[string]struct {
+		lastEventID func(first uint64) uint64
+		wantIDs     []uint64
+		wantOK      bool
+	}{
+		"途中から再開":       {lastEventID: func(first uint64) uint64 { return first + 2 }, wantIDs: []uint64{5}, wantOK: true},
+		"最新まで受信済み":     {lastEventID: func(first uint64) uint64 { return first + 4 }, wantIDs: nil, wantOK: true},
+		"再送範囲外":        {lastEventID: func(first uint64) uint64 { return first }, wantIDs: []uint64{3, 5}, wantOK: false},
+		"再起動前のプロセスのID": {lastEventID: func(first uint64) uint64 { return first - 100 }, wantIDs: []uint64{3, 5}, wantOK: false},
+		"まだ採番していないID":  {lastEventID: func(first uint64) uint64 { return first + 100 }, wantIDs: nil, wantOK: false},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			// 保持数を3にして、1件目と2件目を破棄させる
+			b := event.NewBroker(3)
+			var first uint64
+			for i := 0; i < 5; i++ {
+				workspaceID := 1
+				if i%2 == 1 {
+					workspaceID = 2
+				}
+				e := b.Publish(event.Event{Type: event.TodoUpdated, WorkspaceID: workspaceID})
+				if i == 0 {
+					first = e.ID
+				}
+			}
+
+			sub, missed, ok := b.Subscribe(1, c.lastEventID(first))
+			defer sub.Close()
+
+			if ok != c.wantOK {
+				t.Errorf("期待した再送可否: %v, 実際: %v", c.wantOK, ok)
+			}
+			var gotIDs []uint64
+			for _, e := range missed {
+				gotIDs = append(gotIDs, e.ID-first+1)
+			}
+			if len(gotIDs) != len(c.wantIDs) {
+				t.Fatalf("期待した再送イベント: %v, 実際: %v", c.wantIDs, gotIDs)
+			}
+			for i := range gotIDs {
+				if gotIDs[i] != c.wantIDs[i] {
+					t.Errorf("期待した再送イベント: %v, 実際: %v", c.wantIDs, gotIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestBrokerDropsSlowSubscriber(t *testing.T) {
+	b := event.NewBroker(10)
+	sub, _, _ := b.Subscribe(1, 0)
+
+	// 受信しない購読者はバッファが溢れた時点で切断される
+	for i := 0; i < 100; i++ {
+		b.Publish(event.Event{Type: event.TodoUpdated, WorkspaceID: 1})
+	}
+
+	n := 0
+	for range sub.Events() {
+		n++
+	}
+	if n == 0 || n >= 100 {
+		t.Errorf("想定外の受信件数です: %d", n)
+	}
+	// 切断済みの購読を解除しても問題ない
+	sub.Close()
+}
+
+func TestBrokerSubscribeAll(t *testing.T) {
+	b := event.NewBroker(10)
+	sub := b.SubscribeAll()
+	defer sub.Close()
+
+	b.Publish(event.Event{Type: event.TodoCreated, WorkspaceID: 1})
+	b.Publish(event.Event{Type: event.TodoCreated, WorkspaceID: 2})
+
+	for _, want := range []int{1, 2} {
+		select {
+		case e := <-sub.Events():
+			if e.WorkspaceID != want {
+				t.Errorf("期待したワークスペース: %d, 実際: %d", want, e.WorkspaceID)
+			}
+		default:
+			t.Fatal("イベントが配信されていません")
+		}
+	}
+}
+
+func TestBrokerPublishDeduplicates(t *testing.T) {
+	b := event.NewBroker(2)
+	sub, _, _ := b.Subscribe(1, 0)
+	defer sub.Close()
+
+	first := b.Publish(event.Event{Type: event.TodoCreated, WorkspaceID: 1, DedupID: "a"})
+	again := b.Publish(event.Event{Type: event.TodoCreated, WorkspaceID: 1, DedupID: "a"})
+	if again.ID != first.ID {
+		t.Errorf("重複したイベントに新しいIDが採番されました: %d, %d", first.ID, again.ID)
+	}
+	if n := len(sub.Events()); n != 1 {
+		t.Errorf("重複したイベントが配信されました: %d件", n)
+	}
+
+	// 再送用の保持から外れたイベントは重複として扱わない
+	b.Publish(event.Event{Type: event.TodoUpdated, WorkspaceID: 1, DedupID: "b"})
+	b.Publish(event.Event{Type: event.TodoUpdated, WorkspaceID: 1, DedupID: "c"})
+	if e := b.Publish(event.Event{Type: event.TodoCreated, WorkspaceID: 1, DedupID: "a"}); e.ID == first.ID {
+		t.Errorf("保持から外れたイベントが重複として扱われました: %+v", e)
+	}
+}
@@ -0,0 +1,83 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Nodeは、フィルターの構文木のノード
+type Node interface {
+	// Stringは、再び解析すると同じ構文木になる文字列を返す
+	String() string
+	node()
+}
+
+// Andは、両方の条件を満たすTodoに一致する
+type And struct {
+	Left, Right Node
+}
+
+// Orは、いずれかの条件を満たすTodoに一致する
+type Or struct {
+	Left, Right Node
+}
+
+// Notは、条件を満たさないTodoに一致する
+type Not struct {
+	X Node
+}
+
+// Predicateは、1つの条件（field:value や due:<7d）。キーのない語はタイトルの条件になる
+type Predicate struct {
+	Field string
+	Op    string
+	Value string
+}
+
+func (And) node()       {}
+func (Or) node()        {}
+func (Not) node()       {}
+func (Predicate) node() {}
+
+func (n And) String() string {
+	left := n.Left.String()
+	if _, ok := n.Left.(Or); ok {
+		left = "(" + left + ")"
+	}
+	// 左結合で解析するため、右側の論理式は括弧で囲む
+	right := n.Right.String()
+	switch n.Right.(type) {
+	case And, Or:
+		right = "(" + right + ")"
+	}
+	return left + " " + right
+}
+
+func (n Or) String() string {
+	right := n.Right.String()
+	if _, ok := n.Right.(Or); ok {
+		right = "(" + right + ")"
+	}
+	return n.Left.String() + " OR " + right
+}
+
+func (n Not) String() string {
+	switch n.X.(type) {
+	case And, Or:
+		return "-(" + n.X.String() + ")"
+	}
+	return "-" + n.X.String()
+}
+
+func (n Predicate) String() string {
+	return n.Field + ":" + strings.TrimPrefix(n.Op, ":") + quote(n.Value)
+}
+
+// 区切り文字などを含む値は引用符で囲む
+func quote(s string) string {
+	if s != "" && s != orKeyword && !strings.ContainsAny(s, `()":\`) && strings.IndexFunc(s, unicode.IsSpace) < 0 &&
+		!strings.ContainsAny(s[:1], "-<>") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
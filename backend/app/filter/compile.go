@@ -0,0 +1,252 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// キー
+const (
+	FieldIs    = "is"
+	FieldTag   = "tag"
+	FieldDue   = "due"
+	FieldList  = "list"
+	FieldTitle = "title"
+)
+
+// 演算子
+const (
+	OpEq = ":"
+	OpLt = "<"
+	OpLe = "<="
+	OpGt = ">"
+	OpGe = ">="
+)
+
+var ops = map[string]bool{OpEq: true, OpLt: true, OpLe: true, OpGt: true, OpGe: true}
+
+// 値の最大文字数
+const maxValueLength = 100
+
+type fieldSpec struct {
+	// <や>で比較できる場合はtrue
+	comparable bool
+}
+
+var fields = map[string]fieldSpec{
+	FieldIs:    {},
+	FieldTag:   {},
+	FieldDue:   {comparable: true},
+	FieldList:  {},
+	FieldTitle: {},
+}
+
+var isValues = map[string]bool{"open": true, "done": true, "overdue": true, "recurring": true}
+
+// Envは、相対的な日時を解決するための環境
+type Env struct {
+	Now time.Time
+	// today や日付を解釈するタイムゾーン
+	Location *time.Location
+}
+
+// Compileは、構文木をtodosテーブルに対するWHERE句の条件に変換する。
+// 値はすべてプレースホルダーで渡すため、返却する条件をそのままSQLに連結できる。
+func Compile(n Node, env Env) (string, []any, error) {
+	if env.Location == nil {
+		env.Location = time.UTC
+	}
+	c := &compiler{env: env}
+	if err := c.compile(n); err != nil {
+		return "", nil, err
+	}
+	return c.sql.String(), c.args, nil
+}
+
+type compiler struct {
+	env  Env
+	sql  strings.Builder
+	args []any
+}
+
+func (c *compiler) compile(n Node) error {
+	switch n := n.(type) {
+	case And:
+		return c.binary("AND", n.Left, n.Right)
+	case Or:
+		return c.binary("OR", n.Left, n.Right)
+	case Not:
+		c.sql.WriteString("NOT (")
+		if err := c.compile(n.X); err != nil {
+			return err
+		}
+		c.sql.WriteString(")")
+		return nil
+	case Predicate:
+		return c.predicate(n)
+	default:
+		return fmt.Errorf("unknown node %T", n)
+	}
+}
+
+func (c *compiler) binary(op string, left, right Node) error {
+	c.sql.WriteString("(")
+	if err := c.compile(left); err != nil {
+		return err
+	}
+	c.sql.WriteString(" " + op + " ")
+	if err := c.compile(right); err != nil {
+		return err
+	}
+	c.sql.WriteString(")")
+	return nil
+}
+
+func (c *compiler) write(sql string, args ...any) {
+	c.sql.WriteString(sql)
+	c.args = append(c.args, args...)
+}
+
+// 条件を変換する。NULLを含む列は、否定したときに期限なしのTodoなどが一致するようIS NOT NULLを併記する
+func (c *compiler) predicate(p Predicate) error {
+	if err := validate(p); err != nil {
+		return err
+	}
+
+	switch p.Field {
+	case FieldIs:
+		switch strings.ToLower(p.Value) {
+		case "open":
+			c.write("is_complete = ?", false)
+		case "done":
+			c.write("is_complete = ?", true)
+		case "overdue":
+			c.write("(is_complete = ? AND due_at IS NOT NULL AND due_at < ?)", false, c.env.Now)
+		case "recurring":
+			c.write("recurrence <> ?", "")
+		}
+
+	case FieldTag:
+		c.write("EXISTS (SELECT 1 FROM todo_tags WHERE todo_tags.todo_id = todos.id AND todo_tags.tag = ?)", NormalizeTag(p.Value))
+
+	case FieldList:
+		if p.Value == "none" {
+			c.write("list_id IS NULL")
+		} else {
+			id, _ := strconv.Atoi(p.Value)
+			c.write("(list_id IS NOT NULL AND list_id = ?)", id)
+		}
+
+	case FieldTitle:
+		c.write(`title LIKE ? ESCAPE '\\'`, "%"+EscapeLike(p.Value)+"%")
+
+	case FieldDue:
+		due, err := parseDue(p)
+		if err != nil {
+			return err
+		}
+		c.due(p.Op, due)
+	}
+	return nil
+}
+
+// dueValueは、due:の値
+type dueValue struct {
+	none bool
+	// 日付を指定した場合はtrue。その日の0時から翌日の0時までを範囲とする
+	date bool
+	// 日付で指定した場合の日付。todayやtomorrowの場合はゼロ値
+	day time.Time
+	// todayからの日数
+	offset int
+	// 相対期間
+	relative time.Duration
+}
+
+// due:の値を解析する。none、today、tomorrow、YYYY-MM-DD、数値と単位（h, d, w）の相対期間を受け付ける
+func parseDue(p Predicate) (dueValue, error) {
+	v := strings.ToLower(p.Value)
+	switch v {
+	case "none":
+		if p.Op != OpEq {
+			return dueValue{}, fmt.Errorf("due:noneは比較できません。")
+		}
+		return dueValue{none: true}, nil
+	case "today":
+		return dueValue{date: true}, nil
+	case "tomorrow":
+		return dueValue{date: true, offset: 1}, nil
+	}
+
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return dueValue{date: true, day: t}, nil
+	}
+
+	if len(v) >= 2 {
+		n, err := strconv.Atoi(v[:len(v)-1])
+		if err == nil && n >= 0 && n <= 3650 {
+			switch v[len(v)-1] {
+			case 'h':
+				return dueValue{relative: time.Duration(n) * time.Hour}, nil
+			case 'd':
+				return dueValue{relative: time.Duration(n) * 24 * time.Hour}, nil
+			case 'w':
+				return dueValue{relative: time.Duration(n) * 7 * 24 * time.Hour}, nil
+			}
+		}
+	}
+	return dueValue{}, fmt.Errorf("dueにはnone、today、tomorrow、YYYY-MM-DD、または7dのような期間を指定してください。")
+}
+
+func (c *compiler) due(op string, v dueValue) {
+	if v.none {
+		c.write("due_at IS NULL")
+		return
+	}
+
+	if v.date {
+		// 日付はその日の0時から翌日の0時までの範囲として比較する
+		local := c.env.Now.In(c.env.Location)
+		from := time.Date(local.Year(), local.Month(), local.Day()+v.offset, 0, 0, 0, 0, c.env.Location)
+		if !v.day.IsZero() {
+			from = time.Date(v.day.Year(), v.day.Month(), v.day.Day(), 0, 0, 0, 0, c.env.Location)
+		}
+		to := from.AddDate(0, 0, 1)
+
+		switch op {
+		case OpEq:
+			c.write("(due_at IS NOT NULL AND due_at >= ? AND due_at < ?)", from.UTC(), to.UTC())
+		case OpLt:
+			c.write("(due_at IS NOT NULL AND due_at < ?)", from.UTC())
+		case OpLe:
+			c.write("(due_at IS NOT NULL AND due_at < ?)", to.UTC())
+		case OpGt:
+			c.write("(due_at IS NOT NULL AND due_at >= ?)", to.UTC())
+		case OpGe:
+			c.write("(due_at IS NOT NULL AND due_at >= ?)", from.UTC())
+		}
+		return
+	}
+
+	// 相対期間は今からの時点として比較する。due:7d は今から7日以内
+	now := c.env.Now.UTC()
+	at := now.Add(v.relative)
+	switch op {
+	case OpEq:
+		c.write("(due_at IS NOT NULL AND due_at >= ? AND due_at <= ?)", now, at)
+	default:
+		c.write("(due_at IS NOT NULL AND due_at "+op+" ?)", at)
+	}
+}
+
+// EscapeLikeは、LIKEのワイルドカードをエスケープする。ESCAPE '\\'と組み合わせて使用する
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// NormalizeTagは、タグを比較するための形式に揃える
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
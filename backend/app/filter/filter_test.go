@@ -0,0 +1,207 @@
+package filter_test
+
+import (
+	"backend/app/filter"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var env = filter.Env{Now: time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC), Location: time.UTC}
+
+func TestParse(t *testing.T) {
+	cases := map[string]struct {
+		src  string
+		want string
+	}{
+		"暗黙のAND":     {"is:open tag:work due:<7d -tag:someday", "is:open tag:work due:<7d -tag:someday"},
+		"キーのない語":     {"牛乳　買う", "title:牛乳 title:買う"},
+		"引用符":        {`"月次 報告" title:"a:b"`, `title:"月次 報告" title:"a:b"`},
+		"ORはANDより弱い": {"tag:a tag:b OR tag:c", "tag:a tag:b OR tag:c"},
+		"括弧":         {"tag:a (tag:b OR tag:c)", "tag:a (tag:b OR tag:c)"},
+		"括弧の否定":      {"-(is:done OR due:none)", "-(is:done OR due:none)"},
+		"キーは大文字小文字を区別しない": {"TAG:Work", "tag:Work"},
+		"引用符のエスケープ":       {`"say \"hi\""`, `title:"say \"hi\""`},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			n, err := filter.Parse(c.src)
+			if err != nil {
+				t.Fatalf("解析に失敗しました: %s", err)
+			}
+			if got := n.String(); got != c.want {
+				t.Errorf("want: %s, got: %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	cases := map[string]struct {
+		src     string
+		wantPos int
+		wantMsg string
+	}{
+		"空":          {"  ", 0, "条件がありません。"},
+		"不明なキー":      {"is:open color:red", 8, "不明なキー「color」です。"},
+		"比較できないキー":   {"tag:>a", 0, "tagは比較できません。"},
+		"isの値が不正":    {"is:later", 0, "isにはopen、done、overdue、recurringのいずれかを指定してください。"},
+		"dueの値が不正":   {"due:<soon", 0, "dueにはnone、today、tomorrow、YYYY-MM-DD、または7dのような期間を指定してください。"},
+		"値がない":       {"tag:", 0, "tagの値がありません。"},
+		"引用符が閉じていない": {`title:"abc`, 6, "引用符が閉じられていません。"},
+		"括弧が閉じていない":  {"(tag:a", 0, "括弧が閉じられていません。"},
+		"余分な閉じ括弧":    {"tag:a)", 5, "対応する(がありません。"},
+		"ORの後に条件がない": {"tag:a OR", 8, "条件がありません。"},
+		"ORから始まる":    {"OR tag:a", 0, "ORの前後に条件がありません。"},
+		"否定の後に条件がない": {"tag:a -", 6, "-の後に条件がありません。"},
+		"入れ子が深すぎる":   {strings.Repeat("(", 21) + "a" + strings.Repeat(")", 21), 20, "括弧や否定の入れ子が深すぎます。"},
+		"長すぎる":       {strings.Repeat("a", 501), 500, "フィルターは500文字以内で入力してください。"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := filter.Parse(c.src)
+			var syntaxErr *filter.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("構文エラーが返却されていません: %v", err)
+			}
+			if syntaxErr.Pos != c.wantPos || syntaxErr.Msg != c.wantMsg {
+				t.Errorf("want: %d %s, got: %d %s", c.wantPos, c.wantMsg, syntaxErr.Pos, syntaxErr.Msg)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		src      string
+		env      filter.Env
+		wantSQL  string
+		wantArgs []any
+	}{
+		"例": {
+			"is:open tag:work due:<7d -tag:someday", env,
+			"(((is_complete = ? AND EXISTS (SELECT 1 FROM todo_tags WHERE todo_tags.todo_id = todos.id AND todo_tags.tag = ?)) AND (due_at IS NOT NULL AND due_at < ?)) AND NOT (EXISTS (SELECT 1 FROM todo_tags WHERE todo_tags.todo_id = todos.id AND todo_tags.tag = ?)))",
+			[]any{false, "work", env.Now.Add(7 * 24 * time.Hour), "someday"},
+		},
+		"OR": {
+			"is:done OR list:3", env,
+			"(is_complete = ? OR (list_id IS NOT NULL AND list_id = ?))",
+			[]any{true, 3},
+		},
+		"期限切れ": {
+			"is:overdue", env,
+			"(is_complete = ? AND due_at IS NOT NULL AND due_at < ?)",
+			[]any{false, env.Now},
+		},
+		"タイトルはワイルドカードをエスケープ": {
+			`"100%_達成"`, env,
+			`title LIKE ? ESCAPE '\\'`,
+			[]any{`%100\%\_達成%`},
+		},
+		"今日はタイムゾーンで解釈": {
+			"due:today", filter.Env{Now: env.Now, Location: tokyo},
+			"(due_at IS NOT NULL AND due_at >= ? AND due_at < ?)",
+			[]any{time.Date(2025, 12, 31, 15, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 15, 0, 0, 0, time.UTC)},
+		},
+		"日付より後": {
+			"due:>2026-02-01", env,
+			"(due_at IS NOT NULL AND due_at >= ?)",
+			[]any{time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		"日付以前": {
+			"due:<=2026-02-01", env,
+			"(due_at IS NOT NULL AND due_at < ?)",
+			[]any{time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		"期限なし": {
+			"due:none list:none", env,
+			"(due_at IS NULL AND list_id IS NULL)",
+			nil,
+		},
+		"繰り返し": {
+			"is:recurring", env,
+			"recurrence <> ?",
+			[]any{""},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			n, err := filter.Parse(c.src)
+			if err != nil {
+				t.Fatalf("解析に失敗しました: %s", err)
+			}
+			sql, args, err := filter.Compile(n, c.env)
+			if err != nil {
+				t.Fatalf("変換に失敗しました: %s", err)
+			}
+			if sql != c.wantSQL {
+				t.Errorf("want: %s\ngot:  %s", c.wantSQL, sql)
+			}
+			if !reflect.DeepEqual(args, c.wantArgs) {
+				t.Errorf("want: %v, got: %v", c.wantArgs, args)
+			}
+		})
+	}
+}
+
+func TestCompileRejectsUnknownOperator(t *testing.T) {
+	// 構文木を直接組み立てた場合も、SQLに連結する演算子を検証する
+	n := filter.Predicate{Field: filter.FieldDue, Op: "= 1 OR 1 =", Value: "7d"}
+	if _, _, err := filter.Compile(n, env); err == nil {
+		t.Error("不正な演算子が受け付けられました")
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"is:open tag:work due:<7d -tag:someday",
+		`(tag:a OR "b c") -due:none list:3`,
+		`title:"a:b" "\"q\"" 牛乳　買う`,
+		"due:>=2026-01-01 due:tomorrow is:overdue",
+		"-(-(a OR b) c)",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, src string) {
+		n, err := filter.Parse(src)
+		if err != nil {
+			var syntaxErr *filter.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("構文エラー以外のエラーが返却されました: %v", err)
+			}
+			return
+		}
+
+		// 文字列に戻して再び解析すると、同じ構文木になる
+		again, err := filter.Parse(n.String())
+		if err != nil {
+			t.Fatalf("%q を文字列に戻した %q の解析に失敗しました: %s", src, n.String(), err)
+		}
+		if !reflect.DeepEqual(n, again) {
+			t.Fatalf("構文木が一致しません: %q -> %q", src, n.String())
+		}
+
+		// プレースホルダーと値の数が一致し、値がSQLに埋め込まれない
+		sql, args, err := filter.Compile(n, env)
+		if err != nil {
+			t.Fatalf("変換に失敗しました: %s", err)
+		}
+		if got := strings.Count(sql, "?"); got != len(args) {
+			t.Fatalf("プレースホルダーの数 %d と値の数 %d が一致しません: %s", got, len(args), sql)
+		}
+		if strings.Contains(sql, ";") || strings.Contains(sql, "--") {
+			t.Fatalf("SQLに値が埋め込まれています: %s", sql)
+		}
+	})
+}
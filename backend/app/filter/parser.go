@@ -0,0 +1,345 @@
+// filterは、Todoを絞り込む小さな問い合わせ言語を解析し、パラメーター化したSQLの条件に変換するパッケージ。
+//
+// 例: is:open tag:work due:<7d -tag:someday
+//
+// 空白で区切った条件はすべてを満たすTodoに一致し、ORでいずれかの条件、先頭の-で否定、括弧でまとめる。
+// キーのない語と引用符で囲んだ文字列は、タイトルに含まれるかの条件になる。
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 解析できるフィルターの上限
+const (
+	MaxLength = 500
+	// 括弧と否定の入れ子の深さ
+	maxDepth = 20
+	// 条件の数
+	maxPredicates = 50
+)
+
+const orKeyword = "OR"
+
+// SyntaxErrorは、フィルターの構文の誤り
+type SyntaxError struct {
+	// 誤りのある位置（先頭からの文字数）
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d文字目: %s", e.Pos+1, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenNot
+	tokenOr
+)
+
+type token struct {
+	kind tokenKind
+	pos  int
+	// tokenWordの場合はキーを含む語、tokenStringの場合は引用符を除いた文字列
+	text string
+	// tokenWordの値を引用符で囲んでいた場合はtrue（比較演算子として解釈しない）
+	quotedValue bool
+}
+
+type lexer struct {
+	src []rune
+	pos int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && unicode.IsSpace(l.src[l.pos]) {
+		l.pos++
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	switch c := l.src[l.pos]; {
+	case c == '(':
+		l.pos++
+		return token{kind: tokenLParen, pos: start}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokenRParen, pos: start}, nil
+	case c == '-':
+		l.pos++
+		if l.pos >= len(l.src) || unicode.IsSpace(l.src[l.pos]) || l.src[l.pos] == ')' {
+			return token{}, &SyntaxError{start, "-の後に条件がありません。"}
+		}
+		return token{kind: tokenNot, pos: start}, nil
+	case c == '"':
+		s, err := l.quoted()
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokenString, pos: start, text: s}, nil
+	}
+
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if unicode.IsSpace(c) || c == '(' || c == ')' {
+			break
+		}
+		if c == '"' {
+			// 引用符は「キー:」の直後にのみ書ける
+			if !strings.HasSuffix(b.String(), ":") || strings.Count(b.String(), ":") != 1 {
+				return token{}, &SyntaxError{l.pos, "引用符の位置が不正です。"}
+			}
+			s, err := l.quoted()
+			if err != nil {
+				return token{}, err
+			}
+			b.WriteString(s)
+			if l.pos < len(l.src) && !unicode.IsSpace(l.src[l.pos]) && l.src[l.pos] != ')' {
+				return token{}, &SyntaxError{l.pos, "引用符の後に空白がありません。"}
+			}
+			return token{kind: tokenWord, pos: start, text: b.String(), quotedValue: true}, nil
+		}
+		b.WriteRune(c)
+		l.pos++
+	}
+
+	if b.String() == orKeyword {
+		return token{kind: tokenOr, pos: start}, nil
+	}
+	return token{kind: tokenWord, pos: start, text: b.String()}, nil
+}
+
+// 引用符で囲んだ文字列を読み込む。\" と \\ はエスケープとして扱う
+func (l *lexer) quoted() (string, error) {
+	start := l.pos
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		l.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if l.pos >= len(l.src) {
+				return "", &SyntaxError{start, "引用符が閉じられていません。"}
+			}
+			b.WriteRune(l.src[l.pos])
+			l.pos++
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return "", &SyntaxError{start, "引用符が閉じられていません。"}
+}
+
+type parser struct {
+	lexer      lexer
+	tok        token
+	depth      int
+	predicates int
+}
+
+// Parseは、フィルターを構文木に変換する。キーや値の誤りもここで検出する。
+func Parse(src string) (Node, error) {
+	if !utf8.ValidString(src) {
+		return nil, &SyntaxError{0, "文字コードが不正です。"}
+	}
+	if utf8.RuneCountInString(src) > MaxLength {
+		return nil, &SyntaxError{MaxLength, fmt.Sprintf("フィルターは%d文字以内で入力してください。", MaxLength)}
+	}
+
+	p := &parser{lexer: lexer{src: []rune(src)}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenEOF {
+		return nil, &SyntaxError{0, "条件がありません。"}
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEOF {
+		return nil, &SyntaxError{p.tok.pos, "対応する(がありません。"}
+	}
+	return n, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+// or := and ("OR" and)*
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokenOr {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{left, right}
+	}
+	return left, nil
+}
+
+// and := unary+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind != tokenEOF && p.tok.kind != tokenOr && p.tok.kind != tokenRParen {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{left, right}
+	}
+	return left, nil
+}
+
+// unary := "-" unary | "(" or ")" | predicate
+func (p *parser) parseUnary() (Node, error) {
+	switch p.tok.kind {
+	case tokenNot, tokenLParen:
+		p.depth++
+		if p.depth > maxDepth {
+			return nil, &SyntaxError{p.tok.pos, "括弧や否定の入れ子が深すぎます。"}
+		}
+		defer func() { p.depth-- }()
+	}
+
+	switch p.tok.kind {
+	case tokenNot:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{x}, nil
+
+	case tokenLParen:
+		open := p.tok.pos
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokenRParen {
+			return nil, &SyntaxError{p.tok.pos, "括弧の中に条件がありません。"}
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenRParen {
+			return nil, &SyntaxError{open, "括弧が閉じられていません。"}
+		}
+		return n, p.advance()
+
+	case tokenWord, tokenString:
+		p.predicates++
+		if p.predicates > maxPredicates {
+			return nil, &SyntaxError{p.tok.pos, fmt.Sprintf("条件は%d個以内で指定してください。", maxPredicates)}
+		}
+		pred, err := p.predicate(p.tok)
+		if err != nil {
+			return nil, err
+		}
+		return pred, p.advance()
+
+	case tokenOr:
+		return nil, &SyntaxError{p.tok.pos, "ORの前後に条件がありません。"}
+	case tokenRParen:
+		return nil, &SyntaxError{p.tok.pos, "対応する(がありません。"}
+	default:
+		return nil, &SyntaxError{p.tok.pos, "条件がありません。"}
+	}
+}
+
+// 語を条件に変換し、キーと値を検証する
+func (p *parser) predicate(tok token) (Predicate, error) {
+	// 引用符で囲んだ文字列とキーのない語はタイトルの条件
+	pred := Predicate{Field: FieldTitle, Op: OpEq, Value: tok.text}
+	if key, value, ok := strings.Cut(tok.text, ":"); ok && tok.kind == tokenWord {
+		pred = Predicate{Field: strings.ToLower(key), Op: OpEq, Value: value}
+		if !tok.quotedValue {
+			for _, op := range []string{OpLe, OpGe, OpLt, OpGt} {
+				if strings.HasPrefix(value, op) {
+					pred.Op, pred.Value = op, value[len(op):]
+					break
+				}
+			}
+		}
+	}
+
+	if err := validate(pred); err != nil {
+		return Predicate{}, &SyntaxError{tok.pos, err.Error()}
+	}
+	return pred, nil
+}
+
+// 値を検証する
+func validate(pred Predicate) error {
+	field, ok := fields[pred.Field]
+	if !ok {
+		return fmt.Errorf("不明なキー「%s」です。", pred.Field)
+	}
+	if !ops[pred.Op] {
+		return fmt.Errorf("演算子「%s」は使用できません。", pred.Op)
+	}
+	if pred.Op != OpEq && !field.comparable {
+		return fmt.Errorf("%sは比較できません。", pred.Field)
+	}
+	if pred.Value == "" {
+		return fmt.Errorf("%sの値がありません。", pred.Field)
+	}
+	if utf8.RuneCountInString(pred.Value) > maxValueLength {
+		return fmt.Errorf("値は%d文字以内で指定してください。", maxValueLength)
+	}
+
+	switch pred.Field {
+	case FieldIs:
+		if !isValues[strings.ToLower(pred.Value)] {
+			return fmt.Errorf("isにはopen、done、overdue、recurringのいずれかを指定してください。")
+		}
+	case FieldList:
+		if pred.Value != "none" {
+			if id, err := strconv.Atoi(pred.Value); err != nil || id <= 0 {
+				return fmt.Errorf("listにはリストのIDかnoneを指定してください。")
+			}
+		}
+	case FieldDue:
+		if _, err := parseDue(pred); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"backend/app/audit"
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// 一度に返却するアクティビティの最大件数
+const activityLimit = 100
+
+// アクティビティの種類。コメント以外は監査ログの操作から決める
+const (
+	activityComment   = "comment"
+	activityCreated   = "created"
+	activityUpdated   = "updated"
+	activityCompleted = "completed"
+	activityReopened  = "reopened"
+	activityReverted  = "reverted"
+	activityDeleted   = "deleted"
+)
+
+// Todoのコメントと変更を時系列に並べて取得する。
+// 変更は更新処理が記録した監査ログから求めるため、件数の上限まで新しいものから取得し、古い順に返す
+func GetTodoActivity(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteActivitiesResponse(w, []model.Activity{}, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var todoID int
+	if err := db.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&todoID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteActivitiesResponse(w, []model.Activity{}, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteActivitiesResponse(w, []model.Activity{}, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_GET_ACTIVITY)
+		}
+		return
+	}
+
+	changes, err := changeActivities(db, workspaceID, id)
+	if err != nil {
+		response.WriteActivitiesResponse(w, []model.Activity{}, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_GET_ACTIVITY)
+		return
+	}
+	comments, err := commentActivities(db, workspaceID, id)
+	if err != nil {
+		response.WriteActivitiesResponse(w, []model.Activity{}, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_GET_ACTIVITY)
+		return
+	}
+
+	// 同時刻の場合は変更をコメントより前に並べる
+	activities := append(changes, comments...)
+	slices.SortStableFunc(activities, func(a, b model.Activity) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	if len(activities) > activityLimit {
+		activities = activities[len(activities)-activityLimit:]
+	}
+
+	response.WriteActivitiesResponse(w, activities, http.StatusOK, "")
+}
+
+// Todoの監査ログから変更のアクティビティを古い順に返す
+func changeActivities(db *sql.DB, workspaceID, todoID int) ([]model.Activity, error) {
+	query := "SELECT actor_id, action, before_json, after_json, created_at FROM audit_logs WHERE workspace_id = ? AND todo_id = ? ORDER BY id DESC LIMIT ?"
+	rows, err := db.Query(query, workspaceID, todoID, activityLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activities := []model.Activity{}
+	for rows.Next() {
+		var (
+			actorID       sql.NullInt64
+			action        string
+			before, after []byte
+			createdAt     time.Time
+		)
+		if err := rows.Scan(&actorID, &action, &before, &after, &createdAt); err != nil {
+			return nil, err
+		}
+
+		activity, ok, err := changeActivity(audit.Action(action), before, after)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			activity.ActorID = &id
+		}
+		activity.CreatedAt = createdAt
+		activities = append(activities, activity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(activities)
+	return activities, nil
+}
+
+// 監査ログ1件の操作と変更前後の内容から、アクティビティの種類と変わった項目を求める。
+// 表示する項目が変わっていない更新はfalseを返す
+func changeActivity(action audit.Action, beforeJSON, afterJSON []byte) (model.Activity, bool, error) {
+	switch action {
+	case audit.ActionCreate:
+		return model.Activity{Type: activityCreated}, true, nil
+	case audit.ActionDelete:
+		return model.Activity{Type: activityDeleted}, true, nil
+	}
+
+	var before, after model.Todo
+	if err := json.Unmarshal(beforeJSON, &before); err != nil {
+		return model.Activity{}, false, err
+	}
+	if err := json.Unmarshal(afterJSON, &after); err != nil {
+		return model.Activity{}, false, err
+	}
+
+	activity := model.Activity{Type: activityUpdated, Changes: changedFields(&before, &after)}
+	switch {
+	case action == audit.ActionRevert:
+		activity.Type = activityReverted
+		return activity, true, nil
+	case !before.IsComplete && after.IsComplete:
+		activity.Type = activityCompleted
+	case before.IsComplete && !after.IsComplete:
+		activity.Type = activityReopened
+	case len(activity.Changes) == 0:
+		return model.Activity{}, false, nil
+	}
+	return activity, true, nil
+}
+
+// 値が変わった項目の名前を返す。完了状態と、完了状態とともに変わったステータスは
+// アクティビティの種類で表すため含めない
+func changedFields(before, after *model.Todo) []string {
+	var fields []string
+	if before.Title != after.Title {
+		fields = append(fields, "title")
+	}
+	if before.Status != after.Status && before.IsComplete == after.IsComplete {
+		fields = append(fields, "status")
+	}
+	if !equalPtr(before.Estimate, after.Estimate, func(a, b int) bool { return a == b }) {
+		fields = append(fields, "estimate")
+	}
+	if !equalPtr(before.ListID, after.ListID, func(a, b int) bool { return a == b }) {
+		fields = append(fields, "list_id")
+	}
+	if !equalPtr(before.MilestoneID, after.MilestoneID, func(a, b int) bool { return a == b }) {
+		fields = append(fields, "milestone_id")
+	}
+	if !equalPtr(before.DueAt, after.DueAt, time.Time.Equal) {
+		fields = append(fields, "due_at")
+	}
+	if before.Recurrence != after.Recurrence {
+		fields = append(fields, "recurrence")
+	}
+	if before.TimeZone != after.TimeZone {
+		fields = append(fields, "time_zone")
+	}
+	if before.Notes != after.Notes {
+		fields = append(fields, "notes")
+	}
+	return fields
+}
+
+func equalPtr[T any](a, b *T, eq func(T, T) bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return eq(*a, *b)
+}
+
+// Todoのコメントをアクティビティとして古い順に返す
+func commentActivities(db *sql.DB, workspaceID, todoID int) ([]model.Activity, error) {
+	query := "SELECT " + commentColumns + " FROM comments WHERE todo_id = ? AND workspace_id = ? ORDER BY id DESC LIMIT ?"
+	rows, err := db.Query(query, todoID, workspaceID, activityLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activities := []model.Activity{}
+	for rows.Next() {
+		var comment model.Comment
+		if err := scanComment(rows, &comment); err != nil {
+			return nil, err
+		}
+		authorID := comment.AuthorID
+		activities = append(activities, model.Activity{
+			Type:      activityComment,
+			ActorID:   &authorID,
+			Comment:   &comment,
+			CreatedAt: comment.CreatedAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(activities)
+	return activities, nil
+}
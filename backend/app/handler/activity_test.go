@@ -0,0 +1,121 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetTodoActivity(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	actor := testUserID
+
+	mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	// 監査ログは新しい順に返る
+	mock.ExpectQuery(`^SELECT actor_id, action, before_json, after_json, created_at FROM audit_logs WHERE workspace_id = \? AND todo_id = \? ORDER BY id DESC LIMIT \?$`).
+		WithArgs(testWorkspaceID, 1, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"actor_id", "action", "before_json", "after_json", "created_at"}).
+			AddRow(actor, "update", `{"title":"牛乳","is_complete":true,"revision":3}`, `{"title":"牛乳","is_complete":false,"revision":4}`, at(40)).
+			AddRow(nil, "update", `{"title":"牛乳","is_complete":false,"revision":2}`, `{"title":"牛乳","is_complete":false,"revision":3}`, at(30)).
+			AddRow(actor, "update", `{"title":"牛乳","is_complete":false,"revision":1}`, `{"title":"牛乳","is_complete":true,"revision":2}`, at(20)).
+			AddRow(actor, "update", `{"title":"牛乳を","is_complete":false,"due_at":null,"revision":1}`, `{"title":"牛乳","is_complete":false,"due_at":"2024-05-02T00:00:00Z","revision":1}`, at(5)).
+			AddRow(actor, "create", nil, `{"title":"牛乳を","revision":1}`, at(0)))
+	mock.ExpectQuery(`^SELECT id, todo_id, author_id, body, created_at, updated_at FROM comments WHERE todo_id = \? AND workspace_id = \? ORDER BY id DESC LIMIT \?$`).
+		WithArgs(1, testWorkspaceID, 100).
+		WillReturnRows(sqlmock.NewRows(commentRowColumns).
+			AddRow(2, 1, 11, "ありがとう", at(25), at(25)).
+			AddRow(1, 1, actor, "@alice 買ってきます", at(10), at(10)))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/todos/1/activity", "")
+	req.SetPathValue("id", "1")
+
+	handler.GetTodoActivity(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.ActivitiesResponse](t, rec)
+
+	// 表示する項目が変わっていない更新（リビジョンのみ）は含めない
+	wantTypes := []string{"created", "updated", "comment", "completed", "comment", "reopened"}
+	if len(got.Data) != len(wantTypes) {
+		t.Fatalf("want: %v, got: %+v", wantTypes, got.Data)
+	}
+	for i, want := range wantTypes {
+		if got.Data[i].Type != want {
+			t.Errorf("%d: want: %s, got: %s", i, want, got.Data[i].Type)
+		}
+	}
+	checkResponseBody(t, []string{"title", "due_at"}, got.Data[1].Changes)
+	if c := got.Data[2].Comment; c == nil || c.Body != "@alice 買ってきます" || *got.Data[2].ActorID != actor {
+		t.Errorf("コメントが返されていません: %+v", got.Data[2])
+	}
+}
+
+// 見積もりやマイルストーンだけを変えた更新も、変わった項目とともに含めることを確認する
+func TestGetTodoActivityChangedFields(t *testing.T) {
+	cases := map[string]struct {
+		before, after string
+		want          []string
+	}{
+		"見積もりだけを変えた": {
+			before: `{"title":"牛乳","estimate":3,"revision":1}`,
+			after:  `{"title":"牛乳","estimate":5,"revision":2}`,
+			want:   []string{"estimate"},
+		},
+		"見積もりを外した": {
+			before: `{"title":"牛乳","estimate":3,"revision":1}`,
+			after:  `{"title":"牛乳","revision":2}`,
+			want:   []string{"estimate"},
+		},
+		"マイルストーンだけを変えた": {
+			before: `{"title":"牛乳","revision":1}`,
+			after:  `{"title":"牛乳","milestone_id":2,"revision":2}`,
+			want:   []string{"milestone_id"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			at := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+			mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \?$`).
+				WithArgs(1, testWorkspaceID).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectQuery(`^SELECT actor_id, action, before_json, after_json, created_at FROM audit_logs`).
+				WithArgs(testWorkspaceID, 1, 100).
+				WillReturnRows(sqlmock.NewRows([]string{"actor_id", "action", "before_json", "after_json", "created_at"}).
+					AddRow(testUserID, "update", c.before, c.after, at))
+			mock.ExpectQuery(`^SELECT id, todo_id, author_id, body, created_at, updated_at FROM comments`).
+				WithArgs(1, testWorkspaceID, 100).
+				WillReturnRows(sqlmock.NewRows(commentRowColumns))
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodGet, "/todos/1/activity", "")
+			req.SetPathValue("id", "1")
+
+			handler.GetTodoActivity(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, http.StatusOK, rec.Code)
+			got := decodeResponseBody[model.ActivitiesResponse](t, rec)
+			if len(got.Data) != 1 || got.Data[0].Type != "updated" {
+				t.Fatalf("更新のアクティビティが返されていません: %+v", got.Data)
+			}
+			checkResponseBody(t, c.want, got.Data[0].Changes)
+		})
+	}
+}
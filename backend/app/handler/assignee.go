@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/event"
+	"backend/app/model"
+	"backend/app/outbox"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Todoの担当者をユーザーIDの順に取得する
+func GetTodoAssignees(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var todoID int
+	if err := db.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&todoID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTodoAssigneesResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteTodoAssigneesResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_GET_ASSIGNEE)
+		}
+		return
+	}
+
+	userIDs, err := loadAssignees(db, workspaceID, id)
+	if err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_GET_ASSIGNEE)
+		return
+	}
+
+	response.WriteTodoAssigneesResponse(w, &model.TodoAssignees{UserIDs: userIDs}, http.StatusOK, "")
+}
+
+// Todoの担当者を指定したユーザーで置き換える。担当者にできるのはワークスペースのメンバーのみ。
+// 担当者の変更はTodoの変更として記録し、追加されたユーザーと外されたユーザーには、それぞれ通知する
+func UpdateTodoAssignees(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	var input model.TodoAssignees
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+
+	// 入力値のバリデーション
+	if err := validator.TodoAssigneesInput(input); err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userIDs := slices.Clone(input.UserIDs)
+	slices.Sort(userIDs)
+	userIDs = slices.Compact(userIDs)
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_UPDATE_ASSIGNEE)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	// 通知にTodoの内容を含めるため、ロックと同時にTodoを取得しておく
+	todo, err := lockTodoForChange(tx, workspaceID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTodoAssigneesResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteTodoAssigneesResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_UPDATE_ASSIGNEE)
+		}
+		return
+	}
+
+	outsiders, err := nonMembers(tx, workspaceID, userIDs)
+	if err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_UPDATE_ASSIGNEE)
+		return
+	}
+	if len(outsiders) > 0 {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusBadRequest, fmt.Sprintf(constant.ASSIGNEE_ERR_NOT_MEMBER, joinIDs(outsiders, ", ")))
+		return
+	}
+
+	current, err := loadAssignees(tx, workspaceID, id)
+	if err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_UPDATE_ASSIGNEE)
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM todo_assignees WHERE todo_id = ? AND workspace_id = ?", id, workspaceID); err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_UPDATE_ASSIGNEE)
+		return
+	}
+
+	if len(userIDs) > 0 {
+		values := make([]string, len(userIDs))
+		args := make([]any, 0, len(userIDs)*3)
+		for i, userID := range userIDs {
+			values[i] = "(?, ?, ?)"
+			args = append(args, workspaceID, id, userID)
+		}
+		insertQuery := "INSERT INTO todo_assignees (workspace_id, todo_id, user_id) VALUES " + strings.Join(values, ", ")
+		if _, err := tx.Exec(insertQuery, args...); err != nil {
+			response.WriteTodoAssigneesResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_UPDATE_ASSIGNEE)
+			return
+		}
+	}
+
+	updated, err := recordRelatedChange(r.Context(), tx, &todo)
+	if err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_UPDATE_ASSIGNEE)
+		return
+	}
+	assigned, err := recordAssignments(r.Context(), tx, &todo, event.TodoAssigned, difference(userIDs, current))
+	if err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_UPDATE_ASSIGNEE)
+		return
+	}
+	unassigned, err := recordAssignments(r.Context(), tx, &todo, event.TodoUnassigned, difference(current, userIDs))
+	if err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_UPDATE_ASSIGNEE)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteTodoAssigneesResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_UPDATE_ASSIGNEE)
+		return
+	}
+
+	publishTodoEvents(append(append([]event.Event{updated}, assigned...), unassigned...)...)
+	response.WriteTodoAssigneesResponse(w, &model.TodoAssignees{UserIDs: userIDs}, http.StatusOK, "")
+}
+
+// Todoの担当者のユーザーIDを昇順に返す
+func loadAssignees(q querier, workspaceID, todoID int) ([]int, error) {
+	rows, err := q.Query("SELECT user_id FROM todo_assignees WHERE todo_id = ? AND workspace_id = ? ORDER BY user_id", todoID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := []int{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// 複数のTodoの担当者をまとめて読み込み、それぞれのTodoに設定する
+func attachAssignees(q querier, workspaceID int, todos []model.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(todos))
+	args := []any{workspaceID}
+	for i, todo := range todos {
+		placeholders[i] = "?"
+		args = append(args, todo.ID)
+	}
+
+	query := "SELECT todo_id, user_id FROM todo_assignees WHERE workspace_id = ? AND todo_id IN (" +
+		strings.Join(placeholders, ", ") + ") ORDER BY todo_id, user_id"
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	assignees := map[int][]int{}
+	for rows.Next() {
+		var todoID, userID int
+		if err := rows.Scan(&todoID, &userID); err != nil {
+			return err
+		}
+		assignees[todoID] = append(assignees[todoID], userID)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range todos {
+		todos[i].Assignees = assignees[todos[i].ID]
+	}
+	return nil
+}
+
+// 指定したユーザーのうち、ワークスペースのメンバーではないユーザーのIDを昇順に返す
+func nonMembers(q querier, workspaceID int, userIDs []int) ([]int, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(userIDs))
+	args := make([]any, 0, len(userIDs)+1)
+	args = append(args, workspaceID)
+	for i, userID := range userIDs {
+		placeholders[i] = "?"
+		args = append(args, userID)
+	}
+	query := "SELECT user_id FROM workspace_members WHERE workspace_id = ? AND user_id IN (" + strings.Join(placeholders, ", ") + ")"
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := map[int]bool{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		members[userID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var outsiders []int
+	for _, userID := range userIDs {
+		if !members[userID] {
+			outsiders = append(outsiders, userID)
+		}
+	}
+	return outsiders, nil
+}
+
+// 担当者の追加または解除の通知を記録する。自分で自分を追加・解除した場合は通知しない
+func recordAssignments(ctx context.Context, tx *sql.Tx, todo *model.Todo, eventType event.Type, userIDs []int) ([]event.Event, error) {
+	actorID := requestctx.UserID(ctx)
+	events := make([]event.Event, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userID == actorID {
+			continue
+		}
+		e, err := outbox.Record(tx, event.Event{
+			Type:        eventType,
+			WorkspaceID: requestctx.WorkspaceID(ctx),
+			ListID:      todo.ListID,
+			TodoID:      todo.ID,
+			Todo:        todo,
+			UserID:      userID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// aに含まれ、bに含まれないIDを返す
+func difference(a, b []int) []int {
+	var diff []int
+	for _, id := range a {
+		if !slices.Contains(b, id) {
+			diff = append(diff, id)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,214 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectAssigneeMembersは、担当者にするユーザーのメンバー確認を期待値として設定します。
+func expectAssigneeMembers(mock sqlmock.Sqlmock, userIDs ...int) {
+	rows := sqlmock.NewRows([]string{"user_id"})
+	for _, id := range userIDs {
+		rows.AddRow(id)
+	}
+	mock.ExpectQuery(`^SELECT user_id FROM workspace_members WHERE workspace_id = \? AND user_id IN \(`).
+		WillReturnRows(rows)
+}
+
+func TestGetTodoAssignees(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`^SELECT user_id FROM todo_assignees WHERE todo_id = \? AND workspace_id = \? ORDER BY user_id$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(7).AddRow(11))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/todos/1/assignees", "")
+	req.SetPathValue("id", "1")
+
+	handler.GetTodoAssignees(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.TodoAssigneesResponse](t, rec)
+	checkResponseBody(t, &model.TodoAssignees{UserIDs: []int{7, 11}}, got.Data)
+}
+
+func TestUpdateTodoAssignees(t *testing.T) {
+	cases := map[string]struct {
+		body           string
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantMessage    string
+		wantData       *model.TodoAssignees
+	}{
+		"追加と解除をそれぞれ通知": {
+			body: `{"user_ids": [12, 11, 12]}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectTodoLock(mock, 1)
+				expectAssigneeMembers(mock, 11, 12)
+				mock.ExpectQuery(`^SELECT user_id FROM todo_assignees`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(10).AddRow(11))
+				mock.ExpectExec(`^DELETE FROM todo_assignees WHERE todo_id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(`^INSERT INTO todo_assignees \(workspace_id, todo_id, user_id\) VALUES \(\?, \?, \?\), \(\?, \?, \?\)$`).
+					WithArgs(testWorkspaceID, 1, 11, testWorkspaceID, 1, 12).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				expectRelatedChangeBy(mock, 1, testUserID)
+				expectOutbox(mock, "todo.assigned")
+				expectOutbox(mock, "todo.unassigned")
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusOK,
+			wantData:       &model.TodoAssignees{UserIDs: []int{11, 12}},
+		},
+		"自分を担当者にした場合は通知しない": {
+			body: `{"user_ids": [7]}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT .* FROM todos`).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+				expectAssigneeMembers(mock, testUserID)
+				mock.ExpectQuery(`^SELECT user_id FROM todo_assignees`).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+				mock.ExpectExec(`^DELETE FROM todo_assignees`).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`^INSERT INTO todo_assignees`).
+					WithArgs(testWorkspaceID, 1, testUserID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRelatedChangeBy(mock, 1, testUserID)
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusOK,
+			wantData:       &model.TodoAssignees{UserIDs: []int{testUserID}},
+		},
+		"メンバーではないユーザー": {
+			body: `{"user_ids": [11, 12, 13]}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT .* FROM todos`).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+				expectAssigneeMembers(mock, 12)
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "ワークスペースのメンバーではないユーザーは担当者にできません（ユーザーID: 11, 13）。",
+		},
+		"Todoが存在しない": {
+			body: `{"user_ids": [11]}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT .* FROM todos`).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantMessage:    "TODOが見つかりません。",
+		},
+		"不正なユーザーID": {
+			body:           `{"user_ids": [0]}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "担当者のユーザーIDが不正です。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createUserRequest(t, http.MethodPut, "/todos/1/assignees", c.body)
+			req.SetPathValue("id", "1")
+
+			handler.UpdateTodoAssignees(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.TodoAssigneesResponse](t, rec)
+			if got.Status.ErrorMessage != c.wantMessage {
+				t.Errorf("want: %s, got: %s", c.wantMessage, got.Status.ErrorMessage)
+			}
+			checkResponseBody(t, c.wantData, got.Data)
+		})
+	}
+}
+
+// assignee=meを指定すると、自分が担当するTodoのみを担当者とともに取得することを確認する
+func TestGetTodosAssignedToMe(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE workspace_id = \? AND EXISTS \(SELECT 1 FROM todo_assignees WHERE todo_assignees.todo_id = todos.id AND todo_assignees.user_id = \?\)$`).
+		WithArgs(testWorkspaceID, testUserID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "料理", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+	mock.ExpectQuery(`^SELECT todo_id, user_id FROM todo_assignees WHERE workspace_id = \? AND todo_id IN \(\?\) ORDER BY todo_id, user_id$`).
+		WithArgs(testWorkspaceID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"todo_id", "user_id"}).AddRow(1, testUserID).AddRow(1, 11))
+
+	rec := httptest.NewRecorder()
+	req := createUserRequest(t, http.MethodGet, "/todos?assignee=me&include=assignees", "")
+
+	handler.GetTodos(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.TodosResponse](t, rec)
+	checkResponseBody(t, createTodosResponse(t, []model.Todo{
+		{ID: 1, Title: "料理", Status: "todo", Revision: 1, Assignees: []int{testUserID, 11}},
+	}, http.StatusOK, ""), got)
+}
+
+func TestGetTodosInvalidAssignee(t *testing.T) {
+	cases := map[string]struct {
+		req            func(t *testing.T) *http.Request
+		wantStatusCode int
+		wantMessage    string
+	}{
+		"未認証でme": {
+			req: func(t *testing.T) *http.Request {
+				return createTestRequest(t, http.MethodGet, "/todos?assignee=me", "")
+			},
+			wantStatusCode: http.StatusUnauthorized,
+			wantMessage:    "ユーザーの認証が必要です。",
+		},
+		"不正な値": {
+			req: func(t *testing.T) *http.Request {
+				return createUserRequest(t, http.MethodGet, "/todos?assignee=someone", "")
+			},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "assigneeにはmeかユーザーIDを指定してください。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			rec := httptest.NewRecorder()
+			handler.GetTodos(rec, c.req(t))
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.TodosResponse](t, rec)
+			if got.Status.ErrorMessage != c.wantMessage {
+				t.Errorf("want: %s, got: %s", c.wantMessage, got.Status.ErrorMessage)
+			}
+		})
+	}
+}
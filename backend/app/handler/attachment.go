@@ -0,0 +1,570 @@
+package handler
+
+import (
+	"backend/app/blob"
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/thumbnail"
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// 添付ファイル1件の最大サイズ（バイト）。起動時に設定で変更できる
+	MaxAttachmentSize int64 = 10 << 20
+	// ワークスペースごとの添付ファイルの合計サイズの上限（バイト）。起動時に設定で変更できる
+	AttachmentQuota int64 = 1 << 30
+)
+
+const (
+	// 1つのTodoに添付できるファイルの上限
+	maxAttachmentsPerTodo = 20
+	// マルチパートの境界やファイル以外の項目に見込むサイズ
+	multipartOverhead = 64 << 10
+	// ファイル名の最大文字数
+	maxAttachmentFilename = 200
+)
+
+// attachmentsテーブルから取得するカラム。scanAttachmentと順序を合わせること
+const attachmentColumns = "id, todo_id, filename, content_type, size, blob_key, thumbnail_status, thumbnail_key, created_at"
+
+// 添付できるファイルのメディアタイプ。内容から判定した値で確認する
+var allowedAttachmentTypes = map[string]bool{
+	"image/png":                 true,
+	"image/jpeg":                true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"application/pdf":           true,
+	"text/plain; charset=utf-8": true,
+	"application/zip":           true,
+}
+
+// ZIP形式のOffice文書は、内容からは判別できないため拡張子からメディアタイプを決める
+var officeAttachmentTypes = map[string]string{
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// 読み込んだサイズが上限を超えた場合に返すエラー
+var errAttachmentTooLarge = errors.New("attachment too large")
+
+// attachmentLimitReaderは、上限を超えて読み込んだ時点でerrAttachmentTooLargeを返す
+type attachmentLimitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *attachmentLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, errAttachmentTooLarge
+	}
+	return n, err
+}
+
+// attachmentColumnsの順序で添付ファイルを読み込む
+func scanAttachment(s rowScanner, attachment *model.Attachment) error {
+	var thumbnailKey string
+	if err := s.Scan(
+		&attachment.ID, &attachment.TodoID, &attachment.Filename, &attachment.ContentType,
+		&attachment.Size, &attachment.SHA256, &attachment.ThumbnailStatus, &thumbnailKey, &attachment.CreatedAt,
+	); err != nil {
+		return err
+	}
+	if attachment.ThumbnailStatus == thumbnail.StatusReady {
+		attachment.ThumbnailURL = thumbnailURL(thumbnailKey)
+	}
+	return nil
+}
+
+// 縮小画像のURL。縮小画像の内容のキーを含むため、内容が変わればURLも変わる
+func thumbnailURL(key string) string {
+	return "/thumbnails/" + key
+}
+
+// Todoの添付ファイルを追加した順に取得する
+func GetAttachments(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteAttachmentsResponse(w, []model.Attachment{}, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var todoID int
+	if err := db.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&todoID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteAttachmentsResponse(w, []model.Attachment{}, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteAttachmentsResponse(w, []model.Attachment{}, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_GET_ATTACHMENT)
+		}
+		return
+	}
+
+	rows, err := db.Query("SELECT "+attachmentColumns+" FROM attachments WHERE todo_id = ? AND workspace_id = ? ORDER BY id", id, workspaceID)
+	if err != nil {
+		response.WriteAttachmentsResponse(w, []model.Attachment{}, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_GET_ATTACHMENT)
+		return
+	}
+	defer rows.Close()
+
+	attachments := []model.Attachment{}
+	for rows.Next() {
+		var attachment model.Attachment
+		if err := scanAttachment(rows, &attachment); err != nil {
+			response.WriteAttachmentsResponse(w, []model.Attachment{}, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_GET_ATTACHMENT)
+			return
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	response.WriteAttachmentsResponse(w, attachments, http.StatusOK, "")
+}
+
+// multipart/form-dataのfileという項目で送信されたファイルをTodoに添付する。
+// ファイルはリクエストボディから読み込みながら保存し、メディアタイプは内容から判定する
+func UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	store := blob.Default()
+	if store == nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT)
+		return
+	}
+
+	// JSONのリクエストボディの上限は適用されないため、ここで上限を設ける
+	r.Body = http.MaxBytesReader(w, r.Body, MaxAttachmentSize+multipartOverhead)
+	part, err := attachmentPart(r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			response.WriteAttachmentResponse(w, nil, http.StatusRequestEntityTooLarge, constant.ATTACHMENT_ERR_TOO_LARGE)
+		} else {
+			response.WriteAttachmentResponse(w, nil, http.StatusBadRequest, constant.ATTACHMENT_ERR_INVALID_FORM)
+		}
+		return
+	}
+	defer part.Close()
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	// 保存する前に、TODOの存在と空き容量を確認する
+	var todoID int
+	if err := db.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&todoID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteAttachmentResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT)
+		}
+		return
+	}
+	count, used, err := attachmentUsage(db, workspaceID, id, false)
+	if err != nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT)
+		return
+	}
+	if code, errMessage := checkAttachmentQuota(count, used, 0); errMessage != "" {
+		response.WriteAttachmentResponse(w, nil, code, errMessage)
+		return
+	}
+
+	// 残りの容量がファイルの上限より小さい場合は、残りの容量まで読み込む
+	limit, limitedByQuota := MaxAttachmentSize, false
+	if remaining := AttachmentQuota - used; remaining < limit {
+		limit, limitedByQuota = remaining, true
+	}
+
+	content := bufio.NewReaderSize(part, 512)
+	head, err := content.Peek(512)
+	if len(head) == 0 {
+		if err == io.EOF {
+			response.WriteAttachmentResponse(w, nil, http.StatusBadRequest, constant.ATTACHMENT_ERR_EMPTY_FILE)
+		} else {
+			response.WriteAttachmentResponse(w, nil, http.StatusBadRequest, constant.ATTACHMENT_ERR_INVALID_FORM)
+		}
+		return
+	}
+
+	filename := sanitizeFilename(part.FileName())
+	contentType, ok := detectAttachmentType(head, filename)
+	if !ok {
+		response.WriteAttachmentResponse(w, nil, http.StatusUnsupportedMediaType, constant.ATTACHMENT_ERR_UNSUPPORTED_TYPE)
+		return
+	}
+
+	staged, err := store.Stage(r.Context(), &attachmentLimitReader{r: content, n: limit})
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, errAttachmentTooLarge) && limitedByQuota:
+			response.WriteAttachmentResponse(w, nil, http.StatusRequestEntityTooLarge, constant.ATTACHMENT_ERR_QUOTA_EXCEEDED)
+		case errors.Is(err, errAttachmentTooLarge), errors.As(err, &maxBytesErr):
+			response.WriteAttachmentResponse(w, nil, http.StatusRequestEntityTooLarge, constant.ATTACHMENT_ERR_TOO_LARGE)
+		default:
+			response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT)
+		}
+		return
+	}
+	// 登録できなかった場合は一時的に保存した内容を削除する。Commit後のDiscardは何もしない
+	defer staged.Discard()
+
+	attachment := &model.Attachment{TodoID: id, Filename: filename, ContentType: contentType, Size: staged.Size(), SHA256: staged.Key()}
+	if thumbnail.Supported(contentType) {
+		attachment.ThumbnailStatus = thumbnail.StatusPending
+	}
+	if code, errMessage := insertAttachment(r.Context(), workspaceID, attachment); errMessage != "" {
+		response.WriteAttachmentResponse(w, nil, code, errMessage)
+		return
+	}
+
+	// 内容は添付ファイルを登録してから参照できるようにし、同じ内容の参照を数えて削除する処理に消されないようにする
+	if err := staged.Commit(r.Context()); err != nil {
+		if _, err := db.Exec("DELETE FROM attachments WHERE id = ? AND workspace_id = ?", attachment.ID, workspaceID); err != nil {
+			log.Printf("failed to delete attachment %d without content: %v", attachment.ID, err)
+		}
+		response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT)
+		return
+	}
+
+	// キューが一杯で依頼できなかった場合も、作成待ちの添付ファイルは後で拾い直される
+	if pool := thumbnail.Default(); pool != nil && attachment.ThumbnailStatus == thumbnail.StatusPending {
+		pool.Enqueue(attachment.ID)
+	}
+
+	response.WriteAttachmentResponse(w, attachment, http.StatusCreated, "")
+}
+
+// フォームからfileという名前のファイルの項目を探す
+func attachmentPart(r *http.Request) (*multipart.Part, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// 保存した内容を添付ファイルとして登録する。登録に失敗した場合はステータスコードとエラーメッセージを返す
+func insertAttachment(ctx context.Context, workspaceID int, attachment *model.Attachment) (int, string) {
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		return http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	if err := lockTodo(tx, workspaceID, attachment.TodoID); err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO
+		}
+		return http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT
+	}
+
+	// 同じワークスペースへの同時のアップロードで容量を超えないよう、ロックして集計し直す
+	count, used, err := attachmentUsage(tx, workspaceID, attachment.TodoID, true)
+	if err != nil {
+		return http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT
+	}
+	if code, errMessage := checkAttachmentQuota(count, used, attachment.Size); errMessage != "" {
+		return code, errMessage
+	}
+
+	insertQuery := "INSERT INTO attachments (workspace_id, todo_id, filename, content_type, size, blob_key, thumbnail_status) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	result, err := tx.Exec(
+		insertQuery,
+		workspaceID, attachment.TodoID, attachment.Filename, attachment.ContentType, attachment.Size, attachment.SHA256, attachment.ThumbnailStatus,
+	)
+	if err != nil {
+		return http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT
+	}
+	attachment.ID = int(id)
+
+	if err := tx.QueryRow("SELECT created_at FROM attachments WHERE id = ?", id).Scan(&attachment.CreatedAt); err != nil {
+		return http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT
+	}
+
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_ADD_ATTACHMENT
+	}
+	return 0, ""
+}
+
+// Todoの添付ファイルの件数と、ワークスペースの添付ファイルの合計サイズを返す
+func attachmentUsage(q querier, workspaceID, todoID int, forUpdate bool) (int, int64, error) {
+	query := "SELECT COUNT(CASE WHEN todo_id = ? THEN 1 END), COALESCE(SUM(size), 0) FROM attachments WHERE workspace_id = ?"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var count int
+	var used int64
+	err := q.QueryRow(query, todoID, workspaceID).Scan(&count, &used)
+	return count, used, err
+}
+
+// 添付ファイルを追加できるかどうかを確認する。追加できない場合はステータスコードとエラーメッセージを返す
+func checkAttachmentQuota(count int, used, size int64) (int, string) {
+	if count >= maxAttachmentsPerTodo {
+		return http.StatusBadRequest, constant.ATTACHMENT_ERR_TOO_MANY_ATTACHMENTS
+	}
+	if used+size > AttachmentQuota || used >= AttachmentQuota {
+		return http.StatusRequestEntityTooLarge, constant.ATTACHMENT_ERR_QUOTA_EXCEEDED
+	}
+	return 0, ""
+}
+
+// 内容の先頭からメディアタイプを判定し、添付できる形式かどうかとともに返す
+func detectAttachmentType(head []byte, filename string) (string, bool) {
+	contentType := http.DetectContentType(head)
+	if contentType == "application/zip" {
+		if officeType, ok := officeAttachmentTypes[strings.ToLower(path.Ext(filename))]; ok {
+			return officeType, true
+		}
+	}
+	return contentType, allowedAttachmentTypes[contentType]
+}
+
+// ファイル名からディレクトリと制御文字を取り除く
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if runes := []rune(name); len(runes) > maxAttachmentFilename {
+		// 拡張子を残して切り詰める
+		ext := []rune(path.Ext(name))
+		if len(ext) >= maxAttachmentFilename {
+			ext = nil
+		}
+		name = string(runes[:maxAttachmentFilename-len(ext)]) + string(ext)
+	}
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	return name
+}
+
+// 添付ファイルの内容を返す。Rangeを指定した場合は、指定した範囲のみ返す
+func GetAttachmentContent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+	attachmentID, err := strconv.Atoi(r.PathValue("attachmentID"))
+	if err != nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	store := blob.Default()
+	if store == nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_GET_ATTACHMENT)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var attachment model.Attachment
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE id = ? AND todo_id = ? AND workspace_id = ?"
+	if err := scanAttachment(db.QueryRow(query, attachmentID, id, workspaceID), &attachment); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteAttachmentResponse(w, nil, http.StatusNotFound, constant.ATTACHMENT_ERR_NOT_FOUND_ATTACHMENT)
+		} else {
+			response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_GET_ATTACHMENT)
+		}
+		return
+	}
+
+	content, err := store.Open(r.Context(), attachment.SHA256)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			response.WriteAttachmentResponse(w, nil, http.StatusNotFound, constant.ATTACHMENT_ERR_NOT_FOUND_ATTACHMENT)
+		} else {
+			response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_GET_ATTACHMENT)
+		}
+		return
+	}
+	defer content.Close()
+
+	// ブラウザで開いてもスクリプトが実行されないよう、保存を促して内容の推測も禁止する
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	// 内容はダイジェストで識別するため、そのままETagとして使う
+	w.Header().Set("ETag", `"`+attachment.SHA256+`"`)
+
+	http.ServeContent(w, r, "", attachment.CreatedAt, content)
+}
+
+// 添付ファイルを削除する。内容は他の添付ファイルから参照されていなければ削除する
+func DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+	attachmentID, err := strconv.Atoi(r.PathValue("attachmentID"))
+	if err != nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_DELETE_ATTACHMENT)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	var key, thumbnailKey string
+	selectQuery := "SELECT blob_key, thumbnail_key FROM attachments WHERE id = ? AND todo_id = ? AND workspace_id = ? FOR UPDATE"
+	if err := tx.QueryRow(selectQuery, attachmentID, id, workspaceID).Scan(&key, &thumbnailKey); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteAttachmentResponse(w, nil, http.StatusNotFound, constant.ATTACHMENT_ERR_NOT_FOUND_ATTACHMENT)
+		} else {
+			response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_DELETE_ATTACHMENT)
+		}
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM attachments WHERE id = ? AND workspace_id = ?", attachmentID, workspaceID); err != nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_DELETE_ATTACHMENT)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_DELETE_ATTACHMENT)
+		return
+	}
+
+	keys := []string{key}
+	if thumbnailKey != "" {
+		keys = append(keys, thumbnailKey)
+	}
+	releaseBlobs(r.Context(), db, blob.Default(), keys)
+	response.WriteAttachmentResponse(w, nil, http.StatusOK, "")
+}
+
+// Todoの添付ファイルをすべて削除し、参照していた内容のキーを返す。
+// 内容はコミット後にreleaseBlobsで削除する
+func purgeAttachments(tx *sql.Tx, workspaceID, todoID int) ([]string, error) {
+	rows, err := tx.Query("SELECT blob_key, thumbnail_key FROM attachments WHERE todo_id = ? AND workspace_id = ?", todoID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	seen := map[string]bool{"": true}
+	for rows.Next() {
+		var key, thumbnailKey string
+		if err := rows.Scan(&key, &thumbnailKey); err != nil {
+			return nil, err
+		}
+		for _, k := range []string{key, thumbnailKey} {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec("DELETE FROM attachments WHERE todo_id = ? AND workspace_id = ?", todoID, workspaceID); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// どの添付ファイルからも参照されなくなった内容を削除する。
+// 内容の削除に失敗しても添付ファイルの削除は完了しているため、エラーはログに残すのみとする
+func releaseBlobs(ctx context.Context, db *sql.DB, store blob.Store, keys []string) {
+	if store == nil {
+		return
+	}
+
+	for _, key := range keys {
+		if err := releaseBlob(ctx, db, store, key); err != nil {
+			log.Printf("failed to release blob %s: %v", key, err)
+		}
+	}
+}
+
+// 参照がなければ内容を削除する。参照を数えてから削除し終えるまでロックを保持し、
+// 同じ内容を参照する添付ファイルの登録を待たせる。登録する側は登録をコミットしてから内容を配置するため、削除した内容も配置し直される
+func releaseBlob(ctx context.Context, db *sql.DB, store blob.Store, key string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	// 同じ内容は他のワークスペースからも、縮小画像としても参照されるため、すべての参照を数える
+	var references int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM attachments WHERE blob_key = ? OR thumbnail_key = ? FOR UPDATE", key, key).Scan(&references); err != nil {
+		return err
+	}
+	if references > 0 {
+		return nil
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
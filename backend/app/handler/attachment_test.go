@@ -0,0 +1,409 @@
+package handler_test
+
+import (
+	"backend/app/blob"
+	"backend/app/handler"
+	"backend/app/model"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// attachmentsテーブルから取得するカラム
+var attachmentRowColumns = []string{"id", "todo_id", "filename", "content_type", "size", "blob_key", "thumbnail_status", "thumbnail_key", "created_at"}
+
+// PNGのシグネチャから始まる内容
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// setUpBlobStoreは、一時ディレクトリのストレージを設定し、それを返します。
+func setUpBlobStore(t *testing.T) *blob.LocalStore {
+	t.Helper()
+
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("ストレージの作成に失敗しました: %s", err)
+	}
+	blob.SetDefault(store)
+	t.Cleanup(func() { blob.SetDefault(nil) })
+	return store
+}
+
+// expectBlobReleaseは、参照を数えてから内容を削除するまでのトランザクションを期待値として設定します。
+func expectBlobRelease(mock sqlmock.Sqlmock, key string, references int) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT COUNT\(\*\) FROM attachments WHERE blob_key = \? OR thumbnail_key = \? FOR UPDATE$`).
+		WithArgs(key, key).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(references))
+	if references > 0 {
+		mock.ExpectRollback()
+	} else {
+		mock.ExpectCommit()
+	}
+}
+
+// createUploadRequestは、fileという項目にファイルを含むマルチパートのリクエストを作成し、それを返します。
+func createUploadRequest(t *testing.T, filename string, content []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("フォームの作成に失敗しました: %s", err)
+	}
+	part.Write(content)
+	form.Close()
+
+	req := createTestRequest(t, http.MethodPost, "/todos/1/attachments", body.String())
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.SetPathValue("id", "1")
+	return req
+}
+
+// expectAttachmentUsageは、添付ファイルの件数と合計サイズの集計を期待値として設定します。
+func expectAttachmentUsage(mock sqlmock.Sqlmock, count int, used int64) {
+	mock.ExpectQuery(`^SELECT COUNT\(CASE WHEN todo_id = \? THEN 1 END\), COALESCE\(SUM\(size\), 0\) FROM attachments WHERE workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "used"}).AddRow(count, used))
+}
+
+func TestUploadAttachment(t *testing.T) {
+	t.Run("内容から判定した形式で保存", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+		store := setUpBlobStore(t)
+
+		createdAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \?$`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		expectAttachmentUsage(mock, 0, 0)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(`^SELECT COUNT\(CASE WHEN todo_id = \? THEN 1 END\), COALESCE\(SUM\(size\), 0\) FROM attachments WHERE workspace_id = \? FOR UPDATE$`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "used"}).AddRow(0, 0))
+		mock.ExpectExec(`^INSERT INTO attachments \(workspace_id, todo_id, filename, content_type, size, blob_key, thumbnail_status\) VALUES \(\?, \?, \?, \?, \?, \?, \?\)$`).
+			WithArgs(testWorkspaceID, 1, "photo.png", "image/png", int64(len(testPNG)), sqlmock.AnyArg(), "pending").
+			WillReturnResult(sqlmock.NewResult(5, 1))
+		mock.ExpectQuery(`^SELECT created_at FROM attachments WHERE id = \?$`).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+		mock.ExpectCommit()
+
+		rec := httptest.NewRecorder()
+		// 送信されたファイル名のディレクトリは取り除く
+		req := createUploadRequest(t, `C:\Users\me\photo.png`, testPNG)
+
+		handler.UploadAttachment(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusCreated, rec.Code)
+		got := decodeResponseBody[model.AttachmentResponse](t, rec)
+		if got.Data == nil {
+			t.Fatalf("添付ファイルが返されていません")
+		}
+		checkResponseBody(t, &model.Attachment{
+			ID:              5,
+			TodoID:          1,
+			Filename:        "photo.png",
+			ContentType:     "image/png",
+			Size:            int64(len(testPNG)),
+			SHA256:          got.Data.SHA256,
+			ThumbnailStatus: "pending",
+			CreatedAt:       createdAt,
+		}, got.Data)
+
+		content, err := store.Open(context.Background(), got.Data.SHA256)
+		if err != nil {
+			t.Fatalf("内容が保存されていません: %s", err)
+		}
+		defer content.Close()
+		if saved, _ := io.ReadAll(content); !bytes.Equal(saved, testPNG) {
+			t.Errorf("保存された内容が一致しません: %q", saved)
+		}
+	})
+
+	cases := map[string]struct {
+		filename       string
+		content        []byte
+		quota          int64
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantMessage    string
+	}{
+		"拡張子に関わらず内容がHTMLの場合は添付できない": {
+			filename: "photo.png",
+			content:  []byte("<html><script>alert(1)</script></html>"),
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT id FROM todos`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				expectAttachmentUsage(mock, 0, 0)
+			},
+			wantStatusCode: http.StatusUnsupportedMediaType,
+			wantMessage:    "添付できないファイルの形式です。",
+		},
+		"空のファイル": {
+			filename: "empty.txt",
+			content:  []byte{},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT id FROM todos`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				expectAttachmentUsage(mock, 0, 0)
+			},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "空のファイルは添付できません。",
+		},
+		"添付ファイルの件数が上限": {
+			filename: "photo.png",
+			content:  testPNG,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT id FROM todos`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				expectAttachmentUsage(mock, 20, 0)
+			},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "1つのTODOに添付できるファイルは20件までです。",
+		},
+		"読み込み中に容量を超えた": {
+			filename: "photo.png",
+			content:  testPNG,
+			quota:    100,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT id FROM todos`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				expectAttachmentUsage(mock, 3, 95)
+			},
+			wantStatusCode: http.StatusRequestEntityTooLarge,
+			wantMessage:    "ワークスペースの添付ファイルの容量を超えています。",
+		},
+		"登録時に容量を超えた": {
+			filename: "photo.png",
+			content:  testPNG,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT id FROM todos`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				expectAttachmentUsage(mock, 0, 0)
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`^SELECT COUNT\(CASE WHEN todo_id = \? THEN 1 END\), COALESCE\(SUM\(size\), 0\) FROM attachments WHERE workspace_id = \? FOR UPDATE$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"count", "used"}).AddRow(0, handler.AttachmentQuota))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusRequestEntityTooLarge,
+			wantMessage:    "ワークスペースの添付ファイルの容量を超えています。",
+		},
+		"TODOが見つかりません": {
+			filename: "photo.png",
+			content:  testPNG,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT id FROM todos`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantMessage:    "TODOが見つかりません。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+			store := setUpBlobStore(t)
+
+			if c.quota > 0 {
+				defer func(quota int64) { handler.AttachmentQuota = quota }(handler.AttachmentQuota)
+				handler.AttachmentQuota = c.quota
+			}
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createUploadRequest(t, c.filename, c.content)
+
+			handler.UploadAttachment(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.AttachmentResponse](t, rec)
+			if got.Status.ErrorMessage != c.wantMessage {
+				t.Errorf("want: %s, got: %s", c.wantMessage, got.Status.ErrorMessage)
+			}
+
+			// 添付できなかった内容は保存しない
+			if entries, _ := os.ReadDir(store.Dir); len(entries) != 1 {
+				t.Errorf("内容が残っています: %v", entries)
+			}
+			if tmp, _ := os.ReadDir(filepath.Join(store.Dir, "tmp")); len(tmp) != 0 {
+				t.Errorf("一時ファイルが残っています: %v", tmp)
+			}
+		})
+	}
+
+	t.Run("fileの項目がない", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+		setUpBlobStore(t)
+
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("title", "photo")
+		form.Close()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPost, "/todos/1/attachments", body.String())
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.SetPathValue("id", "1")
+
+		handler.UploadAttachment(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestGetAttachmentContent(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+	store := setUpBlobStore(t)
+
+	key, size, err := store.Put(context.Background(), bytes.NewReader(testPNG))
+	if err != nil {
+		t.Fatalf("保存に失敗しました: %s", err)
+	}
+	createdAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("範囲を指定して取得", func(t *testing.T) {
+		mock.ExpectQuery(`^SELECT id, todo_id, filename, content_type, size, blob_key, thumbnail_status, thumbnail_key, created_at FROM attachments WHERE id = \? AND todo_id = \? AND workspace_id = \?$`).
+			WithArgs(5, 1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(attachmentRowColumns).
+				AddRow(5, 1, "写真.png", "image/png", size, key, "", "", createdAt))
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodGet, "/todos/1/attachments/5/content", "")
+		req.Header.Set("Range", "bytes=1-3")
+		req.SetPathValue("id", "1")
+		req.SetPathValue("attachmentID", "5")
+
+		handler.GetAttachmentContent(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusPartialContent, rec.Code)
+		if got := rec.Body.String(); got != "PNG" {
+			t.Errorf("want: PNG, got: %q", got)
+		}
+		wantHeaders := map[string]string{
+			"Content-Type":           "image/png",
+			"Content-Range":          "bytes 1-3/16",
+			"Content-Disposition":    "attachment; filename*=utf-8''%E5%86%99%E7%9C%9F.png",
+			"X-Content-Type-Options": "nosniff",
+			"ETag":                   `"` + key + `"`,
+		}
+		for name, want := range wantHeaders {
+			if got := rec.Header().Get(name); got != want {
+				t.Errorf("%s: want: %s, got: %s", name, want, got)
+			}
+		}
+	})
+
+	t.Run("ETagが一致する場合は内容を返さない", func(t *testing.T) {
+		mock.ExpectQuery(`^SELECT .* FROM attachments`).
+			WithArgs(5, 1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(attachmentRowColumns).
+				AddRow(5, 1, "photo.png", "image/png", size, key, "", "", createdAt))
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodGet, "/todos/1/attachments/5/content", "")
+		req.Header.Set("If-None-Match", `"`+key+`"`)
+		req.SetPathValue("id", "1")
+		req.SetPathValue("attachmentID", "5")
+
+		handler.GetAttachmentContent(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusNotModified, rec.Code)
+	})
+
+	t.Run("添付ファイルが見つかりません", func(t *testing.T) {
+		mock.ExpectQuery(`^SELECT .* FROM attachments`).
+			WithArgs(6, 1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(attachmentRowColumns))
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodGet, "/todos/1/attachments/6/content", "")
+		req.SetPathValue("id", "1")
+		req.SetPathValue("attachmentID", "6")
+
+		handler.GetAttachmentContent(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	cases := map[string]struct {
+		references  int
+		wantRemoved bool
+	}{
+		"参照がなくなった内容は削除":   {references: 0, wantRemoved: true},
+		"他から参照されている内容は残す": {references: 1, wantRemoved: false},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+			store := setUpBlobStore(t)
+
+			key, _, err := store.Put(context.Background(), bytes.NewReader(testPNG))
+			if err != nil {
+				t.Fatalf("保存に失敗しました: %s", err)
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`^SELECT blob_key, thumbnail_key FROM attachments WHERE id = \? AND todo_id = \? AND workspace_id = \? FOR UPDATE$`).
+				WithArgs(5, 1, testWorkspaceID).
+				WillReturnRows(sqlmock.NewRows([]string{"blob_key", "thumbnail_key"}).AddRow(key, ""))
+			mock.ExpectExec(`^DELETE FROM attachments WHERE id = \? AND workspace_id = \?$`).
+				WithArgs(5, testWorkspaceID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			expectBlobRelease(mock, key, c.references)
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodDelete, "/todos/1/attachments/5", "")
+			req.SetPathValue("id", "1")
+			req.SetPathValue("attachmentID", "5")
+
+			handler.DeleteAttachment(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, http.StatusOK, rec.Code)
+			_, err = store.Open(context.Background(), key)
+			if removed := errors.Is(err, blob.ErrNotFound); removed != c.wantRemoved {
+				t.Errorf("want removed: %v, got: %v (%v)", c.wantRemoved, removed, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"database/sql"
+	"net/http"
+	"strconv"
+)
+
+// 一度に返却する監査ログの最大件数
+const auditLogLimit = 100
+
+// 監査ログを新しい順に取得する。todo_idを指定した場合はそのTodoのログのみ返す。
+func GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	query := "SELECT id, actor_id, action, todo_id, before_json, after_json, request_id, created_at FROM audit_logs WHERE workspace_id = ?"
+	args := []any{workspaceID}
+	if v := r.URL.Query().Get("todo_id"); v != "" {
+		todoID, err := strconv.Atoi(v)
+		if err != nil {
+			response.WriteAuditLogsResponse(w, []model.AuditLog{}, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+			return
+		}
+		query += " AND todo_id = ?"
+		args = append(args, todoID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, auditLogLimit)
+
+	db := database.GetDB()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		response.WriteAuditLogsResponse(w, []model.AuditLog{}, http.StatusInternalServerError, constant.AUDIT_ERR_FAILED_GET_LOG)
+		return
+	}
+	defer rows.Close()
+
+	logs := []model.AuditLog{}
+	for rows.Next() {
+		var (
+			log           model.AuditLog
+			actorID       sql.NullInt64
+			before, after []byte
+		)
+		if err := rows.Scan(&log.ID, &actorID, &log.Action, &log.TodoID, &before, &after, &log.RequestID, &log.CreatedAt); err != nil {
+			response.WriteAuditLogsResponse(w, []model.AuditLog{}, http.StatusInternalServerError, constant.AUDIT_ERR_FAILED_GET_LOG_ROW)
+			return
+		}
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			log.ActorID = &id
+		}
+		log.Before, log.After = before, after
+		logs = append(logs, log)
+	}
+
+	response.WriteAuditLogsResponse(w, logs, http.StatusOK, "")
+}
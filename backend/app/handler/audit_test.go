@@ -0,0 +1,141 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetAuditLogs(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	actorID := 3
+	columns := []string{"id", "actor_id", "action", "todo_id", "before_json", "after_json", "request_id", "created_at"}
+
+	cases := map[string]struct {
+		query          string
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantBody       interface{}
+	}{
+		"正常系": {
+			query: "?todo_id=1",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT id, actor_id, action, todo_id, before_json, after_json, request_id, created_at FROM audit_logs WHERE workspace_id = \? AND todo_id = \? ORDER BY id DESC LIMIT \?$`).
+					WithArgs(testWorkspaceID, 1, 100).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(2, actorID, "delete", 1, `{"id":1,"title":"t","is_complete":false}`, nil, "req-2", createdAt).
+						AddRow(1, nil, "create", 1, nil, `{"id":1,"title":"t","is_complete":false}`, "req-1", createdAt))
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody: model.AuditLogsResponse{
+				Data: []model.AuditLog{
+					{
+						ID: 2, ActorID: &actorID, Action: "delete", TodoID: 1,
+						Before:    json.RawMessage(`{"id":1,"title":"t","is_complete":false}`),
+						After:     json.RawMessage("null"),
+						RequestID: "req-2", CreatedAt: createdAt,
+					},
+					{
+						ID: 1, Action: "create", TodoID: 1,
+						Before:    json.RawMessage("null"),
+						After:     json.RawMessage(`{"id":1,"title":"t","is_complete":false}`),
+						RequestID: "req-1", CreatedAt: createdAt,
+					},
+				},
+				Status: model.StatusInfo{Code: http.StatusOK},
+			},
+		},
+		"todo_idが不正": {
+			query:          "?todo_id=abc",
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+			wantBody: model.AuditLogsResponse{
+				Data:   []model.AuditLog{},
+				Status: model.StatusInfo{Code: http.StatusBadRequest, Error: true, ErrorMessage: "IDが不正です。"},
+			},
+		},
+		"クエリ失敗": {
+			query: "",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT .* FROM audit_logs WHERE workspace_id = \? ORDER BY id DESC LIMIT \?$`).
+					WithArgs(testWorkspaceID, 100).
+					WillReturnError(sqlmock.ErrCancelled)
+			},
+			wantStatusCode: http.StatusInternalServerError,
+			wantBody: model.AuditLogsResponse{
+				Data:   []model.AuditLog{},
+				Status: model.StatusInfo{Code: http.StatusInternalServerError, Error: true, ErrorMessage: "監査ログの取得に失敗しました。"},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodGet, "/audit"+c.query, "")
+
+			handler.GetAuditLogs(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.AuditLogsResponse](t, rec)
+			checkResponseBody(t, c.wantBody, got)
+		})
+	}
+}
+
+// 変更時に実行者・変更前後の状態・リクエストIDが監査ログに記録されることを確認する
+func TestUpdateTodoByIdWritesAuditLog(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "before", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+	expectOpenBlockers(mock, 1)
+	mock.ExpectExec(`^UPDATE todos`).
+		WithArgs("after", true, nil, nil, "", "", "", "done", nil, nil, 1, testWorkspaceID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^INSERT INTO todo_revisions`).
+		WithArgs(testWorkspaceID, 1, 2, sqlmock.AnyArg(), 7, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^INSERT INTO audit_logs`).
+		WithArgs(
+			testWorkspaceID,
+			7,
+			"update",
+			1,
+			`{"id":1,"title":"before","is_complete":false,"status":"todo","revision":1,"list_id":null,"due_at":null}`,
+			`{"id":1,"title":"after","is_complete":true,"status":"done","revision":2,"list_id":null,"due_at":null}`,
+			"req-123",
+			sqlmock.AnyArg(),
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectChange(mock, 1, false)
+	expectOutbox(mock, "todo.updated")
+	expectOutbox(mock, "todo.completed")
+	mock.ExpectCommit()
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodPut, "/todos/1", `{"title": "after", "is_complete": true}`)
+	ctx := requestctx.WithUserID(req.Context(), 7)
+	ctx = requestctx.WithRequestID(ctx, "req-123")
+
+	handler.UpdateTodoById(rec, req.WithContext(ctx))
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+}
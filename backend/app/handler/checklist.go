@@ -0,0 +1,529 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 1つのTodoに追加できるチェックリストの項目の上限
+const maxChecklistItems = 100
+
+// checklist_itemsテーブルから取得するカラム。scanChecklistItemと順序を合わせること
+const checklistItemColumns = "id, text, is_checked, position"
+
+// querierは、*sql.DBと*sql.Txの共通インターフェース
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// checklistItemColumnsの順序でチェックリストの項目を読み込む
+func scanChecklistItem(s rowScanner, item *model.ChecklistItem) error {
+	return s.Scan(&item.ID, &item.Text, &item.IsChecked, &item.Position)
+}
+
+// 項目から完了の割合を求めてチェックリストを作成する
+func newChecklist(items []model.ChecklistItem) *model.Checklist {
+	checklist := &model.Checklist{Items: items, Progress: model.ChecklistProgress{Total: len(items)}}
+	if checklist.Items == nil {
+		checklist.Items = []model.ChecklistItem{}
+	}
+	for _, item := range items {
+		if item.IsChecked {
+			checklist.Progress.Checked++
+		}
+	}
+	if len(items) > 0 {
+		checklist.Progress.Ratio = float64(checklist.Progress.Checked) / float64(len(items))
+	}
+	return checklist
+}
+
+// Todoのチェックリストを表示順に読み込む
+func loadChecklist(q querier, workspaceID, todoID int) (*model.Checklist, error) {
+	query := "SELECT " + checklistItemColumns + " FROM checklist_items WHERE todo_id = ? AND workspace_id = ? ORDER BY position, id"
+	rows, err := q.Query(query, todoID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.ChecklistItem
+	for rows.Next() {
+		var item model.ChecklistItem
+		if err := scanChecklistItem(rows, &item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newChecklist(items), nil
+}
+
+// 複数のTodoのチェックリストをまとめて読み込み、それぞれのTodoに設定する
+func attachChecklists(q querier, workspaceID int, todos []model.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(todos))
+	args := []any{workspaceID}
+	items := map[int][]model.ChecklistItem{}
+	for i, todo := range todos {
+		placeholders[i] = "?"
+		args = append(args, todo.ID)
+	}
+
+	query := "SELECT todo_id, " + checklistItemColumns + " FROM checklist_items WHERE workspace_id = ? AND todo_id IN (" +
+		strings.Join(placeholders, ", ") + ") ORDER BY todo_id, position, id"
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID int
+		var item model.ChecklistItem
+		if err := rows.Scan(&todoID, &item.ID, &item.Text, &item.IsChecked, &item.Position); err != nil {
+			return err
+		}
+		items[todoID] = append(items[todoID], item)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range todos {
+		todos[i].Checklist = newChecklist(items[todos[i].ID])
+	}
+	return nil
+}
+
+// includeで指定した、Todoと一緒に返す関連データ
+type todoIncludes struct {
+	checklist bool
+	assignees bool
+	timeSpent bool
+}
+
+// include=checklist,assigneesのように指定した、Todoと一緒に返す関連データを判定する。
+// 不正な値を指定した場合はエラーメッセージを返す
+func parseIncludes(r *http.Request) (todoIncludes, string) {
+	var includes todoIncludes
+	include := r.URL.Query().Get("include")
+	if include == "" {
+		return includes, ""
+	}
+
+	for _, name := range strings.Split(include, ",") {
+		switch strings.TrimSpace(name) {
+		case "checklist":
+			includes.checklist = true
+		case "assignees":
+			includes.assignees = true
+		case "time_spent":
+			includes.timeSpent = true
+		default:
+			return todoIncludes{}, constant.INPUT_ERR_INVALID_INCLUDE
+		}
+	}
+	return includes, ""
+}
+
+// 変更の対象のTodoをロックし、存在を確認する
+func lockTodo(tx *sql.Tx, workspaceID, todoID int) error {
+	var id int
+	return tx.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ? FOR UPDATE", todoID, workspaceID).Scan(&id)
+}
+
+// Todoのチェックリストを、完了の割合とともに取得する
+func GetChecklist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var todoID int
+	if err := db.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&todoID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteChecklistResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_GET_CHECKLIST)
+		}
+		return
+	}
+
+	checklist, err := loadChecklist(db, workspaceID, id)
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_GET_CHECKLIST)
+		return
+	}
+
+	response.WriteChecklistResponse(w, checklist, http.StatusOK, "")
+}
+
+// チェックリストの末尾に項目を追加し、追加後のチェックリストを返す
+func CreateChecklistItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	var input model.ChecklistItem
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+
+	// 入力値のバリデーション
+	if err := validator.ChecklistItemInput(input); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_ADD_ITEM)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	// 同時に追加された項目と表示順が重ならないよう、Todoをロックしてから件数を数える
+	todo, err := lockTodoForChange(tx, workspaceID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteChecklistResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_ADD_ITEM)
+		}
+		return
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM checklist_items WHERE todo_id = ? AND workspace_id = ?", id, workspaceID).Scan(&count); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_ADD_ITEM)
+		return
+	}
+	if count >= maxChecklistItems {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, constant.CHECKLIST_ERR_TOO_MANY_ITEMS)
+		return
+	}
+
+	insertQuery := "INSERT INTO checklist_items (workspace_id, todo_id, text, is_checked, position) VALUES (?, ?, ?, ?, ?)"
+	if _, err := tx.Exec(insertQuery, workspaceID, id, strings.TrimSpace(input.Text), input.IsChecked, count); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_ADD_ITEM)
+		return
+	}
+
+	checklist, err := loadChecklist(tx, workspaceID, id)
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_ADD_ITEM)
+		return
+	}
+
+	updated, err := recordRelatedChange(r.Context(), tx, &todo)
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_ADD_ITEM)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_ADD_ITEM)
+		return
+	}
+
+	publishTodoEvents(updated)
+	response.WriteChecklistResponse(w, checklist, http.StatusCreated, "")
+}
+
+// チェックリストの項目の内容と完了状態を更新し、更新後のチェックリストを返す
+func UpdateChecklistItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+	itemID, err := strconv.Atoi(r.PathValue("itemID"))
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	var input model.ChecklistItem
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+
+	// 入力値のバリデーション
+	if err := validator.ChecklistItemInput(input); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_UPDATE_ITEM)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	todo, err := lockTodoForChange(tx, workspaceID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteChecklistResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_UPDATE_ITEM)
+		}
+		return
+	}
+
+	updateQuery := "UPDATE checklist_items SET text = ?, is_checked = ? WHERE id = ? AND todo_id = ? AND workspace_id = ?"
+	if _, err := tx.Exec(updateQuery, strings.TrimSpace(input.Text), input.IsChecked, itemID, id, workspaceID); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_UPDATE_ITEM)
+		return
+	}
+
+	// 内容が変わらない場合は更新行数が0になるため、存在は読み込んだ項目で確認する
+	checklist, err := loadChecklist(tx, workspaceID, id)
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_UPDATE_ITEM)
+		return
+	}
+	if !containsChecklistItem(checklist, itemID) {
+		response.WriteChecklistResponse(w, nil, http.StatusNotFound, constant.CHECKLIST_ERR_NOT_FOUND_ITEM)
+		return
+	}
+
+	updated, err := recordRelatedChange(r.Context(), tx, &todo)
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_UPDATE_ITEM)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_UPDATE_ITEM)
+		return
+	}
+
+	publishTodoEvents(updated)
+	response.WriteChecklistResponse(w, checklist, http.StatusOK, "")
+}
+
+func containsChecklistItem(checklist *model.Checklist, itemID int) bool {
+	for _, item := range checklist.Items {
+		if item.ID == itemID {
+			return true
+		}
+	}
+	return false
+}
+
+// チェックリストの項目を削除し、後ろの項目の表示順を詰める
+func DeleteChecklistItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+	itemID, err := strconv.Atoi(r.PathValue("itemID"))
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_DELETE_ITEM)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	todo, err := lockTodoForChange(tx, workspaceID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteChecklistResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_DELETE_ITEM)
+		}
+		return
+	}
+
+	var position int
+	selectQuery := "SELECT position FROM checklist_items WHERE id = ? AND todo_id = ? AND workspace_id = ?"
+	if err := tx.QueryRow(selectQuery, itemID, id, workspaceID).Scan(&position); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteChecklistResponse(w, nil, http.StatusNotFound, constant.CHECKLIST_ERR_NOT_FOUND_ITEM)
+		} else {
+			response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_DELETE_ITEM)
+		}
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM checklist_items WHERE id = ? AND workspace_id = ?", itemID, workspaceID); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_DELETE_ITEM)
+		return
+	}
+
+	shiftQuery := "UPDATE checklist_items SET position = position - 1 WHERE todo_id = ? AND workspace_id = ? AND position > ?"
+	if _, err := tx.Exec(shiftQuery, id, workspaceID, position); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_DELETE_ITEM)
+		return
+	}
+
+	checklist, err := loadChecklist(tx, workspaceID, id)
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_DELETE_ITEM)
+		return
+	}
+
+	updated, err := recordRelatedChange(r.Context(), tx, &todo)
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_DELETE_ITEM)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_DELETE_ITEM)
+		return
+	}
+
+	publishTodoEvents(updated)
+	response.WriteChecklistResponse(w, checklist, http.StatusOK, "")
+}
+
+// チェックリストを指定した項目のIDの順に並べ替える。すべての項目のIDを1回ずつ指定する必要がある
+func ReorderChecklist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	var input model.ChecklistOrder
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_REORDER)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	todo, err := lockTodoForChange(tx, workspaceID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteChecklistResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_REORDER)
+		}
+		return
+	}
+
+	current, err := loadChecklist(tx, workspaceID, id)
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_REORDER)
+		return
+	}
+	if !isPermutation(current, input.ItemIDs) {
+		response.WriteChecklistResponse(w, nil, http.StatusBadRequest, constant.CHECKLIST_ERR_INVALID_ORDER)
+		return
+	}
+
+	if len(input.ItemIDs) > 0 {
+		cases := make([]string, len(input.ItemIDs))
+		args := make([]any, 0, len(input.ItemIDs)*2+2)
+		for position, itemID := range input.ItemIDs {
+			cases[position] = "WHEN ? THEN ?"
+			args = append(args, itemID, position)
+		}
+		args = append(args, id, workspaceID)
+		reorderQuery := "UPDATE checklist_items SET position = CASE id " + strings.Join(cases, " ") + " END WHERE todo_id = ? AND workspace_id = ?"
+		if _, err := tx.Exec(reorderQuery, args...); err != nil {
+			response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_REORDER)
+			return
+		}
+	}
+
+	// 読み込み済みの項目を指定の順に並べる
+	byID := map[int]model.ChecklistItem{}
+	for _, item := range current.Items {
+		byID[item.ID] = item
+	}
+	items := make([]model.ChecklistItem, len(input.ItemIDs))
+	for position, itemID := range input.ItemIDs {
+		item := byID[itemID]
+		item.Position = position
+		items[position] = item
+	}
+	checklist := newChecklist(items)
+
+	updated, err := recordRelatedChange(r.Context(), tx, &todo)
+	if err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_REORDER)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteChecklistResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_REORDER)
+		return
+	}
+
+	publishTodoEvents(updated)
+	response.WriteChecklistResponse(w, checklist, http.StatusOK, "")
+}
+
+// 指定したIDが、チェックリストのすべての項目を1回ずつ含むかどうかを返す
+func isPermutation(checklist *model.Checklist, itemIDs []int) bool {
+	if len(itemIDs) != len(checklist.Items) {
+		return false
+	}
+
+	remaining := map[int]bool{}
+	for _, item := range checklist.Items {
+		remaining[item.ID] = true
+	}
+	for _, itemID := range itemIDs {
+		if !remaining[itemID] {
+			return false
+		}
+		delete(remaining, itemID)
+	}
+	return true
+}
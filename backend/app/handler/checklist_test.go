@@ -0,0 +1,353 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// checklist_itemsテーブルから取得するカラム
+var checklistItemRowColumns = []string{"id", "text", "is_checked", "position"}
+
+func TestGetChecklist(t *testing.T) {
+	t.Run("完了の割合とともに取得", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \?$`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(`^SELECT id, text, is_checked, position FROM checklist_items WHERE todo_id = \? AND workspace_id = \? ORDER BY position, id$`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(checklistItemRowColumns).
+				AddRow(11, "材料を買う", true, 0).
+				AddRow(12, "下ごしらえ", false, 1).
+				AddRow(13, "焼く", false, 2).
+				AddRow(14, "盛り付け", true, 3))
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodGet, "/todos/1/checklist", "")
+		req.SetPathValue("id", "1")
+
+		handler.GetChecklist(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		got := decodeResponseBody[model.ChecklistResponse](t, rec)
+		checkResponseBody(t, &model.Checklist{
+			Items: []model.ChecklistItem{
+				{ID: 11, Text: "材料を買う", IsChecked: true, Position: 0},
+				{ID: 12, Text: "下ごしらえ", Position: 1},
+				{ID: 13, Text: "焼く", Position: 2},
+				{ID: 14, Text: "盛り付け", IsChecked: true, Position: 3},
+			},
+			Progress: model.ChecklistProgress{Checked: 2, Total: 4, Ratio: 0.5},
+		}, got.Data)
+	})
+
+	t.Run("項目がない場合は割合を0とする", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT id FROM todos`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(`^SELECT .* FROM checklist_items`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(checklistItemRowColumns))
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodGet, "/todos/1/checklist", "")
+		req.SetPathValue("id", "1")
+
+		handler.GetChecklist(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		got := decodeResponseBody[model.ChecklistResponse](t, rec)
+		checkResponseBody(t, &model.Checklist{Items: []model.ChecklistItem{}}, got.Data)
+	})
+}
+
+func TestCreateChecklistItem(t *testing.T) {
+	cases := map[string]struct {
+		body           string
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantMessage    string
+	}{
+		"末尾に追加": {
+			body: `{"text": " 焼く "}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectTodoLock(mock, 1)
+				mock.ExpectQuery(`^SELECT COUNT\(\*\) FROM checklist_items WHERE todo_id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectExec(`^INSERT INTO checklist_items \(workspace_id, todo_id, text, is_checked, position\) VALUES \(\?, \?, \?, \?, \?\)$`).
+					WithArgs(testWorkspaceID, 1, "焼く", false, 2).
+					WillReturnResult(sqlmock.NewResult(13, 1))
+				mock.ExpectQuery(`^SELECT .* FROM checklist_items`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(checklistItemRowColumns).
+						AddRow(11, "材料を買う", true, 0).
+						AddRow(12, "下ごしらえ", false, 1).
+						AddRow(13, "焼く", false, 2))
+				expectRelatedChange(mock, 1)
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		"内容が空": {
+			body:           `{"text": ""}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "項目の内容を入力してください。",
+		},
+		"項目が上限に達している": {
+			body: `{"text": "追加"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectTodoLock(mock, 1)
+				mock.ExpectQuery(`^SELECT COUNT\(\*\) FROM checklist_items`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(100))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "チェックリストの項目は100件までです。",
+		},
+		"TODOが存在しない": {
+			body: `{"text": "追加"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT .* FROM todos WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantMessage:    "TODOが見つかりません。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodPost, "/todos/1/checklist", c.body)
+			req.SetPathValue("id", "1")
+
+			handler.CreateChecklistItem(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.ChecklistResponse](t, rec)
+			if got.Status.ErrorMessage != c.wantMessage {
+				t.Errorf("want: %s, got: %s", c.wantMessage, got.Status.ErrorMessage)
+			}
+		})
+	}
+}
+
+func TestUpdateChecklistItem(t *testing.T) {
+	t.Run("完了に切り替える", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		expectTodoLock(mock, 1)
+		mock.ExpectExec(`^UPDATE checklist_items SET text = \?, is_checked = \? WHERE id = \? AND todo_id = \? AND workspace_id = \?$`).
+			WithArgs("下ごしらえ", true, 12, 1, testWorkspaceID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`^SELECT .* FROM checklist_items`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(checklistItemRowColumns).
+				AddRow(11, "材料を買う", true, 0).
+				AddRow(12, "下ごしらえ", true, 1))
+		expectRelatedChange(mock, 1)
+		mock.ExpectCommit()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPut, "/todos/1/checklist/12", `{"text": "下ごしらえ", "is_checked": true}`)
+		req.SetPathValue("id", "1")
+		req.SetPathValue("itemID", "12")
+
+		handler.UpdateChecklistItem(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		got := decodeResponseBody[model.ChecklistResponse](t, rec)
+		checkResponseBody(t, model.ChecklistProgress{Checked: 2, Total: 2, Ratio: 1}, got.Data.Progress)
+	})
+
+	t.Run("項目が存在しない", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		expectTodoLock(mock, 1)
+		mock.ExpectExec(`^UPDATE checklist_items`).
+			WithArgs("下ごしらえ", true, 99, 1, testWorkspaceID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`^SELECT .* FROM checklist_items`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(checklistItemRowColumns).AddRow(11, "材料を買う", true, 0))
+		mock.ExpectRollback()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPut, "/todos/1/checklist/99", `{"text": "下ごしらえ", "is_checked": true}`)
+		req.SetPathValue("id", "1")
+		req.SetPathValue("itemID", "99")
+
+		handler.UpdateChecklistItem(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestDeleteChecklistItem(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	expectTodoLock(mock, 1)
+	mock.ExpectQuery(`^SELECT position FROM checklist_items WHERE id = \? AND todo_id = \? AND workspace_id = \?$`).
+		WithArgs(11, 1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(0))
+	mock.ExpectExec(`^DELETE FROM checklist_items WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(11, testWorkspaceID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^UPDATE checklist_items SET position = position - 1 WHERE todo_id = \? AND workspace_id = \? AND position > \?$`).
+		WithArgs(1, testWorkspaceID, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`^SELECT .* FROM checklist_items`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(checklistItemRowColumns).AddRow(12, "下ごしらえ", false, 0))
+	expectRelatedChange(mock, 1)
+	mock.ExpectCommit()
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodDelete, "/todos/1/checklist/11", "")
+	req.SetPathValue("id", "1")
+	req.SetPathValue("itemID", "11")
+
+	handler.DeleteChecklistItem(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.ChecklistResponse](t, rec)
+	checkResponseBody(t, []model.ChecklistItem{{ID: 12, Text: "下ごしらえ", Position: 0}}, got.Data.Items)
+}
+
+func TestReorderChecklist(t *testing.T) {
+	current := func() *sqlmock.Rows {
+		return sqlmock.NewRows(checklistItemRowColumns).
+			AddRow(11, "材料を買う", true, 0).
+			AddRow(12, "下ごしらえ", false, 1).
+			AddRow(13, "焼く", false, 2)
+	}
+
+	t.Run("指定の順に並べ替える", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		expectTodoLock(mock, 1)
+		mock.ExpectQuery(`^SELECT .* FROM checklist_items`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(current())
+		mock.ExpectExec(`^UPDATE checklist_items SET position = CASE id WHEN \? THEN \? WHEN \? THEN \? WHEN \? THEN \? END WHERE todo_id = \? AND workspace_id = \?$`).
+			WithArgs(13, 0, 11, 1, 12, 2, 1, testWorkspaceID).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		expectRelatedChange(mock, 1)
+		mock.ExpectCommit()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPut, "/todos/1/checklist/order", `{"item_ids": [13, 11, 12]}`)
+		req.SetPathValue("id", "1")
+
+		handler.ReorderChecklist(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		got := decodeResponseBody[model.ChecklistResponse](t, rec)
+		checkResponseBody(t, []model.ChecklistItem{
+			{ID: 13, Text: "焼く", Position: 0},
+			{ID: 11, Text: "材料を買う", IsChecked: true, Position: 1},
+			{ID: 12, Text: "下ごしらえ", Position: 2},
+		}, got.Data.Items)
+	})
+
+	for name, body := range map[string]string{
+		"項目が足りない":   `{"item_ids": [13, 11]}`,
+		"項目が重複している": `{"item_ids": [13, 11, 11]}`,
+		"他の項目を含む":   `{"item_ids": [13, 11, 99]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			expectTodoLock(mock, 1)
+			mock.ExpectQuery(`^SELECT .* FROM checklist_items`).
+				WithArgs(1, testWorkspaceID).
+				WillReturnRows(current())
+			mock.ExpectRollback()
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodPut, "/todos/1/checklist/order", body)
+			req.SetPathValue("id", "1")
+
+			handler.ReorderChecklist(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+// include=checklistを指定すると、Todoごとのチェックリストをまとめて取得することを確認する
+func TestGetTodosWithChecklist(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE workspace_id = \?$`).
+		WithArgs(testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).
+			AddRow(1, "料理", false, 1, nil, nil, "", "", "", "todo", nil, nil).
+			AddRow(2, "掃除", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+	mock.ExpectQuery(`^SELECT todo_id, id, text, is_checked, position FROM checklist_items WHERE workspace_id = \? AND todo_id IN \(\?, \?\) ORDER BY todo_id, position, id$`).
+		WithArgs(testWorkspaceID, 1, 2).
+		WillReturnRows(sqlmock.NewRows(append([]string{"todo_id"}, checklistItemRowColumns...)).
+			AddRow(1, 11, "材料を買う", true, 0).
+			AddRow(1, 12, "焼く", false, 1))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/todos?include=checklist", "")
+
+	handler.GetTodos(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.TodosResponse](t, rec)
+	checkResponseBody(t, createTodosResponse(t, []model.Todo{
+		{ID: 1, Title: "料理", Status: "todo", Revision: 1, Checklist: &model.Checklist{
+			Items: []model.ChecklistItem{
+				{ID: 11, Text: "材料を買う", IsChecked: true, Position: 0},
+				{ID: 12, Text: "焼く", Position: 1},
+			},
+			Progress: model.ChecklistProgress{Checked: 1, Total: 2, Ratio: 0.5},
+		}},
+		{ID: 2, Title: "掃除", Status: "todo", Revision: 1, Checklist: &model.Checklist{Items: []model.ChecklistItem{}}},
+	}, http.StatusOK, ""), got)
+}
@@ -0,0 +1,350 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/event"
+	"backend/app/mention"
+	"backend/app/model"
+	"backend/app/outbox"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commentsテーブルから取得するカラム。scanCommentと順序を合わせること
+const commentColumns = "id, todo_id, author_id, body, created_at, updated_at"
+
+// commentColumnsの順序でコメントを読み込む
+func scanComment(s rowScanner, comment *model.Comment) error {
+	return s.Scan(&comment.ID, &comment.TodoID, &comment.AuthorID, &comment.Body, &comment.CreatedAt, &comment.UpdatedAt)
+}
+
+// Todoのコメントを投稿した順に取得する
+func GetComments(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteCommentsResponse(w, []model.Comment{}, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var todoID int
+	if err := db.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&todoID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteCommentsResponse(w, []model.Comment{}, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteCommentsResponse(w, []model.Comment{}, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_GET_COMMENT)
+		}
+		return
+	}
+
+	rows, err := db.Query("SELECT "+commentColumns+" FROM comments WHERE todo_id = ? AND workspace_id = ? ORDER BY id", id, workspaceID)
+	if err != nil {
+		response.WriteCommentsResponse(w, []model.Comment{}, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_GET_COMMENT)
+		return
+	}
+	defer rows.Close()
+
+	comments := []model.Comment{}
+	for rows.Next() {
+		var comment model.Comment
+		if err := scanComment(rows, &comment); err != nil {
+			response.WriteCommentsResponse(w, []model.Comment{}, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_GET_COMMENT)
+			return
+		}
+		comments = append(comments, comment)
+	}
+
+	response.WriteCommentsResponse(w, comments, http.StatusOK, "")
+}
+
+// Todoにコメントを投稿し、言及したユーザーに通知する
+func CreateComment(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteCommentResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	var input model.Comment
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+	input.Body = strings.TrimSpace(input.Body)
+
+	// 入力値のバリデーション
+	if err := validator.CommentInput(input); err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_ADD_COMMENT)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	// 通知にTodoの内容を含めるため、Todoを取得しておく
+	var todo model.Todo
+	if err := scanTodo(tx.QueryRow("SELECT "+todoColumns+" FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID), &todo); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteCommentResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_ADD_COMMENT)
+		}
+		return
+	}
+
+	now := time.Now()
+	comment := model.Comment{TodoID: id, AuthorID: userID, Body: input.Body, CreatedAt: now, UpdatedAt: now}
+	insertQuery := "INSERT INTO comments (workspace_id, todo_id, author_id, body, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)"
+	result, err := tx.Exec(insertQuery, workspaceID, comment.TodoID, comment.AuthorID, comment.Body, comment.CreatedAt, comment.UpdatedAt)
+	if err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_ADD_COMMENT)
+		return
+	}
+	commentID, err := result.LastInsertId()
+	if err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_ADD_COMMENT)
+		return
+	}
+	comment.ID = int(commentID)
+
+	mentioned, err := recordMentions(r.Context(), tx, &todo, &comment, mention.Parse(comment.Body))
+	if err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_ADD_COMMENT)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_ADD_COMMENT)
+		return
+	}
+
+	publishTodoEvents(mentioned...)
+	response.WriteCommentResponse(w, &comment, http.StatusCreated, "")
+}
+
+// 自分のコメントを編集する。編集で新たに言及したユーザーにのみ通知する
+func UpdateComment(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteCommentResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	id, commentID, ok := commentPathIDs(r)
+	if !ok {
+		response.WriteCommentResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	var input model.Comment
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+	input.Body = strings.TrimSpace(input.Body)
+
+	// 入力値のバリデーション
+	if err := validator.CommentInput(input); err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_UPDATE_COMMENT)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	comment, code, errMessage := lockOwnComment(tx, workspaceID, id, commentID, userID, constant.COMMENT_ERR_FAILED_UPDATE_COMMENT)
+	if errMessage != "" {
+		response.WriteCommentResponse(w, nil, code, errMessage)
+		return
+	}
+
+	var todo model.Todo
+	if err := scanTodo(tx.QueryRow("SELECT "+todoColumns+" FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID), &todo); err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_UPDATE_COMMENT)
+		return
+	}
+
+	added := mention.Added(mention.Parse(comment.Body), mention.Parse(input.Body))
+	comment.Body = input.Body
+	comment.UpdatedAt = time.Now()
+	updateQuery := "UPDATE comments SET body = ?, updated_at = ? WHERE id = ? AND workspace_id = ?"
+	if _, err := tx.Exec(updateQuery, comment.Body, comment.UpdatedAt, comment.ID, workspaceID); err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_UPDATE_COMMENT)
+		return
+	}
+
+	mentioned, err := recordMentions(r.Context(), tx, &todo, comment, added)
+	if err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_UPDATE_COMMENT)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_UPDATE_COMMENT)
+		return
+	}
+
+	publishTodoEvents(mentioned...)
+	response.WriteCommentResponse(w, comment, http.StatusOK, "")
+}
+
+// 自分のコメントを削除する
+func DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteCommentResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	id, commentID, ok := commentPathIDs(r)
+	if !ok {
+		response.WriteCommentResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_DELETE_COMMENT)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	comment, code, errMessage := lockOwnComment(tx, workspaceID, id, commentID, userID, constant.COMMENT_ERR_FAILED_DELETE_COMMENT)
+	if errMessage != "" {
+		response.WriteCommentResponse(w, nil, code, errMessage)
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM comments WHERE id = ? AND workspace_id = ?", comment.ID, workspaceID); err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_DELETE_COMMENT)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteCommentResponse(w, nil, http.StatusInternalServerError, constant.COMMENT_ERR_FAILED_DELETE_COMMENT)
+		return
+	}
+
+	response.WriteCommentResponse(w, nil, http.StatusOK, "")
+}
+
+// パスからTodoのIDとコメントのIDを取得する
+func commentPathIDs(r *http.Request) (int, int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, 0, false
+	}
+	commentID, err := strconv.Atoi(r.PathValue("commentID"))
+	if err != nil {
+		return 0, 0, false
+	}
+	return id, commentID, true
+}
+
+// コメントをロックして取得する。投稿したユーザー以外の場合は、ステータスコードとエラーメッセージを返す
+func lockOwnComment(tx *sql.Tx, workspaceID, todoID, commentID, userID int, failedMessage string) (*model.Comment, int, string) {
+	var comment model.Comment
+	query := "SELECT " + commentColumns + " FROM comments WHERE id = ? AND todo_id = ? AND workspace_id = ? FOR UPDATE"
+	if err := scanComment(tx.QueryRow(query, commentID, todoID, workspaceID), &comment); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, constant.COMMENT_ERR_NOT_FOUND_COMMENT
+		}
+		return nil, http.StatusInternalServerError, failedMessage
+	}
+	if comment.AuthorID != userID {
+		return nil, http.StatusForbidden, constant.COMMENT_ERR_NOT_AUTHOR
+	}
+	return &comment, 0, ""
+}
+
+// 言及されたユーザー名をワークスペースのメンバーから探し、言及の通知をアウトボックスに記録する。
+// メンバーでないユーザー名と、自分自身への言及は通知しない
+func recordMentions(ctx context.Context, tx *sql.Tx, todo *model.Todo, comment *model.Comment, names []string) ([]event.Event, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	workspaceID := requestctx.WorkspaceID(ctx)
+	placeholders := make([]string, len(names))
+	args := make([]any, 0, len(names)+1)
+	args = append(args, workspaceID)
+	for i, name := range names {
+		placeholders[i] = "?"
+		args = append(args, name)
+	}
+	query := "SELECT user_id FROM workspace_members WHERE workspace_id = ? AND username IN (" + strings.Join(placeholders, ", ") + ") ORDER BY user_id"
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if userID != comment.AuthorID {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	events := make([]event.Event, 0, len(userIDs))
+	for _, userID := range userIDs {
+		e, err := outbox.Record(tx, event.Event{
+			Type:        event.TodoMentioned,
+			WorkspaceID: workspaceID,
+			ListID:      todo.ListID,
+			TodoID:      todo.ID,
+			Todo:        todo,
+			Comment:     comment,
+			UserID:      userID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
@@ -0,0 +1,231 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// commentsテーブルから取得するカラム
+var commentRowColumns = []string{"id", "todo_id", "author_id", "body", "created_at", "updated_at"}
+
+// expectTodoForCommentは、通知に含めるTodoの取得を期待値として設定します。
+func expectTodoForComment(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(rows)
+}
+
+// expectMembersは、言及されたユーザー名の検索を期待値として設定します。
+func expectMembers(mock sqlmock.Sqlmock, names []driver.Value, userIDs ...int) {
+	rows := sqlmock.NewRows([]string{"user_id"})
+	for _, id := range userIDs {
+		rows.AddRow(id)
+	}
+	mock.ExpectQuery(`^SELECT user_id FROM workspace_members WHERE workspace_id = \? AND username IN \(`).
+		WithArgs(append([]driver.Value{testWorkspaceID}, names...)...).
+		WillReturnRows(rows)
+}
+
+func TestCreateComment(t *testing.T) {
+	cases := map[string]struct {
+		body           string
+		anonymous      bool
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantMessage    string
+	}{
+		"言及したメンバーに通知": {
+			body: `{"body": "@alice @bob @me 確認お願いします"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectTodoForComment(mock, sqlmock.NewRows(todoRowColumns).AddRow(1, "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+				mock.ExpectExec(`^INSERT INTO comments \(workspace_id, todo_id, author_id, body, created_at, updated_at\) VALUES \(\?, \?, \?, \?, \?, \?\)$`).
+					WithArgs(testWorkspaceID, 1, testUserID, "@alice @bob @me 確認お願いします", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(3, 1))
+				// bobはメンバーでなく、meは投稿したユーザー自身のため通知しない
+				expectMembers(mock, []driver.Value{"alice", "bob", "me"}, 11, testUserID)
+				expectOutbox(mock, "todo.mentioned")
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		"言及なし": {
+			body: `{"body": "了解です"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectTodoForComment(mock, sqlmock.NewRows(todoRowColumns).AddRow(1, "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+				mock.ExpectExec(`^INSERT INTO comments`).
+					WillReturnResult(sqlmock.NewResult(3, 1))
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		"匿名ユーザー": {
+			body:           `{"body": "了解です"}`,
+			anonymous:      true,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusUnauthorized,
+			wantMessage:    "ユーザーの認証が必要です。",
+		},
+		"本文が空": {
+			body:           `{"body": "  "}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "コメントを入力してください。",
+		},
+		"TODOが見つかりません": {
+			body: `{"body": "了解です"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectTodoForComment(mock, sqlmock.NewRows(todoRowColumns))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantMessage:    "TODOが見つかりません。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createUserRequest(t, http.MethodPost, "/todos/1/comments", c.body)
+			if c.anonymous {
+				req = createTestRequest(t, http.MethodPost, "/todos/1/comments", c.body)
+			}
+			req.SetPathValue("id", "1")
+
+			handler.CreateComment(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.CommentResponse](t, rec)
+			if got.Status.ErrorMessage != c.wantMessage {
+				t.Errorf("want: %s, got: %s", c.wantMessage, got.Status.ErrorMessage)
+			}
+			if c.wantStatusCode == http.StatusCreated && (got.Data == nil || got.Data.ID != 3 || got.Data.AuthorID != testUserID) {
+				t.Errorf("追加したコメントが返されていません: %+v", got.Data)
+			}
+		})
+	}
+}
+
+func TestUpdateComment(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		authorID       int
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantMessage    string
+	}{
+		"新たに言及したユーザーのみ通知": {
+			authorID: testUserID,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				expectTodoForComment(mock, sqlmock.NewRows(todoRowColumns).AddRow(1, "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+				mock.ExpectExec(`^UPDATE comments SET body = \?, updated_at = \? WHERE id = \? AND workspace_id = \?$`).
+					WithArgs("@alice @carol 再確認", sqlmock.AnyArg(), 3, testWorkspaceID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectMembers(mock, []driver.Value{"carol"}, 12)
+				expectOutbox(mock, "todo.mentioned")
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		"投稿したユーザー以外": {
+			authorID: 8,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusForbidden,
+			wantMessage:    "コメントを編集・削除できるのは投稿したユーザーのみです。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`^SELECT id, todo_id, author_id, body, created_at, updated_at FROM comments WHERE id = \? AND todo_id = \? AND workspace_id = \? FOR UPDATE$`).
+				WithArgs(3, 1, testWorkspaceID).
+				WillReturnRows(sqlmock.NewRows(commentRowColumns).AddRow(3, 1, c.authorID, "@alice 確認", createdAt, createdAt))
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createUserRequest(t, http.MethodPut, "/todos/1/comments/3", `{"body": "@alice @carol 再確認"}`)
+			req.SetPathValue("id", "1")
+			req.SetPathValue("commentID", "3")
+
+			handler.UpdateComment(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.CommentResponse](t, rec)
+			if got.Status.ErrorMessage != c.wantMessage {
+				t.Errorf("want: %s, got: %s", c.wantMessage, got.Status.ErrorMessage)
+			}
+		})
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("投稿したユーザーは削除できる", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`^SELECT .* FROM comments WHERE id = \? AND todo_id = \? AND workspace_id = \? FOR UPDATE$`).
+			WithArgs(3, 1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(commentRowColumns).AddRow(3, 1, testUserID, "確認", createdAt, createdAt))
+		mock.ExpectExec(`^DELETE FROM comments WHERE id = \? AND workspace_id = \?$`).
+			WithArgs(3, testWorkspaceID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodDelete, "/todos/1/comments/3", "")
+		req.SetPathValue("id", "1")
+		req.SetPathValue("commentID", "3")
+
+		handler.DeleteComment(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("コメントが見つかりません", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`^SELECT .* FROM comments`).
+			WithArgs(4, 1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(commentRowColumns))
+		mock.ExpectRollback()
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodDelete, "/todos/1/comments/4", "")
+		req.SetPathValue("id", "1")
+		req.SetPathValue("commentID", "4")
+
+		handler.DeleteComment(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/dependency"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Todoの完了を妨げているTodoのIDを昇順に取得する
+func GetTodoDependencies(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTodoDependenciesResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var todoID int
+	if err := db.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&todoID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTodoDependenciesResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteTodoDependenciesResponse(w, nil, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_GET_DEPENDENCY)
+		}
+		return
+	}
+
+	rows, err := db.Query("SELECT blocker_id FROM todo_dependencies WHERE todo_id = ? AND workspace_id = ? ORDER BY blocker_id", id, workspaceID)
+	if err != nil {
+		response.WriteTodoDependenciesResponse(w, nil, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_GET_DEPENDENCY)
+		return
+	}
+	defer rows.Close()
+
+	dependencies := model.TodoDependencies{BlockerIDs: []int{}}
+	for rows.Next() {
+		var blockerID int
+		if err := rows.Scan(&blockerID); err != nil {
+			response.WriteTodoDependenciesResponse(w, nil, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_GET_DEPENDENCY)
+			return
+		}
+		dependencies.BlockerIDs = append(dependencies.BlockerIDs, blockerID)
+	}
+
+	response.WriteTodoDependenciesResponse(w, &dependencies, http.StatusOK, "")
+}
+
+// Todoの完了を妨げているTodoを指定したTodoで置き換える。
+// 依存するTodoは同じワークスペースに存在する必要があり、依存関係が循環する場合は設定できない
+func UpdateTodoDependencies(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTodoDependenciesResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	var input model.TodoDependencies
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteTodoDependenciesResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+
+	// 入力値のバリデーション
+	if err := validator.TodoDependenciesInput(input); err != nil {
+		response.WriteTodoDependenciesResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	blockerIDs := slices.Clone(input.BlockerIDs)
+	slices.Sort(blockerIDs)
+	blockerIDs = slices.Compact(blockerIDs)
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteTodoDependenciesResponse(w, nil, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_UPDATE_DEPENDENCY)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	if err := lockTodo(tx, workspaceID, id); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTodoDependenciesResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteTodoDependenciesResponse(w, nil, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_UPDATE_DEPENDENCY)
+		}
+		return
+	}
+
+	missing, err := missingTodos(tx, workspaceID, blockerIDs)
+	if err != nil {
+		response.WriteTodoDependenciesResponse(w, nil, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_UPDATE_DEPENDENCY)
+		return
+	}
+	if len(missing) > 0 {
+		response.WriteTodoDependenciesResponse(w, nil, http.StatusBadRequest, fmt.Sprintf(constant.DEPENDENCY_ERR_NOT_FOUND_BLOCKER, joinIDs(missing, ", ")))
+		return
+	}
+
+	// 別のTodoの依存関係を同時に変更して循環ができないよう、ワークスペースの依存関係をロックして読み込む
+	graph, err := loadDependencyGraph(tx, "SELECT todo_id, blocker_id FROM todo_dependencies WHERE workspace_id = ? FOR UPDATE", workspaceID)
+	if err != nil {
+		response.WriteTodoDependenciesResponse(w, nil, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_UPDATE_DEPENDENCY)
+		return
+	}
+	if cycle := graph.Cycle(id, blockerIDs); cycle != nil {
+		response.WriteTodoDependenciesResponse(w, nil, http.StatusBadRequest, fmt.Sprintf(constant.DEPENDENCY_ERR_CYCLE, joinIDs(cycle, " → ")))
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM todo_dependencies WHERE todo_id = ? AND workspace_id = ?", id, workspaceID); err != nil {
+		response.WriteTodoDependenciesResponse(w, nil, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_UPDATE_DEPENDENCY)
+		return
+	}
+
+	if len(blockerIDs) > 0 {
+		values := make([]string, len(blockerIDs))
+		args := make([]any, 0, len(blockerIDs)*3)
+		for i, blockerID := range blockerIDs {
+			values[i] = "(?, ?, ?)"
+			args = append(args, workspaceID, id, blockerID)
+		}
+		insertQuery := "INSERT INTO todo_dependencies (workspace_id, todo_id, blocker_id) VALUES " + strings.Join(values, ", ")
+		if _, err := tx.Exec(insertQuery, args...); err != nil {
+			response.WriteTodoDependenciesResponse(w, nil, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_UPDATE_DEPENDENCY)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteTodoDependenciesResponse(w, nil, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_UPDATE_DEPENDENCY)
+		return
+	}
+
+	response.WriteTodoDependenciesResponse(w, &model.TodoDependencies{BlockerIDs: blockerIDs}, http.StatusOK, "")
+}
+
+// Todoの完了までに、順に完了する必要がある未完了のTodoの最も長い連なりを取得する。
+// 最初に完了すべきTodoから順に並べ、最後は指定したTodo自身とする
+func GetCriticalPath(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var todoID int
+	if err := db.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&todoID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_GET_CRITICAL_PATH)
+		}
+		return
+	}
+
+	// 完了済みのTodoは完了を妨げないため、未完了のTodoへの依存のみたどる
+	openQuery := "SELECT todo_dependencies.todo_id, todo_dependencies.blocker_id FROM todo_dependencies " +
+		"JOIN todos ON todos.id = todo_dependencies.blocker_id AND todos.workspace_id = todo_dependencies.workspace_id " +
+		"WHERE todo_dependencies.workspace_id = ? AND todos.is_complete = FALSE"
+	graph, err := loadDependencyGraph(db, openQuery, workspaceID)
+	if err != nil {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_GET_CRITICAL_PATH)
+		return
+	}
+	chain := graph.LongestChain(id)
+
+	placeholders := make([]string, len(chain))
+	args := []any{workspaceID}
+	for i, todoID := range chain {
+		placeholders[i] = "?"
+		args = append(args, todoID)
+	}
+	rows, err := db.Query("SELECT "+todoColumns+" FROM todos WHERE workspace_id = ? AND id IN ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_GET_CRITICAL_PATH)
+		return
+	}
+	defer rows.Close()
+
+	todos := map[int]model.Todo{}
+	for rows.Next() {
+		var todo model.Todo
+		if err := scanTodo(rows, &todo); err != nil {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO_ROW)
+			return
+		}
+		todos[todo.ID] = todo
+	}
+	if err := rows.Err(); err != nil {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DEPENDENCY_ERR_FAILED_GET_CRITICAL_PATH)
+		return
+	}
+
+	path := make([]model.Todo, 0, len(chain))
+	for _, todoID := range chain {
+		if todo, ok := todos[todoID]; ok {
+			path = append(path, todo)
+		}
+	}
+	response.WriteTodosResponse(w, path, http.StatusOK, "")
+}
+
+// 依存関係の辺（todo_id, blocker_id）を返すクエリを実行し、グラフを作成する
+func loadDependencyGraph(q querier, query string, args ...any) (dependency.Graph, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	graph := dependency.Graph{}
+	for rows.Next() {
+		var todoID, blockerID int
+		if err := rows.Scan(&todoID, &blockerID); err != nil {
+			return nil, err
+		}
+		graph.Add(todoID, blockerID)
+	}
+	return graph, rows.Err()
+}
+
+// 指定したTodoのうち、ワークスペースに存在しないTodoのIDを昇順に返す
+func missingTodos(q querier, workspaceID int, ids []int) ([]int, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, workspaceID)
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+	rows, err := q.Query("SELECT id FROM todos WHERE workspace_id = ? AND id IN ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []int
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// Todoの完了を妨げている未完了のTodoのIDを昇順に返す
+func openBlockers(tx *sql.Tx, workspaceID, todoID int) ([]int, error) {
+	query := "SELECT todos.id FROM todo_dependencies JOIN todos ON todos.id = todo_dependencies.blocker_id AND todos.workspace_id = todo_dependencies.workspace_id " +
+		"WHERE todo_dependencies.workspace_id = ? AND todo_dependencies.todo_id = ? AND todos.is_complete = FALSE ORDER BY todos.id"
+	rows, err := tx.Query(query, workspaceID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// IDを区切り文字でつないだ文字列を返す
+func joinIDs(ids []int, sep string) string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = strconv.Itoa(id)
+	}
+	return strings.Join(strs, sep)
+}
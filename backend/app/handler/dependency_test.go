@@ -0,0 +1,230 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectDependencyGraphは、ワークスペースの依存関係の読み込みを期待値として設定します。
+// edgesは、依存するTodoと依存されるTodoのIDの組です。
+func expectDependencyGraph(mock sqlmock.Sqlmock, edges ...[2]int) {
+	rows := sqlmock.NewRows([]string{"todo_id", "blocker_id"})
+	for _, edge := range edges {
+		rows.AddRow(edge[0], edge[1])
+	}
+	mock.ExpectQuery(`^SELECT todo_id, blocker_id FROM todo_dependencies WHERE workspace_id = \? FOR UPDATE$`).
+		WithArgs(testWorkspaceID).
+		WillReturnRows(rows)
+}
+
+func TestGetTodoDependencies(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`^SELECT blocker_id FROM todo_dependencies WHERE todo_id = \? AND workspace_id = \? ORDER BY blocker_id$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"blocker_id"}).AddRow(2).AddRow(3))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/todos/1/dependencies", "")
+	req.SetPathValue("id", "1")
+
+	handler.GetTodoDependencies(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.TodoDependenciesResponse](t, rec)
+	checkResponseBody(t, &model.TodoDependencies{BlockerIDs: []int{2, 3}}, got.Data)
+}
+
+func TestUpdateTodoDependencies(t *testing.T) {
+	cases := map[string]struct {
+		body           string
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantMessage    string
+		wantData       *model.TodoDependencies
+	}{
+		"依存関係を置き換える": {
+			body: `{"blocker_ids": [3, 2, 3]}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE workspace_id = \? AND id IN \(\?, \?\)$`).
+					WithArgs(testWorkspaceID, 2, 3).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(3))
+				// 置き換える前の1から4への依存は循環の判定に使わない
+				expectDependencyGraph(mock, [2]int{1, 4}, [2]int{4, 1}, [2]int{3, 5})
+				mock.ExpectExec(`^DELETE FROM todo_dependencies WHERE todo_id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`^INSERT INTO todo_dependencies \(workspace_id, todo_id, blocker_id\) VALUES \(\?, \?, \?\), \(\?, \?, \?\)$`).
+					WithArgs(testWorkspaceID, 1, 2, testWorkspaceID, 1, 3).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusOK,
+			wantData:       &model.TodoDependencies{BlockerIDs: []int{2, 3}},
+		},
+		"循環する": {
+			body: `{"blocker_ids": [2]}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE workspace_id = \? AND id IN`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				expectDependencyGraph(mock, [2]int{2, 3}, [2]int{3, 1})
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "依存関係が循環するため設定できません（1 → 2 → 3 → 1）。",
+		},
+		"自分自身に依存する": {
+			body: `{"blocker_ids": [1]}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE workspace_id = \? AND id IN`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				expectDependencyGraph(mock)
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "依存関係が循環するため設定できません（1 → 1）。",
+		},
+		"存在しないTodo": {
+			body: `{"blocker_ids": [2, 9]}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE workspace_id = \? AND id IN`).
+					WithArgs(testWorkspaceID, 2, 9).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "存在しないTODOには依存できません（ID: 9）。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodPut, "/todos/1/dependencies", c.body)
+			req.SetPathValue("id", "1")
+
+			handler.UpdateTodoDependencies(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.TodoDependenciesResponse](t, rec)
+			if got.Status.ErrorMessage != c.wantMessage {
+				t.Errorf("want: %s, got: %s", c.wantMessage, got.Status.ErrorMessage)
+			}
+			checkResponseBody(t, c.wantData, got.Data)
+		})
+	}
+}
+
+// 未完了のTodoに依存するTodoは、allow_blockedを指定しない限り完了できないことを確認する
+func TestUpdateTodoByIdBlocked(t *testing.T) {
+	t.Run("完了できない", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "リリース", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+		expectOpenBlockers(mock, 1, 2, 5)
+		mock.ExpectRollback()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPut, "/todos/1", `{"title": "リリース", "is_complete": true}`)
+
+		handler.UpdateTodoById(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusUnprocessableEntity, rec.Code)
+		got := decodeResponseBody[model.TodoResponse](t, rec)
+		checkResponseBody(t, "未完了のTODOに依存しているため完了できません（ID: 2, 5）。", got.Status.ErrorMessage)
+	})
+
+	t.Run("allow_blockedを指定すると完了できる", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "リリース", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+		mock.ExpectExec(`^UPDATE todos`).
+			WithArgs("リリース", true, nil, nil, "", "", "", "done", nil, nil, 1, testWorkspaceID, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectRevision(mock, 1, 2)
+		expectAuditLog(mock, "update", 1)
+		expectChange(mock, 1, false)
+		expectOutbox(mock, "todo.updated")
+		expectOutbox(mock, "todo.completed")
+		mock.ExpectCommit()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPut, "/todos/1?allow_blocked=true", `{"title": "リリース", "is_complete": true}`)
+
+		handler.UpdateTodoById(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestGetCriticalPath(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	// 1は2と3に、3は4に依存している
+	mock.ExpectQuery(`^SELECT todo_dependencies.todo_id, todo_dependencies.blocker_id FROM todo_dependencies JOIN todos .* AND todos.is_complete = FALSE$`).
+		WithArgs(testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"todo_id", "blocker_id"}).AddRow(1, 2).AddRow(1, 3).AddRow(3, 4))
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE workspace_id = \? AND id IN \(\?, \?, \?\)$`).
+		WithArgs(testWorkspaceID, 4, 3, 1).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).
+			AddRow(1, "リリース", false, 1, nil, nil, "", "", "", "todo", nil, nil).
+			AddRow(3, "テスト", false, 1, nil, nil, "", "", "", "todo", nil, nil).
+			AddRow(4, "実装", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/todos/1/critical-path", "")
+	req.SetPathValue("id", "1")
+
+	handler.GetCriticalPath(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.TodosResponse](t, rec)
+	checkResponseBody(t, createTodosResponse(t, []model.Todo{
+		{ID: 4, Title: "実装", Status: "todo", Revision: 1},
+		{ID: 3, Title: "テスト", Status: "todo", Revision: 1},
+		{ID: 1, Title: "リリース", Status: "todo", Revision: 1},
+	}, http.StatusOK, ""), got)
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+)
+
+// ダイジェストの配信設定の初期値
+const (
+	defaultDigestSendTime = "08:00"
+	defaultDigestTimeZone = "UTC"
+)
+
+// 自分のダイジェストの配信設定を取得する。未設定の場合は配信しない設定を返す。
+func GetDigestPreference(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteDigestPreferenceResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	pref := model.DigestPreference{SendTime: defaultDigestSendTime, TimeZone: defaultDigestTimeZone}
+
+	db := database.GetDB()
+	query := "SELECT enabled, email, send_time, time_zone FROM digest_preferences WHERE workspace_id = ? AND user_id = ?"
+	err := db.QueryRow(query, workspaceID, userID).Scan(&pref.Enabled, &pref.Email, &pref.SendTime, &pref.TimeZone)
+	if err != nil && err != sql.ErrNoRows {
+		response.WriteDigestPreferenceResponse(w, nil, http.StatusInternalServerError, constant.DIGEST_ERR_FAILED_GET_PREFERENCE)
+		return
+	}
+
+	response.WriteDigestPreferenceResponse(w, &pref, http.StatusOK, "")
+}
+
+// 自分のダイジェストの配信設定を更新する。enabledをfalseにすると配信を停止する。
+// 第三者に送信できないよう、配信先は自分の確認済みのメールアドレスに限る。
+func UpdateDigestPreference(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteDigestPreferenceResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	var input model.DigestPreference
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteDigestPreferenceResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+	if input.SendTime == "" {
+		input.SendTime = defaultDigestSendTime
+	}
+	if input.TimeZone == "" {
+		input.TimeZone = defaultDigestTimeZone
+	}
+
+	// 入力値のバリデーション
+	if err := validator.DigestPreferenceInput(input); err != nil {
+		response.WriteDigestPreferenceResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	// 配信を停止する場合は、配信先を保持しない
+	if input.Enabled {
+		email, err := verifiedEmail(db, userID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				response.WriteDigestPreferenceResponse(w, nil, http.StatusBadRequest, constant.DIGEST_ERR_UNVERIFIED_EMAIL)
+			} else {
+				response.WriteDigestPreferenceResponse(w, nil, http.StatusInternalServerError, constant.DIGEST_ERR_FAILED_UPDATE_PREFERENCE)
+			}
+			return
+		}
+		if !isOwnEmail(email, input.Email) {
+			response.WriteDigestPreferenceResponse(w, nil, http.StatusForbidden, constant.DIGEST_ERR_FORBIDDEN_EMAIL)
+			return
+		}
+		input.Email = email
+	} else {
+		input.Email = ""
+	}
+
+	// 送信済みの日付は引き継ぎ、同じ日に再送しない
+	upsertQuery := "INSERT INTO digest_preferences (workspace_id, user_id, enabled, email, send_time, time_zone) VALUES (?, ?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE enabled = VALUES(enabled), email = VALUES(email), send_time = VALUES(send_time), time_zone = VALUES(time_zone)"
+	if _, err := db.Exec(upsertQuery, workspaceID, userID, input.Enabled, input.Email, input.SendTime, input.TimeZone); err != nil {
+		response.WriteDigestPreferenceResponse(w, nil, http.StatusInternalServerError, constant.DIGEST_ERR_FAILED_UPDATE_PREFERENCE)
+		return
+	}
+
+	response.WriteDigestPreferenceResponse(w, &input, http.StatusOK, "")
+}
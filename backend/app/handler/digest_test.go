@@ -0,0 +1,159 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetDigestPreference(t *testing.T) {
+	t.Run("設定済み", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT enabled, email, send_time, time_zone FROM digest_preferences WHERE workspace_id = \? AND user_id = \?$`).
+			WithArgs(testWorkspaceID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"enabled", "email", "send_time", "time_zone"}).
+				AddRow(true, "user@example.com", "07:30", "Asia/Tokyo"))
+
+		rec := httptest.NewRecorder()
+		handler.GetDigestPreference(rec, createUserRequest(t, http.MethodGet, "/digest/preferences", ""))
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		got := decodeResponseBody[model.DigestPreferenceResponse](t, rec)
+		want := model.DigestPreference{Enabled: true, Email: "user@example.com", SendTime: "07:30", TimeZone: "Asia/Tokyo"}
+		if got.Data == nil || *got.Data != want {
+			t.Errorf("want: %+v, got: %+v", want, got.Data)
+		}
+	})
+
+	t.Run("未設定の場合は配信しない", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT enabled, email, send_time, time_zone FROM digest_preferences`).
+			WithArgs(testWorkspaceID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"enabled", "email", "send_time", "time_zone"}))
+
+		rec := httptest.NewRecorder()
+		handler.GetDigestPreference(rec, createUserRequest(t, http.MethodGet, "/digest/preferences", ""))
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		got := decodeResponseBody[model.DigestPreferenceResponse](t, rec)
+		want := model.DigestPreference{SendTime: "08:00", TimeZone: "UTC"}
+		if got.Data == nil || *got.Data != want {
+			t.Errorf("want: %+v, got: %+v", want, got.Data)
+		}
+	})
+}
+
+func TestUpdateDigestPreference(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		expectVerifiedEmail(mock, "user@example.com")
+		mock.ExpectExec(`^INSERT INTO digest_preferences \(workspace_id, user_id, enabled, email, send_time, time_zone\) VALUES \(\?, \?, \?, \?, \?, \?\) ON DUPLICATE KEY UPDATE`).
+			WithArgs(testWorkspaceID, testUserID, true, "user@example.com", "08:00", "Asia/Tokyo").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPut, "/digest/preferences",
+			`{"enabled": true, "email": "user@example.com", "time_zone": "Asia/Tokyo"}`)
+
+		handler.UpdateDigestPreference(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("配信を停止する", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectExec(`^INSERT INTO digest_preferences`).
+			WithArgs(testWorkspaceID, testUserID, false, "", "08:00", "UTC").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		rec := httptest.NewRecorder()
+		handler.UpdateDigestPreference(rec, createUserRequest(t, http.MethodPut, "/digest/preferences", `{"enabled": false}`))
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("配信先を省略した場合は自分のアドレスに配信する", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		expectVerifiedEmail(mock, "user@example.com")
+		mock.ExpectExec(`^INSERT INTO digest_preferences`).
+			WithArgs(testWorkspaceID, testUserID, true, "user@example.com", "08:00", "UTC").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		rec := httptest.NewRecorder()
+		handler.UpdateDigestPreference(rec, createUserRequest(t, http.MethodPut, "/digest/preferences", `{"enabled": true}`))
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("他人のアドレスは配信先にできない", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		expectVerifiedEmail(mock, "user@example.com")
+
+		rec := httptest.NewRecorder()
+		handler.UpdateDigestPreference(rec, createUserRequest(t, http.MethodPut, "/digest/preferences",
+			`{"enabled": true, "email": "victim@example.com"}`))
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusForbidden, rec.Code)
+		got := decodeResponseBody[model.DigestPreferenceResponse](t, rec)
+		checkResponseBody(t, "配信先には、自分の確認済みのメールアドレスのみ指定できます。", got.Status.ErrorMessage)
+	})
+
+	t.Run("確認済みのメールアドレスがない", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT email FROM user_emails`).
+			WithArgs(testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"email"}))
+
+		rec := httptest.NewRecorder()
+		handler.UpdateDigestPreference(rec, createUserRequest(t, http.MethodPut, "/digest/preferences", `{"enabled": true}`))
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("入力値が不正", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		handler.UpdateDigestPreference(rec, createUserRequest(t, http.MethodPut, "/digest/preferences", `{"enabled": true, "email": "user"}`))
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("ユーザーの指定なし", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		handler.UpdateDigestPreference(rec, createTestRequest(t, http.MethodPut, "/digest/preferences", `{"enabled": false}`))
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusUnauthorized, rec.Code)
+	})
+}
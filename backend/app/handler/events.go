@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/event"
+	"backend/app/model"
+	"backend/app/outbox"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 接続を維持するためのハートビートの送信間隔
+var sseHeartbeatInterval = 15 * time.Second
+
+// Todoの変更イベントをServer-Sent Eventsで配信する。
+// Last-Event-IDヘッダーを指定した場合は、それ以降のイベントを再送してから配信を始める。
+func StreamEvents(w http.ResponseWriter, r *http.Request) {
+	var lastEventID uint64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusBadRequest, constant.EVENT_ERR_INVALID_LAST_EVENT_ID)
+			return
+		}
+		lastEventID = id
+	}
+
+	rc := http.NewResponseController(w)
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	sub, missed, ok := event.Default().Subscribe(workspaceID, lastEventID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// リバースプロキシによるバッファリングを無効にする
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// 再送できないイベントがある場合は、クライアントに再取得を促す
+	if !ok {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	userID := requestctx.UserID(r.Context())
+	for _, e := range missed {
+		if !e.VisibleTo(userID) {
+			continue
+		}
+		if err := writeSSEEvent(w, e); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		// ストリーミングに対応していないResponseWriterでは配信できない
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, open := <-sub.Events():
+			if !open {
+				// 受信が追いつかず切断された。クライアントはLast-Event-IDで再接続する
+				return
+			}
+			if !e.VisibleTo(userID) {
+				continue
+			}
+			if err := writeSSEEvent(w, e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSEEvent(w http.ResponseWriter, e event.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// 変更と同じトランザクションで、Todoの変更イベントをアウトボックスに記録する
+func recordTodoEvent(ctx context.Context, tx *sql.Tx, eventType event.Type, todoID int, listID *int, todo *model.Todo) (event.Event, error) {
+	return outbox.Record(tx, event.Event{
+		Type:        eventType,
+		WorkspaceID: requestctx.WorkspaceID(ctx),
+		ListID:      listID,
+		TodoID:      todoID,
+		Todo:        todo,
+	})
+}
+
+// 変更をコミットした後に、記録したイベントを購読者へ配信する。
+// 配信前にプロセスが停止した場合でも、アウトボックスの中継によって配信される。
+func publishTodoEvents(events ...event.Event) {
+	for _, e := range events {
+		event.Default().Publish(e)
+	}
+}
@@ -0,0 +1,135 @@
+package handler_test
+
+import (
+	"backend/app/event"
+	"backend/app/handler"
+	"backend/app/middleware"
+	"backend/app/model"
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// newEventServerは、ミドルウェアを通してSSEを配信するテスト用サーバーを起動します。
+// ワークスペースのメンバーの確認には、返却するモックDBを使います。
+func newEventServer(t *testing.T) (*httptest.Server, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock := setUpMockDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", handler.StreamEvents)
+	srv := httptest.NewServer(middleware.Chain(mux, middleware.NewRateLimiter()))
+	t.Cleanup(srv.Close)
+
+	return srv, mock
+}
+
+// openEventStreamは、SSEの接続を開き、行単位で読み取るReaderを返します。
+func openEventStream(t *testing.T, srv *httptest.Server, mock sqlmock.Sqlmock, workspaceID int, lastEventID string) *bufio.Reader {
+	t.Helper()
+
+	expectWorkspaceMember(mock, workspaceID, testUserID)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/events", nil)
+	if err != nil {
+		t.Fatalf("リクエストの作成に失敗しました: %s", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", bearerToken(testUserID))
+	req.Header.Set(middleware.WorkspaceHeader, strconv.Itoa(workspaceID))
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("リクエストに失敗しました: %s", err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+
+	checkStatusCode(t, http.StatusOK, res.StatusCode)
+	if got := res.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("期待したContent-Type: text/event-stream, 実際: %s", got)
+	}
+
+	return bufio.NewReader(res.Body)
+}
+
+// readUntilは、指定の文字列を含む行が届くまで読み取ります。
+func readUntil(t *testing.T, r *bufio.Reader, want string) {
+	t.Helper()
+
+	done := make(chan error, 1)
+	go func() {
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				done <- err
+				return
+			}
+			if strings.Contains(line, want) {
+				done <- nil
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("%qを受信する前にストリームが終了しました: %s", want, err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("%qを受信できませんでした", want)
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	srv, mock := newEventServer(t)
+	stream := openEventStream(t, srv, mock, 901, "")
+
+	// 他のワークスペースのイベントは配信されない
+	event.Default().Publish(event.Event{Type: event.TodoDeleted, WorkspaceID: 902, TodoID: 2})
+	published := event.Default().Publish(event.Event{
+		Type:        event.TodoCreated,
+		WorkspaceID: 901,
+		TodoID:      1,
+		Todo:        &model.Todo{ID: 1, Title: "新しいタスク", Revision: 1},
+	})
+
+	readUntil(t, stream, "event: todo.created")
+	readUntil(t, stream, `"title":"新しいタスク"`)
+
+	// 切断後、Last-Event-IDを指定して再接続すると取りこぼしたイベントが再送される
+	event.Default().Publish(event.Event{Type: event.TodoUpdated, WorkspaceID: 901, TodoID: 1})
+	resumed := openEventStream(t, srv, mock, 901, strconv.FormatUint(published.ID, 10))
+	readUntil(t, resumed, "event: todo.updated")
+}
+
+func TestStreamEventsHeartbeat(t *testing.T) {
+	restore := handler.SetSSEHeartbeatInterval(10 * time.Millisecond)
+	defer restore()
+
+	srv, mock := newEventServer(t)
+	stream := openEventStream(t, srv, mock, 903, "")
+
+	readUntil(t, stream, ": heartbeat")
+}
+
+func TestStreamEventsInvalidLastEventID(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/events", "")
+	req.Header.Set("Last-Event-ID", "abc")
+
+	handler.StreamEvents(rec, req)
+
+	checkStatusCode(t, http.StatusBadRequest, rec.Code)
+}
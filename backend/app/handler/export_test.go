@@ -0,0 +1,10 @@
+package handler
+
+import "time"
+
+// SetSSEHeartbeatIntervalは、テスト用にハートビートの送信間隔を変更し、元に戻す関数を返す
+func SetSSEHeartbeatInterval(d time.Duration) (restore func()) {
+	prev := sseHeartbeatInterval
+	sseHeartbeatInterval = d
+	return func() { sseHeartbeatInterval = prev }
+}
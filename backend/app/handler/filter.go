@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/filter"
+	"time"
+)
+
+// フィルターをWHERE句の条件に変換する。誤りがある場合はエラーメッセージを返す。
+// todayや日付はtimeZoneで解釈し、省略した場合はUTCとする
+func compileFilter(src, timeZone string) (string, []any, string) {
+	loc, err := loadTimeZone(timeZone)
+	if err != nil {
+		return "", nil, constant.FILTER_ERR_INVALID_TIME_ZONE
+	}
+
+	n, err := filter.Parse(src)
+	if err != nil {
+		return "", nil, constant.FILTER_ERR_INVALID_FILTER + err.Error()
+	}
+
+	where, args, err := filter.Compile(n, filter.Env{Now: time.Now(), Location: loc})
+	if err != nil {
+		return "", nil, constant.FILTER_ERR_INVALID_FILTER + err.Error()
+	}
+	return where, args, ""
+}
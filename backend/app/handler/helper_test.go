@@ -1,15 +1,19 @@
 package handler_test
 
 import (
+	"backend/app/auth"
 	"backend/app/database"
 	"backend/app/model"
+	"backend/app/requestctx"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 )
@@ -27,6 +31,21 @@ func setUpMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
 	return db, mock
 }
 
+// bearerTokenは、テスト用の鍵で署名したユーザーのAuthorizationヘッダーの値を返します。
+func bearerToken(userID int) string {
+	if auth.Default() == nil {
+		auth.SetDefault(auth.NewSigner([]byte("test-secret")))
+	}
+	return "Bearer " + auth.Default().Issue(userID, time.Hour)
+}
+
+// expectWorkspaceMemberは、ミドルウェアによるワークスペースのメンバーの確認を期待値として設定します。
+func expectWorkspaceMember(mock sqlmock.Sqlmock, workspaceID, userID int) {
+	mock.ExpectQuery(`^SELECT user_id FROM workspace_members WHERE workspace_id = \? AND user_id = \?$`).
+		WithArgs(workspaceID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(userID))
+}
+
 // createTodoResponseは、テスト用のTodoResponseを作成し、それを返します。
 func createTodoResponse(t *testing.T, data *model.Todo, code int, errorMessage string) model.TodoResponse {
 	t.Helper()
@@ -94,12 +113,159 @@ func checkResponseBody(t *testing.T, want, got interface{}) {
 	}
 }
 
+// todosテーブルから取得するカラム
+var todoRowColumns = []string{"id", "title", "is_complete", "revision", "list_id", "due_at", "recurrence", "time_zone", "notes", "status", "estimate", "milestone_id"}
+
+// expectRevisionは、リビジョンの記録を期待値として設定します。
+func expectRevision(mock sqlmock.Sqlmock, todoID, revision int) {
+	expectRevisionBy(mock, todoID, revision, nil)
+}
+
+// expectRevisionByは、指定したユーザーによるリビジョンの記録を期待値として設定します。
+func expectRevisionBy(mock sqlmock.Sqlmock, todoID, revision int, actorID any) {
+	mock.ExpectExec(`^INSERT INTO todo_revisions`).
+		WithArgs(testWorkspaceID, todoID, revision, persistedSnapshot{}, actorID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// persistedSnapshotは、リビジョンの内容にtodosの項目のみが含まれることを確認します。
+// チェックリストなどの付随する項目を含めると、次の更新で削除されたように見えるため
+type persistedSnapshot struct{}
+
+func (persistedSnapshot) Match(v driver.Value) bool {
+	snapshot, ok := v.(string)
+	if !ok {
+		return false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(snapshot), &fields); err != nil {
+		return false
+	}
+	for _, derived := range []string{"notes_html", "checklist", "assignees", "time_spent"} {
+		if _, ok := fields[derived]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// expectAuditLogは、監査ログの記録を期待値として設定します。
+func expectAuditLog(mock sqlmock.Sqlmock, action string, todoID int) {
+	expectAuditLogBy(mock, action, todoID, nil)
+}
+
+// expectAuditLogByは、指定したユーザーによる監査ログの記録を期待値として設定します。
+func expectAuditLogBy(mock sqlmock.Sqlmock, action string, todoID int, actorID any) {
+	mock.ExpectExec(`^INSERT INTO audit_logs`).
+		WithArgs(testWorkspaceID, actorID, action, todoID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectChangeは、同期用の変更の記録を期待値として設定します。
+func expectChange(mock sqlmock.Sqlmock, todoID int, deleted bool) {
+	mock.ExpectExec(`^INSERT INTO change_sequences`).
+		WithArgs(testWorkspaceID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^INSERT INTO todo_changes`).
+		WithArgs(testWorkspaceID, 1, todoID, deleted, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectOpenBlockersは、完了するTodoが依存する未完了のTodoの確認を期待値として設定します。
+func expectOpenBlockers(mock sqlmock.Sqlmock, todoID int, blockerIDs ...int) {
+	rows := sqlmock.NewRows([]string{"id"})
+	for _, id := range blockerIDs {
+		rows.AddRow(id)
+	}
+	mock.ExpectQuery(`^SELECT todos.id FROM todo_dependencies`).
+		WithArgs(testWorkspaceID, todoID).
+		WillReturnRows(rows)
+}
+
+// expectOccurrencesは、完了した繰り返すTodoの次の回を作成済みかどうかの確認を期待値として設定します。
+func expectOccurrences(mock sqlmock.Sqlmock, todoID, count int) {
+	mock.ExpectQuery(`^SELECT COUNT\(\*\) FROM todo_occurrences WHERE todo_id = \? AND workspace_id = \?$`).
+		WithArgs(todoID, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+// expectTodoLockは、Todoに属するデータを変更する前のTodoのロックを期待値として設定します。
+func expectTodoLock(mock sqlmock.Sqlmock, todoID int) {
+	mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+		WithArgs(todoID, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).
+			AddRow(todoID, "title", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+}
+
+// expectRelatedChangeは、Todoに属するデータの変更による、リビジョン・監査ログ・同期用の変更・イベントの記録を期待値として設定します。
+func expectRelatedChange(mock sqlmock.Sqlmock, todoID int) {
+	expectRelatedChangeBy(mock, todoID, nil)
+}
+
+// expectRelatedChangeByは、指定したユーザーによるTodoに属するデータの変更の記録を期待値として設定します。
+func expectRelatedChangeBy(mock sqlmock.Sqlmock, todoID int, actorID any) {
+	mock.ExpectExec(`^UPDATE todos SET revision = revision \+ 1 WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(todoID, testWorkspaceID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRevisionBy(mock, todoID, 2, actorID)
+	expectAuditLogBy(mock, "update", todoID, actorID)
+	expectChange(mock, todoID, false)
+	expectOutbox(mock, "todo.updated")
+}
+
+// expectDependentsPurgeは、Todoに属するチェックリストなどの削除を期待値として設定します。
+func expectDependentsPurge(mock sqlmock.Sqlmock, todoID int) {
+	for _, table := range []string{"checklist_items", "todo_tags", "comments", "todo_assignees", "time_entries", "todo_occurrences"} {
+		mock.ExpectExec(`^DELETE FROM `+table+` WHERE todo_id = \? AND workspace_id = \?$`).
+			WithArgs(todoID, testWorkspaceID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec(`^DELETE FROM todo_dependencies WHERE \(todo_id = \? OR blocker_id = \?\) AND workspace_id = \?$`).
+		WithArgs(todoID, todoID, testWorkspaceID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+// expectAttachmentPurgeは、添付ファイルのないTodoの添付ファイルの削除を期待値として設定します。
+func expectAttachmentPurge(mock sqlmock.Sqlmock, todoID int) {
+	mock.ExpectQuery(`^SELECT blob_key, thumbnail_key FROM attachments WHERE todo_id = \? AND workspace_id = \?`).
+		WithArgs(todoID, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"blob_key", "thumbnail_key"}))
+}
+
+// expectOutboxは、アウトボックスへのイベントの記録を期待値として設定します。
+func expectOutbox(mock sqlmock.Sqlmock, eventType string) {
+	mock.ExpectExec(`^INSERT INTO outbox`).
+		WithArgs(testWorkspaceID, sqlmock.AnyArg(), eventType, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// テスト用リクエストのデフォルトのワークスペースID
+const testWorkspaceID = 1
+
 // createTestRequestは、テスト用のリクエストを作成し、それを返します。
 func createTestRequest(t *testing.T, method, path, body string) *http.Request {
 	t.Helper()
 
+	return createWorkspaceRequest(t, testWorkspaceID, method, path, body)
+}
+
+// createWorkspaceRequestは、ワークスペースを指定したテスト用のリクエストを作成し、それを返します。
+func createWorkspaceRequest(t *testing.T, workspaceID int, method, path, body string) *http.Request {
+	t.Helper()
+
 	req := httptest.NewRequest(method, path, strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 
-	return req
+	return req.WithContext(requestctx.WithWorkspaceID(req.Context(), workspaceID))
+}
+
+// テスト用リクエストのデフォルトのユーザーID
+const testUserID = 7
+
+// createUserRequestは、ユーザーを指定したテスト用のリクエストを作成し、それを返します。
+func createUserRequest(t *testing.T, method, path, body string) *http.Request {
+	t.Helper()
+
+	req := createTestRequest(t, method, path, body)
+	return req.WithContext(requestctx.WithUserID(req.Context(), testUserID))
 }
@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"backend/app/audit"
+	"backend/app/changelog"
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/history"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/workflow"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Todoの変更履歴をリビジョンの古い順に取得する
+func GetTodoHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTodoRevisionsResponse(w, []model.TodoRevision{}, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	// 他のワークスペースのTodoの履歴は返さない
+	var todoID int
+	if err := db.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&todoID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTodoRevisionsResponse(w, []model.TodoRevision{}, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteTodoRevisionsResponse(w, []model.TodoRevision{}, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_GET_HISTORY)
+		}
+		return
+	}
+
+	query := "SELECT revision, snapshot_json, actor_id, created_at FROM todo_revisions WHERE todo_id = ? AND workspace_id = ? ORDER BY revision"
+	rows, err := db.Query(query, id, workspaceID)
+	if err != nil {
+		response.WriteTodoRevisionsResponse(w, []model.TodoRevision{}, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_GET_HISTORY)
+		return
+	}
+	defer rows.Close()
+
+	revisions := []model.TodoRevision{}
+	var previous *model.Todo
+	for rows.Next() {
+		var (
+			revision model.TodoRevision
+			snapshot []byte
+			actorID  sql.NullInt64
+		)
+		if err := rows.Scan(&revision.Revision, &snapshot, &actorID, &revision.CreatedAt); err != nil {
+			response.WriteTodoRevisionsResponse(w, []model.TodoRevision{}, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_GET_HISTORY)
+			return
+		}
+		if err := json.Unmarshal(snapshot, &revision.Todo); err != nil {
+			response.WriteTodoRevisionsResponse(w, []model.TodoRevision{}, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_GET_HISTORY)
+			return
+		}
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			revision.ActorID = &id
+		}
+
+		revision.Changes = history.Diff(previous, revision.Todo)
+		previous = &revision.Todo
+		revisions = append(revisions, revision)
+	}
+
+	response.WriteTodoRevisionsResponse(w, revisions, http.StatusOK, "")
+}
+
+// Todoを指定のリビジョンの状態に戻す。元に戻した結果は新しいリビジョンとして記録する。
+// If-Matchヘッダーで現在のリビジョンを指定した場合は、一致しなければ競合として扱う。
+// 完了した状態に戻す場合は更新で完了する場合と同じく、allow_blocked=trueを指定しない限り未完了のTodoに依存していれば戻せない。
+func RevertTodo(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTodoResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	targetRevision, err := strconv.Atoi(r.URL.Query().Get("revision"))
+	if err != nil || targetRevision <= 0 {
+		response.WriteTodoResponse(w, nil, http.StatusBadRequest, constant.HISTORY_ERR_INVALID_REVISION)
+		return
+	}
+
+	expectedRevision := 0
+	if v := strings.Trim(r.Header.Get("If-Match"), `"`); v != "" {
+		expectedRevision, err = strconv.Atoi(v)
+		if err != nil {
+			response.WriteTodoResponse(w, nil, http.StatusBadRequest, constant.HISTORY_ERR_INVALID_REVISION)
+			return
+		}
+	}
+
+	opts, errMessage := parseUpdateOptions(r)
+	if errMessage != "" {
+		response.WriteTodoResponse(w, nil, http.StatusBadRequest, errMessage)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	var current model.Todo
+	checkQuery := "SELECT " + todoColumns + " FROM todos WHERE id = ? AND workspace_id = ?"
+	if err := scanTodo(tx.QueryRow(checkQuery, id, workspaceID), &current); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTodoResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO_ROW)
+		}
+		return
+	}
+
+	if expectedRevision != 0 && expectedRevision != current.Revision {
+		response.WriteTodoResponse(w, nil, http.StatusConflict, constant.DB_ERR_CONFLICT_TODO)
+		return
+	}
+
+	var snapshot []byte
+	snapshotQuery := "SELECT snapshot_json FROM todo_revisions WHERE todo_id = ? AND workspace_id = ? AND revision = ?"
+	if err := tx.QueryRow(snapshotQuery, id, workspaceID, targetRevision).Scan(&snapshot); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTodoResponse(w, nil, http.StatusNotFound, constant.HISTORY_ERR_NOT_FOUND_REVISION)
+		} else {
+			response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+		}
+		return
+	}
+
+	var reverted model.Todo
+	if err := json.Unmarshal(snapshot, &reverted); err != nil {
+		response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+		return
+	}
+	// 以前のリビジョンにチェックリストなどが記録されていても、元に戻すのはtodosの項目のみ
+	clearDerivedFields(&reverted)
+	reverted.ID = id
+	reverted.Revision = current.Revision + 1
+
+	// 元に戻す先のリストが削除されている場合は、リストから外す
+	if mErr := checkListExists(tx, workspaceID, reverted.ListID); mErr != nil {
+		if mErr.code != http.StatusBadRequest {
+			response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+			return
+		}
+		reverted.ListID = nil
+	}
+	// マイルストーンが削除されている場合も同様に外す
+	if mErr := checkMilestoneExists(tx, workspaceID, reverted.MilestoneID); mErr != nil {
+		if mErr.code != http.StatusBadRequest {
+			response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+			return
+		}
+		reverted.MilestoneID = nil
+	}
+
+	// 元に戻す場合はワークフローの変更の規則を確認しない。元のステータスが現在のワークフローにない場合は、完了状態から決める
+	wf, err := loadWorkflow(tx, workspaceID, reverted.ListID)
+	if err != nil {
+		response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+		return
+	}
+	if _, ok := workflow.State(wf, reverted.Status); !ok {
+		reverted.Status = ""
+	}
+	if err := workflow.Resolve(wf, nil, &reverted); err != nil {
+		response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+		return
+	}
+
+	// 完了した状態に戻す場合は、更新で完了する場合と同じく依存しているTodoを確認する
+	if !current.IsComplete && reverted.IsComplete {
+		if mErr := checkBlockers(tx, workspaceID, id, opts, constant.HISTORY_ERR_FAILED_REVERT_TODO); mErr != nil {
+			response.WriteTodoResponse(w, nil, mErr.code, mErr.message)
+			return
+		}
+	}
+
+	updateQuery := "UPDATE todos SET title = ?, is_complete = ?, list_id = ?, due_at = ?, recurrence = ?, time_zone = ?, notes = ?, status = ?, estimate = ?, milestone_id = ?, revision = ? " +
+		"WHERE id = ? AND workspace_id = ? AND revision = ?"
+	result, err := tx.Exec(
+		updateQuery,
+		reverted.Title, reverted.IsComplete, reverted.ListID, reverted.DueAt, reverted.Recurrence, reverted.TimeZone, reverted.Notes, reverted.Status, reverted.Estimate, reverted.MilestoneID, reverted.Revision,
+		id, workspaceID, current.Revision,
+	)
+	if err != nil {
+		response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		response.WriteTodoResponse(w, nil, http.StatusConflict, constant.DB_ERR_CONFLICT_TODO)
+		return
+	}
+
+	if err := history.Record(r.Context(), tx, &reverted); err != nil {
+		response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+		return
+	}
+
+	if err := audit.Record(r.Context(), tx, audit.ActionRevert, id, &current, &reverted); err != nil {
+		response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+		return
+	}
+
+	if err := changelog.Record(r.Context(), tx, id, false); err != nil {
+		response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+		return
+	}
+
+	events, err := recordUpdateEvents(r.Context(), tx, &current, &reverted)
+	if err != nil {
+		response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+		return
+	}
+
+	// 繰り返すTODOを完了した状態に戻した場合も、次の回を作成する
+	if !current.IsComplete && reverted.IsComplete {
+		created, err := createNextOccurrence(r.Context(), tx, wf, &reverted)
+		if err != nil {
+			response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+			return
+		}
+		events = append(events, created...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.HISTORY_ERR_FAILED_REVERT_TODO)
+		return
+	}
+
+	publishTodoEvents(events...)
+	response.WriteTodoResponse(w, &reverted, http.StatusOK, "")
+}
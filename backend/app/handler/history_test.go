@@ -0,0 +1,308 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetTodoHistory(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	revisionColumns := []string{"revision", "snapshot_json", "actor_id", "created_at"}
+
+	cases := map[string]struct {
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantBody       interface{}
+	}{
+		"正常系": {
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`^SELECT revision, snapshot_json, actor_id, created_at FROM todo_revisions WHERE todo_id = \? AND workspace_id = \? ORDER BY revision$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(revisionColumns).
+						AddRow(1, `{"id":1,"title":"買い物","is_complete":false,"revision":1}`, nil, createdAt).
+						AddRow(2, `{"id":1,"title":"買い物","is_complete":true,"revision":2}`, nil, createdAt))
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody: model.TodoRevisionsResponse{
+				Data: []model.TodoRevision{
+					{
+						Revision:  1,
+						Todo:      model.Todo{ID: 1, Title: "買い物", IsComplete: false, Revision: 1},
+						CreatedAt: createdAt,
+						Changes: []model.FieldChange{
+							{Field: "is_complete", From: nil, To: false},
+							{Field: "title", From: nil, To: "買い物"},
+						},
+					},
+					{
+						Revision:  2,
+						Todo:      model.Todo{ID: 1, Title: "買い物", IsComplete: true, Revision: 2},
+						CreatedAt: createdAt,
+						Changes:   []model.FieldChange{{Field: "is_complete", From: false, To: true}},
+					},
+				},
+				Status: model.StatusInfo{Code: http.StatusOK},
+			},
+		},
+		"TODOが存在しない": {
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantBody: model.TodoRevisionsResponse{
+				Data:   []model.TodoRevision{},
+				Status: model.StatusInfo{Code: http.StatusNotFound, Error: true, ErrorMessage: "TODOが見つかりません。"},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodGet, "/todos/1/history", "")
+			req.SetPathValue("id", "1")
+
+			handler.GetTodoHistory(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.TodoRevisionsResponse](t, rec)
+			checkResponseBody(t, c.wantBody, got)
+		})
+	}
+}
+
+func TestRevertTodo(t *testing.T) {
+	dueAt := time.Date(2024, 3, 25, 8, 0, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		query          string
+		ifMatch        string
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantBody       interface{}
+	}{
+		"正常系": {
+			query:   "?revision=1",
+			ifMatch: `"3"`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "間違えた", true, 3, nil, nil, "", "", "", "done", nil, nil))
+				mock.ExpectQuery(`^SELECT snapshot_json FROM todo_revisions WHERE todo_id = \? AND workspace_id = \? AND revision = \?$`).
+					WithArgs(1, testWorkspaceID, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"snapshot_json"}).
+						AddRow(`{"id":1,"title":"元のタイトル","is_complete":false,"revision":1}`))
+				mock.ExpectExec(`^UPDATE todos SET title = \?, is_complete = \?, list_id = \?, due_at = \?, recurrence = \?, time_zone = \?, notes = \?, status = \?, estimate = \?, milestone_id = \?, revision = \? WHERE id = \? AND workspace_id = \? AND revision = \?$`).
+					WithArgs("元のタイトル", false, nil, nil, "", "", "", "todo", nil, nil, 4, 1, testWorkspaceID, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRevision(mock, 1, 4)
+				expectAuditLog(mock, "revert", 1)
+				expectChange(mock, 1, false)
+				expectOutbox(mock, "todo.updated")
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody: createTodoResponse(
+				t,
+				&model.Todo{ID: 1, Title: "元のタイトル", IsComplete: false, Status: "todo", Revision: 4},
+				http.StatusOK,
+				"",
+			),
+		},
+		"チェックリストと担当者を記録したリビジョンに戻す": {
+			query: "?revision=2",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "間違えた", false, 3, nil, nil, "", "", "", "todo", nil, nil))
+				mock.ExpectQuery(`^SELECT snapshot_json FROM todo_revisions`).
+					WithArgs(1, testWorkspaceID, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"snapshot_json"}).
+						AddRow(`{"id":1,"title":"元のタイトル","is_complete":false,"revision":2,"checklist":{"items":[{"id":1,"text":"材料を買う"}],"progress":{"checked":0,"total":1,"ratio":0}},"assignees":[7]}`))
+				mock.ExpectExec(`^UPDATE todos SET title = \?`).
+					WithArgs("元のタイトル", false, nil, nil, "", "", "", "todo", nil, nil, 4, 1, testWorkspaceID, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRevision(mock, 1, 4)
+				expectAuditLog(mock, "revert", 1)
+				expectChange(mock, 1, false)
+				expectOutbox(mock, "todo.updated")
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody: createTodoResponse(
+				t,
+				&model.Todo{ID: 1, Title: "元のタイトル", IsComplete: false, Status: "todo", Revision: 4},
+				http.StatusOK,
+				"",
+			),
+		},
+		"依存しているTodoが未完了の状態で完了に戻す": {
+			query: "?revision=2",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "牛乳", false, 3, nil, nil, "", "", "", "todo", nil, nil))
+				mock.ExpectQuery(`^SELECT snapshot_json FROM todo_revisions`).
+					WithArgs(1, testWorkspaceID, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"snapshot_json"}).
+						AddRow(`{"id":1,"title":"牛乳","is_complete":true,"status":"done","revision":2}`))
+				expectOpenBlockers(mock, 1, 5)
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+			wantBody:       createTodoResponse(t, nil, http.StatusUnprocessableEntity, "未完了のTODOに依存しているため完了できません（ID: 5）。"),
+		},
+		"依存しているTodoが未完了でも許可して完了に戻す": {
+			query: "?revision=2&allow_blocked=true",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "牛乳", false, 3, nil, nil, "", "", "", "todo", nil, nil))
+				mock.ExpectQuery(`^SELECT snapshot_json FROM todo_revisions`).
+					WithArgs(1, testWorkspaceID, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"snapshot_json"}).
+						AddRow(`{"id":1,"title":"牛乳","is_complete":true,"status":"done","revision":2}`))
+				mock.ExpectExec(`^UPDATE todos SET title = \?`).
+					WithArgs("牛乳", true, nil, nil, "", "", "", "done", nil, nil, 4, 1, testWorkspaceID, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRevision(mock, 1, 4)
+				expectAuditLog(mock, "revert", 1)
+				expectChange(mock, 1, false)
+				expectOutbox(mock, "todo.updated")
+				expectOutbox(mock, "todo.completed")
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody: createTodoResponse(
+				t,
+				&model.Todo{ID: 1, Title: "牛乳", IsComplete: true, Status: "done", Revision: 4},
+				http.StatusOK,
+				"",
+			),
+		},
+		"繰り返すTodoを完了に戻すと次の回を作成する": {
+			query: "?revision=2",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "ゴミ出し", false, 3, nil, dueAt, "FREQ=DAILY", "", "", "todo", nil, nil))
+				mock.ExpectQuery(`^SELECT snapshot_json FROM todo_revisions`).
+					WithArgs(1, testWorkspaceID, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"snapshot_json"}).
+						AddRow(`{"id":1,"title":"ゴミ出し","is_complete":true,"status":"done","revision":2,"due_at":"2024-03-25T08:00:00Z","recurrence":"FREQ=DAILY"}`))
+				expectOpenBlockers(mock, 1)
+				mock.ExpectExec(`^UPDATE todos SET title = \?`).
+					WithArgs("ゴミ出し", true, nil, dueAt, "FREQ=DAILY", "", "", "done", nil, nil, 4, 1, testWorkspaceID, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRevision(mock, 1, 4)
+				expectAuditLog(mock, "revert", 1)
+				expectChange(mock, 1, false)
+				expectOutbox(mock, "todo.updated")
+				expectOutbox(mock, "todo.completed")
+				expectOccurrences(mock, 1, 0)
+				mock.ExpectExec(`^INSERT INTO todos`).
+					WithArgs(testWorkspaceID, "ゴミ出し", false, 1, nil, dueAt.AddDate(0, 0, 1), "FREQ=DAILY", "", "", "todo", nil, nil).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				expectRevision(mock, 2, 1)
+				expectAuditLog(mock, "create", 2)
+				expectChange(mock, 2, false)
+				expectOutbox(mock, "todo.created")
+				mock.ExpectExec(`^INSERT INTO todo_occurrences`).
+					WithArgs(testWorkspaceID, 1, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody: createTodoResponse(
+				t,
+				&model.Todo{ID: 1, Title: "ゴミ出し", IsComplete: true, Status: "done", Revision: 4, DueAt: &dueAt, Recurrence: "FREQ=DAILY"},
+				http.StatusOK,
+				"",
+			),
+		},
+		"リビジョン未指定": {
+			query:          "",
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+			wantBody:       createTodoResponse(t, nil, http.StatusBadRequest, "リビジョンの指定が不正です。"),
+		},
+		"If-Matchが一致しない": {
+			query:   "?revision=1",
+			ifMatch: "2",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "間違えた", true, 3, nil, nil, "", "", "", "done", nil, nil))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusConflict,
+			wantBody: createTodoResponse(
+				t,
+				nil,
+				http.StatusConflict,
+				"TODOは他の操作によって更新されています。最新の状態を取得してください。",
+			),
+		},
+		"リビジョンが存在しない": {
+			query: "?revision=9",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "間違えた", true, 3, nil, nil, "", "", "", "done", nil, nil))
+				mock.ExpectQuery(`^SELECT snapshot_json FROM todo_revisions`).
+					WithArgs(1, testWorkspaceID, 9).
+					WillReturnRows(sqlmock.NewRows([]string{"snapshot_json"}))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantBody:       createTodoResponse(t, nil, http.StatusNotFound, "指定のリビジョンが見つかりません。"),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodPost, "/todos/1/revert"+c.query, "")
+			req.SetPathValue("id", "1")
+			if c.ifMatch != "" {
+				req.Header.Set("If-Match", c.ifMatch)
+			}
+
+			handler.RevertTodo(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.TodoResponse](t, rec)
+			checkResponseBody(t, c.wantBody, got)
+		})
+	}
+}
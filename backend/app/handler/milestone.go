@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"backend/app/burndown"
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const milestoneColumns = "id, name, start_date, end_date"
+
+// マイルストーンの行を読み込む。日付はYYYY-MM-DD形式の文字列にする
+func scanMilestone(row rowScanner, milestone *model.Milestone) error {
+	var start, end time.Time
+	if err := row.Scan(&milestone.ID, &milestone.Name, &start, &end); err != nil {
+		return err
+	}
+	milestone.StartDate = start.Format(time.DateOnly)
+	milestone.EndDate = end.Format(time.DateOnly)
+	return nil
+}
+
+// マイルストーンを開始日の順に取得する
+func GetMilestones(w http.ResponseWriter, r *http.Request) {
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	rows, err := db.Query("SELECT "+milestoneColumns+" FROM milestones WHERE workspace_id = ? ORDER BY start_date, id", workspaceID)
+	if err != nil {
+		response.WriteMilestonesResponse(w, []model.Milestone{}, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_GET_MILESTONE)
+		return
+	}
+	defer rows.Close()
+
+	milestones := []model.Milestone{}
+	for rows.Next() {
+		var milestone model.Milestone
+		if err := scanMilestone(rows, &milestone); err != nil {
+			response.WriteMilestonesResponse(w, []model.Milestone{}, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_GET_MILESTONE)
+			return
+		}
+		milestones = append(milestones, milestone)
+	}
+	if err := rows.Err(); err != nil {
+		response.WriteMilestonesResponse(w, []model.Milestone{}, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_GET_MILESTONE)
+		return
+	}
+
+	response.WriteMilestonesResponse(w, milestones, http.StatusOK, "")
+}
+
+// マイルストーンを作成する
+func CreateMilestone(w http.ResponseWriter, r *http.Request) {
+	input, ok := decodeMilestoneInput(w, r)
+	if !ok {
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	insertQuery := "INSERT INTO milestones (workspace_id, name, start_date, end_date) VALUES (?, ?, ?, ?)"
+	result, err := db.Exec(insertQuery, workspaceID, input.Name, input.StartDate, input.EndDate)
+	if err != nil {
+		response.WriteMilestoneResponse(w, nil, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_ADD_MILESTONE)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		response.WriteMilestoneResponse(w, nil, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_ADD_MILESTONE)
+		return
+	}
+	input.ID = int(id)
+
+	response.WriteMilestoneResponse(w, &input, http.StatusCreated, "")
+}
+
+// マイルストーンの名前と期間を更新する
+func UpdateMilestone(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteMilestoneResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	input, ok := decodeMilestoneInput(w, r)
+	if !ok {
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var milestoneID int
+	if err := db.QueryRow("SELECT id FROM milestones WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&milestoneID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteMilestoneResponse(w, nil, http.StatusNotFound, constant.MILESTONE_ERR_NOT_FOUND_MILESTONE)
+		} else {
+			response.WriteMilestoneResponse(w, nil, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_UPDATE_MILESTONE)
+		}
+		return
+	}
+
+	updateQuery := "UPDATE milestones SET name = ?, start_date = ?, end_date = ? WHERE id = ? AND workspace_id = ?"
+	if _, err := db.Exec(updateQuery, input.Name, input.StartDate, input.EndDate, id, workspaceID); err != nil {
+		response.WriteMilestoneResponse(w, nil, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_UPDATE_MILESTONE)
+		return
+	}
+	input.ID = id
+
+	response.WriteMilestoneResponse(w, &input, http.StatusOK, "")
+}
+
+// マイルストーンを削除する。Todoが属している場合は削除できない
+func DeleteMilestone(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteMilestoneResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteMilestoneResponse(w, nil, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_DELETE_MILESTONE)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	var milestoneID int
+	if err := tx.QueryRow("SELECT id FROM milestones WHERE id = ? AND workspace_id = ? FOR UPDATE", id, workspaceID).Scan(&milestoneID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteMilestoneResponse(w, nil, http.StatusNotFound, constant.MILESTONE_ERR_NOT_FOUND_MILESTONE)
+		} else {
+			response.WriteMilestoneResponse(w, nil, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_DELETE_MILESTONE)
+		}
+		return
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM todos WHERE workspace_id = ? AND milestone_id = ?", workspaceID, id).Scan(&count); err != nil {
+		response.WriteMilestoneResponse(w, nil, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_DELETE_MILESTONE)
+		return
+	}
+	if count > 0 {
+		response.WriteMilestoneResponse(w, nil, http.StatusConflict, constant.MILESTONE_ERR_IN_USE)
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM milestones WHERE id = ? AND workspace_id = ?", id, workspaceID); err != nil {
+		response.WriteMilestoneResponse(w, nil, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_DELETE_MILESTONE)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		response.WriteMilestoneResponse(w, nil, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_DELETE_MILESTONE)
+		return
+	}
+
+	response.WriteMilestoneResponse(w, nil, http.StatusOK, "")
+}
+
+// マイルストーンの入力を読み込み、バリデーションする。失敗した場合はレスポンスを書き込んでfalseを返す
+func decodeMilestoneInput(w http.ResponseWriter, r *http.Request) (model.Milestone, bool) {
+	var input model.Milestone
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteMilestoneResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return input, false
+	}
+	input.Name = strings.TrimSpace(input.Name)
+
+	// 入力値のバリデーション
+	if err := validator.MilestoneInput(input); err != nil {
+		response.WriteMilestoneResponse(w, nil, http.StatusBadRequest, err.Error())
+		return input, false
+	}
+	return input, true
+}
+
+// マイルストーンの残りの見積もりを、開始日から終了日（今日より後の場合は今日）まで日ごとに返す。
+// 一度でもマイルストーンに属したTodoのリビジョンと削除の記録から、各日の終わり（UTC）時点の状態を再現して集計する
+func GetBurndown(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteBurndownResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var milestone model.Milestone
+	if err := scanMilestone(db.QueryRow("SELECT "+milestoneColumns+" FROM milestones WHERE id = ? AND workspace_id = ?", id, workspaceID), &milestone); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteBurndownResponse(w, nil, http.StatusNotFound, constant.MILESTONE_ERR_NOT_FOUND_MILESTONE)
+		} else {
+			response.WriteBurndownResponse(w, nil, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_GET_BURNDOWN)
+		}
+		return
+	}
+
+	start, _ := time.Parse(time.DateOnly, milestone.StartDate)
+	end, _ := time.Parse(time.DateOnly, milestone.EndDate)
+	if today := time.Now().UTC().Truncate(24 * time.Hour); today.Before(end) {
+		end = today
+	}
+	// 制限より前に作成した長い期間のマイルストーンも、直近の上限の日数までに限って集計する
+	if earliest := end.AddDate(0, 0, -(validator.MaxMilestoneDays - 1)); start.Before(earliest) {
+		start = earliest
+	}
+	// 最後の日の終わりより後の記録は集計に影響しない
+	cutoff := end.AddDate(0, 0, 1)
+
+	snapshots, err := loadBurndownSnapshots(db, workspaceID, id, cutoff)
+	if err != nil {
+		response.WriteBurndownResponse(w, nil, http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_GET_BURNDOWN)
+		return
+	}
+
+	result := model.Burndown{
+		MilestoneID: id,
+		Points:      burndown.Compute(id, start, end, snapshots),
+	}
+	response.WriteBurndownResponse(w, &result, http.StatusOK, "")
+}
+
+// 一度でもマイルストーンに属したTodoについて、cutoffより前のリビジョンと削除の記録を読み込む
+func loadBurndownSnapshots(db *sql.DB, workspaceID, milestoneID int, cutoff time.Time) ([]burndown.Snapshot, error) {
+	const members = "SELECT todo_id FROM todo_revisions WHERE workspace_id = ? AND JSON_EXTRACT(snapshot_json, '$.milestone_id') = ?"
+
+	revisionQuery := "SELECT todo_id, snapshot_json, created_at FROM todo_revisions WHERE workspace_id = ? AND todo_id IN (" + members + ") AND created_at < ? ORDER BY created_at, todo_id, revision"
+	rows, err := db.Query(revisionQuery, workspaceID, workspaceID, milestoneID, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []burndown.Snapshot{}
+	for rows.Next() {
+		var snapshot burndown.Snapshot
+		var snapshotJSON string
+		if err := rows.Scan(&snapshot.TodoID, &snapshotJSON, &snapshot.At); err != nil {
+			return nil, err
+		}
+		var todo model.Todo
+		if err := json.Unmarshal([]byte(snapshotJSON), &todo); err != nil {
+			return nil, err
+		}
+		snapshot.Todo = &todo
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	deletionQuery := "SELECT todo_id, changed_at FROM todo_changes WHERE workspace_id = ? AND deleted = TRUE AND todo_id IN (" + members + ") AND changed_at < ? ORDER BY changed_at, todo_id"
+	deletions, err := db.Query(deletionQuery, workspaceID, workspaceID, milestoneID, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer deletions.Close()
+
+	for deletions.Next() {
+		var snapshot burndown.Snapshot
+		if err := deletions.Scan(&snapshot.TodoID, &snapshot.At); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, deletions.Err()
+}
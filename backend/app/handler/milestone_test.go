@@ -0,0 +1,252 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// マイルストーンのカラム
+var milestoneRowColumns = []string{"id", "name", "start_date", "end_date"}
+
+func date(month time.Month, day int) time.Time {
+	return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCreateMilestone(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectExec(`^INSERT INTO milestones \(workspace_id, name, start_date, end_date\) VALUES \(\?, \?, \?, \?\)$`).
+			WithArgs(testWorkspaceID, "スプリント1", "2024-05-01", "2024-05-14").
+			WillReturnResult(sqlmock.NewResult(2, 1))
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPost, "/milestones", `{"name": " スプリント1 ", "start_date": "2024-05-01", "end_date": "2024-05-14"}`)
+
+		handler.CreateMilestone(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusCreated, rec.Code)
+		got := decodeResponseBody[model.MilestoneResponse](t, rec)
+		checkResponseBody(t, &model.Milestone{ID: 2, Name: "スプリント1", StartDate: "2024-05-01", EndDate: "2024-05-14"}, got.Data)
+	})
+
+	t.Run("終了日が開始日より前", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPost, "/milestones", `{"name": "スプリント1", "start_date": "2024-05-14", "end_date": "2024-05-01"}`)
+
+		handler.CreateMilestone(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+		got := decodeResponseBody[model.MilestoneResponse](t, rec)
+		checkResponseBody(t, "終了日は開始日以降にしてください。", got.Status.ErrorMessage)
+	})
+}
+
+func TestGetMilestones(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT id, name, start_date, end_date FROM milestones WHERE workspace_id = \? ORDER BY start_date, id$`).
+		WithArgs(testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(milestoneRowColumns).
+			AddRow(1, "スプリント1", date(time.May, 1), date(time.May, 14)).
+			AddRow(2, "スプリント2", date(time.May, 15), date(time.May, 28)))
+
+	rec := httptest.NewRecorder()
+	handler.GetMilestones(rec, createTestRequest(t, http.MethodGet, "/milestones", ""))
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.MilestonesResponse](t, rec)
+	checkResponseBody(t, []model.Milestone{
+		{ID: 1, Name: "スプリント1", StartDate: "2024-05-01", EndDate: "2024-05-14"},
+		{ID: 2, Name: "スプリント2", StartDate: "2024-05-15", EndDate: "2024-05-28"},
+	}, got.Data)
+}
+
+func TestDeleteMilestone(t *testing.T) {
+	cases := map[string]struct {
+		todoCount      int
+		wantStatusCode int
+		wantMessage    string
+	}{
+		"正常系":        {todoCount: 0, wantStatusCode: http.StatusOK},
+		"Todoが属している": {todoCount: 3, wantStatusCode: http.StatusConflict, wantMessage: "TODOが属しているマイルストーンは削除できません。"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`^SELECT id FROM milestones WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+				WithArgs(2, testWorkspaceID).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+			mock.ExpectQuery(`^SELECT COUNT\(\*\) FROM todos WHERE workspace_id = \? AND milestone_id = \?$`).
+				WithArgs(testWorkspaceID, 2).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(c.todoCount))
+			if c.todoCount == 0 {
+				mock.ExpectExec(`^DELETE FROM milestones WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(2, testWorkspaceID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodDelete, "/milestones/2", "")
+			req.SetPathValue("id", "2")
+
+			handler.DeleteMilestone(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.MilestoneResponse](t, rec)
+			checkResponseBody(t, c.wantMessage, got.Status.ErrorMessage)
+		})
+	}
+}
+
+// リビジョンと削除の記録から、各日の終わり時点の見積もりを集計することを確認する
+func TestGetBurndown(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT id, name, start_date, end_date FROM milestones WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(2, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(milestoneRowColumns).AddRow(2, "スプリント1", date(time.May, 1), date(time.May, 3)))
+	mock.ExpectQuery(`^SELECT todo_id, snapshot_json, created_at FROM todo_revisions WHERE workspace_id = \? AND todo_id IN \(SELECT todo_id FROM todo_revisions WHERE workspace_id = \? AND JSON_EXTRACT\(snapshot_json, '\$\.milestone_id'\) = \?\) AND created_at < \? ORDER BY created_at, todo_id, revision$`).
+		WithArgs(testWorkspaceID, testWorkspaceID, 2, date(time.May, 4)).
+		WillReturnRows(sqlmock.NewRows([]string{"todo_id", "snapshot_json", "created_at"}).
+			AddRow(1, `{"id":1,"title":"設計","is_complete":false,"revision":1,"estimate":5,"milestone_id":2}`, date(time.April, 30)).
+			AddRow(2, `{"id":2,"title":"実装","is_complete":false,"revision":1,"estimate":8,"milestone_id":2}`, date(time.May, 1).Add(9*time.Hour)).
+			AddRow(1, `{"id":1,"title":"設計","is_complete":true,"revision":2,"estimate":5,"milestone_id":2}`, date(time.May, 2).Add(15*time.Hour)))
+	mock.ExpectQuery(`^SELECT todo_id, changed_at FROM todo_changes WHERE workspace_id = \? AND deleted = TRUE AND todo_id IN \(SELECT todo_id FROM todo_revisions .*\) AND changed_at < \? ORDER BY changed_at, todo_id$`).
+		WithArgs(testWorkspaceID, testWorkspaceID, 2, date(time.May, 4)).
+		WillReturnRows(sqlmock.NewRows([]string{"todo_id", "changed_at"}).
+			AddRow(2, date(time.May, 3).Add(10*time.Hour)))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/milestones/2/burndown", "")
+	req.SetPathValue("id", "2")
+
+	handler.GetBurndown(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.BurndownResponse](t, rec)
+	checkResponseBody(t, &model.Burndown{
+		MilestoneID: 2,
+		Points: []model.BurndownPoint{
+			{Date: "2024-05-01", RemainingPoints: 13, TotalPoints: 13},
+			{Date: "2024-05-02", RemainingPoints: 8, TotalPoints: 13},
+			{Date: "2024-05-03", RemainingPoints: 0, TotalPoints: 5},
+		},
+	}, got.Data)
+}
+
+// 上限より長い期間のマイルストーンは、終了日までの上限の日数に限って集計することを確認する
+func TestGetBurndownLongMilestone(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT id, name, start_date, end_date FROM milestones WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(2, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(milestoneRowColumns).AddRow(2, "長期", time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), date(time.May, 3)))
+	mock.ExpectQuery(`^SELECT todo_id, snapshot_json, created_at FROM todo_revisions`).
+		WithArgs(testWorkspaceID, testWorkspaceID, 2, date(time.May, 4)).
+		WillReturnRows(sqlmock.NewRows([]string{"todo_id", "snapshot_json", "created_at"}))
+	mock.ExpectQuery(`^SELECT todo_id, changed_at FROM todo_changes`).
+		WithArgs(testWorkspaceID, testWorkspaceID, 2, date(time.May, 4)).
+		WillReturnRows(sqlmock.NewRows([]string{"todo_id", "changed_at"}))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/milestones/2/burndown", "")
+	req.SetPathValue("id", "2")
+
+	handler.GetBurndown(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.BurndownResponse](t, rec)
+	if n := len(got.Data.Points); n != 366 {
+		t.Fatalf("期待した日数: 366, 実際: %d", n)
+	}
+	if first, last := got.Data.Points[0].Date, got.Data.Points[365].Date; first != "2023-05-04" || last != "2024-05-03" {
+		t.Errorf("期待した期間: 2023-05-04〜2024-05-03, 実際: %s〜%s", first, last)
+	}
+}
+
+func TestGetBurndownNotFound(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT id, name, start_date, end_date FROM milestones WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(9, testWorkspaceID).
+		WillReturnError(sql.ErrNoRows)
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/milestones/9/burndown", "")
+	req.SetPathValue("id", "9")
+
+	handler.GetBurndown(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCreateTodoWithUnknownMilestone(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT id FROM milestones WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(9, testWorkspaceID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodPost, "/todos", `{"title": "設計", "estimate": 3, "milestone_id": 9}`)
+
+	handler.CreateTodo(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusBadRequest, rec.Code)
+	got := decodeResponseBody[model.TodosResponse](t, rec)
+	checkResponseBody(t, "マイルストーンが見つかりません。", got.Status.ErrorMessage)
+}
+
+func TestGetTodosByMilestone(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE workspace_id = \? AND milestone_id = \?$`).
+		WithArgs(testWorkspaceID, 2).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).
+			AddRow(1, "設計", false, 1, nil, nil, "", "", "", "todo", 5, 2))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/todos?milestone=2", "")
+
+	handler.GetTodos(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	estimate, milestoneID := 5, 2
+	checkResponseBody(t, createTodosResponse(t, []model.Todo{{ID: 1, Title: "設計", Status: "todo", Revision: 1, Estimate: &estimate, MilestoneID: &milestoneID}}, http.StatusOK, ""), decodeResponseBody[model.TodosResponse](t, rec))
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/recurrence"
+	"backend/app/response"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// プレビューで返却する件数の既定値
+	defaultPreviewCount = 5
+	// プレビューで返却する件数の上限
+	maxPreviewCount = 100
+)
+
+// 繰り返しのルールから、開始日時以降の日時を指定した件数だけ返す。
+// 日時は指定したタイムゾーンの時刻で返却する。
+func PreviewRecurrence(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	rule, err := recurrence.Parse(query.Get("rule"))
+	if err != nil {
+		response.WriteOccurrencesResponse(w, []time.Time{}, http.StatusBadRequest, constant.RECURRENCE_ERR_INVALID_RULE)
+		return
+	}
+
+	loc, err := loadTimeZone(query.Get("time_zone"))
+	if err != nil {
+		response.WriteOccurrencesResponse(w, []time.Time{}, http.StatusBadRequest, constant.RECURRENCE_ERR_INVALID_TIME_ZONE)
+		return
+	}
+
+	start, err := time.Parse(time.RFC3339, query.Get("start"))
+	if err != nil {
+		response.WriteOccurrencesResponse(w, []time.Time{}, http.StatusBadRequest, constant.RECURRENCE_ERR_INVALID_START)
+		return
+	}
+
+	count := defaultPreviewCount
+	if v := query.Get("count"); v != "" {
+		count, err = strconv.Atoi(v)
+		if err != nil || count < 1 || count > maxPreviewCount {
+			response.WriteOccurrencesResponse(w, []time.Time{}, http.StatusBadRequest, constant.RECURRENCE_ERR_INVALID_COUNT)
+			return
+		}
+	}
+
+	occurrences := rule.Occurrences(start.In(loc), count)
+	response.WriteOccurrencesResponse(w, occurrences, http.StatusOK, "")
+}
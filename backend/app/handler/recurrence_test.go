@@ -0,0 +1,225 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPreviewRecurrence(t *testing.T) {
+	cases := map[string]struct {
+		query          url.Values
+		wantStatusCode int
+		wantDates      []string
+		wantErrMessage string
+	}{
+		"夏時間の開始をまたいでも現地時刻を保つ": {
+			query: url.Values{
+				"rule":      {"FREQ=WEEKLY;BYDAY=MO"},
+				"start":     {"2024-03-25T08:00:00Z"},
+				"time_zone": {"Europe/Berlin"},
+				"count":     {"2"},
+			},
+			wantStatusCode: http.StatusOK,
+			wantDates:      []string{"2024-03-25T09:00:00+01:00", "2024-04-01T09:00:00+02:00"},
+		},
+		"件数の既定値は5件": {
+			query:          url.Values{"rule": {"FREQ=DAILY"}, "start": {"2024-01-01T09:00:00Z"}},
+			wantStatusCode: http.StatusOK,
+			wantDates: []string{
+				"2024-01-01T09:00:00Z", "2024-01-02T09:00:00Z", "2024-01-03T09:00:00Z", "2024-01-04T09:00:00Z", "2024-01-05T09:00:00Z",
+			},
+		},
+		"ルールが不正": {
+			query:          url.Values{"rule": {"FREQ=YEARLY"}, "start": {"2024-01-01T09:00:00Z"}},
+			wantStatusCode: http.StatusBadRequest,
+			wantErrMessage: "繰り返しのルールが不正です。",
+		},
+		"開始日時が不正": {
+			query:          url.Values{"rule": {"FREQ=DAILY"}, "start": {"2024-01-01"}},
+			wantStatusCode: http.StatusBadRequest,
+			wantErrMessage: "開始日時が不正です。",
+		},
+		"タイムゾーンが不正": {
+			query:          url.Values{"rule": {"FREQ=DAILY"}, "start": {"2024-01-01T09:00:00Z"}, "time_zone": {"Mars/Olympus"}},
+			wantStatusCode: http.StatusBadRequest,
+			wantErrMessage: "タイムゾーンが不正です。",
+		},
+		"件数が上限を超える": {
+			query:          url.Values{"rule": {"FREQ=DAILY"}, "start": {"2024-01-01T09:00:00Z"}, "count": {"101"}},
+			wantStatusCode: http.StatusBadRequest,
+			wantErrMessage: "件数は1から100の範囲で指定してください。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodGet, "/recurrence/preview?"+c.query.Encode(), "")
+
+			handler.PreviewRecurrence(rec, req)
+
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.OccurrencesResponse](t, rec)
+			checkResponseBody(t, c.wantErrMessage, got.Status.ErrorMessage)
+
+			gotDates := make([]string, len(got.Data))
+			for i, d := range got.Data {
+				gotDates[i] = d.Format(time.RFC3339)
+			}
+			if c.wantDates == nil {
+				c.wantDates = []string{}
+			}
+			checkResponseBody(t, c.wantDates, gotDates)
+		})
+	}
+}
+
+// 繰り返すTODOを完了すると、同じトランザクションで次の回が作成される
+func TestUpdateTodoByIdCreatesNextOccurrence(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	// ベルリンの月曜9時。次の回は夏時間の開始後のため、UTCでは1時間早くなる
+	dueAt := time.Date(2024, 3, 25, 8, 0, 0, 0, time.UTC)
+	nextDueAt := time.Date(2024, 4, 1, 7, 0, 0, 0, time.UTC)
+	rule := "FREQ=WEEKLY;BYDAY=MO;COUNT=3"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "ゴミ出し", false, 1, nil, dueAt, rule, "Europe/Berlin", "", "todo", nil, nil))
+	expectOpenBlockers(mock, 1)
+	// 完了した回は繰り返しのルールを次の回に引き継ぎ、自身のルールも残す
+	mock.ExpectExec(`^UPDATE todos`).
+		WithArgs("ゴミ出し", true, nil, dueAt, rule, "Europe/Berlin", "", "done", nil, nil, 1, testWorkspaceID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRevision(mock, 1, 2)
+	expectAuditLog(mock, "update", 1)
+	expectChange(mock, 1, false)
+	expectOutbox(mock, "todo.updated")
+	expectOutbox(mock, "todo.completed")
+	expectOccurrences(mock, 1, 0)
+	mock.ExpectExec(`^INSERT INTO todos`).
+		WithArgs(testWorkspaceID, "ゴミ出し", false, 1, nil, nextDueAt, "FREQ=WEEKLY;BYDAY=MO;COUNT=2", "Europe/Berlin", "", "todo", nil, nil).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	expectRevision(mock, 2, 1)
+	expectAuditLog(mock, "create", 2)
+	expectChange(mock, 2, false)
+	expectOutbox(mock, "todo.created")
+	mock.ExpectExec(`^INSERT INTO todo_occurrences \(workspace_id, todo_id, next_todo_id\) VALUES \(\?, \?, \?\)$`).
+		WithArgs(testWorkspaceID, 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// 完了のみを指定し、期限や繰り返しのルールは省略する
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodPut, "/todos/1", `{"is_complete": true}`)
+
+	handler.UpdateTodoById(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+}
+
+// 完了を取り消して再度完了した場合は、次の回を作成し直さない
+func TestUpdateTodoByIdRecompletesOccurrence(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	dueAt := time.Date(2024, 3, 25, 8, 0, 0, 0, time.UTC)
+	nextDueAt := time.Date(2024, 3, 26, 8, 0, 0, 0, time.UTC)
+	rule := "FREQ=DAILY"
+
+	// 完了状態をisCompleteに変更する更新を期待値として設定する
+	expectUpdate := func(revision int, isComplete bool, from, to string) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "ゴミ出し", !isComplete, revision, nil, dueAt, rule, "", "", from, nil, nil))
+		if isComplete {
+			expectOpenBlockers(mock, 1)
+		}
+		mock.ExpectExec(`^UPDATE todos`).
+			WithArgs("ゴミ出し", isComplete, nil, dueAt, rule, "", "", to, nil, nil, 1, testWorkspaceID, revision).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectRevision(mock, 1, revision+1)
+		expectAuditLog(mock, "update", 1)
+		expectChange(mock, 1, false)
+		expectOutbox(mock, "todo.updated")
+		if isComplete {
+			expectOutbox(mock, "todo.completed")
+		}
+	}
+
+	// 1回目の完了で次の回を作成する
+	expectUpdate(1, true, "todo", "done")
+	expectOccurrences(mock, 1, 0)
+	mock.ExpectExec(`^INSERT INTO todos`).
+		WithArgs(testWorkspaceID, "ゴミ出し", false, 1, nil, nextDueAt, rule, "", "", "todo", nil, nil).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	expectRevision(mock, 2, 1)
+	expectAuditLog(mock, "create", 2)
+	expectChange(mock, 2, false)
+	expectOutbox(mock, "todo.created")
+	mock.ExpectExec(`^INSERT INTO todo_occurrences`).
+		WithArgs(testWorkspaceID, 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// 完了を取り消す
+	expectUpdate(2, false, "done", "todo")
+	mock.ExpectCommit()
+	// 再度完了しても、作成済みのため次の回は作成しない
+	expectUpdate(3, true, "todo", "done")
+	expectOccurrences(mock, 1, 1)
+	mock.ExpectCommit()
+
+	for _, body := range []string{`{"is_complete": true}`, `{"is_complete": false}`, `{"is_complete": true}`} {
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPut, "/todos/1", body)
+
+		handler.UpdateTodoById(rec, req)
+
+		checkStatusCode(t, http.StatusOK, rec.Code)
+	}
+	checkMockExpectations(t, mock)
+}
+
+// 最後の回を完了した場合は、次の回を作成しない
+func TestUpdateTodoByIdCompletesLastOccurrence(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	dueAt := time.Date(2024, 3, 25, 8, 0, 0, 0, time.UTC)
+	rule := "FREQ=DAILY;COUNT=1"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "ゴミ出し", false, 1, nil, dueAt, rule, "", "", "todo", nil, nil))
+	expectOpenBlockers(mock, 1)
+	mock.ExpectExec(`^UPDATE todos`).
+		WithArgs("ゴミ出し", true, nil, dueAt, rule, "", "", "done", nil, nil, 1, testWorkspaceID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRevision(mock, 1, 2)
+	expectAuditLog(mock, "update", 1)
+	expectChange(mock, 1, false)
+	expectOutbox(mock, "todo.updated")
+	expectOutbox(mock, "todo.completed")
+	mock.ExpectCommit()
+
+	body := `{"title": "ゴミ出し", "is_complete": true, "due_at": "2024-03-25T08:00:00Z", "recurrence": "` + rule + `"}`
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodPut, "/todos/1", body)
+
+	handler.UpdateTodoById(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+}
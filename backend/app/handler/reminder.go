@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/reminder"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Todoにリマインダーを追加する。リマインダーは追加したユーザーにのみ通知する。
+func CreateReminder(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteReminderResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	todoID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteReminderResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	var input model.Reminder
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteReminderResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+
+	// 入力値のバリデーション
+	if err := validator.ReminderInput(input); err != nil {
+		response.WriteReminderResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var id int
+	if err := db.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ?", todoID, workspaceID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteReminderResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteReminderResponse(w, nil, http.StatusInternalServerError, constant.REMINDER_ERR_FAILED_ADD_REMINDER)
+		}
+		return
+	}
+
+	// 宛先はメールの場合のみ保持する。第三者に送信できないよう、宛先は自分の確認済みのアドレスに限る
+	if input.Channel == reminder.ChannelEmail {
+		email, err := verifiedEmail(db, userID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				response.WriteReminderResponse(w, nil, http.StatusBadRequest, constant.REMINDER_ERR_UNVERIFIED_EMAIL)
+			} else {
+				response.WriteReminderResponse(w, nil, http.StatusInternalServerError, constant.REMINDER_ERR_FAILED_ADD_REMINDER)
+			}
+			return
+		}
+		if !isOwnEmail(email, input.Target) {
+			response.WriteReminderResponse(w, nil, http.StatusForbidden, constant.REMINDER_ERR_FORBIDDEN_TARGET)
+			return
+		}
+		input.Target = email
+	} else {
+		input.Target = ""
+	}
+	input.TodoID = todoID
+	input.RemindAt = input.RemindAt.UTC()
+	input.Status = reminder.StatusPending
+	input.SentAt = nil
+	input.CreatedAt = time.Now()
+
+	insertQuery := "INSERT INTO reminders (workspace_id, todo_id, user_id, channel, target, remind_at, next_attempt_at, status, attempts, created_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := db.Exec(insertQuery, workspaceID, todoID, userID, input.Channel, input.Target, input.RemindAt, input.RemindAt, input.Status, 0, input.CreatedAt)
+	if err != nil {
+		response.WriteReminderResponse(w, nil, http.StatusInternalServerError, constant.REMINDER_ERR_FAILED_ADD_REMINDER)
+		return
+	}
+
+	insertedID, err := result.LastInsertId()
+	if err != nil {
+		response.WriteReminderResponse(w, nil, http.StatusInternalServerError, constant.REMINDER_ERR_FAILED_ADD_REMINDER)
+		return
+	}
+	input.ID = int(insertedID)
+
+	response.WriteReminderResponse(w, &input, http.StatusCreated, "")
+}
+
+// Todoに設定した自分のリマインダーを通知日時の順に取得する
+func GetReminders(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteRemindersResponse(w, []model.Reminder{}, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	todoID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteRemindersResponse(w, []model.Reminder{}, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	query := "SELECT id, todo_id, remind_at, channel, target, status, sent_at, created_at FROM reminders " +
+		"WHERE todo_id = ? AND workspace_id = ? AND user_id = ? ORDER BY remind_at, id"
+	rows, err := db.Query(query, todoID, workspaceID, userID)
+	if err != nil {
+		response.WriteRemindersResponse(w, []model.Reminder{}, http.StatusInternalServerError, constant.REMINDER_ERR_FAILED_GET_REMINDER)
+		return
+	}
+	defer rows.Close()
+
+	reminders := []model.Reminder{}
+	for rows.Next() {
+		var rem model.Reminder
+		if err := rows.Scan(&rem.ID, &rem.TodoID, &rem.RemindAt, &rem.Channel, &rem.Target, &rem.Status, &rem.SentAt, &rem.CreatedAt); err != nil {
+			response.WriteRemindersResponse(w, []model.Reminder{}, http.StatusInternalServerError, constant.REMINDER_ERR_FAILED_GET_REMINDER)
+			return
+		}
+		// 通知中の状態は利用者には未通知として見せる
+		if rem.Status == reminder.StatusLeased {
+			rem.Status = reminder.StatusPending
+		}
+		reminders = append(reminders, rem)
+	}
+
+	response.WriteRemindersResponse(w, reminders, http.StatusOK, "")
+}
+
+// 未通知の自分のリマインダーを取り消す
+func DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteReminderResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	todoID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteReminderResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+	reminderID, err := strconv.Atoi(r.PathValue("reminderID"))
+	if err != nil {
+		response.WriteReminderResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	// 通知中のリマインダーは取り消せない
+	cancelQuery := "UPDATE reminders SET status = ? WHERE id = ? AND todo_id = ? AND workspace_id = ? AND user_id = ? AND status = ?"
+	result, err := db.Exec(cancelQuery, reminder.StatusCanceled, reminderID, todoID, workspaceID, userID, reminder.StatusPending)
+	if err != nil {
+		response.WriteReminderResponse(w, nil, http.StatusInternalServerError, constant.REMINDER_ERR_FAILED_DELETE_REMINDER)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		response.WriteReminderResponse(w, nil, http.StatusNotFound, constant.REMINDER_ERR_NOT_FOUND_REMINDER)
+		return
+	}
+
+	response.WriteReminderResponse(w, nil, http.StatusOK, "")
+}
@@ -0,0 +1,263 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectVerifiedEmailは、ユーザー自身の確認済みのメールアドレスの取得を期待値として設定します。
+func expectVerifiedEmail(mock sqlmock.Sqlmock, email string) {
+	mock.ExpectQuery(`^SELECT email FROM user_emails WHERE user_id = \? AND verified_at IS NOT NULL$`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow(email))
+}
+
+func TestCreateReminder(t *testing.T) {
+	remindAt := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("メールで通知", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \?$`).
+			WithArgs(3, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		expectVerifiedEmail(mock, "user@example.com")
+		mock.ExpectExec(`^INSERT INTO reminders \(workspace_id, todo_id, user_id, channel, target, remind_at, next_attempt_at, status, attempts, created_at\)`).
+			WithArgs(testWorkspaceID, 3, testUserID, "email", "user@example.com", remindAt, remindAt, "pending", 0, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(11, 1))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/todos/3/reminders",
+			`{"remind_at": "2026-01-01T18:00:00+09:00", "channel": "email", "target": "user@example.com"}`)
+		req.SetPathValue("id", "3")
+
+		handler.CreateReminder(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusCreated, rec.Code)
+		got := decodeResponseBody[model.ReminderResponse](t, rec)
+		if got.Data == nil || got.Data.ID != 11 || got.Data.Status != "pending" || !got.Data.RemindAt.Equal(remindAt) {
+			t.Errorf("レスポンスが不正です: %+v", got.Data)
+		}
+	})
+
+	t.Run("宛先を省略した場合は自分のアドレスに通知する", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT id FROM todos`).
+			WithArgs(3, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		expectVerifiedEmail(mock, "user@example.com")
+		mock.ExpectExec(`^INSERT INTO reminders`).
+			WithArgs(testWorkspaceID, 3, testUserID, "email", "user@example.com", remindAt, remindAt, "pending", 0, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(13, 1))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/todos/3/reminders",
+			`{"remind_at": "2026-01-01T09:00:00Z", "channel": "email"}`)
+		req.SetPathValue("id", "3")
+
+		handler.CreateReminder(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("他人のアドレスは宛先にできない", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT id FROM todos`).
+			WithArgs(3, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		expectVerifiedEmail(mock, "user@example.com")
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/todos/3/reminders",
+			`{"remind_at": "2026-01-01T09:00:00Z", "channel": "email", "target": "victim@example.com"}`)
+		req.SetPathValue("id", "3")
+
+		handler.CreateReminder(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusForbidden, rec.Code)
+		got := decodeResponseBody[model.ReminderResponse](t, rec)
+		checkResponseBody(t, "メールの宛先には、自分の確認済みのメールアドレスのみ指定できます。", got.Status.ErrorMessage)
+	})
+
+	t.Run("確認済みのメールアドレスがない", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT id FROM todos`).
+			WithArgs(3, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectQuery(`^SELECT email FROM user_emails`).
+			WithArgs(testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"email"}))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/todos/3/reminders",
+			`{"remind_at": "2026-01-01T09:00:00Z", "channel": "email"}`)
+		req.SetPathValue("id", "3")
+
+		handler.CreateReminder(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+		got := decodeResponseBody[model.ReminderResponse](t, rec)
+		checkResponseBody(t, "メールで通知するには、確認済みのメールアドレスが必要です。", got.Status.ErrorMessage)
+	})
+
+	t.Run("プッシュ通知では宛先を保持しない", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT id FROM todos`).
+			WithArgs(3, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectExec(`^INSERT INTO reminders`).
+			WithArgs(testWorkspaceID, 3, testUserID, "push", "", remindAt, remindAt, "pending", 0, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(12, 1))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/todos/3/reminders",
+			`{"remind_at": "2026-01-01T09:00:00Z", "channel": "push", "target": "ignored"}`)
+		req.SetPathValue("id", "3")
+
+		handler.CreateReminder(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("Todoが存在しない", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT id FROM todos`).
+			WithArgs(99, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/todos/99/reminders",
+			`{"remind_at": "2026-01-01T09:00:00Z", "channel": "push"}`)
+		req.SetPathValue("id", "99")
+
+		handler.CreateReminder(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("入力値が不正", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/todos/3/reminders",
+			`{"remind_at": "2026-01-01T09:00:00Z", "channel": "email", "target": "user"}`)
+		req.SetPathValue("id", "3")
+
+		handler.CreateReminder(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("ユーザーの指定なし", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPost, "/todos/3/reminders",
+			`{"remind_at": "2026-01-01T09:00:00Z", "channel": "push"}`)
+		req.SetPathValue("id", "3")
+
+		handler.CreateReminder(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestGetReminders(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	remindAt := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	sentAt := remindAt.Add(time.Second)
+	mock.ExpectQuery(`^SELECT id, todo_id, remind_at, channel, target, status, sent_at, created_at FROM reminders WHERE todo_id = \? AND workspace_id = \? AND user_id = \? ORDER BY remind_at, id$`).
+		WithArgs(3, testWorkspaceID, testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "todo_id", "remind_at", "channel", "target", "status", "sent_at", "created_at"}).
+			AddRow(1, 3, remindAt, "push", "", "sent", sentAt, remindAt).
+			AddRow(2, 3, remindAt.Add(time.Hour), "email", "user@example.com", "leased", nil, remindAt))
+
+	rec := httptest.NewRecorder()
+	req := createUserRequest(t, http.MethodGet, "/todos/3/reminders", "")
+	req.SetPathValue("id", "3")
+
+	handler.GetReminders(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.RemindersResponse](t, rec)
+	if len(got.Data) != 2 {
+		t.Fatalf("件数が不正です: %d", len(got.Data))
+	}
+	if got.Data[0].SentAt == nil || !got.Data[0].SentAt.Equal(sentAt) {
+		t.Errorf("通知日時が不正です: %v", got.Data[0].SentAt)
+	}
+	// 通知中は未通知として返す
+	if got.Data[1].Status != "pending" {
+		t.Errorf("状態が不正です: %s", got.Data[1].Status)
+	}
+}
+
+func TestDeleteReminder(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectExec(`^UPDATE reminders SET status = \? WHERE id = \? AND todo_id = \? AND workspace_id = \? AND user_id = \? AND status = \?$`).
+			WithArgs("canceled", 5, 3, testWorkspaceID, testUserID, "pending").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodDelete, "/todos/3/reminders/5", "")
+		req.SetPathValue("id", "3")
+		req.SetPathValue("reminderID", "5")
+
+		handler.DeleteReminder(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("通知済み", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectExec(`^UPDATE reminders SET status = \?`).
+			WithArgs("canceled", 5, 3, testWorkspaceID, testUserID, "pending").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodDelete, "/todos/3/reminders/5", "")
+		req.SetPathValue("id", "3")
+		req.SetPathValue("reminderID", "5")
+
+		handler.DeleteReminder(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/filter"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/search"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	// 検索結果の件数の既定値と上限
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// 抜粋の最大文字数
+	searchSnippetWidth = 60
+	// FULLTEXTインデックスを使用できない場合に、スコアを計算する候補の上限
+	searchFallbackCandidates = 1000
+)
+
+// MySQLのエラー番号: FULLTEXTインデックスが存在しない
+const errNoFullTextIndex = 1191
+
+// scoredScannerは、todoColumnsに続けて関連度のカラムを読み込む
+type scoredScanner struct {
+	rowScanner
+	score *float64
+}
+
+func (s scoredScanner) Scan(dest ...any) error {
+	return s.rowScanner.Scan(append(dest, s.score)...)
+}
+
+// Todoのタイトルとメモを全文検索し、関連度の高い順に返す。
+// 検索語は空白で区切るとすべての語を含むTodoに一致し、語の途中や先頭からの一致も対象にする。
+func SearchTodos(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	query, err := search.Parse(params.Get("q"))
+	if err != nil {
+		response.WriteTodoSearchResponse(w, []model.TodoSearchResult{}, http.StatusBadRequest, constant.SEARCH_ERR_INVALID_QUERY)
+		return
+	}
+
+	limit := defaultSearchLimit
+	if v := params.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			response.WriteTodoSearchResponse(w, []model.TodoSearchResult{}, http.StatusBadRequest, constant.SEARCH_ERR_INVALID_LIMIT)
+			return
+		}
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	results, err := searchTodosFullText(db, workspaceID, query, limit)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errNoFullTextIndex {
+		results, err = searchTodosFallback(db, workspaceID, query, limit)
+	}
+	if err != nil {
+		response.WriteTodoSearchResponse(w, []model.TodoSearchResult{}, http.StatusInternalServerError, constant.SEARCH_ERR_FAILED_SEARCH)
+		return
+	}
+
+	for i := range results {
+		results[i].Snippet = searchSnippet(query, results[i].Todo)
+	}
+
+	response.WriteTodoSearchResponse(w, results, http.StatusOK, "")
+}
+
+// 抜粋を作成する。タイトルがすべての語を含まず、メモに一致箇所がある場合はメモから切り出す
+func searchSnippet(query search.Query, todo model.Todo) string {
+	if !query.Match(todo.Title) && query.MatchAny(todo.Notes) {
+		return query.Snippet(todo.Notes, searchSnippetWidth)
+	}
+	return query.Snippet(todo.Title, searchSnippetWidth)
+}
+
+// FULLTEXTインデックスで検索する
+func searchTodosFullText(db *sql.DB, workspaceID int, query search.Query, limit int) ([]model.TodoSearchResult, error) {
+	expr := query.BooleanMode()
+	searchQuery := "SELECT " + todoColumns + ", MATCH(title, notes) AGAINST(? IN BOOLEAN MODE) AS score FROM todos " +
+		"WHERE workspace_id = ? AND MATCH(title, notes) AGAINST(? IN BOOLEAN MODE) ORDER BY score DESC, id LIMIT ?"
+	rows, err := db.Query(searchQuery, expr, workspaceID, expr, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []model.TodoSearchResult{}
+	for rows.Next() {
+		var result model.TodoSearchResult
+		if err := scanTodo(scoredScanner{rows, &result.Score}, &result.Todo); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// FULLTEXTインデックスを使用できない場合に、LIKEで候補を絞り込んでから関連度を計算する
+func searchTodosFallback(db *sql.DB, workspaceID int, query search.Query, limit int) ([]model.TodoSearchResult, error) {
+	conditions := make([]string, len(query.Terms))
+	args := []any{workspaceID}
+	for i, t := range query.Terms {
+		pattern := "%" + filter.EscapeLike(t.Text) + "%"
+		conditions[i] = `(title LIKE ? ESCAPE '\\' OR notes LIKE ? ESCAPE '\\')`
+		args = append(args, pattern, pattern)
+	}
+	args = append(args, searchFallbackCandidates)
+
+	searchQuery := "SELECT " + todoColumns + " FROM todos WHERE workspace_id = ? AND " + strings.Join(conditions, " AND ") + " ORDER BY id LIMIT ?"
+	rows, err := db.Query(searchQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []model.TodoSearchResult{}
+	for rows.Next() {
+		var result model.TodoSearchResult
+		if err := scanTodo(rows, &result.Todo); err != nil {
+			return nil, err
+		}
+		// 語がタイトルとメモに分かれて含まれる場合も一致するよう、両方を合わせてスコアを計算する
+		result.Score = query.Score(result.Todo.Title + "\n" + result.Todo.Notes)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
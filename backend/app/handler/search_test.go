@@ -0,0 +1,92 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestSearchTodos(t *testing.T) {
+	scoredColumns := append(append([]string{}, todoRowColumns...), "score")
+
+	t.Run("FULLTEXTインデックスで検索", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id, MATCH\(title, notes\) AGAINST\(\? IN BOOLEAN MODE\) AS score FROM todos WHERE workspace_id = \? AND MATCH\(title, notes\) AGAINST\(\? IN BOOLEAN MODE\) ORDER BY score DESC, id LIMIT \?$`).
+			WithArgs(`+"牛乳" +"買う"`, testWorkspaceID, `+"牛乳" +"買う"`, 20).
+			WillReturnRows(sqlmock.NewRows(scoredColumns).
+				AddRow(3, "牛乳を買う", false, 1, nil, nil, "", "", "", "todo", nil, nil, 1.5).
+				AddRow(1, "スーパーで<牛乳>を買う", false, 1, nil, nil, "", "", "", "todo", nil, nil, 0.7).
+				AddRow(4, "買い物", false, 1, nil, nil, "", "", "牛乳を2本買う", "todo", nil, nil, 0.5))
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodGet, "/todos/search?q=牛乳%E3%80%80買う", "")
+
+		handler.SearchTodos(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		got := decodeResponseBody[model.TodoSearchResponse](t, rec)
+		want := []model.TodoSearchResult{
+			{Todo: model.Todo{ID: 3, Title: "牛乳を買う", Status: "todo", Revision: 1}, Score: 1.5, Snippet: "<mark>牛乳</mark>を<mark>買う</mark>"},
+			{Todo: model.Todo{ID: 1, Title: "スーパーで<牛乳>を買う", Status: "todo", Revision: 1}, Score: 0.7, Snippet: "スーパーで&lt;<mark>牛乳</mark>&gt;を<mark>買う</mark>"},
+			// タイトルに一致しない場合は、メモから抜粋する
+			{Todo: model.Todo{ID: 4, Title: "買い物", Notes: "牛乳を2本買う", Status: "todo", Revision: 1}, Score: 0.5, Snippet: "<mark>牛乳</mark>を2本<mark>買う</mark>"},
+		}
+		checkResponseBody(t, want, got.Data)
+	})
+
+	t.Run("FULLTEXTインデックスがない場合はLIKEで検索", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT .* MATCH\(title, notes\)`).
+			WillReturnError(&mysql.MySQLError{Number: 1191, Message: "Can't find FULLTEXT index matching the column list"})
+		mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE workspace_id = \? AND \(title LIKE \? ESCAPE '\\\\' OR notes LIKE \? ESCAPE '\\\\'\) ORDER BY id LIMIT \?$`).
+			WithArgs(testWorkspaceID, `%100\%%`, `%100\%%`, 1000).
+			WillReturnRows(sqlmock.NewRows(todoRowColumns).
+				AddRow(1, "進捗100%を報告", false, 1, nil, nil, "", "", "", "todo", nil, nil).
+				AddRow(2, "100%", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodGet, "/todos/search?q=100%25&limit=1", "")
+
+		handler.SearchTodos(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		got := decodeResponseBody[model.TodoSearchResponse](t, rec)
+		// 完全に一致するタイトルが上位になる
+		if len(got.Data) != 1 || got.Data[0].Todo.ID != 2 {
+			t.Errorf("検索結果が不正です: %+v", got.Data)
+		}
+	})
+
+	t.Run("検索語が空", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		handler.SearchTodos(rec, createTestRequest(t, http.MethodGet, "/todos/search?q=", ""))
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("件数が不正", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		handler.SearchTodos(rec, createTestRequest(t, http.MethodGet, "/todos/search?q=a&limit=101", ""))
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 共有トークンのバイト長（推測不可能な長さにする）
+const shareTokenBytes = 32
+
+// リストの共有リンクを作成する。リストの所有者のみ作成できる
+func CreateShareLink(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteShareLinkResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	listID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteShareLinkResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	// ボディは省略可能（省略時は無期限）
+	var input model.ShareLinkInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		response.WriteShareLinkResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+	if input.ExpiresInHours < 0 {
+		response.WriteShareLinkResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_EXPIRES)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	if code, errMessage := checkListOwner(db, workspaceID, listID, userID); errMessage != "" {
+		response.WriteShareLinkResponse(w, nil, code, errMessage)
+		return
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		response.WriteShareLinkResponse(w, nil, http.StatusInternalServerError, constant.SHARE_ERR_FAILED_GENERATE_TOKEN)
+		return
+	}
+
+	now := time.Now()
+	link := &model.ShareLink{ListID: listID, Token: token, CreatedAt: now}
+	if input.ExpiresInHours > 0 {
+		expiresAt := now.Add(time.Duration(input.ExpiresInHours) * time.Hour)
+		link.ExpiresAt = &expiresAt
+	}
+
+	// トークンそのものは保存せず、ハッシュ値のみを保存する
+	insertQuery := "INSERT INTO share_links (workspace_id, list_id, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?, ?)"
+	result, err := db.Exec(insertQuery, workspaceID, listID, hashShareToken(token), link.ExpiresAt, now)
+	if err != nil {
+		response.WriteShareLinkResponse(w, nil, http.StatusInternalServerError, constant.SHARE_ERR_FAILED_ADD_LINK)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		response.WriteShareLinkResponse(w, nil, http.StatusInternalServerError, constant.SHARE_ERR_FAILED_ADD_LINK)
+		return
+	}
+	link.ID = int(id)
+
+	response.WriteShareLinkResponse(w, link, http.StatusCreated, "")
+}
+
+// リストの共有リンクを無効化する。リストの所有者のみ無効化できる
+func RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteShareLinkResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	listID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteShareLinkResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+	linkID, err := strconv.Atoi(r.PathValue("linkID"))
+	if err != nil {
+		response.WriteShareLinkResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	if code, errMessage := checkListOwner(db, workspaceID, listID, userID); errMessage != "" {
+		response.WriteShareLinkResponse(w, nil, code, errMessage)
+		return
+	}
+
+	revokeQuery := "UPDATE share_links SET revoked_at = ? WHERE id = ? AND list_id = ? AND workspace_id = ? AND revoked_at IS NULL"
+	result, err := db.Exec(revokeQuery, time.Now(), linkID, listID, workspaceID)
+	if err != nil {
+		response.WriteShareLinkResponse(w, nil, http.StatusInternalServerError, constant.SHARE_ERR_FAILED_REVOKE_LINK)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil || rowsAffected == 0 {
+		response.WriteShareLinkResponse(w, nil, http.StatusNotFound, constant.SHARE_ERR_NOT_FOUND_LINK)
+		return
+	}
+
+	response.WriteShareLinkResponse(w, nil, http.StatusOK, "")
+}
+
+// 共有トークンからリストのTodoを読み取り専用で取得する
+func GetSharedTodos(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	if token == "" {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusNotFound, constant.SHARE_ERR_NOT_FOUND_LINK)
+		return
+	}
+
+	db := database.GetDB()
+
+	// 共有リンクは未認証でアクセスされるため、ワークスペースはリンク自体から解決する
+	var (
+		workspaceID int
+		listID      int
+		expiresAt   sql.NullTime
+	)
+	linkQuery := "SELECT workspace_id, list_id, expires_at FROM share_links WHERE token_hash = ? AND revoked_at IS NULL"
+	if err := db.QueryRow(linkQuery, hashShareToken(token)).Scan(&workspaceID, &listID, &expiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusNotFound, constant.SHARE_ERR_NOT_FOUND_LINK)
+		} else {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO)
+		}
+		return
+	}
+	if expiresAt.Valid && !time.Now().Before(expiresAt.Time) {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusGone, constant.SHARE_ERR_EXPIRED_LINK)
+		return
+	}
+
+	todosQuery := "SELECT " + todoColumns + " FROM todos WHERE list_id = ? AND workspace_id = ?"
+	rows, err := db.Query(todosQuery, listID, workspaceID)
+	if err != nil {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO)
+		return
+	}
+	defer rows.Close()
+
+	todos := []model.Todo{}
+	for rows.Next() {
+		var todo model.Todo
+		if err := scanTodo(rows, &todo); err != nil {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO_ROW)
+			return
+		}
+		todos = append(todos, todo)
+	}
+
+	// 共有リンク経由のレスポンスはキャッシュさせない
+	w.Header().Set("Cache-Control", "no-store")
+	response.WriteTodosResponse(w, todos, http.StatusOK, "")
+}
+
+// リストがワークスペースに存在し、ユーザーが所有していることを確認する。
+// 確認できない場合はステータスコードとエラーメッセージを返す
+func checkListOwner(db *sql.DB, workspaceID, listID, userID int) (int, string) {
+	var ownerID int
+	listQuery := "SELECT owner_id FROM lists WHERE id = ? AND workspace_id = ?"
+	if err := db.QueryRow(listQuery, listID, workspaceID).Scan(&ownerID); err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, constant.LIST_ERR_NOT_FOUND_LIST
+		}
+		return http.StatusInternalServerError, constant.LIST_ERR_FAILED_GET_LIST
+	}
+	if ownerID != userID {
+		return http.StatusForbidden, constant.SHARE_ERR_NOT_LIST_OWNER
+	}
+	return 0, ""
+}
+
+// 推測不可能な共有トークンを生成する
+func generateShareToken() (string, error) {
+	b := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// 共有トークンのハッシュ値を返す
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
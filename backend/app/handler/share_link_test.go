@@ -0,0 +1,276 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateShareLink(t *testing.T) {
+	cases := map[string]struct {
+		listID         string
+		inputBody      string
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantErrMessage string
+		wantExpires    bool
+	}{
+		"正常系（無期限）": {
+			listID:    "1",
+			inputBody: "",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				expectListOwner(mock, 1, testUserID)
+				mock.ExpectExec(`^INSERT INTO share_links`).
+					WithArgs(testWorkspaceID, 1, sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(5, 1))
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		"正常系（有効期限あり）": {
+			listID:    "1",
+			inputBody: `{"expires_in_hours": 24}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				expectListOwner(mock, 1, testUserID)
+				mock.ExpectExec(`^INSERT INTO share_links`).
+					WithArgs(testWorkspaceID, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(5, 1))
+			},
+			wantStatusCode: http.StatusCreated,
+			wantExpires:    true,
+		},
+		"IDが不正": {
+			listID:         "abc",
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+			wantErrMessage: "IDが不正です。",
+		},
+		"有効期限が負数": {
+			listID:         "1",
+			inputBody:      `{"expires_in_hours": -1}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+			wantErrMessage: "有効期限が不正です。",
+		},
+		"リストが存在しない": {
+			listID: "1",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT owner_id FROM lists WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantErrMessage: "リストが見つかりません。",
+		},
+		"リストの所有者ではない": {
+			listID: "1",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				expectListOwner(mock, 1, testUserID+1)
+			},
+			wantStatusCode: http.StatusForbidden,
+			wantErrMessage: "共有リンクはリストの所有者のみ操作できます。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createUserRequest(t, http.MethodPost, "/lists/"+c.listID+"/share-links", c.inputBody)
+			req.SetPathValue("id", c.listID)
+
+			handler.CreateShareLink(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.ShareLinkResponse](t, rec)
+			checkResponseBody(t, c.wantErrMessage, got.Status.ErrorMessage)
+			if c.wantErrMessage != "" {
+				return
+			}
+			if got.Data == nil || got.Data.ID != 5 || got.Data.ListID != 1 {
+				t.Fatalf("共有リンクが正しく返却されていません: %+v", got.Data)
+			}
+			if len(got.Data.Token) < 43 {
+				t.Errorf("トークンが短すぎます: %q", got.Data.Token)
+			}
+			if (got.Data.ExpiresAt != nil) != c.wantExpires {
+				t.Errorf("期待した有効期限の有無: %v, 実際: %v", c.wantExpires, got.Data.ExpiresAt)
+			}
+		})
+	}
+}
+
+// 共有リンクを作成するには認証が必要であることを確認する
+func TestCreateShareLinkUnauthorized(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodPost, "/lists/1/share-links", "")
+	req.SetPathValue("id", "1")
+
+	handler.CreateShareLink(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusUnauthorized, rec.Code)
+}
+
+// expectListOwnerは、リストの所有者の取得を期待値として設定します。
+func expectListOwner(mock sqlmock.Sqlmock, listID, ownerID int) {
+	mock.ExpectQuery(`^SELECT owner_id FROM lists WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(listID, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"owner_id"}).AddRow(ownerID))
+}
+
+func TestRevokeShareLink(t *testing.T) {
+	cases := map[string]struct {
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantBody       interface{}
+	}{
+		"正常系": {
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				expectListOwner(mock, 1, testUserID)
+				mock.ExpectExec(`^UPDATE share_links SET revoked_at = \? WHERE id = \? AND list_id = \? AND workspace_id = \? AND revoked_at IS NULL$`).
+					WithArgs(sqlmock.AnyArg(), 2, 1, testWorkspaceID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody:       model.ShareLinkResponse{Status: model.StatusInfo{Code: http.StatusOK}},
+		},
+		"無効化済み": {
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				expectListOwner(mock, 1, testUserID)
+				mock.ExpectExec(`^UPDATE share_links`).
+					WithArgs(sqlmock.AnyArg(), 2, 1, testWorkspaceID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantBody: model.ShareLinkResponse{Status: model.StatusInfo{
+				Code: http.StatusNotFound, Error: true, ErrorMessage: "共有リンクが見つかりません。",
+			}},
+		},
+		"リストの所有者ではない": {
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				expectListOwner(mock, 1, testUserID+1)
+			},
+			wantStatusCode: http.StatusForbidden,
+			wantBody: model.ShareLinkResponse{Status: model.StatusInfo{
+				Code: http.StatusForbidden, Error: true, ErrorMessage: "共有リンクはリストの所有者のみ操作できます。",
+			}},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createUserRequest(t, http.MethodDelete, "/lists/1/share-links/2", "")
+			req.SetPathValue("id", "1")
+			req.SetPathValue("linkID", "2")
+
+			handler.RevokeShareLink(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.ShareLinkResponse](t, rec)
+			checkResponseBody(t, c.wantBody, got)
+		})
+	}
+}
+
+func TestGetSharedTodos(t *testing.T) {
+	const token = "shared-token"
+	sum := sha256.Sum256([]byte(token))
+	tokenHash := hex.EncodeToString(sum[:])
+
+	cases := map[string]struct {
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantBody       interface{}
+	}{
+		"正常系": {
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT workspace_id, list_id, expires_at FROM share_links WHERE token_hash = \? AND revoked_at IS NULL$`).
+					WithArgs(tokenHash).
+					WillReturnRows(sqlmock.NewRows([]string{"workspace_id", "list_id", "expires_at"}).AddRow(2, 3, nil))
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE list_id = \? AND workspace_id = \?$`).
+					WithArgs(3, 2).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).
+						AddRow(1, "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody: createTodosResponse(
+				t,
+				[]model.Todo{{ID: 1, Title: "title1", IsComplete: false, Status: "todo", Revision: 1}},
+				http.StatusOK,
+				"",
+			),
+		},
+		"トークンが無効": {
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT workspace_id, list_id, expires_at FROM share_links`).
+					WithArgs(tokenHash).
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantBody: createTodosResponse(
+				t,
+				[]model.Todo{},
+				http.StatusNotFound,
+				"共有リンクが見つかりません。",
+			),
+		},
+		"有効期限切れ": {
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT workspace_id, list_id, expires_at FROM share_links`).
+					WithArgs(tokenHash).
+					WillReturnRows(sqlmock.NewRows([]string{"workspace_id", "list_id", "expires_at"}).
+						AddRow(2, 3, time.Now().Add(-time.Hour)))
+			},
+			wantStatusCode: http.StatusGone,
+			wantBody: createTodosResponse(
+				t,
+				[]model.Todo{},
+				http.StatusGone,
+				"共有リンクの有効期限が切れています。",
+			),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodGet, "/shared/"+token, "")
+			req.SetPathValue("token", token)
+
+			handler.GetSharedTodos(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.TodosResponse](t, rec)
+			checkResponseBody(t, c.wantBody, got)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQLのエラー番号: 一意制約違反
+const errDuplicateEntry = 1062
+
+// 自分のスマートリストを作成した順に取得する
+func GetSmartLists(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteSmartListsResponse(w, []model.SmartList{}, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	query := "SELECT id, name, filter, created_at FROM smart_lists WHERE workspace_id = ? AND user_id = ? ORDER BY id"
+	rows, err := db.Query(query, workspaceID, userID)
+	if err != nil {
+		response.WriteSmartListsResponse(w, []model.SmartList{}, http.StatusInternalServerError, constant.SMART_LIST_ERR_FAILED_GET_LIST)
+		return
+	}
+	defer rows.Close()
+
+	lists := []model.SmartList{}
+	for rows.Next() {
+		var list model.SmartList
+		if err := rows.Scan(&list.ID, &list.Name, &list.Filter, &list.CreatedAt); err != nil {
+			response.WriteSmartListsResponse(w, []model.SmartList{}, http.StatusInternalServerError, constant.SMART_LIST_ERR_FAILED_GET_LIST)
+			return
+		}
+		lists = append(lists, list)
+	}
+
+	response.WriteSmartListsResponse(w, lists, http.StatusOK, "")
+}
+
+// フィルターに名前を付けてスマートリストとして保存する
+func CreateSmartList(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteSmartListResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	var input model.SmartList
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteSmartListResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+	input.Name = strings.TrimSpace(input.Name)
+
+	// 入力値のバリデーション
+	if err := validator.SmartListInput(input); err != nil {
+		response.WriteSmartListResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	input.CreatedAt = time.Now()
+
+	db := database.GetDB()
+	insertQuery := "INSERT INTO smart_lists (workspace_id, user_id, name, filter, created_at) VALUES (?, ?, ?, ?, ?)"
+	result, err := db.Exec(insertQuery, workspaceID, userID, input.Name, input.Filter, input.CreatedAt)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry {
+			response.WriteSmartListResponse(w, nil, http.StatusConflict, constant.SMART_LIST_ERR_DUPLICATE_NAME)
+		} else {
+			response.WriteSmartListResponse(w, nil, http.StatusInternalServerError, constant.SMART_LIST_ERR_FAILED_ADD_LIST)
+		}
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		response.WriteSmartListResponse(w, nil, http.StatusInternalServerError, constant.SMART_LIST_ERR_FAILED_ADD_LIST)
+		return
+	}
+	input.ID = int(id)
+
+	response.WriteSmartListResponse(w, &input, http.StatusCreated, "")
+}
+
+// 自分のスマートリストを削除する
+func DeleteSmartList(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteSmartListResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteSmartListResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	result, err := db.Exec("DELETE FROM smart_lists WHERE id = ? AND workspace_id = ? AND user_id = ?", id, workspaceID, userID)
+	if err != nil {
+		response.WriteSmartListResponse(w, nil, http.StatusInternalServerError, constant.SMART_LIST_ERR_FAILED_DELETE_LIST)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		response.WriteSmartListResponse(w, nil, http.StatusNotFound, constant.SMART_LIST_ERR_NOT_FOUND_LIST)
+		return
+	}
+
+	response.WriteSmartListResponse(w, nil, http.StatusOK, "")
+}
+
+// スマートリストのフィルターに一致するTodoを取得する
+func GetSmartListTodos(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var src string
+	query := "SELECT filter FROM smart_lists WHERE id = ? AND workspace_id = ? AND user_id = ?"
+	if err := db.QueryRow(query, id, workspaceID, userID).Scan(&src); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusNotFound, constant.SMART_LIST_ERR_NOT_FOUND_LIST)
+		} else {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.SMART_LIST_ERR_FAILED_GET_LIST)
+		}
+		return
+	}
+
+	where, args, errMessage := compileFilter(src, r.URL.Query().Get("time_zone"))
+	if errMessage != "" {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusBadRequest, errMessage)
+		return
+	}
+
+	rows, err := db.Query("SELECT "+todoColumns+" FROM todos WHERE workspace_id = ? AND "+where, append([]any{workspaceID}, args...)...)
+	if err != nil {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO)
+		return
+	}
+	defer rows.Close()
+
+	todos := []model.Todo{}
+	for rows.Next() {
+		var todo model.Todo
+		if err := scanTodo(rows, &todo); err != nil {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO_ROW)
+			return
+		}
+		todos = append(todos, todo)
+	}
+
+	response.WriteTodosResponse(w, todos, http.StatusOK, "")
+}
@@ -0,0 +1,145 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestCreateSmartList(t *testing.T) {
+	t.Run("正常系", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectExec(`^INSERT INTO smart_lists \(workspace_id, user_id, name, filter, created_at\) VALUES \(\?, \?, \?, \?, \?\)$`).
+			WithArgs(testWorkspaceID, testUserID, "今週の仕事", "is:open tag:work due:<7d", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(4, 1))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/smart-lists", `{"name": " 今週の仕事 ", "filter": "is:open tag:work due:<7d"}`)
+
+		handler.CreateSmartList(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusCreated, rec.Code)
+		got := decodeResponseBody[model.SmartListResponse](t, rec)
+		if got.Data == nil || got.Data.ID != 4 || got.Data.Name != "今週の仕事" {
+			t.Errorf("レスポンスが不正です: %+v", got.Data)
+		}
+	})
+
+	t.Run("同じ名前のスマートリストがある", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectExec(`^INSERT INTO smart_lists`).
+			WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/smart-lists", `{"name": "今週の仕事", "filter": "is:open"}`)
+
+		handler.CreateSmartList(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("フィルターが不正", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/smart-lists", `{"name": "a", "filter": "(is:open"}`)
+
+		handler.CreateSmartList(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestGetSmartLists(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	createdAt := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`^SELECT id, name, filter, created_at FROM smart_lists WHERE workspace_id = \? AND user_id = \? ORDER BY id$`).
+		WithArgs(testWorkspaceID, testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "filter", "created_at"}).
+			AddRow(1, "期限切れ", "is:overdue", createdAt))
+
+	rec := httptest.NewRecorder()
+	handler.GetSmartLists(rec, createUserRequest(t, http.MethodGet, "/smart-lists", ""))
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.SmartListsResponse](t, rec)
+	checkResponseBody(t, []model.SmartList{{ID: 1, Name: "期限切れ", Filter: "is:overdue", CreatedAt: createdAt}}, got.Data)
+}
+
+func TestDeleteSmartList(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(`^DELETE FROM smart_lists WHERE id = \? AND workspace_id = \? AND user_id = \?$`).
+		WithArgs(2, testWorkspaceID, testUserID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rec := httptest.NewRecorder()
+	req := createUserRequest(t, http.MethodDelete, "/smart-lists/2", "")
+	req.SetPathValue("id", "2")
+
+	handler.DeleteSmartList(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGetSmartListTodos(t *testing.T) {
+	t.Run("保存したフィルターで取得", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT filter FROM smart_lists WHERE id = \? AND workspace_id = \? AND user_id = \?$`).
+			WithArgs(1, testWorkspaceID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"filter"}).AddRow("is:done OR list:none"))
+		mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE workspace_id = \? AND \(is_complete = \? OR list_id IS NULL\)$`).
+			WithArgs(testWorkspaceID, true).
+			WillReturnRows(sqlmock.NewRows(todoRowColumns).
+				AddRow(2, "title2", true, 1, nil, nil, "", "", "", "done", nil, nil))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodGet, "/smart-lists/1/todos", "")
+		req.SetPathValue("id", "1")
+
+		handler.GetSmartListTodos(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		checkResponseBody(t, createTodosResponse(t, []model.Todo{{ID: 2, Title: "title2", IsComplete: true, Status: "done", Revision: 1}}, http.StatusOK, ""), decodeResponseBody[model.TodosResponse](t, rec))
+	})
+
+	t.Run("他のユーザーのスマートリスト", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT filter FROM smart_lists`).
+			WithArgs(9, testWorkspaceID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"filter"}))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodGet, "/smart-lists/9/todos", "")
+		req.SetPathValue("id", "9")
+
+		handler.GetSmartListTodos(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusNotFound, rec.Code)
+	})
+}
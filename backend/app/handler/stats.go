@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"backend/app/audit"
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/stats"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// 統計の推移を求める期間の既定値と上限（日数）
+	defaultStatsDays = 30
+	maxStatsDays     = 366
+	// 連続日数を求める期間（日数）。監査ログ全体を走査しないよう、この期間より前の完了は数えない
+	streakDays = 366
+)
+
+// 監査ログの変更前後の完了状態。作成時の変更前と削除時の変更後は未完了として扱う
+const (
+	auditBeforeComplete = "COALESCE(JSON_UNQUOTE(JSON_EXTRACT(before_json, '$.is_complete')), 'false')"
+	auditAfterComplete  = "COALESCE(JSON_UNQUOTE(JSON_EXTRACT(after_json, '$.is_complete')), 'false')"
+	// 未完了から完了になった変更
+	auditCompleted = "(" + auditBeforeComplete + " = 'false' AND " + auditAfterComplete + " = 'true')"
+	// 完了から未完了に戻った変更
+	auditReopened = "(" + auditBeforeComplete + " = 'true' AND " + auditAfterComplete + " = 'false')"
+)
+
+// ワークスペースのTodoの統計を返す。daysを指定した場合は、今日（UTC）までのその日数の推移を返す。
+// 集計は短い時間キャッシュするため、直前の変更が反映されていない場合がある
+func GetStats(w http.ResponseWriter, r *http.Request) {
+	days := defaultStatsDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxStatsDays {
+			response.WriteStatsResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_DAYS)
+			return
+		}
+		days = n
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	load := func() (model.Stats, error) {
+		return loadStats(db, workspaceID, days, time.Now().UTC())
+	}
+	var result model.Stats
+	var err error
+	if cache := stats.Default(); cache != nil {
+		result, err = cache.Get(fmt.Sprintf("%d:%d", workspaceID, days), load)
+	} else {
+		result, err = load()
+	}
+	if err != nil {
+		response.WriteStatsResponse(w, nil, http.StatusInternalServerError, constant.STATS_ERR_FAILED_GET_STATS)
+		return
+	}
+
+	response.WriteStatsResponse(w, &result, http.StatusOK, "")
+}
+
+// ワークスペースのTodoを集計する。推移と連続日数は、監査ログに記録した作成・削除・完了の変更から求める
+func loadStats(db *sql.DB, workspaceID, days int, now time.Time) (model.Stats, error) {
+	result := model.Stats{ByStatus: []model.StatusCount{}, GeneratedAt: now}
+
+	// ステータスごとの数と、そのうち完了・期限切れの数
+	countQuery := "SELECT status, COUNT(*), SUM(is_complete = TRUE), SUM(is_complete = FALSE AND due_at IS NOT NULL AND due_at < ?) " +
+		"FROM todos WHERE workspace_id = ? GROUP BY status ORDER BY status"
+	rows, err := db.Query(countQuery, now, workspaceID)
+	if err != nil {
+		return model.Stats{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var count model.StatusCount
+		var completed, overdue int
+		if err := rows.Scan(&count.Status, &count.Count, &completed, &overdue); err != nil {
+			return model.Stats{}, err
+		}
+		result.ByStatus = append(result.ByStatus, count)
+		result.Total += count.Count
+		result.Completed += completed
+		result.Overdue += overdue
+	}
+	if err := rows.Err(); err != nil {
+		return model.Stats{}, err
+	}
+	result.Open = result.Total - result.Completed
+	if result.Total > 0 {
+		result.CompletionRate = float64(result.Completed) / float64(result.Total)
+	}
+
+	today := now.Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -(days - 1))
+
+	changes, err := loadDailyChanges(db, workspaceID, from)
+	if err != nil {
+		return model.Stats{}, err
+	}
+	result.Days = stats.Series(from, today, result.Total, result.Completed, changes)
+
+	// 作成日時は最初のリビジョンの記録日時とする。完了した状態で作成したTodoは含めない
+	averageQuery := "SELECT AVG(TIMESTAMPDIFF(SECOND, todo_revisions.created_at, audit_logs.created_at)) FROM audit_logs " +
+		"JOIN todo_revisions ON todo_revisions.workspace_id = audit_logs.workspace_id AND todo_revisions.todo_id = audit_logs.todo_id AND todo_revisions.revision = 1 " +
+		"WHERE audit_logs.workspace_id = ? AND audit_logs.action <> ? AND audit_logs.created_at >= ? AND " + auditCompleted
+	var average sql.NullFloat64
+	if err := db.QueryRow(averageQuery, workspaceID, string(audit.ActionCreate), from).Scan(&average); err != nil {
+		return model.Stats{}, err
+	}
+	if average.Valid {
+		seconds := int(math.Round(average.Float64))
+		result.AverageSecondsToComplete = &seconds
+	}
+
+	completionDays, err := loadCompletionDays(db, workspaceID, today.AddDate(0, 0, -(streakDays-1)))
+	if err != nil {
+		return model.Stats{}, err
+	}
+	result.CurrentStreak, result.LongestStreak = stats.Streaks(completionDays, today)
+
+	return result, nil
+}
+
+// from以降の作成・削除・完了の数を日（UTC）ごとに集計する
+func loadDailyChanges(db *sql.DB, workspaceID int, from time.Time) (map[string]stats.Changes, error) {
+	query := "SELECT DATE_FORMAT(created_at, '%Y-%m-%d'), SUM(action = ?), SUM(action = ?), SUM" + auditCompleted + ", SUM" + auditReopened + " " +
+		"FROM audit_logs WHERE workspace_id = ? AND created_at >= ? GROUP BY DATE_FORMAT(created_at, '%Y-%m-%d')"
+	rows, err := db.Query(query, string(audit.ActionCreate), string(audit.ActionDelete), workspaceID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := map[string]stats.Changes{}
+	for rows.Next() {
+		var day string
+		var c stats.Changes
+		if err := rows.Scan(&day, &c.Created, &c.Deleted, &c.Completed, &c.Reopened); err != nil {
+			return nil, err
+		}
+		changes[day] = c
+	}
+	return changes, rows.Err()
+}
+
+// from以降にTodoを完了した日（UTC）を昇順に取得する
+func loadCompletionDays(db *sql.DB, workspaceID int, from time.Time) ([]time.Time, error) {
+	query := "SELECT DISTINCT DATE_FORMAT(created_at, '%Y-%m-%d') AS day FROM audit_logs WHERE workspace_id = ? AND created_at >= ? AND " + auditCompleted + " ORDER BY day"
+	rows, err := db.Query(query, workspaceID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []time.Time{}
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		day, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
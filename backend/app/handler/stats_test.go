@@ -0,0 +1,126 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"backend/app/stats"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// 統計の集計に使う4つのクエリを期待値として設定する。推移は今日までの2日間とする
+func expectStatsQueries(mock sqlmock.Sqlmock, today time.Time) {
+	yesterday := today.AddDate(0, 0, -1)
+
+	mock.ExpectQuery(`^SELECT status, COUNT\(\*\), SUM\(is_complete = TRUE\), SUM\(is_complete = FALSE AND due_at IS NOT NULL AND due_at < \?\) FROM todos WHERE workspace_id = \? GROUP BY status ORDER BY status$`).
+		WithArgs(sqlmock.AnyArg(), testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count", "completed", "overdue"}).
+			AddRow("doing", 1, 0, 1).
+			AddRow("done", 2, 2, 0).
+			AddRow("todo", 1, 0, 0))
+	mock.ExpectQuery(`^SELECT DATE_FORMAT\(created_at, '%Y-%m-%d'\), SUM\(action = \?\), SUM\(action = \?\), SUM\(.*\), SUM\(.*\) FROM audit_logs WHERE workspace_id = \? AND created_at >= \? GROUP BY DATE_FORMAT\(created_at, '%Y-%m-%d'\)$`).
+		WithArgs("create", "delete", testWorkspaceID, yesterday).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "created", "deleted", "completed", "reopened"}).
+			AddRow(today.Format(time.DateOnly), 2, 0, 1, 0))
+	mock.ExpectQuery(`^SELECT AVG\(TIMESTAMPDIFF\(SECOND, todo_revisions.created_at, audit_logs.created_at\)\) FROM audit_logs JOIN todo_revisions ON .* WHERE audit_logs.workspace_id = \? AND audit_logs.action <> \? AND audit_logs.created_at >= \? AND \(`).
+		WithArgs(testWorkspaceID, "create", yesterday).
+		WillReturnRows(sqlmock.NewRows([]string{"avg"}).AddRow(5400.4))
+	mock.ExpectQuery(`^SELECT DISTINCT DATE_FORMAT\(created_at, '%Y-%m-%d'\) AS day FROM audit_logs WHERE workspace_id = \? AND created_at >= \? AND \(.*\) ORDER BY day$`).
+		WithArgs(testWorkspaceID, today.AddDate(0, 0, -365)).
+		WillReturnRows(sqlmock.NewRows([]string{"day"}).
+			AddRow(today.AddDate(0, 0, -5).Format(time.DateOnly)).
+			AddRow(yesterday.Format(time.DateOnly)).
+			AddRow(today.Format(time.DateOnly)))
+}
+
+func TestGetStats(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	expectStatsQueries(mock, today)
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/stats?days=2", "")
+
+	handler.GetStats(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.StatsResponse](t, rec)
+	if got.Data == nil {
+		t.Fatalf("統計が返されていません: %+v", got.Status)
+	}
+	got.Data.GeneratedAt = time.Time{}
+
+	half, average := 0.5, 5400
+	checkResponseBody(t, &model.Stats{
+		Total:     4,
+		Completed: 2,
+		Open:      2,
+		Overdue:   1,
+		ByStatus: []model.StatusCount{
+			{Status: "doing", Count: 1},
+			{Status: "done", Count: 2},
+			{Status: "todo", Count: 1},
+		},
+		CompletionRate: 0.5,
+		Days: []model.StatsDay{
+			// 今日の変更を打ち消すと、2件中1件が完了していたことになる
+			{Date: today.AddDate(0, 0, -1).Format(time.DateOnly), CompletionRate: &half},
+			{Date: today.Format(time.DateOnly), Created: 2, Completed: 1, CompletionRate: &half},
+		},
+		AverageSecondsToComplete: &average,
+		CurrentStreak:            2,
+		LongestStreak:            2,
+	}, got.Data)
+}
+
+// キャッシュが有効な間は、同じ条件の統計をデータベースに問い合わせないことを確認する
+func TestGetStatsCached(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	stats.SetDefault(stats.NewCache(time.Minute))
+	t.Cleanup(func() { stats.SetDefault(nil) })
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	expectStatsQueries(mock, today)
+
+	var first model.StatsResponse
+	for i := range 2 {
+		rec := httptest.NewRecorder()
+		handler.GetStats(rec, createTestRequest(t, http.MethodGet, "/stats?days=2", ""))
+
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		got := decodeResponseBody[model.StatsResponse](t, rec)
+		if i == 0 {
+			first = got
+		} else {
+			checkResponseBody(t, first, got)
+		}
+	}
+	checkMockExpectations(t, mock)
+}
+
+func TestGetStatsInvalidDays(t *testing.T) {
+	for _, days := range []string{"0", "367", "a"} {
+		t.Run(days, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			rec := httptest.NewRecorder()
+			handler.GetStats(rec, createTestRequest(t, http.MethodGet, fmt.Sprintf("/stats?days=%s", days), ""))
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, http.StatusBadRequest, rec.Code)
+			got := decodeResponseBody[model.StatsResponse](t, rec)
+			checkResponseBody(t, "daysには1から366までの整数を指定してください。", got.Status.ErrorMessage)
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"backend/app/changelog"
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/history"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+)
+
+// 同期した変更の適用結果
+const (
+	// 変更をそのまま適用した
+	syncStatusApplied = "applied"
+	// サーバー側の変更とマージして適用した。競合したフィールドはサーバーの値を残す
+	syncStatusMerged = "merged"
+	// 変更を適用できなかった
+	syncStatusRejected = "rejected"
+)
+
+// 更新が他の変更と競合した場合に、マージをやり直す最大回数
+const syncMaxRetries = 3
+
+// 変更トークン以降に変更・削除されたTodoを取得する。
+// トークンを省略した場合は、すべてのTodoと現在のトークンを返す。
+func GetSyncChanges(w http.ResponseWriter, r *http.Request) {
+	since, err := changelog.DecodeToken(r.URL.Query().Get("since"))
+	if err != nil {
+		response.WriteSyncChangesResponse(w, nil, http.StatusBadRequest, constant.SYNC_ERR_INVALID_TOKEN)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	// 変更の範囲とTodoを同じスナップショットから読み取る。
+	// 連番はコミット順に採番されるため、スナップショットの最大値より前の変更はすべてコミット済み
+	db := database.GetDB()
+	tx, err := db.BeginTx(r.Context(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		response.WriteSyncChangesResponse(w, nil, http.StatusInternalServerError, constant.SYNC_ERR_FAILED_GET_CHANGE)
+		return
+	}
+	defer tx.Rollback()
+
+	var latest int64
+	if err := tx.QueryRow("SELECT COALESCE(MAX(seq), 0) FROM todo_changes WHERE workspace_id = ?", workspaceID).Scan(&latest); err != nil {
+		response.WriteSyncChangesResponse(w, nil, http.StatusInternalServerError, constant.SYNC_ERR_FAILED_GET_CHANGE)
+		return
+	}
+	// 変更がない間は、受け取ったトークンをそのまま返す
+	if latest < since {
+		latest = since
+	}
+
+	changes := &model.SyncChanges{Todos: []model.Todo{}, Deleted: []int{}, Token: changelog.EncodeToken(latest)}
+
+	var rows *sql.Rows
+	if since == 0 {
+		rows, err = tx.Query("SELECT "+todoColumns+" FROM todos WHERE workspace_id = ? ORDER BY id", workspaceID)
+	} else {
+		query := "SELECT " + todoColumns + " FROM todos WHERE workspace_id = ? AND id IN " +
+			"(SELECT todo_id FROM todo_changes WHERE workspace_id = ? AND seq > ? AND seq <= ?) ORDER BY id"
+		rows, err = tx.Query(query, workspaceID, workspaceID, since, latest)
+	}
+	if err != nil {
+		response.WriteSyncChangesResponse(w, nil, http.StatusInternalServerError, constant.SYNC_ERR_FAILED_GET_CHANGE)
+		return
+	}
+	for rows.Next() {
+		var todo model.Todo
+		if err := scanTodo(rows, &todo); err != nil {
+			rows.Close()
+			response.WriteSyncChangesResponse(w, nil, http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO_ROW)
+			return
+		}
+		changes.Todos = append(changes.Todos, todo)
+	}
+	rows.Close()
+
+	// 最初からの同期では、削除済みのTodoを伝える必要はない
+	if since != 0 {
+		query := "SELECT DISTINCT todo_id FROM todo_changes WHERE workspace_id = ? AND seq > ? AND seq <= ? AND deleted = TRUE ORDER BY todo_id"
+		rows, err := tx.Query(query, workspaceID, since, latest)
+		if err != nil {
+			response.WriteSyncChangesResponse(w, nil, http.StatusInternalServerError, constant.SYNC_ERR_FAILED_GET_CHANGE)
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				response.WriteSyncChangesResponse(w, nil, http.StatusInternalServerError, constant.SYNC_ERR_FAILED_GET_CHANGE)
+				return
+			}
+			changes.Deleted = append(changes.Deleted, id)
+		}
+	}
+
+	response.WriteSyncChangesResponse(w, changes, http.StatusOK, "")
+}
+
+// オフライン中の変更をまとめて適用し、変更ごとの結果を返す。
+// 変更は指定された順に1件ずつ適用し、失敗した変更があっても残りの変更は適用する。
+func PostSync(w http.ResponseWriter, r *http.Request) {
+	var req model.SyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.WriteSyncResultsResponse(w, []model.SyncResult{}, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+
+	// 入力値のバリデーション
+	if err := validator.SyncInput(req); err != nil {
+		response.WriteSyncResultsResponse(w, []model.SyncResult{}, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results := make([]model.SyncResult, 0, len(req.Mutations))
+	for _, m := range req.Mutations {
+		results = append(results, applySyncMutation(r.Context(), m))
+	}
+
+	response.WriteSyncResultsResponse(w, results, http.StatusOK, "")
+}
+
+// オフライン中の変更1件を適用する
+func applySyncMutation(ctx context.Context, m model.SyncMutation) model.SyncResult {
+	result := model.SyncResult{ClientID: m.ClientID, Status: syncStatusApplied}
+
+	switch m.Op {
+	case "create":
+		todo, mErr := createTodo(ctx, m.Todo.Todo)
+		if mErr != nil {
+			return rejectSyncMutation(result, mErr)
+		}
+		result.Todo = todo
+	case "update":
+		todo, conflicts, mErr := mergeSyncUpdate(ctx, m)
+		if mErr != nil {
+			return rejectSyncMutation(result, mErr)
+		}
+		result.Todo = todo
+		if len(conflicts) > 0 {
+			result.Status = syncStatusMerged
+			result.Conflicts = conflicts
+		}
+	case "delete":
+		// 削除は他の変更より優先する。すでに削除済みの場合も適用済みとして扱う
+		if mErr := deleteTodo(ctx, m.ID); mErr != nil && mErr.code != http.StatusNotFound {
+			return rejectSyncMutation(result, mErr)
+		}
+	}
+	return result
+}
+
+func rejectSyncMutation(result model.SyncResult, mErr *mutationError) model.SyncResult {
+	result.Status = syncStatusRejected
+	result.Error = mErr.message
+	return result
+}
+
+// クライアントの更新を、元にしたリビジョン以降のサーバー側の変更とフィールド単位でマージして適用する。
+// 同じフィールドをサーバー側でも変更していた場合は、サーバーの値を残して競合として返す。
+func mergeSyncUpdate(ctx context.Context, m model.SyncMutation) (*model.Todo, []model.SyncConflict, *mutationError) {
+	workspaceID := requestctx.WorkspaceID(ctx)
+	db := database.GetDB()
+
+	for attempt := 0; ; attempt++ {
+		var current model.Todo
+		checkQuery := "SELECT " + todoColumns + " FROM todos WHERE id = ? AND workspace_id = ?"
+		if err := scanTodo(db.QueryRow(checkQuery, m.ID, workspaceID), &current); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, nil, newMutationError(http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+			}
+			return nil, nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO_ROW)
+		}
+
+		// 省略された項目は、クライアントが元にした状態から変更していないものとして補う
+		client := m.Todo.Todo
+		merged := client
+		conflicts := []model.SyncConflict{}
+		if m.BaseRevision != current.Revision {
+			base, err := loadRevisionSnapshot(db, workspaceID, m.ID, m.BaseRevision)
+			if err != nil {
+				return nil, nil, newMutationError(http.StatusInternalServerError, constant.SYNC_ERR_FAILED_SYNC)
+			}
+			origin := base
+			if origin == nil {
+				origin = &current
+			}
+			keepOmittedFields(&client, origin, m.Todo.Fields)
+			merged, conflicts = history.Merge(base, current, client)
+		} else {
+			keepOmittedFields(&merged, &current, m.Todo.Fields)
+		}
+		// ステータスを省略した場合は、完了状態の変更に合わせてステータスを決め直す
+		if m.Todo.Status == "" {
+			merged.Status = ""
+			if merged.IsComplete == current.IsComplete {
+				merged.Status = current.Status
+			}
+		}
+
+		// マージの結果がサーバーの状態と同じ場合は更新しない
+		if len(history.Diff(&current, merged)) == 0 {
+			return &current, conflicts, nil
+		}
+
+		merged.Revision = current.Revision
+		updated, mErr := updateTodo(ctx, m.ID, model.TodoUpdate{Todo: merged}, updateOptions{allowBlocked: m.AllowBlocked})
+		if mErr != nil && mErr.revisionConflict && attempt < syncMaxRetries {
+			// マージ中に他の更新が割り込んだ場合は、最新の状態からやり直す
+			continue
+		}
+		return updated, conflicts, mErr
+	}
+}
+
+// 指定したリビジョンのTodoを取得する。記録がない場合はnilを返す。
+func loadRevisionSnapshot(db *sql.DB, workspaceID, todoID, revision int) (*model.Todo, error) {
+	var snapshot []byte
+	query := "SELECT snapshot_json FROM todo_revisions WHERE todo_id = ? AND workspace_id = ? AND revision = ?"
+	if err := db.QueryRow(query, todoID, workspaceID, revision).Scan(&snapshot); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var todo model.Todo
+	if err := json.Unmarshal(snapshot, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
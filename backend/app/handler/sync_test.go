@@ -0,0 +1,233 @@
+package handler_test
+
+import (
+	"backend/app/changelog"
+	"backend/app/handler"
+	"backend/app/model"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetSyncChanges(t *testing.T) {
+	cases := map[string]struct {
+		query          string
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantBody       interface{}
+	}{
+		"トークンなしはすべてのTODOを返す": {
+			query: "",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT COALESCE\(MAX\(seq\), 0\) FROM todo_changes WHERE workspace_id = \?$`).
+					WithArgs(testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(5))
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE workspace_id = \? ORDER BY id$`).
+					WithArgs(testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody: model.SyncChangesResponse{
+				Data: &model.SyncChanges{
+					Todos:   []model.Todo{{ID: 1, Title: "title1", Status: "todo", Revision: 1}},
+					Deleted: []int{},
+					Token:   changelog.EncodeToken(5),
+				},
+				Status: model.StatusInfo{Code: http.StatusOK},
+			},
+		},
+		"トークン以降の変更と削除を返す": {
+			query: "?since=" + changelog.EncodeToken(3),
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT COALESCE\(MAX\(seq\), 0\) FROM todo_changes`).
+					WithArgs(testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(6))
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE workspace_id = \? AND id IN \(SELECT todo_id FROM todo_changes WHERE workspace_id = \? AND seq > \? AND seq <= \?\) ORDER BY id$`).
+					WithArgs(testWorkspaceID, testWorkspaceID, 3, 6).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(4, "title4", true, 2, nil, nil, "", "", "", "done", nil, nil))
+				mock.ExpectQuery(`^SELECT DISTINCT todo_id FROM todo_changes WHERE workspace_id = \? AND seq > \? AND seq <= \? AND deleted = TRUE ORDER BY todo_id$`).
+					WithArgs(testWorkspaceID, 3, 6).
+					WillReturnRows(sqlmock.NewRows([]string{"todo_id"}).AddRow(2))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody: model.SyncChangesResponse{
+				Data: &model.SyncChanges{
+					Todos:   []model.Todo{{ID: 4, Title: "title4", IsComplete: true, Status: "done", Revision: 2}},
+					Deleted: []int{2},
+					Token:   changelog.EncodeToken(6),
+				},
+				Status: model.StatusInfo{Code: http.StatusOK},
+			},
+		},
+		"トークンが不正": {
+			query:          "?since=invalid",
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+			wantBody: model.SyncChangesResponse{
+				Status: model.StatusInfo{Code: http.StatusBadRequest, Error: true, ErrorMessage: "変更トークンが不正です。"},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodGet, "/sync"+c.query, "")
+
+			handler.GetSyncChanges(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.SyncChangesResponse](t, rec)
+			checkResponseBody(t, c.wantBody, got)
+		})
+	}
+}
+
+func TestPostSync(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	// 1件目: 作成。入力値が不正なため適用しない
+	// 2件目: リビジョン1を元にした更新。サーバー側ではタイトルだけが変更されている
+	mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "サーバー", false, 3, nil, nil, "", "", "", "todo", nil, nil))
+	mock.ExpectQuery(`^SELECT snapshot_json FROM todo_revisions WHERE todo_id = \? AND workspace_id = \? AND revision = \?$`).
+		WithArgs(1, testWorkspaceID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"snapshot_json"}).
+			AddRow(`{"id":1,"title":"元","is_complete":false,"revision":1,"list_id":null,"due_at":null}`))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "サーバー", false, 3, nil, nil, "", "", "", "todo", nil, nil))
+	expectOpenBlockers(mock, 1)
+	// タイトルは競合するためサーバーの値を残し、完了状態はクライアントの値を採用する
+	mock.ExpectExec(`^UPDATE todos`).
+		WithArgs("サーバー", true, nil, nil, "", "", "", "done", nil, nil, 1, testWorkspaceID, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRevision(mock, 1, 4)
+	expectAuditLog(mock, "update", 1)
+	expectChange(mock, 1, false)
+	expectOutbox(mock, "todo.updated")
+	expectOutbox(mock, "todo.completed")
+	mock.ExpectCommit()
+	// 3件目: 削除。すでに削除済みのため、適用済みとして扱う
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(2, testWorkspaceID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	body := `{"mutations": [
+		{"client_id": "c1", "op": "create", "todo": {"title": ""}},
+		{"client_id": "c2", "op": "update", "id": 1, "base_revision": 1, "todo": {"title": "クライアント", "is_complete": true}},
+		{"client_id": "c3", "op": "delete", "id": 2}
+	]}`
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodPost, "/sync", body)
+
+	handler.PostSync(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.SyncResultsResponse](t, rec)
+	want := model.SyncResultsResponse{
+		Data: []model.SyncResult{
+			{ClientID: "c1", Status: "rejected", Error: "タイトルは必須です。"},
+			{
+				ClientID: "c2",
+				Status:   "merged",
+				Todo:     &model.Todo{ID: 1, Title: "サーバー", IsComplete: true, Status: "done", Revision: 4},
+				Conflicts: []model.SyncConflict{
+					{Field: "title", ClientValue: "クライアント", ServerValue: "サーバー"},
+				},
+			},
+			{ClientID: "c3", Status: "applied"},
+		},
+		Status: model.StatusInfo{Code: http.StatusOK},
+	}
+	checkResponseBody(t, want, got)
+}
+
+// 未完了のTodoに依存して完了できない更新はやり直さずに適用せず、allow_blockedを指定した更新は適用することを確認する
+func TestPostSyncBlocked(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	// 1件目: allow_blockedの指定なし。リビジョンの競合ではないため、読み直さない
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "リリース", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "リリース", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+	expectOpenBlockers(mock, 1, 2)
+	mock.ExpectRollback()
+	// 2件目: allow_blockedを指定
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "リリース", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "リリース", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+	mock.ExpectExec(`^UPDATE todos`).
+		WithArgs("リリース", true, nil, nil, "", "", "", "done", nil, nil, 1, testWorkspaceID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRevision(mock, 1, 2)
+	expectAuditLog(mock, "update", 1)
+	expectChange(mock, 1, false)
+	expectOutbox(mock, "todo.updated")
+	expectOutbox(mock, "todo.completed")
+	mock.ExpectCommit()
+
+	body := `{"mutations": [
+		{"client_id": "c1", "op": "update", "id": 1, "base_revision": 1, "todo": {"is_complete": true}},
+		{"client_id": "c2", "op": "update", "id": 1, "base_revision": 1, "allow_blocked": true, "todo": {"is_complete": true}}
+	]}`
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodPost, "/sync", body)
+
+	handler.PostSync(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.SyncResultsResponse](t, rec)
+	want := model.SyncResultsResponse{
+		Data: []model.SyncResult{
+			{ClientID: "c1", Status: "rejected", Error: "未完了のTODOに依存しているため完了できません（ID: 2）。"},
+			{ClientID: "c2", Status: "applied", Todo: &model.Todo{ID: 1, Title: "リリース", IsComplete: true, Status: "done", Revision: 2}},
+		},
+		Status: model.StatusInfo{Code: http.StatusOK},
+	}
+	checkResponseBody(t, want, got)
+}
+
+func TestPostSyncInvalidInput(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodPost, "/sync", `{"mutations": [{"client_id": "c1", "op": "upsert"}]}`)
+
+	handler.PostSync(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusBadRequest, rec.Code)
+	got := decodeResponseBody[model.SyncResultsResponse](t, rec)
+	checkResponseBody(t, "変更の種類が不正です。", got.Status.ErrorMessage)
+}
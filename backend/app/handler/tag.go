@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/filter"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Todoに付けたタグを名前の順に取得する
+func GetTodoTags(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTodoTagsResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var todoID int
+	if err := db.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&todoID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTodoTagsResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteTodoTagsResponse(w, nil, http.StatusInternalServerError, constant.TAG_ERR_FAILED_GET_TAG)
+		}
+		return
+	}
+
+	rows, err := db.Query("SELECT tag FROM todo_tags WHERE todo_id = ? AND workspace_id = ? ORDER BY tag", id, workspaceID)
+	if err != nil {
+		response.WriteTodoTagsResponse(w, nil, http.StatusInternalServerError, constant.TAG_ERR_FAILED_GET_TAG)
+		return
+	}
+	defer rows.Close()
+
+	tags := model.TodoTags{Tags: []string{}}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			response.WriteTodoTagsResponse(w, nil, http.StatusInternalServerError, constant.TAG_ERR_FAILED_GET_TAG)
+			return
+		}
+		tags.Tags = append(tags.Tags, tag)
+	}
+
+	response.WriteTodoTagsResponse(w, &tags, http.StatusOK, "")
+}
+
+// Todoのタグを指定したタグで置き換える。タグは小文字に揃え、重複を除いて保存する
+func UpdateTodoTags(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTodoTagsResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	var input model.TodoTags
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteTodoTagsResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+
+	// 入力値のバリデーション
+	if err := validator.TodoTagsInput(input); err != nil {
+		response.WriteTodoTagsResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tags := normalizeTags(input.Tags)
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteTodoTagsResponse(w, nil, http.StatusInternalServerError, constant.TAG_ERR_FAILED_UPDATE_TAG)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	todo, err := lockTodoForChange(tx, workspaceID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTodoTagsResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteTodoTagsResponse(w, nil, http.StatusInternalServerError, constant.TAG_ERR_FAILED_UPDATE_TAG)
+		}
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM todo_tags WHERE todo_id = ? AND workspace_id = ?", id, workspaceID); err != nil {
+		response.WriteTodoTagsResponse(w, nil, http.StatusInternalServerError, constant.TAG_ERR_FAILED_UPDATE_TAG)
+		return
+	}
+
+	if len(tags) > 0 {
+		values := make([]string, len(tags))
+		args := make([]any, 0, len(tags)*3)
+		for i, tag := range tags {
+			values[i] = "(?, ?, ?)"
+			args = append(args, workspaceID, id, tag)
+		}
+		insertQuery := "INSERT INTO todo_tags (workspace_id, todo_id, tag) VALUES " + strings.Join(values, ", ")
+		if _, err := tx.Exec(insertQuery, args...); err != nil {
+			response.WriteTodoTagsResponse(w, nil, http.StatusInternalServerError, constant.TAG_ERR_FAILED_UPDATE_TAG)
+			return
+		}
+	}
+
+	updated, err := recordRelatedChange(r.Context(), tx, &todo)
+	if err != nil {
+		response.WriteTodoTagsResponse(w, nil, http.StatusInternalServerError, constant.TAG_ERR_FAILED_UPDATE_TAG)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteTodoTagsResponse(w, nil, http.StatusInternalServerError, constant.TAG_ERR_FAILED_UPDATE_TAG)
+		return
+	}
+
+	publishTodoEvents(updated)
+
+	response.WriteTodoTagsResponse(w, &model.TodoTags{Tags: tags}, http.StatusOK, "")
+}
+
+// タグを比較用の形式に揃え、重複を除いて名前の順に並べる
+func normalizeTags(tags []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = filter.NormalizeTag(tag)
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
@@ -0,0 +1,105 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetTodoTags(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`^SELECT tag FROM todo_tags WHERE todo_id = \? AND workspace_id = \? ORDER BY tag$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"tag"}).AddRow("home").AddRow("work"))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/todos/1/tags", "")
+	req.SetPathValue("id", "1")
+
+	handler.GetTodoTags(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.TodoTagsResponse](t, rec)
+	checkResponseBody(t, &model.TodoTags{Tags: []string{"home", "work"}}, got.Data)
+}
+
+func TestUpdateTodoTags(t *testing.T) {
+	t.Run("タグを小文字に揃えて重複を除く", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		expectTodoLock(mock, 1)
+		mock.ExpectExec(`^DELETE FROM todo_tags WHERE todo_id = \? AND workspace_id = \?$`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`^INSERT INTO todo_tags \(workspace_id, todo_id, tag\) VALUES \(\?, \?, \?\), \(\?, \?, \?\)$`).
+			WithArgs(testWorkspaceID, 1, "someday", testWorkspaceID, 1, "work").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		expectRelatedChange(mock, 1)
+		mock.ExpectCommit()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPut, "/todos/1/tags", `{"tags": ["Work", "someday", " work "]}`)
+		req.SetPathValue("id", "1")
+
+		handler.UpdateTodoTags(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		got := decodeResponseBody[model.TodoTagsResponse](t, rec)
+		checkResponseBody(t, &model.TodoTags{Tags: []string{"someday", "work"}}, got.Data)
+	})
+
+	t.Run("タグをすべて外す", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		expectTodoLock(mock, 1)
+		mock.ExpectExec(`^DELETE FROM todo_tags`).
+			WithArgs(1, testWorkspaceID).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		expectRelatedChange(mock, 1)
+		mock.ExpectCommit()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPut, "/todos/1/tags", `{"tags": []}`)
+		req.SetPathValue("id", "1")
+
+		handler.UpdateTodoTags(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Todoが存在しない", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`^SELECT .* FROM todos WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+			WithArgs(99, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(todoRowColumns))
+		mock.ExpectRollback()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPut, "/todos/99/tags", `{"tags": ["work"]}`)
+		req.SetPathValue("id", "99")
+
+		handler.UpdateTodoTags(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusNotFound, rec.Code)
+	})
+}
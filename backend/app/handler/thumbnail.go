@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"backend/app/blob"
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/thumbnail"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// 縮小画像はURLに内容のキーを含み、同じURLの内容は変わらないため1年間キャッシュさせる
+const thumbnailCacheControl = "private, max-age=31536000, immutable"
+
+// 縮小画像を返す。ワークスペースの添付ファイルの縮小画像のみ返す
+func GetThumbnail(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if !blob.ValidKey(key) {
+		response.WriteAttachmentResponse(w, nil, http.StatusNotFound, constant.ATTACHMENT_ERR_NOT_FOUND_THUMBNAIL)
+		return
+	}
+
+	store := blob.Default()
+	if store == nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_GET_ATTACHMENT)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var attachmentID int
+	query := "SELECT id FROM attachments WHERE thumbnail_key = ? AND thumbnail_status = ? AND workspace_id = ? LIMIT 1"
+	if err := db.QueryRow(query, key, thumbnail.StatusReady, workspaceID).Scan(&attachmentID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteAttachmentResponse(w, nil, http.StatusNotFound, constant.ATTACHMENT_ERR_NOT_FOUND_THUMBNAIL)
+		} else {
+			response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_GET_ATTACHMENT)
+		}
+		return
+	}
+
+	content, err := store.Open(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			response.WriteAttachmentResponse(w, nil, http.StatusNotFound, constant.ATTACHMENT_ERR_NOT_FOUND_THUMBNAIL)
+		} else {
+			response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_GET_ATTACHMENT)
+		}
+		return
+	}
+	defer content.Close()
+
+	// 縮小画像はPNGかJPEGで作成するため、内容から形式を判定する
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_GET_ATTACHMENT)
+		return
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		response.WriteAttachmentResponse(w, nil, http.StatusInternalServerError, constant.ATTACHMENT_ERR_FAILED_GET_ATTACHMENT)
+		return
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(head[:n]))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", thumbnailCacheControl)
+	w.Header().Set("ETag", `"`+key+`"`)
+
+	http.ServeContent(w, r, "", time.Time{}, content)
+}
@@ -0,0 +1,78 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetThumbnail(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+	store := setUpBlobStore(t)
+
+	key, _, err := store.Put(context.Background(), bytes.NewReader(testPNG))
+	if err != nil {
+		t.Fatalf("保存に失敗しました: %s", err)
+	}
+
+	t.Run("長期間キャッシュできるヘッダーとともに返す", func(t *testing.T) {
+		mock.ExpectQuery(`^SELECT id FROM attachments WHERE thumbnail_key = \? AND thumbnail_status = \? AND workspace_id = \? LIMIT 1$`).
+			WithArgs(key, "ready", testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodGet, "/thumbnails/"+key, "")
+		req.SetPathValue("key", key)
+
+		handler.GetThumbnail(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		if !bytes.Equal(rec.Body.Bytes(), testPNG) {
+			t.Errorf("内容が一致しません: %q", rec.Body.Bytes())
+		}
+		wantHeaders := map[string]string{
+			"Content-Type":  "image/png",
+			"Cache-Control": "private, max-age=31536000, immutable",
+			"ETag":          `"` + key + `"`,
+		}
+		for name, want := range wantHeaders {
+			if got := rec.Header().Get(name); got != want {
+				t.Errorf("%s: want: %s, got: %s", name, want, got)
+			}
+		}
+	})
+
+	t.Run("他のワークスペースの縮小画像は返さない", func(t *testing.T) {
+		mock.ExpectQuery(`^SELECT id FROM attachments WHERE thumbnail_key = \?`).
+			WithArgs(key, "ready", 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		rec := httptest.NewRecorder()
+		req := createWorkspaceRequest(t, 2, http.MethodGet, "/thumbnails/"+key, "")
+		req.SetPathValue("key", key)
+
+		handler.GetThumbnail(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("キーの形式でない", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodGet, "/thumbnails/x", "")
+		req.SetPathValue("key", strings.Repeat("../", 3))
+
+		handler.GetThumbnail(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusNotFound, rec.Code)
+	})
+}
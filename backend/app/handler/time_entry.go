@@ -0,0 +1,381 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// time_entriesテーブルから取得するカラム。scanTimeEntryと順序を合わせること
+const timeEntryColumns = "id, todo_id, user_id, started_at, ended_at, duration_seconds, note"
+
+// timeEntryColumnsの順序で作業時間の記録を読み込む
+func scanTimeEntry(s rowScanner, entry *model.TimeEntry) error {
+	return s.Scan(&entry.ID, &entry.TodoID, &entry.UserID, &entry.StartedAt, &entry.EndedAt, &entry.DurationSeconds, &entry.Note)
+}
+
+// Todoの作業時間の記録を開始した順に、終了した記録の合計とともに取得する
+func GetTimeEntries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTimeEntriesResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var todoID int
+	if err := db.QueryRow("SELECT id FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&todoID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTimeEntriesResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteTimeEntriesResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_GET_ENTRY)
+		}
+		return
+	}
+
+	rows, err := db.Query("SELECT "+timeEntryColumns+" FROM time_entries WHERE todo_id = ? AND workspace_id = ? ORDER BY started_at, id", id, workspaceID)
+	if err != nil {
+		response.WriteTimeEntriesResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_GET_ENTRY)
+		return
+	}
+	defer rows.Close()
+
+	entries := model.TimeEntries{Entries: []model.TimeEntry{}}
+	for rows.Next() {
+		var entry model.TimeEntry
+		if err := scanTimeEntry(rows, &entry); err != nil {
+			response.WriteTimeEntriesResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_GET_ENTRY)
+			return
+		}
+		entries.Entries = append(entries.Entries, entry)
+		entries.TotalSeconds += entry.DurationSeconds
+	}
+	if err := rows.Err(); err != nil {
+		response.WriteTimeEntriesResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_GET_ENTRY)
+		return
+	}
+
+	response.WriteTimeEntriesResponse(w, &entries, http.StatusOK, "")
+}
+
+// Todoの作業時間を手動で記録する
+func CreateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteTimeEntryResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	var input model.TimeEntry
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+	input.Note = strings.TrimSpace(input.Note)
+
+	// 入力値のバリデーション
+	if err := validator.TimeEntryInput(input); err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_ADD_ENTRY)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	if err := lockTodo(tx, workspaceID, id); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTimeEntryResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_ADD_ENTRY)
+		}
+		return
+	}
+
+	startedAt, endedAt := input.StartedAt.UTC(), input.EndedAt.UTC()
+	entry := model.TimeEntry{
+		TodoID:          id,
+		UserID:          userID,
+		StartedAt:       startedAt,
+		EndedAt:         &endedAt,
+		DurationSeconds: int(endedAt.Sub(startedAt) / time.Second),
+		Note:            input.Note,
+	}
+	if err := insertTimeEntry(tx, workspaceID, &entry); err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_ADD_ENTRY)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_ADD_ENTRY)
+		return
+	}
+
+	response.WriteTimeEntryResponse(w, &entry, http.StatusCreated, "")
+}
+
+// 自分の作業時間の記録を削除する。実行中のタイマーを取り消す場合にも使う
+func DeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteTimeEntryResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+	entryID, err := strconv.Atoi(r.PathValue("entryID"))
+	if err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_DELETE_ENTRY)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	var entry model.TimeEntry
+	query := "SELECT " + timeEntryColumns + " FROM time_entries WHERE id = ? AND todo_id = ? AND workspace_id = ? FOR UPDATE"
+	if err := scanTimeEntry(tx.QueryRow(query, entryID, id, workspaceID), &entry); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTimeEntryResponse(w, nil, http.StatusNotFound, constant.TIME_ERR_NOT_FOUND_ENTRY)
+		} else {
+			response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_DELETE_ENTRY)
+		}
+		return
+	}
+	if entry.UserID != userID {
+		response.WriteTimeEntryResponse(w, nil, http.StatusForbidden, constant.TIME_ERR_NOT_OWNER)
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM time_entries WHERE id = ? AND workspace_id = ?", entry.ID, workspaceID); err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_DELETE_ENTRY)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_DELETE_ENTRY)
+		return
+	}
+
+	response.WriteTimeEntryResponse(w, nil, http.StatusOK, "")
+}
+
+// Todoのタイマーを開始する。タイマーは1人につき1つまでで、
+// 他のTodoのタイマーを実行中の場合は、先に停止するよう求める
+func StartTimer(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteTimeEntryResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_START_TIMER)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	if err := lockTodo(tx, workspaceID, id); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTimeEntryResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		} else {
+			response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_START_TIMER)
+		}
+		return
+	}
+
+	// 実行中のタイマーはワークスペースをまたいで1つまでのため、ユーザーで検索してロックする
+	var runningWorkspaceID, runningTodoID int
+	runningQuery := "SELECT workspace_id, todo_id FROM time_entries WHERE user_id = ? AND ended_at IS NULL FOR UPDATE"
+	err = tx.QueryRow(runningQuery, userID).Scan(&runningWorkspaceID, &runningTodoID)
+	switch {
+	case err == nil && runningWorkspaceID != workspaceID:
+		// 他のワークスペースのTodoは明かさない
+		response.WriteTimeEntryResponse(w, nil, http.StatusConflict, constant.TIME_ERR_TIMER_RUNNING_ELSEWHERE)
+		return
+	case err == nil:
+		response.WriteTimeEntryResponse(w, nil, http.StatusConflict, fmt.Sprintf(constant.TIME_ERR_TIMER_RUNNING, runningTodoID))
+		return
+	case err != sql.ErrNoRows:
+		response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_START_TIMER)
+		return
+	}
+
+	// 同時に開始した場合は、実行中のタイマーの一意制約により後の登録が失敗する
+	entry := model.TimeEntry{TodoID: id, UserID: userID, StartedAt: time.Now().UTC()}
+	if err := insertTimeEntry(tx, workspaceID, &entry); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry {
+			response.WriteTimeEntryResponse(w, nil, http.StatusConflict, constant.TIME_ERR_TIMER_ALREADY_RUNNING)
+		} else {
+			response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_START_TIMER)
+		}
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_START_TIMER)
+		return
+	}
+
+	response.WriteTimeEntryResponse(w, &entry, http.StatusCreated, "")
+}
+
+// Todoで実行中の自分のタイマーを停止し、作業時間を記録する
+func StopTimer(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteTimeEntryResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_STOP_TIMER)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	var entry model.TimeEntry
+	query := "SELECT " + timeEntryColumns + " FROM time_entries WHERE todo_id = ? AND user_id = ? AND workspace_id = ? AND ended_at IS NULL FOR UPDATE"
+	if err := scanTimeEntry(tx.QueryRow(query, id, userID, workspaceID), &entry); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteTimeEntryResponse(w, nil, http.StatusNotFound, constant.TIME_ERR_NOT_FOUND_TIMER)
+		} else {
+			response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_STOP_TIMER)
+		}
+		return
+	}
+
+	endedAt := time.Now().UTC()
+	entry.EndedAt = &endedAt
+	entry.DurationSeconds = max(int(endedAt.Sub(entry.StartedAt)/time.Second), 0)
+	updateQuery := "UPDATE time_entries SET ended_at = ?, duration_seconds = ? WHERE id = ? AND workspace_id = ?"
+	if _, err := tx.Exec(updateQuery, entry.EndedAt, entry.DurationSeconds, entry.ID, workspaceID); err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_STOP_TIMER)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteTimeEntryResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_STOP_TIMER)
+		return
+	}
+
+	response.WriteTimeEntryResponse(w, &entry, http.StatusOK, "")
+}
+
+// 作業時間の記録を追加し、採番したIDを設定する
+func insertTimeEntry(tx *sql.Tx, workspaceID int, entry *model.TimeEntry) error {
+	insertQuery := "INSERT INTO time_entries (workspace_id, todo_id, user_id, started_at, ended_at, duration_seconds, note) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	result, err := tx.Exec(insertQuery, workspaceID, entry.TodoID, entry.UserID, entry.StartedAt, entry.EndedAt, entry.DurationSeconds, entry.Note)
+	if err != nil {
+		return err
+	}
+	entryID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	entry.ID = int(entryID)
+	return nil
+}
+
+// 複数のTodoに、記録した作業時間の合計をまとめて設定する
+func attachTimeSpent(q querier, workspaceID int, todos []model.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(todos))
+	args := []any{workspaceID}
+	for i, todo := range todos {
+		placeholders[i] = "?"
+		args = append(args, todo.ID)
+	}
+
+	query := "SELECT todo_id, SUM(duration_seconds) FROM time_entries WHERE workspace_id = ? AND todo_id IN (" +
+		strings.Join(placeholders, ", ") + ") GROUP BY todo_id"
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	spent := map[int]int{}
+	for rows.Next() {
+		var todoID, seconds int
+		if err := rows.Scan(&todoID, &seconds); err != nil {
+			return err
+		}
+		spent[todoID] = seconds
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range todos {
+		seconds := spent[todos[i].ID]
+		todos[i].TimeSpent = &seconds
+	}
+	return nil
+}
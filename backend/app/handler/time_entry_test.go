@@ -0,0 +1,335 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+var timeEntryRowColumns = []string{"id", "todo_id", "user_id", "started_at", "ended_at", "duration_seconds", "note"}
+
+func TestStartTimer(t *testing.T) {
+	cases := map[string]struct {
+		req            func(t *testing.T) *http.Request
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantMessage    string
+	}{
+		"タイマーを開始する": {
+			req: func(t *testing.T) *http.Request {
+				return createUserRequest(t, http.MethodPost, "/todos/1/timer/start", "")
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`^SELECT workspace_id, todo_id FROM time_entries WHERE user_id = \? AND ended_at IS NULL FOR UPDATE$`).
+					WithArgs(testUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"workspace_id", "todo_id"}))
+				mock.ExpectExec(`^INSERT INTO time_entries \(workspace_id, todo_id, user_id, started_at, ended_at, duration_seconds, note\) VALUES \(\?, \?, \?, \?, \?, \?, \?\)$`).
+					WithArgs(testWorkspaceID, 1, testUserID, sqlmock.AnyArg(), nil, 0, "").
+					WillReturnResult(sqlmock.NewResult(5, 1))
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		"別のTodoのタイマーを実行中": {
+			req: func(t *testing.T) *http.Request {
+				return createUserRequest(t, http.MethodPost, "/todos/1/timer/start", "")
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM todos`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`^SELECT workspace_id, todo_id FROM time_entries`).
+					WillReturnRows(sqlmock.NewRows([]string{"workspace_id", "todo_id"}).AddRow(testWorkspaceID, 2))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusConflict,
+			wantMessage:    "実行中のタイマーがあります。先に停止してください（TODO ID: 2）。",
+		},
+		"別のワークスペースでタイマーを実行中": {
+			req: func(t *testing.T) *http.Request {
+				return createUserRequest(t, http.MethodPost, "/todos/1/timer/start", "")
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM todos`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`^SELECT workspace_id, todo_id FROM time_entries`).
+					WillReturnRows(sqlmock.NewRows([]string{"workspace_id", "todo_id"}).AddRow(99, 30))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusConflict,
+			wantMessage:    "別のワークスペースで実行中のタイマーがあります。先に停止してください。",
+		},
+		"同時に開始したタイマーが先に登録された": {
+			req: func(t *testing.T) *http.Request {
+				return createUserRequest(t, http.MethodPost, "/todos/1/timer/start", "")
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM todos`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`^SELECT workspace_id, todo_id FROM time_entries`).
+					WillReturnRows(sqlmock.NewRows([]string{"workspace_id", "todo_id"}))
+				mock.ExpectExec(`^INSERT INTO time_entries`).
+					WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '7' for key 'uq_time_entries_running'"})
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusConflict,
+			wantMessage:    "実行中のタイマーがあります。先に停止してください。",
+		},
+		"Todoが存在しない": {
+			req: func(t *testing.T) *http.Request {
+				return createUserRequest(t, http.MethodPost, "/todos/1/timer/start", "")
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM todos`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantMessage:    "TODOが見つかりません。",
+		},
+		"未認証": {
+			req: func(t *testing.T) *http.Request {
+				return createTestRequest(t, http.MethodPost, "/todos/1/timer/start", "")
+			},
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusUnauthorized,
+			wantMessage:    "ユーザーの認証が必要です。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := c.req(t)
+			req.SetPathValue("id", "1")
+
+			handler.StartTimer(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.TimeEntryResponse](t, rec)
+			if got.Status.ErrorMessage != c.wantMessage {
+				t.Errorf("want: %s, got: %s", c.wantMessage, got.Status.ErrorMessage)
+			}
+			if c.wantStatusCode == http.StatusCreated && (got.Data == nil || got.Data.ID != 5 || got.Data.EndedAt != nil) {
+				t.Errorf("実行中のタイマーが返却されていません: %+v", got.Data)
+			}
+		})
+	}
+}
+
+func TestStopTimer(t *testing.T) {
+	t.Run("タイマーを停止して作業時間を記録する", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		startedAt := time.Now().UTC().Add(-90 * time.Minute)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`^SELECT id, todo_id, user_id, started_at, ended_at, duration_seconds, note FROM time_entries WHERE todo_id = \? AND user_id = \? AND workspace_id = \? AND ended_at IS NULL FOR UPDATE$`).
+			WithArgs(1, testUserID, testWorkspaceID).
+			WillReturnRows(sqlmock.NewRows(timeEntryRowColumns).AddRow(5, 1, testUserID, startedAt, nil, 0, ""))
+		mock.ExpectExec(`^UPDATE time_entries SET ended_at = \?, duration_seconds = \? WHERE id = \? AND workspace_id = \?$`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 5, testWorkspaceID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/todos/1/timer/stop", "")
+		req.SetPathValue("id", "1")
+
+		handler.StopTimer(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		got := decodeResponseBody[model.TimeEntryResponse](t, rec)
+		if got.Data == nil || got.Data.EndedAt == nil {
+			t.Fatalf("終了日時が記録されていません: %+v", got.Data)
+		}
+		if d := got.Data.DurationSeconds; d < 90*60 || d > 91*60 {
+			t.Errorf("want: 5400秒前後, got: %d", d)
+		}
+	})
+
+	t.Run("実行中のタイマーがない", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`^SELECT .* FROM time_entries`).
+			WillReturnRows(sqlmock.NewRows(timeEntryRowColumns))
+		mock.ExpectRollback()
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/todos/1/timer/stop", "")
+		req.SetPathValue("id", "1")
+
+		handler.StopTimer(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusNotFound, rec.Code)
+		got := decodeResponseBody[model.TimeEntryResponse](t, rec)
+		checkResponseBody(t, "このTODOで実行中のタイマーがありません。", got.Status.ErrorMessage)
+	})
+}
+
+func TestCreateTimeEntry(t *testing.T) {
+	startedAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	endedAt := startedAt.Add(90 * time.Minute)
+
+	cases := map[string]struct {
+		body           string
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantMessage    string
+		wantData       *model.TimeEntry
+	}{
+		"作業時間を記録する": {
+			body: `{"started_at": "2024-05-01T18:00:00+09:00", "ended_at": "2024-05-01T10:30:00Z", "note": " レビュー "}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(`^INSERT INTO time_entries`).
+					WithArgs(testWorkspaceID, 1, testUserID, startedAt, endedAt, 5400, "レビュー").
+					WillReturnResult(sqlmock.NewResult(6, 1))
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusCreated,
+			wantData: &model.TimeEntry{
+				ID: 6, TodoID: 1, UserID: testUserID, StartedAt: startedAt, EndedAt: &endedAt, DurationSeconds: 5400, Note: "レビュー",
+			},
+		},
+		"終了日時が開始日時より前": {
+			body:           `{"started_at": "2024-05-01T10:00:00Z", "ended_at": "2024-05-01T09:00:00Z"}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "終了日時は開始日時より後にしてください。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createUserRequest(t, http.MethodPost, "/todos/1/time-entries", c.body)
+			req.SetPathValue("id", "1")
+
+			handler.CreateTimeEntry(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.TimeEntryResponse](t, rec)
+			if got.Status.ErrorMessage != c.wantMessage {
+				t.Errorf("want: %s, got: %s", c.wantMessage, got.Status.ErrorMessage)
+			}
+			checkResponseBody(t, c.wantData, got.Data)
+		})
+	}
+}
+
+// 合計には実行中のタイマーを含めないことを確認する
+func TestGetTimeEntries(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	startedAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	endedAt := startedAt.Add(time.Hour)
+	mock.ExpectQuery(`^SELECT id FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`^SELECT id, todo_id, user_id, started_at, ended_at, duration_seconds, note FROM time_entries WHERE todo_id = \? AND workspace_id = \? ORDER BY started_at, id$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(timeEntryRowColumns).
+			AddRow(5, 1, testUserID, startedAt, endedAt, 3600, "").
+			AddRow(6, 1, 11, endedAt, nil, 0, ""))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/todos/1/time-entries", "")
+	req.SetPathValue("id", "1")
+
+	handler.GetTimeEntries(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.TimeEntriesResponse](t, rec)
+	checkResponseBody(t, &model.TimeEntries{
+		Entries: []model.TimeEntry{
+			{ID: 5, TodoID: 1, UserID: testUserID, StartedAt: startedAt, EndedAt: &endedAt, DurationSeconds: 3600},
+			{ID: 6, TodoID: 1, UserID: 11, StartedAt: endedAt},
+		},
+		TotalSeconds: 3600,
+	}, got.Data)
+}
+
+func TestDeleteTimeEntryNotOwner(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT .* FROM time_entries WHERE id = \? AND todo_id = \? AND workspace_id = \? FOR UPDATE$`).
+		WithArgs(5, 1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(timeEntryRowColumns).AddRow(5, 1, 11, time.Now(), nil, 0, ""))
+	mock.ExpectRollback()
+
+	rec := httptest.NewRecorder()
+	req := createUserRequest(t, http.MethodDelete, "/todos/1/time-entries/5", "")
+	req.SetPathValue("id", "1")
+	req.SetPathValue("entryID", "5")
+
+	handler.DeleteTimeEntry(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusForbidden, rec.Code)
+	got := decodeResponseBody[model.TimeEntryResponse](t, rec)
+	checkResponseBody(t, "作業時間の記録を削除できるのは記録したユーザーのみです。", got.Status.ErrorMessage)
+}
+
+// include=time_spentを指定すると、記録のないTodoも0秒として返すことを確認する
+func TestGetTodosWithTimeSpent(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE workspace_id = \?$`).
+		WithArgs(testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).
+			AddRow(1, "料理", false, 1, nil, nil, "", "", "", "todo", nil, nil).
+			AddRow(2, "掃除", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+	mock.ExpectQuery(`^SELECT todo_id, SUM\(duration_seconds\) FROM time_entries WHERE workspace_id = \? AND todo_id IN \(\?, \?\) GROUP BY todo_id$`).
+		WithArgs(testWorkspaceID, 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"todo_id", "seconds"}).AddRow(1, 5400))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/todos?include=time_spent", "")
+
+	handler.GetTodos(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	spent, none := 5400, 0
+	got := decodeResponseBody[model.TodosResponse](t, rec)
+	checkResponseBody(t, createTodosResponse(t, []model.Todo{
+		{ID: 1, Title: "料理", Status: "todo", Revision: 1, TimeSpent: &spent},
+		{ID: 2, Title: "掃除", Status: "todo", Revision: 1, TimeSpent: &none},
+	}, http.StatusOK, ""), got)
+}
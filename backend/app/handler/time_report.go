@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 作業時間の集計で指定できる期間の上限（日数）
+const maxTimeReportDays = 366
+
+// 集計の対象とする作業時間の記録。削除したTodoの記録を除くため、Todoと結合する
+const timeReportSource = " FROM time_entries JOIN todos ON todos.id = time_entries.todo_id AND todos.workspace_id = time_entries.workspace_id"
+
+// group_byごとの、集計の単位とする式と追加で結合するテーブル
+var timeReportGroups = map[string]struct {
+	key  string
+	join string
+}{
+	"list": {key: "todos.list_id"},
+	"tag": {
+		key:  "todo_tags.tag",
+		join: " LEFT JOIN todo_tags ON todo_tags.todo_id = time_entries.todo_id AND todo_tags.workspace_id = time_entries.workspace_id",
+	},
+	"day": {key: "DATE_FORMAT(time_entries.started_at, '%Y-%m-%d')"},
+}
+
+// 期間内に開始した作業時間を、リスト・タグ・日（UTC）ごとに集計する。
+// 実行中のタイマーの記録は含まない。format=csvを指定した場合はCSVで返す
+func GetTimeReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	asCSV := false
+	switch query.Get("format") {
+	case "", "json":
+	case "csv":
+		asCSV = true
+	default:
+		response.WriteTimeReportResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_FORMAT)
+		return
+	}
+
+	groupBy := query.Get("group_by")
+	if groupBy == "" {
+		groupBy = "day"
+	}
+	group, ok := timeReportGroups[groupBy]
+	if !ok {
+		response.WriteTimeReportResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_GROUP_BY)
+		return
+	}
+
+	from, to, ok := parseReportRange(query.Get("from"), query.Get("to"))
+	if !ok {
+		response.WriteTimeReportResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_DATE_RANGE)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	source := timeReportSource + group.join
+	where := " WHERE time_entries.workspace_id = ? AND time_entries.ended_at IS NOT NULL AND time_entries.started_at >= ? AND time_entries.started_at < ?"
+	args := []any{workspaceID, from, to.AddDate(0, 0, 1)}
+
+	reportQuery := "SELECT " + group.key + ", SUM(time_entries.duration_seconds)" + source + where + " GROUP BY " + group.key + " ORDER BY " + group.key
+	rows, err := db.Query(reportQuery, args...)
+	if err != nil {
+		response.WriteTimeReportResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_GET_REPORT)
+		return
+	}
+	defer rows.Close()
+
+	report := model.TimeReport{
+		From:    from.Format(time.DateOnly),
+		To:      to.Format(time.DateOnly),
+		GroupBy: groupBy,
+		Rows:    []model.TimeReportRow{},
+	}
+	for rows.Next() {
+		// リストやタグがない記録はNULLとして集計される
+		var key sql.NullString
+		var row model.TimeReportRow
+		if err := rows.Scan(&key, &row.Seconds); err != nil {
+			response.WriteTimeReportResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_GET_REPORT)
+			return
+		}
+		row.Key = key.String
+		report.Rows = append(report.Rows, row)
+		report.TotalSeconds += row.Seconds
+	}
+	if err := rows.Err(); err != nil {
+		response.WriteTimeReportResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_GET_REPORT)
+		return
+	}
+
+	// タグを複数付けたTodoは各タグの行に含まれるため、合計は別に求める
+	if group.join != "" {
+		totalQuery := "SELECT COALESCE(SUM(time_entries.duration_seconds), 0)" + timeReportSource + where
+		if err := db.QueryRow(totalQuery, args...).Scan(&report.TotalSeconds); err != nil {
+			response.WriteTimeReportResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_GET_REPORT)
+			return
+		}
+	}
+
+	if asCSV {
+		response.WriteCSV(w, "time-report-"+report.From+"-"+report.To+".csv", timeReportRecords(&report))
+		return
+	}
+	response.WriteTimeReportResponse(w, &report, http.StatusOK, "")
+}
+
+// 集計の期間を解釈する。省略した場合は、今日（UTC）までの30日間とする
+func parseReportRange(fromStr, toStr string) (time.Time, time.Time, bool) {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if toStr != "" {
+		t, err := time.Parse(time.DateOnly, toStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -29)
+	if fromStr != "" {
+		t, err := time.Parse(time.DateOnly, fromStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		from = t
+	}
+
+	if to.Before(from) || to.Sub(from) >= maxTimeReportDays*24*time.Hour {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// 集計をCSVの行に変換する。最初の行は見出しとする
+func timeReportRecords(report *model.TimeReport) [][]string {
+	records := [][]string{{report.GroupBy, "seconds", "hours"}}
+	for _, row := range report.Rows {
+		records = append(records, []string{row.Key, strconv.Itoa(row.Seconds), formatHours(row.Seconds)})
+	}
+	return records
+}
+
+// 秒を、小数点以下2桁の時間に変換する
+func formatHours(seconds int) string {
+	return strconv.FormatFloat(float64(seconds)/3600, 'f', 2, 64)
+}
@@ -0,0 +1,143 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// タグごとの集計では、複数のタグを付けたTodoを各行に含め、合計は別に求めることを確認する
+func TestGetTimeReportByTag(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`^SELECT todo_tags.tag, SUM\(time_entries.duration_seconds\) FROM time_entries JOIN todos ON todos.id = time_entries.todo_id AND todos.workspace_id = time_entries.workspace_id LEFT JOIN todo_tags ON .* WHERE time_entries.workspace_id = \? AND time_entries.ended_at IS NOT NULL AND time_entries.started_at >= \? AND time_entries.started_at < \? GROUP BY todo_tags.tag ORDER BY todo_tags.tag$`).
+		WithArgs(testWorkspaceID, from, end).
+		WillReturnRows(sqlmock.NewRows([]string{"tag", "seconds"}).
+			AddRow(nil, 600).
+			AddRow("家事", 3600).
+			AddRow("買い物", 1800))
+	mock.ExpectQuery(`^SELECT COALESCE\(SUM\(time_entries.duration_seconds\), 0\) FROM time_entries JOIN todos ON .* WHERE time_entries.workspace_id = \?`).
+		WithArgs(testWorkspaceID, from, end).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(4200))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/reports/time?from=2024-05-01&to=2024-05-31&group_by=tag", "")
+
+	handler.GetTimeReport(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.TimeReportResponse](t, rec)
+	checkResponseBody(t, &model.TimeReport{
+		From:    "2024-05-01",
+		To:      "2024-05-31",
+		GroupBy: "tag",
+		Rows: []model.TimeReportRow{
+			{Key: "", Seconds: 600},
+			{Key: "家事", Seconds: 3600},
+			{Key: "買い物", Seconds: 1800},
+		},
+		TotalSeconds: 4200,
+	}, got.Data)
+}
+
+func TestGetTimeReportCSV(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT DATE_FORMAT\(time_entries.started_at, '%Y-%m-%d'\), SUM\(time_entries.duration_seconds\) FROM time_entries JOIN todos ON .* GROUP BY DATE_FORMAT`).
+		WithArgs(testWorkspaceID, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "seconds"}).
+			AddRow("2024-05-01", 5400).
+			AddRow("2024-05-02", 900))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/reports/time?from=2024-05-01&to=2024-05-02&group_by=day&format=csv", "")
+
+	handler.GetTimeReport(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	checkResponseBody(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	checkResponseBody(t, `attachment; filename=time-report-2024-05-01-2024-05-02.csv`, rec.Header().Get("Content-Disposition"))
+	checkResponseBody(t, "day,seconds,hours\n2024-05-01,5400,1.50\n2024-05-02,900,0.25\n", rec.Body.String())
+}
+
+// タグ名などの項目は、表計算ソフトで数式として実行されないよう変換して返すことを確認する
+func TestGetTimeReportCSVFormula(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT todo_tags.tag, SUM\(time_entries.duration_seconds\) FROM time_entries JOIN todos ON .* LEFT JOIN todo_tags ON .* GROUP BY todo_tags.tag`).
+		WithArgs(testWorkspaceID, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"tag", "seconds"}).
+			AddRow("=HYPERLINK(\"http://example.com\")", 3600).
+			AddRow("@SUM(A1)", 1800).
+			AddRow("設計", 900))
+	mock.ExpectQuery(`^SELECT COALESCE\(SUM\(time_entries.duration_seconds\), 0\) FROM time_entries JOIN todos ON .* WHERE time_entries.workspace_id = \?`).
+		WithArgs(testWorkspaceID, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(6300))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/reports/time?from=2024-05-01&to=2024-05-01&group_by=tag&format=csv", "")
+
+	handler.GetTimeReport(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	want := "tag,seconds,hours\n\"'=HYPERLINK(\"\"http://example.com\"\")\",3600,1.00\n'@SUM(A1),1800,0.50\n設計,900,0.25\n"
+	checkResponseBody(t, want, rec.Body.String())
+}
+
+func TestGetTimeReportInvalidQuery(t *testing.T) {
+	cases := map[string]struct {
+		query       string
+		wantMessage string
+	}{
+		"group_byが不正": {
+			query:       "?group_by=user",
+			wantMessage: "group_byにはlist、tag、dayのいずれかを指定してください。",
+		},
+		"日付の形式が不正": {
+			query:       "?from=2024/05/01",
+			wantMessage: "期間はfromとtoにYYYY-MM-DD形式で、fromからtoまで366日以内で指定してください。",
+		},
+		"fromがtoより後": {
+			query:       "?from=2024-05-02&to=2024-05-01",
+			wantMessage: "期間はfromとtoにYYYY-MM-DD形式で、fromからtoまで366日以内で指定してください。",
+		},
+		"期間が367日": {
+			query:       "?from=2024-01-01&to=2025-01-01",
+			wantMessage: "期間はfromとtoにYYYY-MM-DD形式で、fromからtoまで366日以内で指定してください。",
+		},
+		"formatが不正": {
+			query:       "?format=xlsx",
+			wantMessage: "formatにはjsonかcsvを指定してください。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodGet, "/reports/time"+c.query, "")
+
+			handler.GetTimeReport(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, http.StatusBadRequest, rec.Code)
+			got := decodeResponseBody[model.TimeReportResponse](t, rec)
+			checkResponseBody(t, c.wantMessage, got.Status.ErrorMessage)
+		})
+	}
+}
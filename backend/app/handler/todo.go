@@ -3,17 +3,101 @@ package handler
 import (
 	"backend/app/constant"
 	"backend/app/database"
+	"backend/app/markdown"
 	"backend/app/model"
+	"backend/app/requestctx"
 	"backend/app/response"
-	"backend/app/validator"
 	"encoding/json"
 	"net/http"
+	"strconv"
 )
 
-// Todoリストをすべて取得する
-func GetTodos(w http.ResponseWriter, _ *http.Request) {
+// todosテーブルから取得するカラム。scanTodoと順序を合わせること
+const todoColumns = "id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id"
+
+// rowScannerは、*sql.Rowと*sql.Rowsの共通インターフェース
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// todoColumnsの順序でTodoを読み込む
+func scanTodo(s rowScanner, todo *model.Todo) error {
+	return s.Scan(&todo.ID, &todo.Title, &todo.IsComplete, &todo.Revision, &todo.ListID, &todo.DueAt, &todo.Recurrence, &todo.TimeZone, &todo.Notes, &todo.Status, &todo.Estimate, &todo.MilestoneID)
+}
+
+// render=htmlを指定した場合に、メモをHTMLに変換して返すかどうかを判定する。
+// 不正な値を指定した場合はfalseとエラーメッセージを返す
+func wantsNotesHTML(r *http.Request) (bool, string) {
+	switch r.URL.Query().Get("render") {
+	case "":
+		return false, ""
+	case "html":
+		return true, ""
+	default:
+		return false, constant.INPUT_ERR_INVALID_RENDER
+	}
+}
+
+// メモをサニタイズしたHTMLに変換し、NotesHTMLに設定する
+func renderNotes(todo *model.Todo) {
+	if todo.Notes != "" {
+		todo.NotesHTML = markdown.Render(todo.Notes)
+	}
+}
+
+// Todoリストをすべて取得する。filterを指定した場合は、フィルターに一致するTodoのみ取得する。
+// render=htmlを指定した場合は、メモをHTMLに変換したものも返す。
+// assigneeを指定した場合は、そのユーザーが担当するTodoのみ取得する。meは自分を表す。
+// milestoneを指定した場合は、そのマイルストーンに属するTodoのみ取得する。
+// include=checklistを指定した場合は、チェックリストと完了の割合も返す。
+// include=assigneesを指定した場合は、担当者も返す。
+// include=time_spentを指定した場合は、記録した作業時間の合計も返す
+func GetTodos(w http.ResponseWriter, r *http.Request) {
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	renderHTML, errMessage := wantsNotesHTML(r)
+	if errMessage != "" {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusBadRequest, errMessage)
+		return
+	}
+	includes, errMessage := parseIncludes(r)
+	if errMessage != "" {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusBadRequest, errMessage)
+		return
+	}
+
+	query := "SELECT " + todoColumns + " FROM todos WHERE workspace_id = ?"
+	args := []any{workspaceID}
+	if src := r.URL.Query().Get("filter"); src != "" {
+		where, filterArgs, errMessage := compileFilter(src, r.URL.Query().Get("time_zone"))
+		if errMessage != "" {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusBadRequest, errMessage)
+			return
+		}
+		query += " AND " + where
+		args = append(args, filterArgs...)
+	}
+	if assignee := r.URL.Query().Get("assignee"); assignee != "" {
+		userID, code, errMessage := assigneeUserID(r, assignee)
+		if errMessage != "" {
+			response.WriteTodosResponse(w, []model.Todo{}, code, errMessage)
+			return
+		}
+		query += " AND EXISTS (SELECT 1 FROM todo_assignees WHERE todo_assignees.todo_id = todos.id AND todo_assignees.user_id = ?)"
+		args = append(args, userID)
+	}
+	if milestone := r.URL.Query().Get("milestone"); milestone != "" {
+		milestoneID, err := strconv.Atoi(milestone)
+		if err != nil {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+			return
+		}
+		query += " AND milestone_id = ?"
+		args = append(args, milestoneID)
+	}
+
 	db := database.GetDB()
-	rows, err := db.Query("SELECT id, title, is_complete FROM todos")
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO)
 		return
@@ -23,16 +107,55 @@ func GetTodos(w http.ResponseWriter, _ *http.Request) {
 	// レコードがある限り、次の行に進む
 	for rows.Next() {
 		var todo model.Todo
-		if err := rows.Scan(&todo.ID, &todo.Title, &todo.IsComplete); err != nil {
+		if err := scanTodo(rows, &todo); err != nil {
 			response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO_ROW)
 			return
 		}
+		if renderHTML {
+			renderNotes(&todo)
+		}
 		todos = append(todos, todo)
 	}
 
+	if includes.checklist {
+		if err := attachChecklists(db, workspaceID, todos); err != nil {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_GET_CHECKLIST)
+			return
+		}
+	}
+	if includes.assignees {
+		if err := attachAssignees(db, workspaceID, todos); err != nil {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_GET_ASSIGNEE)
+			return
+		}
+	}
+	if includes.timeSpent {
+		if err := attachTimeSpent(db, workspaceID, todos); err != nil {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.TIME_ERR_FAILED_GET_ENTRY)
+			return
+		}
+	}
+
 	response.WriteTodosResponse(w, todos, http.StatusOK, "")
 }
 
+// assigneeの指定からユーザーIDを求める。meの場合は認証したユーザーとし、
+// 認証していない場合や不正な値の場合はステータスコードとエラーメッセージを返す
+func assigneeUserID(r *http.Request, assignee string) (int, int, string) {
+	if assignee == "me" {
+		userID := requestctx.UserID(r.Context())
+		if userID == 0 {
+			return 0, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED
+		}
+		return userID, 0, ""
+	}
+	userID, err := strconv.Atoi(assignee)
+	if err != nil || userID <= 0 {
+		return 0, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ASSIGNEE
+	}
+	return userID, 0, ""
+}
+
 // Todoリストを追加する
 func CreateTodo(w http.ResponseWriter, r *http.Request) {
 	var newTodo model.Todo
@@ -41,16 +164,8 @@ func CreateTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 入力値のバリデーション
-	if err := validator.TodoInput(newTodo); err != nil {
-		response.WriteTodosResponse(w, []model.Todo{}, http.StatusBadRequest, err.Error())
-		return
-	}
-
-	db := database.GetDB()
-	_, err := db.Exec("INSERT INTO todos (title, is_complete) VALUES (?, ?)", newTodo.Title, newTodo.IsComplete)
-	if err != nil {
-		response.WriteTodosResponse(w, []model.Todo{}, http.StatusInternalServerError, constant.DB_ERR_FAILED_ADD_TODO)
+	if _, mErr := createTodo(r.Context(), newTodo); mErr != nil {
+		response.WriteTodosResponse(w, []model.Todo{}, mErr.code, mErr.message)
 		return
 	}
 
@@ -4,8 +4,8 @@ import (
 	"backend/app/constant"
 	"backend/app/database"
 	"backend/app/model"
+	"backend/app/requestctx"
 	"backend/app/response"
-	"backend/app/validator"
 	"database/sql"
 	"encoding/json"
 	"net/http"
@@ -13,7 +13,8 @@ import (
 	"strings"
 )
 
-// TodoリストのIDを指定して取得する
+// TodoリストのIDを指定して取得する。render=htmlを指定した場合は、メモをHTMLに変換したものも返す。
+// include=checklistを指定した場合は、チェックリストと完了の割合も返す
 func GetTodoById(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/todos/")
 	id, err := strconv.Atoi(idStr)
@@ -22,10 +23,23 @@ func GetTodoById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	renderHTML, errMessage := wantsNotesHTML(r)
+	if errMessage != "" {
+		response.WriteTodoResponse(w, nil, http.StatusBadRequest, errMessage)
+		return
+	}
+	includes, errMessage := parseIncludes(r)
+	if errMessage != "" {
+		response.WriteTodoResponse(w, nil, http.StatusBadRequest, errMessage)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
 	todo := &model.Todo{}
 	db := database.GetDB()
-	query := "SELECT id, title, is_complete FROM todos WHERE id = ?"
-	err = db.QueryRow(query, id).Scan(&todo.ID, &todo.Title, &todo.IsComplete)
+	query := "SELECT " + todoColumns + " FROM todos WHERE id = ? AND workspace_id = ?"
+	err = scanTodo(db.QueryRow(query, id, workspaceID), todo)
 	if err != nil {
 		// QueryRow()は結果がない場合sql.ErrNoRowsを返すため、適切なエラーハンドリングを行う
 		if err == sql.ErrNoRows {
@@ -36,10 +50,35 @@ func GetTodoById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if renderHTML {
+		renderNotes(todo)
+	}
+	if includes.checklist {
+		todo.Checklist, err = loadChecklist(db, workspaceID, id)
+		if err != nil {
+			response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.CHECKLIST_ERR_FAILED_GET_CHECKLIST)
+			return
+		}
+	}
+	if includes.assignees {
+		todo.Assignees, err = loadAssignees(db, workspaceID, id)
+		if err != nil {
+			response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.ASSIGNEE_ERR_FAILED_GET_ASSIGNEE)
+			return
+		}
+	}
+	if includes.timeSpent {
+		todos := []model.Todo{*todo}
+		if err := attachTimeSpent(db, workspaceID, todos); err != nil {
+			response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.TIME_ERR_FAILED_GET_ENTRY)
+			return
+		}
+		todo.TimeSpent = todos[0].TimeSpent
+	}
 	response.WriteTodoResponse(w, todo, http.StatusOK, "")
 }
 
-// TodoリストのIDを指定して更新する
+// TodoリストのIDを指定して更新する。省略した項目は現在の値のまま変更しない
 func UpdateTodoById(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/todos/")
 	id, err := strconv.Atoi(idStr)
@@ -48,41 +87,21 @@ func UpdateTodoById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var updatedTodo model.Todo
+	// 省略した項目は変更しない
+	var updatedTodo model.TodoUpdate
 	if err := json.NewDecoder(r.Body).Decode(&updatedTodo); err != nil {
 		response.WriteTodoResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
 		return
 	}
 
-	// 入力値のバリデーション
-	if err := validator.TodoInput(updatedTodo); err != nil {
-		response.WriteTodoResponse(w, nil, http.StatusBadRequest, err.Error())
-		return
-	}
-
-	db := database.GetDB()
-
-	var existingTodo model.Todo
-	checkQuery := "SELECT id, title, is_complete FROM todos WHERE id = ?"
-	if err := db.QueryRow(checkQuery, id).Scan(&existingTodo.ID, &existingTodo.Title, &existingTodo.IsComplete); err != nil {
-		if err == sql.ErrNoRows {
-			response.WriteTodoResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
-		} else {
-			response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO_ROW)
-		}
+	opts, errMessage := parseUpdateOptions(r)
+	if errMessage != "" {
+		response.WriteTodoResponse(w, nil, http.StatusBadRequest, errMessage)
 		return
 	}
 
-	updateQuery := "UPDATE todos SET title = ?, is_complete = ? WHERE id = ?"
-	result, err := db.Exec(updateQuery, updatedTodo.Title, updatedTodo.IsComplete, id)
-	if err != nil {
-		response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.DB_ERR_FAILED_UPDATE_TODO)
-	}
-
-	// ResultインターフェースのRowsAffected()は更新された行数を返す。
-	rowsAffected, err := result.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		response.WriteTodoResponse(w, nil, http.StatusNotFound, constant.DB_ERR_NOT_UPDATED_TODO)
+	if _, mErr := updateTodo(r.Context(), id, updatedTodo, opts); mErr != nil {
+		response.WriteTodoResponse(w, nil, mErr.code, mErr.message)
 		return
 	}
 
@@ -98,18 +117,23 @@ func DeleteTodoById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db := database.GetDB()
-	result, err := db.Exec("DELETE FROM todos WHERE id = ?", id)
-	if err != nil {
-		response.WriteTodoResponse(w, nil, http.StatusInternalServerError, constant.DB_ERR_FAILED_DELETE_TODO)
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		response.WriteTodoResponse(w, nil, http.StatusNotFound, constant.DB_ERR_DELETED_TODO)
+	if mErr := deleteTodo(r.Context(), id); mErr != nil {
+		response.WriteTodoResponse(w, nil, mErr.code, mErr.message)
 		return
 	}
 
 	response.WriteTodoResponse(w, nil, http.StatusOK, "")
 }
+
+// クエリパラメータから更新時の動作の指定を読み込む。不正な値の場合はエラーメッセージを返す
+func parseUpdateOptions(r *http.Request) (updateOptions, string) {
+	var opts updateOptions
+	if allowBlocked := r.URL.Query().Get("allow_blocked"); allowBlocked != "" {
+		var err error
+		opts.allowBlocked, err = strconv.ParseBool(allowBlocked)
+		if err != nil {
+			return updateOptions{}, constant.INPUT_ERR_INVALID_ALLOW_BLOCKED
+		}
+	}
+	return opts, ""
+}
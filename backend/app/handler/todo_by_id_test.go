@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 )
@@ -22,15 +23,15 @@ func TestGetTodoById(t *testing.T) {
 		"正常系": {
 			ID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, title, is_complete FROM todos WHERE id = ?").
-					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "is_complete"}).
-						AddRow(1, "title1", false))
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).
+						AddRow(1, "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil))
 			},
 			wantStatusCode: http.StatusOK,
 			wantBody: createTodoResponse(
 				t,
-				&model.Todo{ID: 1, Title: "title1", IsComplete: false},
+				&model.Todo{ID: 1, Title: "title1", IsComplete: false, Status: "todo", Revision: 1},
 				http.StatusOK,
 				"",
 			),
@@ -49,8 +50,8 @@ func TestGetTodoById(t *testing.T) {
 		"TODOが存在しない": {
 			ID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, title, is_complete FROM todos WHERE id = ?").
-					WithArgs(1).
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, testWorkspaceID).
 					WillReturnError(sql.ErrNoRows)
 			},
 			wantStatusCode: http.StatusNotFound,
@@ -64,8 +65,8 @@ func TestGetTodoById(t *testing.T) {
 		"クエリ失敗": {
 			ID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, title, is_complete FROM todos WHERE id = ?").
-					WithArgs(1).
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, testWorkspaceID).
 					WillReturnError(sql.ErrConnDone)
 			},
 			wantStatusCode: http.StatusInternalServerError,
@@ -100,6 +101,7 @@ func TestGetTodoById(t *testing.T) {
 }
 
 func TestUpdateTodoById(t *testing.T) {
+	dueAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
 	cases := map[string]struct {
 		ID             int
 		inputBody      string
@@ -111,13 +113,21 @@ func TestUpdateTodoById(t *testing.T) {
 			ID:        1,
 			inputBody: `{"title": "Updated Title", "is_complete": true}`,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(`^SELECT id, title, is_complete FROM todos WHERE id = \?$`).
-					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "is_complete"}).
-						AddRow(1, "Existing Title", false))
-				mock.ExpectExec(`^UPDATE todos SET title = \?, is_complete = \? WHERE id = \?$`).
-					WithArgs("Updated Title", true, 1).
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).
+						AddRow(1, "Existing Title", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+				expectOpenBlockers(mock, 1)
+				mock.ExpectExec(`^UPDATE todos SET title = \?, is_complete = \?, list_id = \?, due_at = \?, recurrence = \?, time_zone = \?, notes = \?, status = \?, estimate = \?, milestone_id = \?, revision = revision \+ 1 WHERE id = \? AND workspace_id = \? AND revision = \?$`).
+					WithArgs("Updated Title", true, nil, nil, "", "", "", "done", nil, nil, 1, testWorkspaceID, 1).
 					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRevision(mock, 1, 2)
+				expectAuditLog(mock, "update", 1)
+				expectChange(mock, 1, false)
+				expectOutbox(mock, "todo.updated")
+				expectOutbox(mock, "todo.completed")
+				mock.ExpectCommit()
 			},
 			wantStatusCode: http.StatusOK,
 			wantBody: createTodoResponse(
@@ -127,13 +137,90 @@ func TestUpdateTodoById(t *testing.T) {
 				"",
 			),
 		},
+		"省略した項目は変更しない": {
+			ID:        1,
+			inputBody: `{"title": "Updated Title"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).
+						AddRow(1, "Existing Title", false, 1, 3, dueAt, "FREQ=DAILY", "Asia/Tokyo", "memo", "todo", 5, 4))
+				mock.ExpectQuery(`^SELECT id FROM lists WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(3, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(`^SELECT id FROM milestones WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(4, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+				expectWorkflow(mock)
+				mock.ExpectExec(`^UPDATE todos SET title = \?, is_complete = \?, list_id = \?, due_at = \?, recurrence = \?, time_zone = \?, notes = \?, status = \?, estimate = \?, milestone_id = \?, revision = revision \+ 1 WHERE id = \? AND workspace_id = \? AND revision = \?$`).
+					WithArgs("Updated Title", false, 3, dueAt, "FREQ=DAILY", "Asia/Tokyo", "memo", "todo", 5, 4, 1, testWorkspaceID, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRevision(mock, 1, 2)
+				expectAuditLog(mock, "update", 1)
+				expectChange(mock, 1, false)
+				expectOutbox(mock, "todo.updated")
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody: createTodoResponse(
+				t,
+				nil,
+				http.StatusOK,
+				"",
+			),
+		},
+		"リビジョンが一致しない": {
+			ID:        1,
+			inputBody: `{"title": "Updated Title", "is_complete": true, "revision": 1}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).
+						AddRow(1, "Existing Title", false, 2, nil, nil, "", "", "", "todo", nil, nil))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusConflict,
+			wantBody: createTodoResponse(
+				t,
+				nil,
+				http.StatusConflict,
+				"TODOは他の操作によって更新されています。最新の状態を取得してください。",
+			),
+		},
+		"同時更新による競合": {
+			ID:        1,
+			inputBody: `{"title": "Updated Title", "is_complete": true}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).
+						AddRow(1, "Existing Title", false, 2, nil, nil, "", "", "", "todo", nil, nil))
+				expectOpenBlockers(mock, 1)
+				mock.ExpectExec(`^UPDATE todos`).
+					WithArgs("Updated Title", true, nil, nil, "", "", "", "done", nil, nil, 1, testWorkspaceID, 2).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusConflict,
+			wantBody: createTodoResponse(
+				t,
+				nil,
+				http.StatusConflict,
+				"TODOは他の操作によって更新されています。最新の状態を取得してください。",
+			),
+		},
 		"TODOが見つかりません": {
 			ID:        1,
 			inputBody: `{"title": "Updated Title", "is_complete": true}`,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, title, is_complete FROM todos WHERE id = ?").
-					WithArgs(1).
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, testWorkspaceID).
 					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
 			},
 			wantStatusCode: http.StatusNotFound,
 			wantBody: createTodoResponse(
@@ -147,9 +234,11 @@ func TestUpdateTodoById(t *testing.T) {
 			ID:        1,
 			inputBody: `{"title": "Updated Title", "is_complete": true}`,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, title, is_complete FROM todos WHERE id = ?").
-					WithArgs(1).
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, testWorkspaceID).
 					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
 			},
 			wantStatusCode: http.StatusInternalServerError,
 			wantBody: createTodoResponse(
@@ -192,9 +281,20 @@ func TestDeleteTodoById(t *testing.T) {
 		"正常系": {
 			ID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM todos WHERE id = ?").
-					WithArgs(1).
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).
+						AddRow(1, "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+				mock.ExpectExec(`DELETE FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, testWorkspaceID).
 					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectDependentsPurge(mock, 1)
+				expectAttachmentPurge(mock, 1)
+				expectAuditLog(mock, "delete", 1)
+				expectChange(mock, 1, true)
+				expectOutbox(mock, "todo.deleted")
+				mock.ExpectCommit()
 			},
 			wantStatusCode: http.StatusOK,
 			wantBody: createTodoResponse(
@@ -207,9 +307,11 @@ func TestDeleteTodoById(t *testing.T) {
 		"TODOが見つかりません": {
 			ID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM todos WHERE id = ?").
-					WithArgs(1).
-					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
 			},
 			wantStatusCode: http.StatusNotFound,
 			wantBody: createTodoResponse(
@@ -222,9 +324,15 @@ func TestDeleteTodoById(t *testing.T) {
 		"クエリ失敗": {
 			ID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectExec("DELETE FROM todos WHERE id = ?").
-					WithArgs(1).
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).
+						AddRow(1, "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+				mock.ExpectExec(`DELETE FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, testWorkspaceID).
 					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
 			},
 			wantStatusCode: http.StatusInternalServerError,
 			wantBody: createTodoResponse(
@@ -256,3 +364,64 @@ func TestDeleteTodoById(t *testing.T) {
 		})
 	}
 }
+
+// render=htmlを指定すると、メモをサニタイズしたHTMLも返すことを確認する
+func TestGetTodoByIdRenderNotes(t *testing.T) {
+	notes := "**牛乳**を買う <script>alert(1)</script>\n\n[店](javascript:alert(1))"
+
+	cases := map[string]struct {
+		query          string
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantBody       interface{}
+	}{
+		"HTMLに変換する": {
+			query: "?render=html",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT .* FROM todos WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "買い物", false, 1, nil, nil, "", "", notes, "todo", nil, nil))
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody: createTodoResponse(t, &model.Todo{
+				ID: 1, Title: "買い物", Status: "todo", Revision: 1, Notes: notes,
+				NotesHTML: "<p><strong>牛乳</strong>を買う &lt;script&gt;alert(1)&lt;/script&gt;</p>\n<p>店)</p>",
+			}, http.StatusOK, ""),
+		},
+		"指定しない場合はMarkdownのみ": {
+			query: "",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT .* FROM todos WHERE id = \? AND workspace_id = \?$`).
+					WithArgs(1, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "買い物", false, 1, nil, nil, "", "", notes, "todo", nil, nil))
+			},
+			wantStatusCode: http.StatusOK,
+			wantBody:       createTodoResponse(t, &model.Todo{ID: 1, Title: "買い物", Status: "todo", Revision: 1, Notes: notes}, http.StatusOK, ""),
+		},
+		"renderが不正": {
+			query:          "?render=pdf",
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+			wantBody:       createTodoResponse(t, nil, http.StatusBadRequest, "renderの指定が不正です。"),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodGet, "/todos/1"+c.query, "")
+
+			handler.GetTodoById(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.TodoResponse](t, rec)
+			checkResponseBody(t, c.wantBody, got)
+		})
+	}
+}
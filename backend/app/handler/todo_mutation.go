@@ -0,0 +1,547 @@
+package handler
+
+import (
+	"backend/app/audit"
+	"backend/app/blob"
+	"backend/app/changelog"
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/event"
+	"backend/app/history"
+	"backend/app/model"
+	"backend/app/recurrence"
+	"backend/app/requestctx"
+	"backend/app/validator"
+	"backend/app/workflow"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Todoの変更処理は、HTTPとWebSocketの両方から同じ検証・記録を通して実行する。
+
+// mutationErrorは、Todoの変更に失敗した際に返却するステータスコードとメッセージ
+type mutationError struct {
+	code    int
+	message string
+	// 読み取り後に他の更新が割り込み、リビジョンが一致しなかった
+	revisionConflict bool
+}
+
+func (e *mutationError) Error() string {
+	return e.message
+}
+
+func newMutationError(code int, message string) *mutationError {
+	return &mutationError{code: code, message: message}
+}
+
+// リビジョンが一致しない場合のエラー。最新の状態から読み直せば適用できる可能性がある
+func newRevisionConflictError() *mutationError {
+	return &mutationError{code: http.StatusConflict, message: constant.DB_ERR_CONFLICT_TODO, revisionConflict: true}
+}
+
+// 取得時にのみ返す項目は、入力されても保存しない
+func clearDerivedFields(todo *model.Todo) {
+	todo.NotesHTML = ""
+	todo.Checklist = nil
+	todo.Assignees = nil
+	todo.TimeSpent = nil
+}
+
+// Todoを作成し、作成したTodoを返す
+func createTodo(ctx context.Context, newTodo model.Todo) (*model.Todo, *mutationError) {
+	clearDerivedFields(&newTodo)
+
+	// 入力値のバリデーション
+	if err := validator.TodoInput(newTodo); err != nil {
+		return nil, newMutationError(http.StatusBadRequest, err.Error())
+	}
+
+	workspaceID := requestctx.WorkspaceID(ctx)
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_ADD_TODO)
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	if mErr := checkListExists(tx, workspaceID, newTodo.ListID); mErr != nil {
+		return nil, mErr
+	}
+	if mErr := checkMilestoneExists(tx, workspaceID, newTodo.MilestoneID); mErr != nil {
+		return nil, mErr
+	}
+
+	wf, err := loadWorkflow(tx, workspaceID, newTodo.ListID)
+	if err != nil {
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_ADD_TODO)
+	}
+	if err := workflow.Resolve(wf, nil, &newTodo); err != nil {
+		return nil, workflowMutationError(err)
+	}
+
+	created, err := insertTodo(ctx, tx, &newTodo)
+	if err != nil {
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_ADD_TODO)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_ADD_TODO)
+	}
+
+	publishTodoEvents(created)
+	return &newTodo, nil
+}
+
+// updateOptionsは、Todoの更新時の動作の指定
+type updateOptions struct {
+	// 未完了のTodoに依存している場合も完了できるようにする
+	allowBlocked bool
+}
+
+// IDを指定してTodoを更新し、更新後のTodoを返す。省略された項目は現在の値のまま変更しない
+func updateTodo(ctx context.Context, id int, update model.TodoUpdate, opts updateOptions) (*model.Todo, *mutationError) {
+	updatedTodo := update.Todo
+	clearDerivedFields(&updatedTodo)
+
+	workspaceID := requestctx.WorkspaceID(ctx)
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_UPDATE_TODO)
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	var existingTodo model.Todo
+	checkQuery := "SELECT " + todoColumns + " FROM todos WHERE id = ? AND workspace_id = ?"
+	if err := scanTodo(tx.QueryRow(checkQuery, id, workspaceID), &existingTodo); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, newMutationError(http.StatusNotFound, constant.DB_ERR_NOT_FOUND_TODO)
+		}
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO_ROW)
+	}
+
+	// 入力値のバリデーションは、省略された項目を補ってから行う
+	keepOmittedFields(&updatedTodo, &existingTodo, update.Fields)
+	if err := validator.TodoInput(updatedTodo); err != nil {
+		return nil, newMutationError(http.StatusBadRequest, err.Error())
+	}
+
+	// リビジョンが指定された場合は、取得済みのTodoと一致しなければ競合として扱う
+	if updatedTodo.Revision != 0 && updatedTodo.Revision != existingTodo.Revision {
+		return nil, newRevisionConflictError()
+	}
+
+	if mErr := checkListExists(tx, workspaceID, updatedTodo.ListID); mErr != nil {
+		return nil, mErr
+	}
+	if mErr := checkMilestoneExists(tx, workspaceID, updatedTodo.MilestoneID); mErr != nil {
+		return nil, mErr
+	}
+
+	// ステータスと完了状態は、移動先のリストのワークフローに従って決める
+	wf, err := loadWorkflow(tx, workspaceID, updatedTodo.ListID)
+	if err != nil {
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_UPDATE_TODO)
+	}
+	if err := workflow.Resolve(wf, &existingTodo, &updatedTodo); err != nil {
+		return nil, workflowMutationError(err)
+	}
+
+	if !existingTodo.IsComplete && updatedTodo.IsComplete {
+		if mErr := checkBlockers(tx, workspaceID, id, opts, constant.DB_ERR_FAILED_UPDATE_TODO); mErr != nil {
+			return nil, mErr
+		}
+	}
+
+	// 読み取り後に他の更新が割り込んだ場合は、リビジョンが一致せず更新されない
+	updateQuery := "UPDATE todos SET title = ?, is_complete = ?, list_id = ?, due_at = ?, recurrence = ?, time_zone = ?, notes = ?, status = ?, estimate = ?, milestone_id = ?, revision = revision + 1 " +
+		"WHERE id = ? AND workspace_id = ? AND revision = ?"
+	result, err := tx.Exec(
+		updateQuery,
+		updatedTodo.Title, updatedTodo.IsComplete, updatedTodo.ListID, updatedTodo.DueAt, updatedTodo.Recurrence, updatedTodo.TimeZone, updatedTodo.Notes, updatedTodo.Status, updatedTodo.Estimate, updatedTodo.MilestoneID,
+		id, workspaceID, existingTodo.Revision,
+	)
+	if err != nil {
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_UPDATE_TODO)
+	}
+
+	// ResultインターフェースのRowsAffected()は更新された行数を返す。
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, newMutationError(http.StatusNotFound, constant.DB_ERR_NOT_UPDATED_TODO)
+	}
+	if rowsAffected == 0 {
+		return nil, newRevisionConflictError()
+	}
+
+	updatedTodo.ID = id
+	updatedTodo.Revision = existingTodo.Revision + 1
+	if err := history.Record(ctx, tx, &updatedTodo); err != nil {
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_UPDATE_TODO)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, id, &existingTodo, &updatedTodo); err != nil {
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_UPDATE_TODO)
+	}
+
+	if err := changelog.Record(ctx, tx, id, false); err != nil {
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_UPDATE_TODO)
+	}
+
+	events, err := recordUpdateEvents(ctx, tx, &existingTodo, &updatedTodo)
+	if err != nil {
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_UPDATE_TODO)
+	}
+
+	// 繰り返すTODOを完了した場合は、次の回を作成して繰り返しのルールを引き継ぐ。
+	// 完了した回にも、どの繰り返しの回だったかがわかるようにルールを残す
+	if !existingTodo.IsComplete && updatedTodo.IsComplete {
+		created, err := createNextOccurrence(ctx, tx, wf, &updatedTodo)
+		if err != nil {
+			return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_UPDATE_TODO)
+		}
+		events = append(events, created...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_UPDATE_TODO)
+	}
+
+	publishTodoEvents(events...)
+	return &updatedTodo, nil
+}
+
+// 更新で省略された項目を、現在の値で補う。fieldsがnilの場合は、すべての項目が指定されたものとして扱う。
+// ステータスは、省略された場合に完了状態の変更からworkflow.Resolveが決める
+func keepOmittedFields(todo, existing *model.Todo, fields map[string]bool) {
+	if fields == nil {
+		return
+	}
+	if !fields["title"] {
+		todo.Title = existing.Title
+	}
+	if !fields["is_complete"] {
+		todo.IsComplete = existing.IsComplete
+	}
+	if !fields["list_id"] {
+		todo.ListID = existing.ListID
+	}
+	if !fields["due_at"] {
+		todo.DueAt = existing.DueAt
+	}
+	if !fields["recurrence"] {
+		todo.Recurrence = existing.Recurrence
+	}
+	if !fields["time_zone"] {
+		todo.TimeZone = existing.TimeZone
+	}
+	if !fields["notes"] {
+		todo.Notes = existing.Notes
+	}
+	if !fields["estimate"] {
+		todo.Estimate = existing.Estimate
+	}
+	if !fields["milestone_id"] {
+		todo.MilestoneID = existing.MilestoneID
+	}
+}
+
+// IDを指定してTodoを削除する
+func deleteTodo(ctx context.Context, id int) *mutationError {
+	workspaceID := requestctx.WorkspaceID(ctx)
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		return newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_DELETE_TODO)
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	// 監査ログに削除前の状態を残すため、削除対象を取得しておく
+	var existingTodo model.Todo
+	checkQuery := "SELECT " + todoColumns + " FROM todos WHERE id = ? AND workspace_id = ?"
+	if err := scanTodo(tx.QueryRow(checkQuery, id, workspaceID), &existingTodo); err != nil {
+		if err == sql.ErrNoRows {
+			return newMutationError(http.StatusNotFound, constant.DB_ERR_DELETED_TODO)
+		}
+		return newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_DELETE_TODO)
+	}
+
+	result, err := tx.Exec("DELETE FROM todos WHERE id = ? AND workspace_id = ?", id, workspaceID)
+	if err != nil {
+		return newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_DELETE_TODO)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil || rowsAffected == 0 {
+		return newMutationError(http.StatusNotFound, constant.DB_ERR_DELETED_TODO)
+	}
+
+	if err := purgeTodoDependents(tx, workspaceID, id); err != nil {
+		return newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_DELETE_TODO)
+	}
+
+	// 添付ファイルの内容は、参照がなくなったことをコミット後に確認して削除する
+	blobKeys, err := purgeAttachments(tx, workspaceID, id)
+	if err != nil {
+		return newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_DELETE_TODO)
+	}
+
+	if err := audit.Record(ctx, tx, audit.ActionDelete, id, &existingTodo, nil); err != nil {
+		return newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_DELETE_TODO)
+	}
+
+	if err := changelog.Record(ctx, tx, id, true); err != nil {
+		return newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_DELETE_TODO)
+	}
+
+	deleted, err := recordTodoEvent(ctx, tx, event.TodoDeleted, id, existingTodo.ListID, nil)
+	if err != nil {
+		return newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_DELETE_TODO)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return newMutationError(http.StatusInternalServerError, constant.DB_ERR_FAILED_DELETE_TODO)
+	}
+
+	releaseBlobs(ctx, db, blob.Default(), blobKeys)
+	publishTodoEvents(deleted)
+	return nil
+}
+
+// Todoに属するデータを変更する前に、Todoをロックして読み込む
+func lockTodoForChange(tx *sql.Tx, workspaceID, todoID int) (model.Todo, error) {
+	var todo model.Todo
+	err := scanTodo(tx.QueryRow("SELECT "+todoColumns+" FROM todos WHERE id = ? AND workspace_id = ? FOR UPDATE", todoID, workspaceID), &todo)
+	return todo, err
+}
+
+// Todoに属するデータ（チェックリスト・タグ・担当者）の変更を、Todoの変更として記録する。
+// リビジョンを進めて履歴・監査ログ・同期用の変更・todo.updatedイベントを記録し、同期しているクライアントや購読者が変更を取得できるようにする。
+// todoはlockTodoForChangeで読み込んだもの。履歴にはtodosの項目のみを記録し、チェックリストなどの付随する項目は含めない
+func recordRelatedChange(ctx context.Context, tx *sql.Tx, todo *model.Todo) (event.Event, error) {
+	clearDerivedFields(todo)
+	before := *todo
+
+	if _, err := tx.Exec("UPDATE todos SET revision = revision + 1 WHERE id = ? AND workspace_id = ?", todo.ID, requestctx.WorkspaceID(ctx)); err != nil {
+		return event.Event{}, err
+	}
+	todo.Revision++
+
+	if err := history.Record(ctx, tx, todo); err != nil {
+		return event.Event{}, err
+	}
+	if err := audit.Record(ctx, tx, audit.ActionUpdate, todo.ID, &before, todo); err != nil {
+		return event.Event{}, err
+	}
+	if err := changelog.Record(ctx, tx, todo.ID, false); err != nil {
+		return event.Event{}, err
+	}
+	return recordTodoEvent(ctx, tx, event.TodoUpdated, todo.ID, todo.ListID, todo)
+}
+
+// Todoに属するチェックリスト・タグ・コメント・担当者・依存関係・作業時間・繰り返しの記録を削除する。
+// 依存関係は、削除するTodoが依存しているものと、他のTodoが削除するTodoに依存しているものの両方を削除する
+func purgeTodoDependents(tx *sql.Tx, workspaceID, todoID int) error {
+	queries := []string{
+		"DELETE FROM checklist_items WHERE todo_id = ? AND workspace_id = ?",
+		"DELETE FROM todo_tags WHERE todo_id = ? AND workspace_id = ?",
+		"DELETE FROM comments WHERE todo_id = ? AND workspace_id = ?",
+		"DELETE FROM todo_assignees WHERE todo_id = ? AND workspace_id = ?",
+		"DELETE FROM time_entries WHERE todo_id = ? AND workspace_id = ?",
+		"DELETE FROM todo_occurrences WHERE todo_id = ? AND workspace_id = ?",
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, todoID, workspaceID); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec("DELETE FROM todo_dependencies WHERE (todo_id = ? OR blocker_id = ?) AND workspace_id = ?", todoID, todoID, workspaceID)
+	return err
+}
+
+// Todoを追加し、履歴・監査ログ・同期用の変更・イベントを記録する。
+// 追加したTodoのIDとリビジョンはtodoに設定する。
+func insertTodo(ctx context.Context, tx *sql.Tx, todo *model.Todo) (event.Event, error) {
+	// 作成時のリビジョンは常に1から始める
+	todo.Revision = 1
+	insertQuery := "INSERT INTO todos (workspace_id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := tx.Exec(
+		insertQuery,
+		requestctx.WorkspaceID(ctx), todo.Title, todo.IsComplete, todo.Revision, todo.ListID, todo.DueAt, todo.Recurrence, todo.TimeZone, todo.Notes, todo.Status, todo.Estimate, todo.MilestoneID,
+	)
+	if err != nil {
+		return event.Event{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return event.Event{}, err
+	}
+	todo.ID = int(id)
+
+	if err := history.Record(ctx, tx, todo); err != nil {
+		return event.Event{}, err
+	}
+	if err := audit.Record(ctx, tx, audit.ActionCreate, todo.ID, nil, todo); err != nil {
+		return event.Event{}, err
+	}
+	if err := changelog.Record(ctx, tx, todo.ID, false); err != nil {
+		return event.Event{}, err
+	}
+	return recordTodoEvent(ctx, tx, event.TodoCreated, todo.ID, todo.ListID, todo)
+}
+
+// 未完了のTodoに依存している場合は、明示的に許可しない限り完了できない。
+// 読み直しても解消しないため、リビジョンの競合とは区別して422を返す。確認に失敗した場合はfailedMessageを返す
+func checkBlockers(tx *sql.Tx, workspaceID, id int, opts updateOptions, failedMessage string) *mutationError {
+	if opts.allowBlocked {
+		return nil
+	}
+	blockers, err := openBlockers(tx, workspaceID, id)
+	if err != nil {
+		return newMutationError(http.StatusInternalServerError, failedMessage)
+	}
+	if len(blockers) > 0 {
+		return newMutationError(http.StatusUnprocessableEntity, fmt.Sprintf(constant.DEPENDENCY_ERR_BLOCKED, joinIDs(blockers, ", ")))
+	}
+	return nil
+}
+
+// 完了した繰り返すTODOの次の回を作成し、作成のイベントを返す。
+// 次の回は完了した回ごとに1つまでとし、todo_occurrencesに記録する。完了を取り消して再度完了した場合や、
+// 作成した次の回を削除した場合は作成し直さない。完了したTodoの行はロック済みのため、同時に完了しても重複しない
+func createNextOccurrence(ctx context.Context, tx *sql.Tx, wf model.Workflow, todo *model.Todo) ([]event.Event, error) {
+	next := nextOccurrence(*todo)
+	if next == nil {
+		return nil, nil
+	}
+	next.Status = workflow.Initial(wf)
+
+	workspaceID := requestctx.WorkspaceID(ctx)
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM todo_occurrences WHERE todo_id = ? AND workspace_id = ?", todo.ID, workspaceID).Scan(&count); err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, nil
+	}
+
+	created, err := insertTodo(ctx, tx, next)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("INSERT INTO todo_occurrences (workspace_id, todo_id, next_todo_id) VALUES (?, ?, ?)", workspaceID, todo.ID, next.ID); err != nil {
+		return nil, err
+	}
+	return []event.Event{created}, nil
+}
+
+// 繰り返すTODOの次の回を返す。繰り返しでない場合や、繰り返しが終了した場合はnilを返す。
+func nextOccurrence(todo model.Todo) *model.Todo {
+	if todo.Recurrence == "" || todo.DueAt == nil {
+		return nil
+	}
+	rule, err := recurrence.Parse(todo.Recurrence)
+	if err != nil {
+		return nil
+	}
+	loc, err := loadTimeZone(todo.TimeZone)
+	if err != nil {
+		return nil
+	}
+
+	nextAt, rest, ok := rule.Next(todo.DueAt.In(loc))
+	if !ok {
+		return nil
+	}
+	nextAt = nextAt.UTC()
+	// 見積もりは引き継ぐが、次の回は別の期間に行うため、マイルストーンは引き継がない
+	return &model.Todo{
+		Title:      todo.Title,
+		ListID:     todo.ListID,
+		DueAt:      &nextAt,
+		Recurrence: rest.String(),
+		TimeZone:   todo.TimeZone,
+		Estimate:   todo.Estimate,
+	}
+}
+
+// タイムゾーンを読み込む。省略した場合はUTCとする
+func loadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// 更新のイベントを記録する。未完了から完了に変わった場合は、完了のイベントも記録する。
+func recordUpdateEvents(ctx context.Context, tx *sql.Tx, before, after *model.Todo) ([]event.Event, error) {
+	updated, err := recordTodoEvent(ctx, tx, event.TodoUpdated, after.ID, after.ListID, after)
+	if err != nil {
+		return nil, err
+	}
+	events := []event.Event{updated}
+
+	if !before.IsComplete && after.IsComplete {
+		completed, err := recordTodoEvent(ctx, tx, event.TodoCompleted, after.ID, after.ListID, after)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, completed)
+	}
+	return events, nil
+}
+
+// ワークフローの規則に反する場合のエラーを、返却するステータスコードとメッセージに変換する。
+// 許可していない変更は読み直しても解消しないため、リビジョンの競合とは区別して422を返す
+func workflowMutationError(err error) *mutationError {
+	var transitionErr *workflow.TransitionError
+	if errors.As(err, &transitionErr) {
+		return newMutationError(http.StatusUnprocessableEntity, err.Error())
+	}
+	return newMutationError(http.StatusBadRequest, err.Error())
+}
+
+// リストが指定された場合は、同じワークスペースに存在することを確認する
+func checkListExists(tx *sql.Tx, workspaceID int, listID *int) *mutationError {
+	if listID == nil {
+		return nil
+	}
+
+	var id int
+	if err := tx.QueryRow("SELECT id FROM lists WHERE id = ? AND workspace_id = ?", *listID, workspaceID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return newMutationError(http.StatusBadRequest, constant.LIST_ERR_NOT_FOUND_LIST)
+		}
+		return newMutationError(http.StatusInternalServerError, constant.LIST_ERR_FAILED_GET_LIST)
+	}
+	return nil
+}
+
+// マイルストーンが指定された場合は、同じワークスペースに存在することを確認する
+func checkMilestoneExists(tx *sql.Tx, workspaceID int, milestoneID *int) *mutationError {
+	if milestoneID == nil {
+		return nil
+	}
+
+	var id int
+	if err := tx.QueryRow("SELECT id FROM milestones WHERE id = ? AND workspace_id = ?", *milestoneID, workspaceID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return newMutationError(http.StatusBadRequest, constant.MILESTONE_ERR_NOT_FOUND_MILESTONE)
+		}
+		return newMutationError(http.StatusInternalServerError, constant.MILESTONE_ERR_FAILED_GET_MILESTONE)
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -19,22 +20,24 @@ func TestGetTodos(t *testing.T) {
 	}{
 		"正常系": {
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, title, is_complete FROM todos").
-					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "is_complete"}).
-						AddRow(1, "title1", false).
-						AddRow(2, "title2", true))
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE workspace_id = \?`).
+					WithArgs(testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).
+						AddRow(1, "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil).
+						AddRow(2, "title2", true, 1, nil, nil, "", "", "", "done", nil, nil))
 			},
 			wantStatusCode: http.StatusOK,
 			wantBody: createTodosResponse(
 				t,
-				[]model.Todo{{ID: 1, Title: "title1", IsComplete: false}, {ID: 2, Title: "title2", IsComplete: true}},
+				[]model.Todo{{ID: 1, Title: "title1", IsComplete: false, Status: "todo", Revision: 1}, {ID: 2, Title: "title2", IsComplete: true, Status: "done", Revision: 1}},
 				http.StatusOK,
 				"",
 			),
 		},
 		"クエリ失敗": {
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, title, is_complete FROM todos").
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE workspace_id = \?`).
+					WithArgs(testWorkspaceID).
 					WillReturnError(fmt.Errorf("DBエラー"))
 			},
 			wantStatusCode: http.StatusInternalServerError,
@@ -47,9 +50,10 @@ func TestGetTodos(t *testing.T) {
 		},
 		"行スキャン失敗": {
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, title, is_complete FROM todos").
-					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "is_complete"}).
-						AddRow("不正なID", "title1", false))
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE workspace_id = \?`).
+					WithArgs(testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns).
+						AddRow("不正なID", "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil))
 			},
 			wantStatusCode: http.StatusInternalServerError,
 			wantBody: createTodosResponse(
@@ -91,9 +95,15 @@ func TestCreateTodo(t *testing.T) {
 		"正常系": {
 			inputBody: `{"title": "新しいタスク", "is_complete": false}`,
 			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
 				mock.ExpectExec(`INSERT INTO todos`).
-					WithArgs("新しいタスク", false).
+					WithArgs(testWorkspaceID, "新しいタスク", false, 1, nil, nil, "", "", "", "todo", nil, nil).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectRevision(mock, 1, 1)
+				expectAuditLog(mock, "create", 1)
+				expectChange(mock, 1, false)
+				expectOutbox(mock, "todo.created")
+				mock.ExpectCommit()
 			},
 			wantStatusCode: http.StatusCreated,
 			wantBody: createTodosResponse(
@@ -117,9 +127,11 @@ func TestCreateTodo(t *testing.T) {
 		"DB追加失敗": {
 			inputBody: `{"title": "新しいタスク", "is_complete": false}`,
 			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
 				mock.ExpectExec(`INSERT INTO todos`).
-					WithArgs("新しいタスク", false).
+					WithArgs(testWorkspaceID, "新しいタスク", false, 1, nil, nil, "", "", "", "todo", nil, nil).
 					WillReturnError(fmt.Errorf("DBエラー"))
+				mock.ExpectRollback()
 			},
 			wantStatusCode: http.StatusInternalServerError,
 			wantBody: createTodosResponse(
@@ -150,3 +162,58 @@ func TestCreateTodo(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTodosWithFilter(t *testing.T) {
+	t.Run("フィルターに一致するTodoを取得", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`^SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE workspace_id = \? AND \(is_complete = \? AND EXISTS \(SELECT 1 FROM todo_tags WHERE todo_tags\.todo_id = todos\.id AND todo_tags\.tag = \?\)\)$`).
+			WithArgs(testWorkspaceID, false, "work").
+			WillReturnRows(sqlmock.NewRows(todoRowColumns).
+				AddRow(1, "title1", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodGet, "/todos?filter="+url.QueryEscape("is:open tag:Work"), "")
+
+		handler.GetTodos(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusOK, rec.Code)
+		checkResponseBody(t, createTodosResponse(t, []model.Todo{{ID: 1, Title: "title1", Status: "todo", Revision: 1}}, http.StatusOK, ""), decodeResponseBody[model.TodosResponse](t, rec))
+	})
+
+	t.Run("フィルターの構文が不正", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodGet, "/todos?filter="+url.QueryEscape("is:open color:red"), "")
+
+		handler.GetTodos(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+		got := decodeResponseBody[model.TodosResponse](t, rec)
+		if got.Status.ErrorMessage != "フィルターが不正です。9文字目: 不明なキー「color」です。" {
+			t.Errorf("エラーメッセージが不正です: %s", got.Status.ErrorMessage)
+		}
+	})
+
+	t.Run("フィルターのタイムゾーンが不正", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodGet, "/todos?filter="+url.QueryEscape("is:open")+"&time_zone=Mars/Olympus", "")
+
+		handler.GetTodos(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+		got := decodeResponseBody[model.TodosResponse](t, rec)
+		if got.Status.ErrorMessage != "フィルターのタイムゾーンが不正です。" {
+			t.Errorf("エラーメッセージが不正です: %s", got.Status.ErrorMessage)
+		}
+	})
+}
@@ -0,0 +1,20 @@
+package handler
+
+import "strings"
+
+// ユーザー自身の確認済みのメールアドレスを取得する。
+// 確認済みのアドレスがない場合はsql.ErrNoRowsを返す
+func verifiedEmail(q querier, userID int) (string, error) {
+	var email string
+	query := "SELECT email FROM user_emails WHERE user_id = ? AND verified_at IS NOT NULL"
+	if err := q.QueryRow(query, userID).Scan(&email); err != nil {
+		return "", err
+	}
+	return email, nil
+}
+
+// 指定された宛先が、ユーザー自身の確認済みのアドレスかどうかを判定する。
+// 宛先を省略した場合は自身のアドレスとみなし、大文字と小文字は区別せずに比較する
+func isOwnEmail(own, target string) bool {
+	return target == "" || strings.EqualFold(own, target)
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/netguard"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"backend/app/webhook"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 一度に返却する配信履歴の最大件数
+const webhookDeliveryLimit = 100
+
+// Webhookを登録する。シークレットを省略した場合は生成して返却する。
+// 特定のユーザー宛てのイベント（リマインダーなど）は、登録したユーザーのWebhookにのみ配信する。
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID := requestctx.UserID(r.Context())
+	if userID == 0 {
+		response.WriteWebhookResponse(w, nil, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+
+	var input model.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteWebhookResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+
+	// 入力値のバリデーション
+	if err := validator.WebhookInput(input); err != nil {
+		response.WriteWebhookResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+	// 送信先が内部のアドレスに解決される場合は登録しない。配信時にも接続先を再検証する
+	u, _ := url.Parse(input.URL)
+	if err := netguard.CheckHost(r.Context(), u.Hostname()); err != nil {
+		errMessage := constant.WEBHOOK_ERR_UNRESOLVABLE_URL
+		if errors.Is(err, netguard.ErrForbiddenAddress) {
+			errMessage = constant.WEBHOOK_ERR_FORBIDDEN_URL
+		}
+		response.WriteWebhookResponse(w, nil, http.StatusBadRequest, errMessage)
+		return
+	}
+
+	if input.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			response.WriteWebhookResponse(w, nil, http.StatusInternalServerError, constant.WEBHOOK_ERR_FAILED_ADD_WEBHOOK)
+			return
+		}
+		input.Secret = hex.EncodeToString(b)
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	input.CreatedAt = time.Now()
+
+	db := database.GetDB()
+	insertQuery := "INSERT INTO webhooks (workspace_id, user_id, url, event_types, secret, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	result, err := db.Exec(insertQuery, workspaceID, userID, input.URL, strings.Join(input.EventTypes, ","), input.Secret, input.CreatedAt)
+	if err != nil {
+		response.WriteWebhookResponse(w, nil, http.StatusInternalServerError, constant.WEBHOOK_ERR_FAILED_ADD_WEBHOOK)
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		response.WriteWebhookResponse(w, nil, http.StatusInternalServerError, constant.WEBHOOK_ERR_FAILED_ADD_WEBHOOK)
+		return
+	}
+	input.ID = int(id)
+
+	response.WriteWebhookResponse(w, &input, http.StatusCreated, "")
+}
+
+// 登録済みのWebhookをすべて取得する。シークレットは返却しない。
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	query := "SELECT id, url, event_types, created_at FROM webhooks WHERE workspace_id = ? AND deleted_at IS NULL ORDER BY id"
+	rows, err := db.Query(query, workspaceID)
+	if err != nil {
+		response.WriteWebhooksResponse(w, []model.Webhook{}, http.StatusInternalServerError, constant.WEBHOOK_ERR_FAILED_GET_WEBHOOK)
+		return
+	}
+	defer rows.Close()
+
+	webhooks := []model.Webhook{}
+	for rows.Next() {
+		var (
+			hook       model.Webhook
+			eventTypes string
+		)
+		if err := rows.Scan(&hook.ID, &hook.URL, &eventTypes, &hook.CreatedAt); err != nil {
+			response.WriteWebhooksResponse(w, []model.Webhook{}, http.StatusInternalServerError, constant.WEBHOOK_ERR_FAILED_GET_WEBHOOK)
+			return
+		}
+		hook.EventTypes = strings.Split(eventTypes, ",")
+		webhooks = append(webhooks, hook)
+	}
+
+	response.WriteWebhooksResponse(w, webhooks, http.StatusOK, "")
+}
+
+// Webhookを削除する。配信待ちの配信は取り消し、配信履歴は残す。
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteWebhookResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteWebhookResponse(w, nil, http.StatusInternalServerError, constant.WEBHOOK_ERR_FAILED_DELETE_WEBHOOK)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	deleteQuery := "UPDATE webhooks SET deleted_at = ? WHERE id = ? AND workspace_id = ? AND deleted_at IS NULL"
+	result, err := tx.Exec(deleteQuery, time.Now(), id, workspaceID)
+	if err != nil {
+		response.WriteWebhookResponse(w, nil, http.StatusInternalServerError, constant.WEBHOOK_ERR_FAILED_DELETE_WEBHOOK)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		response.WriteWebhookResponse(w, nil, http.StatusNotFound, constant.WEBHOOK_ERR_NOT_FOUND_WEBHOOK)
+		return
+	}
+
+	cancelQuery := "UPDATE webhook_deliveries SET status = ? WHERE webhook_id = ? AND status = ?"
+	if _, err := tx.Exec(cancelQuery, webhook.StatusFailed, id, webhook.StatusPending); err != nil {
+		response.WriteWebhookResponse(w, nil, http.StatusInternalServerError, constant.WEBHOOK_ERR_FAILED_DELETE_WEBHOOK)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteWebhookResponse(w, nil, http.StatusInternalServerError, constant.WEBHOOK_ERR_FAILED_DELETE_WEBHOOK)
+		return
+	}
+
+	response.WriteWebhookResponse(w, nil, http.StatusOK, "")
+}
+
+// Webhookの配信履歴を新しい順に取得する
+func GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteWebhookDeliveriesResponse(w, []model.WebhookDelivery{}, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var webhookID int
+	if err := db.QueryRow("SELECT id FROM webhooks WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&webhookID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteWebhookDeliveriesResponse(w, []model.WebhookDelivery{}, http.StatusNotFound, constant.WEBHOOK_ERR_NOT_FOUND_WEBHOOK)
+		} else {
+			response.WriteWebhookDeliveriesResponse(w, []model.WebhookDelivery{}, http.StatusInternalServerError, constant.WEBHOOK_ERR_FAILED_GET_DELIVERY)
+		}
+		return
+	}
+
+	query := "SELECT id, webhook_id, event_type, status, attempts, last_status_code, last_error, next_attempt_at, created_at " +
+		"FROM webhook_deliveries WHERE webhook_id = ? AND workspace_id = ? ORDER BY id DESC LIMIT ?"
+	rows, err := db.Query(query, id, workspaceID, webhookDeliveryLimit)
+	if err != nil {
+		response.WriteWebhookDeliveriesResponse(w, []model.WebhookDelivery{}, http.StatusInternalServerError, constant.WEBHOOK_ERR_FAILED_GET_DELIVERY)
+		return
+	}
+	defer rows.Close()
+
+	deliveries := []model.WebhookDelivery{}
+	for rows.Next() {
+		var (
+			d              model.WebhookDelivery
+			lastStatusCode sql.NullInt64
+			lastError      sql.NullString
+		)
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventType, &d.Status, &d.Attempts, &lastStatusCode, &lastError, &d.NextAttemptAt, &d.CreatedAt); err != nil {
+			response.WriteWebhookDeliveriesResponse(w, []model.WebhookDelivery{}, http.StatusInternalServerError, constant.WEBHOOK_ERR_FAILED_GET_DELIVERY)
+			return
+		}
+		if lastStatusCode.Valid {
+			code := int(lastStatusCode.Int64)
+			d.LastStatusCode = &code
+		}
+		d.LastError = lastError.String
+		deliveries = append(deliveries, d)
+	}
+
+	response.WriteWebhookDeliveriesResponse(w, deliveries, http.StatusOK, "")
+}
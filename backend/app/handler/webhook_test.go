@@ -0,0 +1,237 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"backend/app/netguard"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// stubResolverは、固定のアドレスを返すResolverです。
+type stubResolver map[string][]netip.Addr
+
+func (r stubResolver) LookupNetIP(_ context.Context, _, host string) ([]netip.Addr, error) {
+	return r[host], nil
+}
+
+func TestCreateWebhook(t *testing.T) {
+	restore := netguard.SetResolver(stubResolver{
+		"example.com":      {netip.MustParseAddr("93.184.216.34")},
+		"internal.example": {netip.MustParseAddr("10.0.0.5")},
+	})
+	defer restore()
+
+	t.Run("シークレット指定あり", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectExec(`^INSERT INTO webhooks \(workspace_id, user_id, url, event_types, secret, created_at\) VALUES \(\?, \?, \?, \?, \?, \?\)$`).
+			WithArgs(testWorkspaceID, testUserID, "https://example.com/hook", "todo.created,todo.completed", "s3cret", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(5, 1))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/webhooks",
+			`{"url": "https://example.com/hook", "event_types": ["todo.created", "todo.completed"], "secret": "s3cret"}`)
+
+		handler.CreateWebhook(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusCreated, rec.Code)
+		got := decodeResponseBody[model.WebhookResponse](t, rec)
+		if got.Data == nil || got.Data.ID != 5 || got.Data.Secret != "s3cret" {
+			t.Errorf("レスポンスが不正です: %+v", got.Data)
+		}
+	})
+
+	t.Run("シークレット省略時は生成して返却する", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		mock.ExpectExec(`^INSERT INTO webhooks`).
+			WithArgs(testWorkspaceID, testUserID, "https://example.com/hook", "todo.deleted", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(6, 1))
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/webhooks",
+			`{"url": "https://example.com/hook", "event_types": ["todo.deleted"]}`)
+
+		handler.CreateWebhook(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusCreated, rec.Code)
+		got := decodeResponseBody[model.WebhookResponse](t, rec)
+		if got.Data == nil || len(got.Data.Secret) != 64 {
+			t.Errorf("生成されたシークレットが不正です: %+v", got.Data)
+		}
+	})
+
+	t.Run("入力値が不正", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/webhooks",
+			`{"url": "ftp://example.com/hook", "event_types": ["todo.created"]}`)
+
+		handler.CreateWebhook(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("内部のアドレスに解決されるホスト", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		req := createUserRequest(t, http.MethodPost, "/webhooks",
+			`{"url": "https://internal.example/hook", "event_types": ["todo.created"]}`)
+
+		handler.CreateWebhook(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusBadRequest, rec.Code)
+		got := decodeResponseBody[model.WebhookResponse](t, rec)
+		checkResponseBody(t, "URLに内部のアドレスは指定できません。", got.Status.ErrorMessage)
+	})
+
+	t.Run("ユーザーの指定なし", func(t *testing.T) {
+		db, mock := setUpMockDB(t)
+		defer db.Close()
+
+		rec := httptest.NewRecorder()
+		req := createTestRequest(t, http.MethodPost, "/webhooks",
+			`{"url": "https://example.com/hook", "event_types": ["todo.created"]}`)
+
+		handler.CreateWebhook(rec, req)
+
+		checkMockExpectations(t, mock)
+		checkStatusCode(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestGetWebhooks(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	createdAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`^SELECT id, url, event_types, created_at FROM webhooks WHERE workspace_id = \? AND deleted_at IS NULL ORDER BY id$`).
+		WithArgs(testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "event_types", "created_at"}).
+			AddRow(1, "https://example.com/hook", "todo.created,todo.updated", createdAt))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/webhooks", "")
+
+	handler.GetWebhooks(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.WebhooksResponse](t, rec)
+	want := model.WebhooksResponse{
+		Data: []model.Webhook{
+			{ID: 1, URL: "https://example.com/hook", EventTypes: []string{"todo.created", "todo.updated"}, CreatedAt: createdAt},
+		},
+		Status: model.StatusInfo{Code: http.StatusOK},
+	}
+	checkResponseBody(t, want, got)
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	cases := map[string]struct {
+		id             string
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+	}{
+		"正常系": {
+			id: "3",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE webhooks SET deleted_at = \? WHERE id = \? AND workspace_id = \? AND deleted_at IS NULL$`).
+					WithArgs(sqlmock.AnyArg(), 3, testWorkspaceID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`^UPDATE webhook_deliveries SET status = \? WHERE webhook_id = \? AND status = \?$`).
+					WithArgs("failed", 3, "pending").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		"存在しない": {
+			id: "4",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE webhooks`).
+					WithArgs(sqlmock.AnyArg(), 4, testWorkspaceID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		"IDが不正": {
+			id:             "abc",
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodDelete, "/webhooks/"+c.id, "")
+			req.SetPathValue("id", c.id)
+
+			handler.DeleteWebhook(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+		})
+	}
+}
+
+func TestGetWebhookDeliveries(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	createdAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	nextAttemptAt := createdAt.Add(time.Minute)
+	mock.ExpectQuery(`^SELECT id FROM webhooks WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(2, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery(`^SELECT id, webhook_id, event_type, status, attempts, last_status_code, last_error, next_attempt_at, created_at FROM webhook_deliveries WHERE webhook_id = \? AND workspace_id = \? ORDER BY id DESC LIMIT \?$`).
+		WithArgs(2, testWorkspaceID, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "event_type", "status", "attempts", "last_status_code", "last_error", "next_attempt_at", "created_at"}).
+			AddRow(11, 2, "todo.updated", "pending", 1, 500, "unexpected status", nextAttemptAt, createdAt).
+			AddRow(10, 2, "todo.created", "succeeded", 1, 200, nil, createdAt, createdAt))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/webhooks/2/deliveries", "")
+	req.SetPathValue("id", "2")
+
+	handler.GetWebhookDeliveries(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	serverError, ok := 500, 200
+	want := model.WebhookDeliveriesResponse{
+		Data: []model.WebhookDelivery{
+			{ID: 11, WebhookID: 2, EventType: "todo.updated", Status: "pending", Attempts: 1, LastStatusCode: &serverError, LastError: "unexpected status", NextAttemptAt: nextAttemptAt, CreatedAt: createdAt},
+			{ID: 10, WebhookID: 2, EventType: "todo.created", Status: "succeeded", Attempts: 1, LastStatusCode: &ok, NextAttemptAt: createdAt, CreatedAt: createdAt},
+		},
+		Status: model.StatusInfo{Code: http.StatusOK},
+	}
+	got := decodeResponseBody[model.WebhookDeliveriesResponse](t, rec)
+	checkResponseBody(t, want, got)
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/event"
+	"backend/app/middleware"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/websocket"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// 受信するメッセージの最大長。HTTPのリクエストボディの上限に合わせる
+	wsMaxMessageSize = 1024
+	// 接続ごとの送信待ちメッセージの上限。溢れた場合は受信が追いつかないとみなして切断する
+	wsSendBufferSize = 32
+	// 書き込みのタイムアウト
+	wsWriteWait = 10 * time.Second
+	// Pongを待つ時間。Pingはこの間隔より短い周期で送信する
+	wsPongWait = 60 * time.Second
+	// サブプロトコル。認証トークンをサブプロトコルで指定する場合は、あわせて提示する
+	wsProtocol = "todo"
+)
+
+// wsSessionは、1つのWebSocket接続の状態
+type wsSession struct {
+	ctx  context.Context
+	conn *websocket.Conn
+	send chan model.WSServerMessage
+	done chan struct{}
+
+	mu    sync.Mutex
+	lists map[int]bool
+
+	closeOnce sync.Once
+}
+
+// WebSocketでリストの変更を購読し、Todoを変更する。
+// 変更はHTTPのAPIと同じ検証を通して実行する。
+// 接続にはユーザーの指定が必要で、ブラウザからの接続はフロントエンドのオリジンのみ許可する。
+// ブラウザからは、サブプロトコルに「todo」と「bearer.認証トークン」を、クエリパラメータにworkspace_idを指定して接続する。
+func ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	if requestctx.UserID(r.Context()) == 0 {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && origin != middleware.AllowedOrigin {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusForbidden, constant.USER_ERR_INVALID_ORIGIN)
+		return
+	}
+
+	// サブプロトコルを提示された場合は、合意したものを応答しないとブラウザが接続を切断する
+	protocol := ""
+	if slices.Contains(websocket.Subprotocols(r), wsProtocol) {
+		protocol = wsProtocol
+	}
+	conn, err := websocket.Upgrade(w, r, protocol)
+	if err != nil {
+		response.WriteTodosResponse(w, []model.Todo{}, http.StatusBadRequest, constant.WS_ERR_BAD_HANDSHAKE)
+		return
+	}
+	conn.MaxMessageSize = wsMaxMessageSize
+
+	s := &wsSession{
+		ctx:   r.Context(),
+		conn:  conn,
+		send:  make(chan model.WSServerMessage, wsSendBufferSize),
+		done:  make(chan struct{}),
+		lists: map[int]bool{},
+	}
+
+	sub, _, _ := event.Default().Subscribe(requestctx.WorkspaceID(r.Context()), 0)
+	defer sub.Close()
+
+	go s.writeLoop()
+	go s.forwardEvents(sub)
+	s.readLoop()
+}
+
+// クライアントからのメッセージを処理する
+func (s *wsSession) readLoop() {
+	defer s.close(websocket.CloseNormal, "")
+
+	_ = s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	s.conn.PongHandler = func() {
+		_ = s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	}
+
+	for {
+		_, payload, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg model.WSClientMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			s.reply(model.WSServerMessage{Type: "error", Code: http.StatusBadRequest, Message: constant.WS_ERR_INVALID_MESSAGE})
+			continue
+		}
+		s.reply(s.handle(msg))
+	}
+}
+
+func (s *wsSession) handle(msg model.WSClientMessage) model.WSServerMessage {
+	ack := model.WSServerMessage{Type: "ack", Ref: msg.Ref}
+	fail := func(code int, message string) model.WSServerMessage {
+		return model.WSServerMessage{Type: "error", Ref: msg.Ref, Code: code, Message: message}
+	}
+
+	switch msg.Type {
+	case "subscribe":
+		var id int
+		query := "SELECT id FROM lists WHERE id = ? AND workspace_id = ?"
+		if err := database.GetDB().QueryRow(query, msg.ListID, requestctx.WorkspaceID(s.ctx)).Scan(&id); err != nil {
+			if err == sql.ErrNoRows {
+				return fail(http.StatusNotFound, constant.LIST_ERR_NOT_FOUND_LIST)
+			}
+			return fail(http.StatusInternalServerError, constant.LIST_ERR_FAILED_GET_LIST)
+		}
+		s.mu.Lock()
+		s.lists[msg.ListID] = true
+		s.mu.Unlock()
+		return ack
+
+	case "unsubscribe":
+		s.mu.Lock()
+		delete(s.lists, msg.ListID)
+		s.mu.Unlock()
+		return ack
+
+	case "create", "update":
+		if msg.Todo == nil {
+			return fail(http.StatusBadRequest, constant.WS_ERR_INVALID_MESSAGE)
+		}
+		var (
+			todo *model.Todo
+			mErr *mutationError
+		)
+		if msg.Type == "create" {
+			todo, mErr = createTodo(s.ctx, msg.Todo.Todo)
+		} else {
+			todo, mErr = updateTodo(s.ctx, msg.TodoID, *msg.Todo, updateOptions{allowBlocked: msg.AllowBlocked})
+		}
+		if mErr != nil {
+			return fail(mErr.code, mErr.message)
+		}
+		ack.Todo = todo
+		return ack
+
+	case "delete":
+		if mErr := deleteTodo(s.ctx, msg.TodoID); mErr != nil {
+			return fail(mErr.code, mErr.message)
+		}
+		return ack
+	}
+
+	return fail(http.StatusBadRequest, constant.WS_ERR_UNKNOWN_TYPE)
+}
+
+// 購読中のリストの変更イベントをクライアントに転送する
+func (s *wsSession) forwardEvents(sub *event.Subscription) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case e, open := <-sub.Events():
+			if !open {
+				s.close(websocket.ClosePolicyViolation, "too slow")
+				return
+			}
+			if e.ListID == nil || !s.subscribed(*e.ListID) || !e.VisibleTo(requestctx.UserID(s.ctx)) {
+				continue
+			}
+			s.reply(model.WSServerMessage{Type: "event", Event: e})
+		}
+	}
+}
+
+// 送信待ちのメッセージとPingを送信する
+func (s *wsSession) writeLoop() {
+	ping := time.NewTicker(wsPongWait * 9 / 10)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case msg := <-s.send:
+			payload, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := s.conn.WriteMessage(websocket.OpText, payload); err != nil {
+				s.close(websocket.CloseGoingAway, "")
+				return
+			}
+		case <-ping.C:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := s.conn.WriteControl(websocket.OpPing, nil); err != nil {
+				s.close(websocket.CloseGoingAway, "")
+				return
+			}
+		}
+	}
+}
+
+// 送信待ちに追加する。上限を超えた場合は、受信が追いつかないクライアントとして切断する
+func (s *wsSession) reply(msg model.WSServerMessage) {
+	select {
+	case <-s.done:
+	case s.send <- msg:
+	default:
+		s.close(websocket.ClosePolicyViolation, "too slow")
+	}
+}
+
+func (s *wsSession) subscribed(listID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lists[listID]
+}
+
+func (s *wsSession) close(code int, reason string) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		_ = s.conn.CloseWithReason(code, reason)
+	})
+}
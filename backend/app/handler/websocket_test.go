@@ -0,0 +1,204 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/middleware"
+	"backend/app/model"
+	"backend/app/websocket"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// dialWebSocketは、ミドルウェアを通したテスト用サーバーにWebSocketで接続します。
+// pathには/wsにクエリパラメータを付けたものを指定できます。
+func dialWebSocket(t *testing.T, path string, header http.Header) *websocket.Conn {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", handler.ServeWebSocket)
+	srv := httptest.NewServer(middleware.Chain(mux, middleware.NewRateLimiter()))
+	t.Cleanup(srv.Close)
+
+	conn, err := websocket.Dial("ws://"+strings.TrimPrefix(srv.URL, "http://")+path, header)
+	if err != nil {
+		t.Fatalf("接続に失敗しました: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// sendWSは、メッセージを送信します。
+func sendWS(t *testing.T, conn *websocket.Conn, msg string) {
+	t.Helper()
+
+	if err := conn.WriteMessage(websocket.OpText, []byte(msg)); err != nil {
+		t.Fatalf("送信に失敗しました: %s", err)
+	}
+}
+
+// receiveWSは、メッセージを1件受信します。
+func receiveWS(t *testing.T, conn *websocket.Conn) map[string]any {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	_, payload, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("受信に失敗しました: %s", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("メッセージのデコードに失敗しました: %s", err)
+	}
+	return got
+}
+
+func TestServeWebSocket(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	expectWorkspaceMember(mock, 801, 5)
+	mock.ExpectQuery(`^SELECT id FROM lists WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(4, 801).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT id FROM lists WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(4, 801).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(`^SELECT name, is_done FROM workflow_states`).
+		WithArgs(4, 801).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "is_done"}))
+	mock.ExpectExec(`^INSERT INTO todos`).
+		WithArgs(801, "共同編集", false, 1, 4, nil, "", "", "", "todo", nil, nil).
+		WillReturnResult(sqlmock.NewResult(10, 1))
+	mock.ExpectExec(`^INSERT INTO todo_revisions`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^INSERT INTO audit_logs`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^INSERT INTO change_sequences`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^INSERT INTO todo_changes`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	conn := dialWebSocket(t, "/ws", http.Header{
+		"Authorization":            {bearerToken(5)},
+		middleware.WorkspaceHeader: {"801"},
+	})
+
+	sendWS(t, conn, `{"type": "subscribe", "ref": "1", "list_id": 4}`)
+	if got := receiveWS(t, conn); got["type"] != "ack" || got["ref"] != "1" {
+		t.Fatalf("購読の応答が不正です: %v", got)
+	}
+
+	// 検証はHTTPのAPIと同じルールで行われる
+	sendWS(t, conn, `{"type": "create", "ref": "2", "todo": {"title": "", "list_id": 4}}`)
+	if got := receiveWS(t, conn); got["type"] != "error" || got["message"] != "タイトルは必須です。" {
+		t.Fatalf("検証エラーが返却されていません: %v", got)
+	}
+
+	sendWS(t, conn, `{"type": "create", "ref": "3", "todo": {"title": "共同編集", "list_id": 4}}`)
+
+	// 応答と、購読中のリストの変更イベントの両方を受信する
+	var gotAck, gotEvent bool
+	for i := 0; i < 2; i++ {
+		got := receiveWS(t, conn)
+		switch got["type"] {
+		case "ack":
+			gotAck = got["ref"] == "3"
+		case "event":
+			e, _ := got["event"].(map[string]any)
+			gotEvent = e["type"] == "todo.created" && e["todo_id"] == float64(10)
+		}
+	}
+	if !gotAck || !gotEvent {
+		t.Errorf("応答またはイベントを受信していません: ack=%v, event=%v", gotAck, gotEvent)
+	}
+
+	checkMockExpectations(t, mock)
+}
+
+// 未完了のTodoに依存するTodoは、allow_blockedを指定した場合のみWebSocketからも完了できることを確認する
+func TestServeWebSocketAllowBlocked(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	expectWorkspaceMember(mock, 801, 5)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, 801).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "リリース", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+	mock.ExpectQuery(`^SELECT todos.id FROM todo_dependencies`).
+		WithArgs(801, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, 801).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "リリース", false, 1, nil, nil, "", "", "", "todo", nil, nil))
+	mock.ExpectExec(`^UPDATE todos`).
+		WithArgs("リリース", true, nil, nil, "", "", "", "done", nil, nil, 1, 801, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^INSERT INTO todo_revisions`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^INSERT INTO audit_logs`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^INSERT INTO change_sequences`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^INSERT INTO todo_changes`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	conn := dialWebSocket(t, "/ws", http.Header{
+		"Authorization":            {bearerToken(5)},
+		middleware.WorkspaceHeader: {"801"},
+	})
+
+	sendWS(t, conn, `{"type": "update", "ref": "1", "todo_id": 1, "todo": {"is_complete": true}}`)
+	if got := receiveWS(t, conn); got["type"] != "error" || got["code"] != float64(http.StatusUnprocessableEntity) {
+		t.Fatalf("完了できないことが返却されていません: %v", got)
+	}
+
+	sendWS(t, conn, `{"type": "update", "ref": "2", "todo_id": 1, "allow_blocked": true, "todo": {"is_complete": true}}`)
+	if got := receiveWS(t, conn); got["type"] != "ack" || got["ref"] != "2" {
+		t.Fatalf("更新の応答が不正です: %v", got)
+	}
+
+	checkMockExpectations(t, mock)
+}
+
+// ヘッダーを指定できないブラウザから、サブプロトコルとクエリパラメータで接続できることを確認する
+func TestServeWebSocketBrowserCredentials(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	expectWorkspaceMember(mock, 801, 5)
+
+	token := strings.TrimPrefix(bearerToken(5), "Bearer ")
+	conn := dialWebSocket(t, "/ws?workspace_id=801", http.Header{
+		"Origin":                 {middleware.AllowedOrigin},
+		"Sec-WebSocket-Protocol": {"todo, " + middleware.BearerProtocolPrefix + token},
+	})
+
+	if conn.Subprotocol != "todo" {
+		t.Errorf("期待したサブプロトコル: todo, 実際: %q", conn.Subprotocol)
+	}
+	sendWS(t, conn, `{"type": "unsubscribe", "ref": "1", "list_id": 4}`)
+	if got := receiveWS(t, conn); got["type"] != "ack" {
+		t.Fatalf("応答が不正です: %v", got)
+	}
+
+	checkMockExpectations(t, mock)
+}
+
+func TestServeWebSocketRequiresUser(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/ws", "")
+
+	handler.ServeWebSocket(rec, req)
+
+	checkStatusCode(t, http.StatusUnauthorized, rec.Code)
+	got := decodeResponseBody[model.TodosResponse](t, rec)
+	checkResponseBody(t, "ユーザーの認証が必要です。", got.Status.ErrorMessage)
+}
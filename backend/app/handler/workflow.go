@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/validator"
+	"backend/app/workflow"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// リストのワークフローを取得する。設定していない場合は既定のワークフローを返す
+func GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteWorkflowResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var listID int
+	if err := db.QueryRow("SELECT id FROM lists WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&listID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteWorkflowResponse(w, nil, http.StatusNotFound, constant.LIST_ERR_NOT_FOUND_LIST)
+		} else {
+			response.WriteWorkflowResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_GET_WORKFLOW)
+		}
+		return
+	}
+
+	wf, err := loadWorkflow(db, workspaceID, &listID)
+	if err != nil {
+		response.WriteWorkflowResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_GET_WORKFLOW)
+		return
+	}
+
+	response.WriteWorkflowResponse(w, &wf, http.StatusOK, "")
+}
+
+// リストのワークフローを置き換える。
+// Todoが使用しているステータスを削除したり、その完了の扱いを変えたりすることはできない
+func UpdateWorkflow(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteWorkflowResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	var input model.Workflow
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.WriteWorkflowResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_INPUT)
+		return
+	}
+	for i := range input.States {
+		input.States[i].Name = strings.TrimSpace(input.States[i].Name)
+	}
+	for i := range input.Transitions {
+		input.Transitions[i].From = strings.TrimSpace(input.Transitions[i].From)
+		input.Transitions[i].To = strings.TrimSpace(input.Transitions[i].To)
+	}
+	if input.Transitions == nil {
+		input.Transitions = []model.WorkflowTransition{}
+	}
+
+	// 入力値のバリデーション
+	if err := validator.WorkflowInput(input); err != nil {
+		response.WriteWorkflowResponse(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+
+	db := database.GetDB()
+	tx, err := db.Begin()
+	if err != nil {
+		response.WriteWorkflowResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_UPDATE_WORKFLOW)
+		return
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	var listID int
+	if err := tx.QueryRow("SELECT id FROM lists WHERE id = ? AND workspace_id = ? FOR UPDATE", id, workspaceID).Scan(&listID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteWorkflowResponse(w, nil, http.StatusNotFound, constant.LIST_ERR_NOT_FOUND_LIST)
+		} else {
+			response.WriteWorkflowResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_UPDATE_WORKFLOW)
+		}
+		return
+	}
+
+	// Todoのステータスと完了状態が、新しいワークフローでも同じ意味を持つことを確認する
+	rows, err := tx.Query("SELECT DISTINCT status, is_complete FROM todos WHERE workspace_id = ? AND list_id = ? ORDER BY status", workspaceID, id)
+	if err != nil {
+		response.WriteWorkflowResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_UPDATE_WORKFLOW)
+		return
+	}
+	var removed, doneChanged []string
+	for rows.Next() {
+		var status string
+		var isComplete bool
+		if err := rows.Scan(&status, &isComplete); err != nil {
+			rows.Close()
+			response.WriteWorkflowResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_UPDATE_WORKFLOW)
+			return
+		}
+		state, ok := workflow.State(input, status)
+		switch {
+		case !ok:
+			removed = append(removed, status)
+		case state.Done != isComplete:
+			doneChanged = append(doneChanged, status)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		response.WriteWorkflowResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_UPDATE_WORKFLOW)
+		return
+	}
+	if len(removed) > 0 {
+		response.WriteWorkflowResponse(w, nil, http.StatusConflict, fmt.Sprintf(constant.WORKFLOW_ERR_STATUS_IN_USE, strings.Join(removed, ", ")))
+		return
+	}
+	if len(doneChanged) > 0 {
+		response.WriteWorkflowResponse(w, nil, http.StatusConflict, fmt.Sprintf(constant.WORKFLOW_ERR_DONE_IN_USE, strings.Join(doneChanged, ", ")))
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM workflow_states WHERE list_id = ? AND workspace_id = ?", id, workspaceID); err != nil {
+		response.WriteWorkflowResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_UPDATE_WORKFLOW)
+		return
+	}
+	if _, err := tx.Exec("DELETE FROM workflow_transitions WHERE list_id = ? AND workspace_id = ?", id, workspaceID); err != nil {
+		response.WriteWorkflowResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_UPDATE_WORKFLOW)
+		return
+	}
+
+	values := make([]string, len(input.States))
+	args := make([]any, 0, len(input.States)*5)
+	for i, state := range input.States {
+		values[i] = "(?, ?, ?, ?, ?)"
+		args = append(args, workspaceID, id, state.Name, state.Done, i)
+	}
+	insertQuery := "INSERT INTO workflow_states (workspace_id, list_id, name, is_done, position) VALUES " + strings.Join(values, ", ")
+	if _, err := tx.Exec(insertQuery, args...); err != nil {
+		response.WriteWorkflowResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_UPDATE_WORKFLOW)
+		return
+	}
+
+	if len(input.Transitions) > 0 {
+		values := make([]string, len(input.Transitions))
+		args := make([]any, 0, len(input.Transitions)*4)
+		for i, t := range input.Transitions {
+			values[i] = "(?, ?, ?, ?)"
+			args = append(args, workspaceID, id, t.From, t.To)
+		}
+		insertQuery := "INSERT INTO workflow_transitions (workspace_id, list_id, from_status, to_status) VALUES " + strings.Join(values, ", ")
+		if _, err := tx.Exec(insertQuery, args...); err != nil {
+			response.WriteWorkflowResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_UPDATE_WORKFLOW)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		response.WriteWorkflowResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_UPDATE_WORKFLOW)
+		return
+	}
+
+	response.WriteWorkflowResponse(w, &input, http.StatusOK, "")
+}
+
+// リストのTodoを、ワークフローのステータスごとの列に分けて取得する
+func GetBoard(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		response.WriteBoardResponse(w, nil, http.StatusBadRequest, constant.INPUT_ERR_INVALID_ID)
+		return
+	}
+
+	workspaceID := requestctx.WorkspaceID(r.Context())
+	db := database.GetDB()
+
+	var listID int
+	if err := db.QueryRow("SELECT id FROM lists WHERE id = ? AND workspace_id = ?", id, workspaceID).Scan(&listID); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteBoardResponse(w, nil, http.StatusNotFound, constant.LIST_ERR_NOT_FOUND_LIST)
+		} else {
+			response.WriteBoardResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_GET_BOARD)
+		}
+		return
+	}
+
+	wf, err := loadWorkflow(db, workspaceID, &listID)
+	if err != nil {
+		response.WriteBoardResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_GET_BOARD)
+		return
+	}
+
+	board := model.Board{ListID: id, Columns: make([]model.BoardColumn, len(wf.States))}
+	columns := map[string]int{}
+	for i, state := range wf.States {
+		board.Columns[i] = model.BoardColumn{Status: state.Name, Done: state.Done, Todos: []model.Todo{}}
+		columns[state.Name] = i
+	}
+
+	rows, err := db.Query("SELECT "+todoColumns+" FROM todos WHERE workspace_id = ? AND list_id = ? ORDER BY id", workspaceID, id)
+	if err != nil {
+		response.WriteBoardResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_GET_BOARD)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todo model.Todo
+		if err := scanTodo(rows, &todo); err != nil {
+			response.WriteBoardResponse(w, nil, http.StatusInternalServerError, constant.DB_ERR_FAILED_GET_TODO_ROW)
+			return
+		}
+		// ワークフローにないステータスのTodoは、完了状態に対応する列に並べる
+		i, ok := columns[todo.Status]
+		if !ok {
+			if todo.IsComplete {
+				i = columns[workflow.Completed(wf)]
+			} else {
+				i = columns[workflow.Initial(wf)]
+			}
+		}
+		board.Columns[i].Todos = append(board.Columns[i].Todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		response.WriteBoardResponse(w, nil, http.StatusInternalServerError, constant.WORKFLOW_ERR_FAILED_GET_BOARD)
+		return
+	}
+
+	response.WriteBoardResponse(w, &board, http.StatusOK, "")
+}
+
+// リストのワークフローを読み込む。リストに属さないTodoや、ワークフローを設定していないリストは既定のワークフローとする
+func loadWorkflow(q querier, workspaceID int, listID *int) (model.Workflow, error) {
+	if listID == nil {
+		return workflow.Default(), nil
+	}
+
+	rows, err := q.Query("SELECT name, is_done FROM workflow_states WHERE list_id = ? AND workspace_id = ? ORDER BY position", *listID, workspaceID)
+	if err != nil {
+		return model.Workflow{}, err
+	}
+	wf := model.Workflow{Transitions: []model.WorkflowTransition{}}
+	for rows.Next() {
+		var state model.WorkflowState
+		if err := rows.Scan(&state.Name, &state.Done); err != nil {
+			rows.Close()
+			return model.Workflow{}, err
+		}
+		wf.States = append(wf.States, state)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return model.Workflow{}, err
+	}
+	if len(wf.States) == 0 {
+		return workflow.Default(), nil
+	}
+
+	rows, err = q.Query("SELECT from_status, to_status FROM workflow_transitions WHERE list_id = ? AND workspace_id = ? ORDER BY id", *listID, workspaceID)
+	if err != nil {
+		return model.Workflow{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t model.WorkflowTransition
+		if err := rows.Scan(&t.From, &t.To); err != nil {
+			return model.Workflow{}, err
+		}
+		wf.Transitions = append(wf.Transitions, t)
+	}
+	return wf, rows.Err()
+}
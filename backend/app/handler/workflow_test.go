@@ -0,0 +1,267 @@
+package handler_test
+
+import (
+	"backend/app/handler"
+	"backend/app/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectWorkflowは、リスト3のワークフローの読み込みを期待値として設定します。
+// todo → doing → done の順にのみ進められるワークフローです。
+func expectWorkflow(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`^SELECT name, is_done FROM workflow_states WHERE list_id = \? AND workspace_id = \? ORDER BY position$`).
+		WithArgs(3, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "is_done"}).
+			AddRow("todo", false).
+			AddRow("doing", false).
+			AddRow("done", true))
+	mock.ExpectQuery(`^SELECT from_status, to_status FROM workflow_transitions WHERE list_id = \? AND workspace_id = \? ORDER BY id$`).
+		WithArgs(3, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"from_status", "to_status"}).
+			AddRow("todo", "doing").
+			AddRow("doing", "done"))
+}
+
+// ワークフローを設定していないリストは、既定のワークフローを返すことを確認する
+func TestGetWorkflowDefault(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT id FROM lists WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(3, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(`^SELECT name, is_done FROM workflow_states`).
+		WithArgs(3, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "is_done"}))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/lists/3/workflow", "")
+	req.SetPathValue("id", "3")
+
+	handler.GetWorkflow(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.WorkflowResponse](t, rec)
+	checkResponseBody(t, &model.Workflow{
+		States: []model.WorkflowState{
+			{Name: "todo"}, {Name: "in_progress"}, {Name: "review"}, {Name: "done", Done: true},
+		},
+		Transitions: []model.WorkflowTransition{},
+	}, got.Data)
+}
+
+func TestUpdateWorkflow(t *testing.T) {
+	body := `{
+		"states": [{"name": " todo "}, {"name": "doing"}, {"name": "done", "done": true}],
+		"transitions": [{"from": "todo", "to": "doing"}, {"from": "doing", "to": "done"}]
+	}`
+
+	cases := map[string]struct {
+		body           string
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+		wantMessage    string
+		wantData       *model.Workflow
+	}{
+		"ワークフローを置き換える": {
+			body: body,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM lists WHERE id = \? AND workspace_id = \? FOR UPDATE$`).
+					WithArgs(3, testWorkspaceID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(`^SELECT DISTINCT status, is_complete FROM todos WHERE workspace_id = \? AND list_id = \? ORDER BY status$`).
+					WithArgs(testWorkspaceID, 3).
+					WillReturnRows(sqlmock.NewRows([]string{"status", "is_complete"}).AddRow("done", true).AddRow("todo", false))
+				mock.ExpectExec(`^DELETE FROM workflow_states WHERE list_id = \? AND workspace_id = \?$`).
+					WithArgs(3, testWorkspaceID).
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectExec(`^DELETE FROM workflow_transitions WHERE list_id = \? AND workspace_id = \?$`).
+					WithArgs(3, testWorkspaceID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`^INSERT INTO workflow_states \(workspace_id, list_id, name, is_done, position\) VALUES \(\?, \?, \?, \?, \?\), \(\?, \?, \?, \?, \?\), \(\?, \?, \?, \?, \?\)$`).
+					WithArgs(testWorkspaceID, 3, "todo", false, 0, testWorkspaceID, 3, "doing", false, 1, testWorkspaceID, 3, "done", true, 2).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec(`^INSERT INTO workflow_transitions \(workspace_id, list_id, from_status, to_status\) VALUES \(\?, \?, \?, \?\), \(\?, \?, \?, \?\)$`).
+					WithArgs(testWorkspaceID, 3, "todo", "doing", testWorkspaceID, 3, "doing", "done").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusOK,
+			wantData: &model.Workflow{
+				States: []model.WorkflowState{{Name: "todo"}, {Name: "doing"}, {Name: "done", Done: true}},
+				Transitions: []model.WorkflowTransition{
+					{From: "todo", To: "doing"},
+					{From: "doing", To: "done"},
+				},
+			},
+		},
+		"使用中のステータスを削除する": {
+			body: body,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM lists`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(`^SELECT DISTINCT status, is_complete FROM todos`).
+					WillReturnRows(sqlmock.NewRows([]string{"status", "is_complete"}).
+						AddRow("in_progress", false).
+						AddRow("review", false).
+						AddRow("todo", false))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusConflict,
+			wantMessage:    "TODOが使用しているステータスは削除できません（in_progress, review）。",
+		},
+		"使用中のステータスの完了の扱いを変える": {
+			body: `{"states": [{"name": "todo", "done": true}, {"name": "doing"}]}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM lists`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(`^SELECT DISTINCT status, is_complete FROM todos`).
+					WillReturnRows(sqlmock.NewRows([]string{"status", "is_complete"}).AddRow("todo", false))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusConflict,
+			wantMessage:    "TODOが使用しているステータスの完了の扱いは変更できません（todo）。",
+		},
+		"リストが存在しない": {
+			body: body,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT id FROM lists`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusNotFound,
+			wantMessage:    "リストが見つかりません。",
+		},
+		"完了のステータスがない": {
+			body:           `{"states": [{"name": "todo"}, {"name": "doing"}]}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+			wantMessage:    "完了として扱うステータスを1個以上指定してください。",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock := setUpMockDB(t)
+			defer db.Close()
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createTestRequest(t, http.MethodPut, "/lists/3/workflow", c.body)
+			req.SetPathValue("id", "3")
+
+			handler.UpdateWorkflow(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			got := decodeResponseBody[model.WorkflowResponse](t, rec)
+			if got.Status.ErrorMessage != c.wantMessage {
+				t.Errorf("want: %s, got: %s", c.wantMessage, got.Status.ErrorMessage)
+			}
+			checkResponseBody(t, c.wantData, got.Data)
+		})
+	}
+}
+
+// ワークフローにないステータスのTodoは、完了状態に対応する列に並べることを確認する
+func TestGetBoard(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT id FROM lists WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(3, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	expectWorkflow(mock)
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE workspace_id = \? AND list_id = \? ORDER BY id$`).
+		WithArgs(testWorkspaceID, 3).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).
+			AddRow(1, "設計", false, 1, 3, nil, "", "", "", "doing", nil, nil).
+			AddRow(2, "調査", false, 1, 3, nil, "", "", "", "review", nil, nil).
+			AddRow(3, "リリース", true, 1, 3, nil, "", "", "", "done", nil, nil))
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodGet, "/lists/3/board", "")
+	req.SetPathValue("id", "3")
+
+	handler.GetBoard(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusOK, rec.Code)
+	got := decodeResponseBody[model.BoardResponse](t, rec)
+	listID := 3
+	checkResponseBody(t, &model.Board{
+		ListID: 3,
+		Columns: []model.BoardColumn{
+			{Status: "todo", Todos: []model.Todo{
+				{ID: 2, Title: "調査", Status: "review", Revision: 1, ListID: &listID},
+			}},
+			{Status: "doing", Todos: []model.Todo{
+				{ID: 1, Title: "設計", Status: "doing", Revision: 1, ListID: &listID},
+			}},
+			{Status: "done", Done: true, Todos: []model.Todo{
+				{ID: 3, Title: "リリース", IsComplete: true, Status: "done", Revision: 1, ListID: &listID},
+			}},
+		},
+	}, got.Data)
+}
+
+// ワークフローで許可していないステータスの変更は、422を返すことを確認する
+func TestUpdateTodoByIdDisallowedTransition(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "設計", false, 1, 3, nil, "", "", "", "todo", nil, nil))
+	mock.ExpectQuery(`^SELECT id FROM lists WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(3, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	expectWorkflow(mock)
+	mock.ExpectRollback()
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodPut, "/todos/1", `{"title": "設計", "list_id": 3, "is_complete": true}`)
+
+	handler.UpdateTodoById(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusUnprocessableEntity, rec.Code)
+	got := decodeResponseBody[model.TodoResponse](t, rec)
+	checkResponseBody(t, "ステータスを「todo」から「done」に変更することはできません。変更できるステータス: doing", got.Status.ErrorMessage)
+}
+
+// 別のリストに移す場合も、移動先のリストのワークフローで許可していない変更はできないことを確認する
+func TestUpdateTodoByIdDisallowedTransitionOnMove(t *testing.T) {
+	db, mock := setUpMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT .* FROM todos WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(1, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns).AddRow(1, "設計", false, 1, 2, nil, "", "", "", "todo", nil, nil))
+	mock.ExpectQuery(`^SELECT id FROM lists WHERE id = \? AND workspace_id = \?$`).
+		WithArgs(3, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	expectWorkflow(mock)
+	mock.ExpectRollback()
+
+	rec := httptest.NewRecorder()
+	req := createTestRequest(t, http.MethodPut, "/todos/1", `{"title": "設計", "list_id": 3, "status": "done"}`)
+
+	handler.UpdateTodoById(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusUnprocessableEntity, rec.Code)
+	got := decodeResponseBody[model.TodoResponse](t, rec)
+	checkResponseBody(t, "ステータスを「todo」から「done」に変更することはできません。変更できるステータス: doing", got.Status.ErrorMessage)
+}
@@ -0,0 +1,167 @@
+package handler_test
+
+import (
+	"backend/app/database"
+	"backend/app/handler"
+	"backend/app/model"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// テスト用リクエストとは別のワークスペース
+const otherWorkspaceID = testWorkspaceID + 1
+
+// setUpScopedMockDBは、すべてのクエリがworkspace_idで絞り込まれていることを検証するモックDBを作成します。
+func setUpScopedMockDB(t *testing.T) sqlmock.Sqlmock {
+	t.Helper()
+
+	matcher := sqlmock.QueryMatcherFunc(func(expectedSQL, actualSQL string) error {
+		if !strings.Contains(actualSQL, "workspace_id") {
+			return fmt.Errorf("workspace_idで絞り込まれていないクエリです: %s", actualSQL)
+		}
+		return sqlmock.QueryMatcherRegexp.Match(expectedSQL, actualSQL)
+	})
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(matcher))
+	if err != nil {
+		t.Fatalf("モックDBの作成に失敗しました: %s", err)
+	}
+	database.SetDB(db)
+	t.Cleanup(func() { db.Close() })
+
+	return mock
+}
+
+// 他のワークスペースのTODOは、取得・更新・削除のいずれもできないことを確認する
+func TestWorkspaceIsolation(t *testing.T) {
+	cases := map[string]struct {
+		method         string
+		path           string
+		body           string
+		handle         http.HandlerFunc
+		mockSetup      func(mock sqlmock.Sqlmock)
+		wantStatusCode int
+	}{
+		"一覧に他のワークスペースのTODOが含まれない": {
+			method: http.MethodGet,
+			path:   "/todos",
+			handle: handler.GetTodos,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE workspace_id = \?`).
+					WithArgs(otherWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns))
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		"他のワークスペースのTODOを取得できない": {
+			method: http.MethodGet,
+			path:   "/todos/1",
+			handle: handler.GetTodoById,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, otherWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns))
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		"他のワークスペースのTODOを更新できない": {
+			method: http.MethodPut,
+			path:   "/todos/1",
+			body:   `{"title": "乗っ取り", "is_complete": true}`,
+			handle: handler.UpdateTodoById,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, otherWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		"他のワークスペースのTODOを削除できない": {
+			method: http.MethodDelete,
+			path:   "/todos/1",
+			handle: handler.DeleteTodoById,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?`).
+					WithArgs(1, otherWorkspaceID).
+					WillReturnRows(sqlmock.NewRows(todoRowColumns))
+				mock.ExpectRollback()
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		"作成したTODOは自分のワークスペースに属する": {
+			method: http.MethodPost,
+			path:   "/todos",
+			body:   `{"title": "新しいタスク", "is_complete": false}`,
+			handle: handler.CreateTodo,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO todos \(workspace_id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id\)`).
+					WithArgs(otherWorkspaceID, "新しいタスク", false, 1, nil, nil, "", "", "", "todo", nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`INSERT INTO todo_revisions \(workspace_id,`).
+					WithArgs(otherWorkspaceID, 1, 1, sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`INSERT INTO audit_logs \(workspace_id,`).
+					WithArgs(otherWorkspaceID, nil, "create", 1, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`INSERT INTO change_sequences \(workspace_id, seq\)`).
+					WithArgs(otherWorkspaceID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`INSERT INTO todo_changes \(workspace_id,`).
+					WithArgs(otherWorkspaceID, 1, 1, false, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`INSERT INTO outbox \(workspace_id,`).
+					WithArgs(otherWorkspaceID, sqlmock.AnyArg(), "todo.created", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			mock := setUpScopedMockDB(t)
+
+			c.mockSetup(mock)
+
+			rec := httptest.NewRecorder()
+			req := createWorkspaceRequest(t, otherWorkspaceID, c.method, c.path, c.body)
+
+			c.handle(rec, req)
+
+			checkMockExpectations(t, mock)
+			checkStatusCode(t, c.wantStatusCode, rec.Code)
+			if c.method == http.MethodGet && c.path == "/todos" {
+				got := decodeResponseBody[model.TodosResponse](t, rec)
+				if len(got.Data) != 0 {
+					t.Errorf("他のワークスペースのTODOが含まれています: %v", got.Data)
+				}
+			}
+		})
+	}
+}
+
+// ワークスペース未設定のリクエストは、既存のワークスペースに一致しないことを確認する
+func TestWorkspaceUnset(t *testing.T) {
+	mock := setUpScopedMockDB(t)
+
+	mock.ExpectQuery(`SELECT id, title, is_complete, revision, list_id, due_at, recurrence, time_zone, notes, status, estimate, milestone_id FROM todos WHERE id = \? AND workspace_id = \?`).
+		WithArgs(1, 0).
+		WillReturnRows(sqlmock.NewRows(todoRowColumns))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/todos/1", nil)
+
+	handler.GetTodoById(rec, req)
+
+	checkMockExpectations(t, mock)
+	checkStatusCode(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,131 @@
+// historyは、Todoの状態をリビジョンとして記録し、リビジョン間の差分を求めるパッケージ
+package history
+
+import (
+	"backend/app/model"
+	"backend/app/requestctx"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// 差分の対象外とするフィールド
+var ignoredFields = map[string]bool{"id": true, "revision": true}
+
+// Recordは、Todoの現在の状態を新しいリビジョンとして記録する。
+// Todoの変更と同じトランザクションで呼び出すこと。
+func Record(ctx context.Context, tx *sql.Tx, todo *model.Todo) error {
+	snapshot, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+
+	// 匿名ユーザーの場合はNULLとして記録する
+	var actorID any
+	if id := requestctx.UserID(ctx); id != 0 {
+		actorID = id
+	}
+
+	query := "INSERT INTO todo_revisions (workspace_id, todo_id, revision, snapshot_json, actor_id, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err = tx.Exec(query, requestctx.WorkspaceID(ctx), todo.ID, todo.Revision, string(snapshot), actorID, time.Now())
+	return err
+}
+
+// Diffは、beforeからafterへの変更をフィールド単位で返す。
+// beforeがnilの場合（最初のリビジョン）は、すべてのフィールドを変更として扱う。
+func Diff(before *model.Todo, after model.Todo) []model.FieldChange {
+	beforeFields := map[string]any{}
+	if before != nil {
+		beforeFields = toFields(*before)
+	}
+	afterFields := toFields(after)
+
+	changes := []model.FieldChange{}
+	for _, name := range fieldNames(beforeFields, afterFields) {
+		from, to := beforeFields[name], afterFields[name]
+		if !reflect.DeepEqual(from, to) {
+			changes = append(changes, model.FieldChange{Field: name, From: from, To: to})
+		}
+	}
+	return changes
+}
+
+// Mergeは、baseから分岐したサーバーとクライアントの変更をフィールド単位でマージする。
+// クライアントだけが変更したフィールドはクライアントの値を採用し、
+// 両方が同じフィールドを異なる値に変更した場合はサーバーの値を残して競合として返す。
+// baseが不明（nil）の場合は、値が異なるすべてのフィールドを競合として扱う。
+func Merge(base *model.Todo, server, client model.Todo) (model.Todo, []model.SyncConflict) {
+	serverFields := toFields(server)
+	clientFields := toFields(client)
+	var baseFields map[string]any
+	if base != nil {
+		baseFields = toFields(*base)
+	}
+
+	conflicts := []model.SyncConflict{}
+	for _, name := range fieldNames(serverFields, clientFields) {
+		clientValue, serverValue := clientFields[name], serverFields[name]
+		if reflect.DeepEqual(clientValue, serverValue) {
+			continue
+		}
+		if baseFields != nil {
+			baseValue := baseFields[name]
+			if reflect.DeepEqual(clientValue, baseValue) {
+				// クライアントは変更していない
+				continue
+			}
+			if reflect.DeepEqual(serverValue, baseValue) {
+				// クライアントだけが変更した
+				if clientValue == nil {
+					delete(serverFields, name)
+				} else {
+					serverFields[name] = clientValue
+				}
+				continue
+			}
+		}
+		conflicts = append(conflicts, model.SyncConflict{Field: name, ClientValue: clientValue, ServerValue: serverValue})
+	}
+
+	// 省略したフィールドを空にするため、ゼロ値から組み立てる
+	var merged model.Todo
+	b, err := json.Marshal(serverFields)
+	if err != nil {
+		return server, conflicts
+	}
+	// 同じmodel.Todoから作ったマップのため、エラーは発生しない
+	_ = json.Unmarshal(b, &merged)
+	return merged, conflicts
+}
+
+// 差分の対象となるフィールド名を、いずれかのマップに含まれるものから名前順に返す。
+// 省略可能なフィールドは、値が空の場合にマップに含まれないため。
+func fieldNames(a, b map[string]any) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, fields := range []map[string]any{a, b} {
+		for name := range fields {
+			if !ignoredFields[name] && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// JSONのフィールド名をキーとしたマップに変換する
+func toFields(todo model.Todo) map[string]any {
+	fields := map[string]any{}
+	b, err := json.Marshal(todo)
+	if err != nil {
+		return fields
+	}
+	// model.TodoのJSONは必ずオブジェクトになるため、エラーは発生しない
+	_ = json.Unmarshal(b, &fields)
+	return fields
+}
@@ -0,0 +1,74 @@
+package history_test
+
+import (
+	"backend/app/history"
+	"backend/app/model"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	listID := 2
+	base := model.Todo{ID: 1, Title: "元", IsComplete: false, Revision: 1}
+
+	cases := map[string]struct {
+		base          *model.Todo
+		server        model.Todo
+		client        model.Todo
+		wantMerged    model.Todo
+		wantConflicts []model.SyncConflict
+	}{
+		"異なるフィールドの変更は両方を採用する": {
+			base:          &base,
+			server:        model.Todo{ID: 1, Title: "サーバー", Revision: 2},
+			client:        model.Todo{Title: "元", IsComplete: true, ListID: &listID},
+			wantMerged:    model.Todo{ID: 1, Title: "サーバー", IsComplete: true, Revision: 2, ListID: &listID},
+			wantConflicts: []model.SyncConflict{},
+		},
+		"同じフィールドの変更はサーバーの値を残す": {
+			base:       &base,
+			server:     model.Todo{ID: 1, Title: "サーバー", Revision: 2},
+			client:     model.Todo{Title: "クライアント"},
+			wantMerged: model.Todo{ID: 1, Title: "サーバー", Revision: 2},
+			wantConflicts: []model.SyncConflict{
+				{Field: "title", ClientValue: "クライアント", ServerValue: "サーバー"},
+			},
+		},
+		"同じ値への変更は競合しない": {
+			base:          &base,
+			server:        model.Todo{ID: 1, Title: "同じ", Revision: 2},
+			client:        model.Todo{Title: "同じ"},
+			wantMerged:    model.Todo{ID: 1, Title: "同じ", Revision: 2},
+			wantConflicts: []model.SyncConflict{},
+		},
+		"クライアントだけが繰り返しを解除した": {
+			base:          &model.Todo{ID: 1, Title: "元", Recurrence: "FREQ=DAILY", Revision: 1},
+			server:        model.Todo{ID: 1, Title: "サーバー", Recurrence: "FREQ=DAILY", Revision: 2},
+			client:        model.Todo{Title: "元"},
+			wantMerged:    model.Todo{ID: 1, Title: "サーバー", Revision: 2},
+			wantConflicts: []model.SyncConflict{},
+		},
+		"元の状態が不明な場合は値が異なるフィールドをすべて競合とする": {
+			base:       nil,
+			server:     model.Todo{ID: 1, Title: "サーバー", Revision: 2},
+			client:     model.Todo{Title: "クライアント", IsComplete: true},
+			wantMerged: model.Todo{ID: 1, Title: "サーバー", Revision: 2},
+			wantConflicts: []model.SyncConflict{
+				{Field: "is_complete", ClientValue: true, ServerValue: false},
+				{Field: "title", ClientValue: "クライアント", ServerValue: "サーバー"},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			merged, conflicts := history.Merge(c.base, c.server, c.client)
+			if !reflect.DeepEqual(merged, c.wantMerged) {
+				t.Errorf("期待したマージ結果: %+v, 実際: %+v", c.wantMerged, merged)
+			}
+			if !reflect.DeepEqual(conflicts, c.wantConflicts) {
+				t.Errorf("期待した競合: %+v, 実際: %+v", c.wantConflicts, conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// mailerは、メールを組み立ててSMTPサーバーへ送信する、またはスプールディレクトリに書き出すパッケージ
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+var ErrInvalidAddress = errors.New("invalid mail address")
+
+// Messageは、送信するメール1通
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	// HTML形式の本文。指定した場合はテキスト形式との multipart/alternative で送信する
+	HTML string
+	// 受信側で重複を判別するためのMessage-ID。同じ通知の再送では同じ値にする
+	MessageID string
+	Date      time.Time
+}
+
+// Senderは、メールを送信する
+type Sender interface {
+	Send(msg Message) error
+}
+
+// SMTPSenderは、SMTPサーバーを経由してメールを送信する
+type SMTPSender struct {
+	// SMTPサーバーのアドレス（host:port）
+	Addr string
+	// 認証しない場合はnil
+	Auth smtp.Auth
+}
+
+// Sendは、メールを送信する
+func (s *SMTPSender) Send(msg Message) error {
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAddress, msg.From)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAddress, msg.To)
+	}
+
+	body, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(s.Addr, s.Auth, from.Address, []string{to.Address}, body)
+}
+
+// Bytesは、メールをRFC 5322の形式に変換する
+func (msg Message) Bytes() ([]byte, error) {
+	// ヘッダーインジェクションを防ぐため、改行を含む値は受け付けない
+	for _, v := range []string{msg.From, msg.To, msg.Subject, msg.MessageID} {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, errors.New("header value contains newline")
+		}
+	}
+
+	date := msg.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	if msg.MessageID != "" {
+		fmt.Fprintf(&b, "Message-ID: <%s>\r\n", msg.MessageID)
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	if msg.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+		b.WriteString("\r\n")
+		b.WriteString(normalizeNewlines(msg.Text))
+		return b.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%s\r\n", mw.Boundary())
+	b.WriteString("\r\n")
+	// 受信側は後のパートを優先して表示するため、テキスト形式を先に書き込む
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		if err := writeQuotedPrintablePart(mw, part.contentType, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// multipartのパートを1つ書き込む。
+// HTMLは1行が長くなりやすく、SMTPの行長の上限を超えないようquoted-printableで符号化する
+func writeQuotedPrintablePart(mw *multipart.Writer, contentType, body string) error {
+	w, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qw := quotedprintable.NewWriter(w)
+	if _, err := qw.Write([]byte(normalizeNewlines(body))); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+// 改行をCRLFに揃える
+func normalizeNewlines(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+}
@@ -0,0 +1,162 @@
+package mailer_test
+
+import (
+	"backend/app/mailer"
+	"backend/app/mailer/mailertest"
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSMTPSenderSend(t *testing.T) {
+	srv := mailertest.NewServer(t)
+	sender := &mailer.SMTPSender{Addr: srv.Addr}
+
+	err := sender.Send(mailer.Message{
+		From:      "Todo <noreply@example.com>",
+		To:        "user@example.com",
+		Subject:   "リマインダー: 買い物",
+		Text:      "買い物の期限です。\n.で始まる行",
+		MessageID: "reminder-1@todo",
+		Date:      time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("送信に失敗しました: %s", err)
+	}
+
+	mails := srv.Mails()
+	if len(mails) != 1 {
+		t.Fatalf("期待した受信件数: 1, 実際: %d", len(mails))
+	}
+	got := mails[0]
+	if got.From != "noreply@example.com" || len(got.To) != 1 || got.To[0] != "user@example.com" {
+		t.Errorf("送信者・宛先が不正です: %+v", got)
+	}
+	for _, want := range []string{
+		"Subject: =?utf-8?q?",
+		"Message-ID: <reminder-1@todo>\r\n",
+		"Date: Mon, 01 Jan 2024 09:00:00 +0000\r\n",
+		"\r\n\r\n買い物の期限です。\r\n.で始まる行",
+	} {
+		if !strings.Contains(got.Data, want) {
+			t.Errorf("メールに%qが含まれていません:\n%s", want, got.Data)
+		}
+	}
+}
+
+func TestSMTPSenderSendError(t *testing.T) {
+	srv := mailertest.NewServer(t)
+	srv.FailData(true)
+	sender := &mailer.SMTPSender{Addr: srv.Addr}
+
+	if err := sender.Send(mailer.Message{From: "noreply@example.com", To: "user@example.com", Subject: "件名"}); err == nil {
+		t.Error("サーバーが拒否したメールの送信でエラーが返却されていません")
+	}
+
+	err := sender.Send(mailer.Message{From: "noreply@example.com", To: "not an address", Subject: "件名"})
+	if !errors.Is(err, mailer.ErrInvalidAddress) {
+		t.Errorf("期待したエラー: %v, 実際: %v", mailer.ErrInvalidAddress, err)
+	}
+}
+
+func TestMessageBytesRejectsHeaderInjection(t *testing.T) {
+	msg := mailer.Message{From: "noreply@example.com", To: "user@example.com", Subject: "件名\r\nBcc: evil@example.com"}
+	if _, err := msg.Bytes(); err == nil {
+		t.Error("改行を含むヘッダーが受け付けられました")
+	}
+}
+
+func TestMessageBytesMultipart(t *testing.T) {
+	msg := mailer.Message{
+		From:    "noreply@example.com",
+		To:      "user@example.com",
+		Subject: "ダイジェスト",
+		Text:    "テキスト本文\n",
+		HTML:    "<p>" + strings.Repeat("長い行", 200) + "</p>",
+	}
+	b, err := msg.Bytes()
+	if err != nil {
+		t.Fatalf("変換に失敗しました: %s", err)
+	}
+
+	m, err := mail.ReadMessage(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("メールの解析に失敗しました: %s", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Typeが不正です: %s", m.Header.Get("Content-Type"))
+	}
+
+	var got []string
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("パートの解析に失敗しました: %s", err)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(p))
+		if err != nil {
+			t.Fatalf("本文の復号に失敗しました: %s", err)
+		}
+		got = append(got, p.Header.Get("Content-Type")+"|"+string(body))
+	}
+
+	want := []string{
+		"text/plain; charset=utf-8|テキスト本文\r\n",
+		"text/html; charset=utf-8|" + msg.HTML,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("パート数が不正です: %d", len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("パート%dが不正です: %q", i, got[i])
+		}
+	}
+	for _, line := range strings.Split(string(b), "\r\n") {
+		if len(line) > 998 {
+			t.Fatalf("行長の上限を超えています: %d", len(line))
+		}
+	}
+}
+
+func TestSpoolSenderSend(t *testing.T) {
+	dir := t.TempDir()
+	sender := &mailer.SpoolSender{Dir: dir}
+
+	msg := mailer.Message{From: "noreply@example.com", To: "user@example.com", Subject: "件名", Text: "本文", MessageID: "digest-1-7-2024-01-01@todo"}
+	// 同じMessage-IDで再送しても1ファイルになる
+	for range 2 {
+		if err := sender.Send(msg); err != nil {
+			t.Fatalf("書き出しに失敗しました: %s", err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "digest-1-7-2024-01-01_todo.eml" {
+		t.Fatalf("スプールのファイルが不正です: %v", entries)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "Message-ID: <digest-1-7-2024-01-01@todo>") {
+		t.Errorf("書き出したメールが不正です:\n%s", b)
+	}
+}
@@ -0,0 +1,151 @@
+// mailertestは、テスト用にローカルで動作する最小限のSMTPサーバーを提供するパッケージ
+package mailertest
+
+import (
+	"bufio"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Mailは、サーバーが受信したメール1通
+type Mail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// Serverは、受信したメールを保持するSMTPサーバー
+type Server struct {
+	Addr string
+
+	listener net.Listener
+	mu       sync.Mutex
+	mails    []Mail
+	// trueの場合は、DATAの受信後に一時的なエラーを返す
+	failData bool
+	wg       sync.WaitGroup
+}
+
+// NewServerは、ランダムなポートでSMTPサーバーを起動する。テストの終了時に停止する。
+func NewServer(t *testing.T) *Server {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("SMTPサーバーの起動に失敗しました: %s", err)
+	}
+	s := &Server{Addr: l.Addr().String(), listener: l}
+
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(func() {
+		l.Close()
+		s.wg.Wait()
+	})
+	return s
+}
+
+// Mailsは、受信したメールを返す
+func (s *Server) Mails() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mail(nil), s.mails...)
+}
+
+// FailDataは、以降のメールの受信を一時的なエラーで拒否するかを設定する
+func (s *Server) FailData(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failData = fail
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP mailertest")
+
+	var current Mail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250 localhost")
+		case "MAIL":
+			current = Mail{From: trimPath(arg)}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			current.To = append(current.To, trimPath(arg))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := readData(tp.Reader.R)
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			fail := s.failData
+			if !fail {
+				current.Data = data
+				s.mails = append(s.mails, current)
+			}
+			s.mu.Unlock()
+			if fail {
+				tp.PrintfLine("451 temporary failure")
+			} else {
+				tp.PrintfLine("250 OK")
+			}
+		case "RSET", "NOOP":
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+// "FROM:<a@example.com>"からアドレスを取り出す
+func trimPath(arg string) string {
+	_, path, _ := strings.Cut(arg, ":")
+	return strings.Trim(strings.TrimSpace(path), "<>")
+}
+
+// 終端の"."までのデータを読み込み、ドットスタッフィングを戻して返す
+func readData(r *bufio.Reader) (string, error) {
+	var b strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line == "." {
+			return b.String(), nil
+		}
+		b.WriteString(strings.TrimPrefix(line, "."))
+		b.WriteString("\r\n")
+	}
+}
@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ファイル名に使用できない文字
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// SpoolSenderは、メールを送信せずにスプールディレクトリへ.emlファイルとして書き出す。
+// 開発環境での確認や、別のプロセスが送信を担う場合に使用する。
+type SpoolSender struct {
+	Dir string
+}
+
+// Sendは、メールをファイルに書き出す。
+// Message-IDを指定した場合はそれをファイル名にするため、同じメールを再送しても1ファイルになる。
+func (s *SpoolSender) Send(msg Message) error {
+	body, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	name := msg.MessageID
+	if name == "" {
+		name = strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+	name = unsafeFileChars.ReplaceAllString(name, "_") + ".eml"
+
+	// 書き込み途中のファイルを読み取られないよう、一時ファイルに書き込んでから名前を変更する
+	tmp, err := os.CreateTemp(s.Dir, ".spool-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.Dir, name)); err != nil {
+		return fmt.Errorf("failed to spool mail: %w", err)
+	}
+	return nil
+}
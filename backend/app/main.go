@@ -1,27 +1,87 @@
 package main
 
 import (
+	"backend/app/auth"
+	"backend/app/blob"
 	"backend/app/database"
+	"backend/app/digest"
+	"backend/app/event"
 	"backend/app/handler"
+	"backend/app/mailer"
 	"backend/app/middleware"
+	"backend/app/netguard"
+	"backend/app/outbox"
+	"backend/app/reminder"
 	"backend/app/router"
+	"backend/app/stats"
+	"backend/app/thumbnail"
+	"backend/app/validator"
+	"backend/app/webhook"
+	"context"
 	"log"
 	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
 const (
 	serverAddress = ":8080"
+
+	// 未中継のイベントをアウトボックスから読み出す間隔
+	outboxPollInterval = time.Second
+	// Webhook配信キューを確認する間隔
+	webhookPollInterval = 5 * time.Second
+	// Webhook送信先へのリクエストのタイムアウト
+	webhookRequestTimeout = 10 * time.Second
+	// 通知日時を迎えたリマインダーを確認する間隔
+	reminderPollInterval = 15 * time.Second
+	// ダイジェストの送信時刻を確認する間隔
+	digestPollInterval = time.Minute
+	// 作成待ちの縮小画像を拾い直す間隔
+	thumbnailPollInterval = time.Minute
+	// 縮小画像の作成を待てる依頼の数
+	thumbnailQueueSize = 256
+	// メモ以外の項目とJSONの構造に見込むリクエストボディのサイズ
+	todoBodyOverhead = 1024
+	// 統計の集計を再利用する時間（秒）の既定値
+	statsCacheTTLSeconds = 30
+	// 認証トークンの署名に使う鍵の最小の長さ（バイト）
+	authSecretMinBytes = 32
 )
 
 func main() {
+	configureAuth()
 	initDatabase()
 	defer database.GetDB().Close()
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dispatcher := startWebhookDispatcher(ctx)
+	startOutboxRelay(ctx, dispatcher)
+	startReminderScheduler(ctx, dispatcher)
+	startDigestJob(ctx)
+
+	configureNotes()
+	configureAttachments()
+	configureStats()
+	startThumbnailPool(ctx)
 	startServer()
 }
 
+// 認証トークンの検証の設定。署名の鍵はAUTH_SECRETで指定し、未設定や短すぎる場合は起動しない
+func configureAuth() {
+	secret := os.Getenv("AUTH_SECRET")
+	if len(secret) < authSecretMinBytes {
+		log.Fatalf("AUTH_SECRET must be at least %d bytes", authSecretMinBytes)
+	}
+	auth.SetDefault(auth.NewSigner([]byte(secret)))
+}
+
 // データベースの初期化
 func initDatabase() {
 	if err := database.Init(); err != nil {
@@ -29,6 +89,142 @@ func initDatabase() {
 	}
 }
 
+// アウトボックスの中継の起動。Webhookの配信キューには、中継済みにする前に必ず登録する
+func startOutboxRelay(ctx context.Context, dispatcher *webhook.Dispatcher) {
+	relay := outbox.NewRelay(database.GetDB(), event.Default())
+	relay.Handlers = []outbox.Handler{dispatcher.Enqueue}
+	go relay.Run(ctx, outboxPollInterval)
+}
+
+// Webhook配信の起動
+func startWebhookDispatcher(ctx context.Context) *webhook.Dispatcher {
+	// 送信先は利用者が指定するため、公開されたアドレスにのみ接続する
+	dispatcher := webhook.NewDispatcher(database.GetDB(), netguard.NewClient(webhookRequestTimeout))
+	go dispatcher.Run(ctx, webhookPollInterval)
+	return dispatcher
+}
+
+// リマインダーの通知の起動。メールは送信先が設定されている場合のみ送信する
+func startReminderScheduler(ctx context.Context, dispatcher *webhook.Dispatcher) {
+	notifiers := map[string]reminder.Notifier{
+		reminder.ChannelPush:    &reminder.PushNotifier{Broker: event.Default()},
+		reminder.ChannelWebhook: &reminder.WebhookNotifier{Dispatcher: dispatcher},
+	}
+	if sender := newMailSender(); sender != nil {
+		notifiers[reminder.ChannelEmail] = &reminder.EmailNotifier{Sender: sender, From: mailFrom()}
+	}
+
+	scheduler := reminder.NewScheduler(database.GetDB(), notifiers)
+	go scheduler.Run(ctx, reminderPollInterval)
+}
+
+// ダイジェストメールの送信の起動。メールの送信先が未設定の場合は起動しない
+func startDigestJob(ctx context.Context) {
+	sender := newMailSender()
+	if sender == nil {
+		return
+	}
+
+	job := digest.NewJob(database.GetDB(), sender, mailFrom())
+	go job.Run(ctx, digestPollInterval)
+}
+
+// 環境変数からメールの送信設定を読み込む。
+// SMTP_ADDRが設定されている場合はSMTPで送信し、MAIL_SPOOL_DIRが設定されている場合はディレクトリに書き出す。
+// どちらも未設定の場合はnilを返す
+func newMailSender() mailer.Sender {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		if dir := os.Getenv("MAIL_SPOOL_DIR"); dir != "" {
+			return &mailer.SpoolSender{Dir: dir}
+		}
+		return nil
+	}
+
+	sender := &mailer.SMTPSender{Addr: addr}
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		sender.Auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return sender
+}
+
+// 送信元のメールアドレス
+func mailFrom() string {
+	if from := os.Getenv("SMTP_FROM"); from != "" {
+		return from
+	}
+	return "noreply@localhost"
+}
+
+// メモの最大サイズの設定。TODO_NOTES_MAX_BYTESで変更できる。
+// メモを含むTodoを受け付けるパスは、リクエストボディの上限をメモの最大サイズに合わせて広げる
+func configureNotes() {
+	if v := os.Getenv("TODO_NOTES_MAX_BYTES"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 0 {
+			log.Fatalf("invalid TODO_NOTES_MAX_BYTES: %q", v)
+		}
+		validator.MaxNotesSize = size
+	}
+
+	// JSONのエスケープで大きくなる分を見込む
+	limit := int64(validator.MaxNotesSize)*2 + todoBodyOverhead
+	for _, pattern := range []string{"/todos", "/todos/"} {
+		middleware.SetBodyLimit(pattern, limit)
+	}
+	// 同期は1回に複数のTodoを送るため、変更の最大件数分を見込む
+	middleware.SetBodyLimit("/sync", limit*validator.MaxSyncMutations)
+}
+
+// 添付ファイルの保存先と上限の設定。
+// 保存先はATTACHMENT_DIR、1件の上限はATTACHMENT_MAX_BYTES、ワークスペースごとの上限はATTACHMENT_QUOTA_BYTESで変更できる
+func configureAttachments() {
+	dir := os.Getenv("ATTACHMENT_DIR")
+	if dir == "" {
+		dir = "data/attachments"
+	}
+	store, err := blob.NewLocalStore(dir)
+	if err != nil {
+		log.Fatalf("failed to initialize attachment storage: %v", err)
+	}
+	blob.SetDefault(store)
+
+	handler.MaxAttachmentSize = int64EnvOr("ATTACHMENT_MAX_BYTES", handler.MaxAttachmentSize)
+	handler.AttachmentQuota = int64EnvOr("ATTACHMENT_QUOTA_BYTES", handler.AttachmentQuota)
+
+	// アップロードはハンドラーがボディを読み込みながら保存し、上限もハンドラー側で確認する
+	middleware.SetBodyLimit("/todos/{id}/attachments", -1)
+	middleware.SetContentTypes("/todos/{id}/attachments", "multipart/form-data")
+}
+
+// 統計の集計のキャッシュの設定。保持する時間（秒）はSTATS_CACHE_TTL_SECONDSで変更できる
+func configureStats() {
+	ttl := time.Duration(int64EnvOr("STATS_CACHE_TTL_SECONDS", statsCacheTTLSeconds)) * time.Second
+	stats.SetDefault(stats.NewCache(ttl))
+}
+
+// 縮小画像を作成するワーカーの起動。ワーカーの数はTHUMBNAIL_WORKERSで変更できる
+func startThumbnailPool(ctx context.Context) {
+	pool := thumbnail.NewPool(database.GetDB(), blob.Default(), thumbnailQueueSize)
+	pool.Workers = int(int64EnvOr("THUMBNAIL_WORKERS", int64(pool.Workers)))
+	thumbnail.SetDefault(pool)
+	go pool.Run(ctx, thumbnailPollInterval)
+}
+
+// 環境変数を正の整数として読み込む。未設定の場合は既定値を返す
+func int64EnvOr(name string, fallback int64) int64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		log.Fatalf("invalid %s: %q", name, v)
+	}
+	return n
+}
+
 // サーバーの起動
 func startServer() {
 	mux := setupRouter()
@@ -43,6 +239,9 @@ func startServer() {
 func setupRouter() *http.ServeMux {
 	mux := http.NewServeMux()
 
+	// 共有リンクは未認証でアクセスされ、ワークスペースはリンクから解決する
+	middleware.SetPublic("/shared/{token}")
+
 	mux.HandleFunc("/todos", router.MethodRouter(map[string]http.HandlerFunc{
 		http.MethodGet:  handler.GetTodos,
 		http.MethodPost: handler.CreateTodo,
@@ -54,5 +253,203 @@ func setupRouter() *http.ServeMux {
 		http.MethodDelete: handler.DeleteTodoById,
 	}))
 
+	mux.HandleFunc("/todos/search", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.SearchTodos,
+	}))
+
+	mux.HandleFunc("/lists/{id}/workflow", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetWorkflow,
+		http.MethodPut: handler.UpdateWorkflow,
+	}))
+
+	mux.HandleFunc("/lists/{id}/board", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetBoard,
+	}))
+
+	mux.HandleFunc("/todos/{id}/tags", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetTodoTags,
+		http.MethodPut: handler.UpdateTodoTags,
+	}))
+
+	mux.HandleFunc("/todos/{id}/assignees", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetTodoAssignees,
+		http.MethodPut: handler.UpdateTodoAssignees,
+	}))
+
+	mux.HandleFunc("/todos/{id}/dependencies", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetTodoDependencies,
+		http.MethodPut: handler.UpdateTodoDependencies,
+	}))
+
+	mux.HandleFunc("/todos/{id}/critical-path", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetCriticalPath,
+	}))
+
+	mux.HandleFunc("/todos/{id}/checklist", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet:  handler.GetChecklist,
+		http.MethodPost: handler.CreateChecklistItem,
+	}))
+
+	mux.HandleFunc("/todos/{id}/checklist/order", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodPut: handler.ReorderChecklist,
+	}))
+
+	mux.HandleFunc("/todos/{id}/checklist/{itemID}", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodPut:    handler.UpdateChecklistItem,
+		http.MethodDelete: handler.DeleteChecklistItem,
+	}))
+
+	mux.HandleFunc("/todos/{id}/attachments", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet:  handler.GetAttachments,
+		http.MethodPost: handler.UploadAttachment,
+	}))
+
+	mux.HandleFunc("/todos/{id}/attachments/{attachmentID}", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodDelete: handler.DeleteAttachment,
+	}))
+
+	mux.HandleFunc("/todos/{id}/attachments/{attachmentID}/content", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetAttachmentContent,
+	}))
+
+	mux.HandleFunc("/thumbnails/{key}", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetThumbnail,
+	}))
+
+	mux.HandleFunc("/todos/{id}/comments", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet:  handler.GetComments,
+		http.MethodPost: handler.CreateComment,
+	}))
+
+	mux.HandleFunc("/todos/{id}/comments/{commentID}", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodPut:    handler.UpdateComment,
+		http.MethodDelete: handler.DeleteComment,
+	}))
+
+	mux.HandleFunc("/todos/{id}/time-entries", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet:  handler.GetTimeEntries,
+		http.MethodPost: handler.CreateTimeEntry,
+	}))
+
+	mux.HandleFunc("/todos/{id}/time-entries/{entryID}", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodDelete: handler.DeleteTimeEntry,
+	}))
+
+	mux.HandleFunc("/todos/{id}/timer/start", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodPost: handler.StartTimer,
+	}))
+
+	mux.HandleFunc("/todos/{id}/timer/stop", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodPost: handler.StopTimer,
+	}))
+
+	mux.HandleFunc("/reports/time", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetTimeReport,
+	}))
+
+	mux.HandleFunc("/stats", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetStats,
+	}))
+
+	mux.HandleFunc("/milestones", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet:  handler.GetMilestones,
+		http.MethodPost: handler.CreateMilestone,
+	}))
+
+	mux.HandleFunc("/milestones/{id}", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodPut:    handler.UpdateMilestone,
+		http.MethodDelete: handler.DeleteMilestone,
+	}))
+
+	mux.HandleFunc("/milestones/{id}/burndown", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetBurndown,
+	}))
+
+	mux.HandleFunc("/todos/{id}/activity", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetTodoActivity,
+	}))
+
+	mux.HandleFunc("/todos/{id}/history", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetTodoHistory,
+	}))
+
+	mux.HandleFunc("/todos/{id}/revert", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodPost: handler.RevertTodo,
+	}))
+
+	mux.HandleFunc("/todos/{id}/reminders", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet:  handler.GetReminders,
+		http.MethodPost: handler.CreateReminder,
+	}))
+
+	mux.HandleFunc("/todos/{id}/reminders/{reminderID}", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodDelete: handler.DeleteReminder,
+	}))
+
+	mux.HandleFunc("/smart-lists", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet:  handler.GetSmartLists,
+		http.MethodPost: handler.CreateSmartList,
+	}))
+
+	mux.HandleFunc("/smart-lists/{id}", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodDelete: handler.DeleteSmartList,
+	}))
+
+	mux.HandleFunc("/smart-lists/{id}/todos", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetSmartListTodos,
+	}))
+
+	mux.HandleFunc("/digest/preferences", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetDigestPreference,
+		http.MethodPut: handler.UpdateDigestPreference,
+	}))
+
+	mux.HandleFunc("/recurrence/preview", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.PreviewRecurrence,
+	}))
+
+	mux.HandleFunc("/sync", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet:  handler.GetSyncChanges,
+		http.MethodPost: handler.PostSync,
+	}))
+
+	mux.HandleFunc("/events", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.StreamEvents,
+	}))
+
+	mux.HandleFunc("/ws", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.ServeWebSocket,
+	}))
+
+	mux.HandleFunc("/audit", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetAuditLogs,
+	}))
+
+	mux.HandleFunc("/webhooks", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet:  handler.GetWebhooks,
+		http.MethodPost: handler.CreateWebhook,
+	}))
+
+	mux.HandleFunc("/webhooks/{id}", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodDelete: handler.DeleteWebhook,
+	}))
+
+	mux.HandleFunc("/webhooks/{id}/deliveries", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetWebhookDeliveries,
+	}))
+
+	mux.HandleFunc("/lists/{id}/share-links", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodPost: handler.CreateShareLink,
+	}))
+
+	mux.HandleFunc("/lists/{id}/share-links/{linkID}", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodDelete: handler.RevokeShareLink,
+	}))
+
+	// 共有リンクは読み取り専用のため、GET以外は受け付けない
+	mux.HandleFunc("/shared/{token}", router.MethodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: handler.GetSharedTodos,
+	}))
+
 	return mux
 }
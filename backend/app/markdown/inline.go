@@ -0,0 +1,350 @@
+package markdown
+
+import (
+	"html"
+	"strings"
+)
+
+// インライン要素の入れ子の上限。これより深い入れ子はテキストとして出力する
+const maxInlineDepth = 10
+
+// inlineは、1つのブロック内のテキストをインライン要素として出力する。
+// 長い入力でも処理時間が入力の長さにほぼ比例するよう、コードと角括弧の対応は事前に求めておく
+type inline struct {
+	text  string
+	depth int
+	// インラインコードの開始位置から終了位置（閉じるバッククォートの直後）への対応
+	codeSpans map[int]int
+	// [の位置から対応する]の位置への対応
+	brackets map[int]int
+	// 強調の記号ごとに、閉じる記号が見つからなかった最小の開始位置
+	noCloser map[string]int
+}
+
+// インライン要素を出力する
+func renderInline(b *strings.Builder, text string) {
+	renderInlineDepth(b, text, 0)
+}
+
+func renderInlineDepth(b *strings.Builder, text string, depth int) {
+	if depth >= maxInlineDepth {
+		b.WriteString(html.EscapeString(text))
+		return
+	}
+	p := &inline{text: text, depth: depth, noCloser: map[string]int{}}
+	p.codeSpans = findCodeSpans(text)
+	p.brackets = p.findBrackets()
+	p.render(b)
+}
+
+func (p *inline) render(b *strings.Builder) {
+	text := p.text
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte(punctuationChars, text[i+1]) >= 0:
+			b.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+
+		case c == '\\' && i+1 < len(text) && text[i+1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+
+		case c == ' ' && strings.HasPrefix(text[i:], "  \n"):
+			b.WriteString("<br>\n")
+			i += len("  \n")
+
+		case c == '`':
+			if end, ok := p.codeSpans[i]; ok {
+				writeCodeSpan(b, text[i:end])
+				i = end
+				continue
+			}
+			run := backtickRun(text, i)
+			b.WriteString(text[i : i+run])
+			i += run
+
+		case c == '!' && strings.HasPrefix(text[i:], "!["):
+			if n := p.renderLink(b, i+1, true); n > 0 {
+				i = n
+				continue
+			}
+			b.WriteString("!")
+			i++
+
+		case c == '[':
+			if n := p.renderLink(b, i, false); n > 0 {
+				i = n
+				continue
+			}
+			b.WriteString("[")
+			i++
+
+		case c == '<':
+			if m := autolinkPattern.FindStringSubmatch(text[i:]); m != nil && safeURL(m[1]) {
+				writeLink(b, m[1], html.EscapeString(m[1]))
+				i += len(m[0])
+				continue
+			}
+			b.WriteString("&lt;")
+			i++
+
+		case c == '*' || c == '_':
+			if n := p.renderEmphasis(b, i); n > 0 {
+				i = n
+				continue
+			}
+			run := len(text[i:]) - len(strings.TrimLeft(text[i:], string(c)))
+			b.WriteString(text[i : i+run])
+			i += run
+
+		default:
+			// 特殊文字が現れるまでをまとめてエスケープする
+			j := i + 1
+			for j < len(text) && strings.IndexByte("\\ `![<*_", text[j]) < 0 {
+				j++
+			}
+			b.WriteString(html.EscapeString(text[i:j]))
+			i = j
+		}
+	}
+}
+
+// 位置iから続くバッククォートの数を返す
+func backtickRun(text string, i int) int {
+	n := 0
+	for i+n < len(text) && text[i+n] == '`' {
+		n++
+	}
+	return n
+}
+
+// インラインコードの範囲を求める。同じ長さのバッククォートの並びで閉じたものだけをコードとみなす
+func findCodeSpans(text string) map[int]int {
+	type run struct{ pos, length int }
+	var runs []run
+	for i := 0; i < len(text); {
+		switch {
+		case text[i] == '\\':
+			i += 2
+		case text[i] == '`':
+			n := backtickRun(text, i)
+			runs = append(runs, run{i, n})
+			i += n
+		default:
+			i++
+		}
+	}
+
+	// 長さごとに、まだ使っていない並びの位置を前から順に持つ
+	byLength := map[int][]int{}
+	for k, r := range runs {
+		byLength[r.length] = append(byLength[r.length], k)
+	}
+
+	spans := map[int]int{}
+	used := make([]bool, len(runs))
+	for k, r := range runs {
+		if used[k] {
+			continue
+		}
+		queue := byLength[r.length]
+		for len(queue) > 0 && queue[0] <= k {
+			queue = queue[1:]
+		}
+		byLength[r.length] = queue
+		if len(queue) == 0 {
+			continue
+		}
+		closing := runs[queue[0]]
+		// 開始と終了の間にある並びは、コードの一部として扱う
+		for m := k + 1; m <= queue[0]; m++ {
+			used[m] = true
+		}
+		spans[r.pos] = closing.pos + closing.length
+	}
+	return spans
+}
+
+// バッククォートを含むインラインコードを出力する
+func writeCodeSpan(b *strings.Builder, span string) {
+	run := backtickRun(span, 0)
+	code := strings.ReplaceAll(span[run:len(span)-run], "\n", " ")
+	if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+		code = code[1 : len(code)-1]
+	}
+	b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+}
+
+// 角括弧の対応を求める。インラインコードの中の角括弧は無視する
+func (p *inline) findBrackets() map[int]int {
+	brackets := map[int]int{}
+	var stack []int
+	text := p.text
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '`':
+			if end, ok := p.codeSpans[i]; ok {
+				i = end - 1
+			}
+		case '[':
+			stack = append(stack, i)
+		case ']':
+			if len(stack) > 0 {
+				brackets[stack[len(stack)-1]] = i
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return brackets
+}
+
+// 位置startの[から始まる[テキスト](URL "タイトル")形式のリンクを出力し、リンクの直後の位置を返す。
+// 画像の場合は代替テキストをリンクのテキストとして出力する。リンクとして扱えない場合は0を返す
+func (p *inline) renderLink(b *strings.Builder, start int, image bool) int {
+	text := p.text
+	closeLabel, ok := p.brackets[start]
+	if !ok || closeLabel+1 >= len(text) || text[closeLabel+1] != '(' {
+		return 0
+	}
+	closeDest := strings.IndexByte(text[closeLabel+2:], ')')
+	if closeDest < 0 {
+		// 以降の[も閉じ括弧がないため、リンクとして扱わない
+		p.brackets = nil
+		return 0
+	}
+	closeDest += closeLabel + 2
+
+	label := text[start+1 : closeLabel]
+	dest := strings.TrimSpace(text[closeLabel+2 : closeDest])
+	// タイトルは出力しない。URLの後に引用符で囲まれていない文字列が続く場合は、リンクとして扱わない
+	if k := strings.IndexAny(dest, " \t\n"); k >= 0 {
+		if !isLinkTitle(strings.TrimSpace(dest[k:])) {
+			return 0
+		}
+		dest = dest[:k]
+	}
+	dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+	dest = unescapePunctuation(dest)
+
+	var inner strings.Builder
+	if image {
+		inner.WriteString(html.EscapeString(label))
+	} else {
+		renderInlineDepth(&inner, label, p.depth+1)
+	}
+
+	if safeURL(dest) {
+		writeLink(b, dest, inner.String())
+	} else {
+		// 許可しないURLは、リンクにせずテキストだけ出力する
+		b.WriteString(inner.String())
+	}
+	return closeDest + 1
+}
+
+// リンクのタイトルとして正しい形式かどうかを返す
+func isLinkTitle(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	first, last := s[0], s[len(s)-1]
+	return (first == '"' && last == '"') || (first == '\'' && last == '\'')
+}
+
+func unescapePunctuation(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(punctuationChars, s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func writeLink(b *strings.Builder, href, inner string) {
+	b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="` + linkRel + `">`)
+	b.WriteString(inner)
+	b.WriteString("</a>")
+}
+
+// 位置iから始まる強調を出力し、強調の直後の位置を返す。閉じる記号がない場合は0を返す
+func (p *inline) renderEmphasis(b *strings.Builder, i int) int {
+	text := p.text
+	c := text[i]
+	// 単語の途中の_は強調として扱わない
+	if c == '_' && i > 0 && isWordByte(text[i-1]) {
+		return 0
+	}
+
+	for _, delim := range []string{strings.Repeat(string(c), 2), string(c)} {
+		if !strings.HasPrefix(text[i:], delim) {
+			continue
+		}
+		start := i + len(delim)
+		if start >= len(text) || text[start] == ' ' || text[start] == '\n' {
+			continue
+		}
+		end := p.closingDelimiter(start, delim)
+		if end < 0 {
+			continue
+		}
+
+		tag := "em"
+		if len(delim) == 2 {
+			tag = "strong"
+		}
+		b.WriteString("<" + tag + ">")
+		renderInlineDepth(b, text[start:end], p.depth+1)
+		b.WriteString("</" + tag + ">")
+		return end + len(delim)
+	}
+	return 0
+}
+
+// 位置start以降で強調を閉じる記号の位置を返す。見つからない場合は-1を返す
+func (p *inline) closingDelimiter(start int, delim string) int {
+	// 前の位置から見つからなかった場合は、それより後ろからも見つからない
+	if failed, ok := p.noCloser[delim]; ok && start >= failed {
+		return -1
+	}
+
+	text := p.text
+	for j := start; j < len(text); j++ {
+		switch {
+		case text[j] == '\\':
+			j++
+		case text[j] == '`':
+			// インラインコードの中の記号は無視する
+			if end, ok := p.codeSpans[j]; ok {
+				j = end - 1
+			}
+		case text[j] == delim[0]:
+			// 同じ記号の並びはまとめて判定する
+			run := 1
+			for j+run < len(text) && text[j+run] == delim[0] {
+				run++
+			}
+			after := j + run
+			closes := j > start && text[j-1] != ' ' && text[j-1] != '\n' &&
+				// *の強調の中の**は閉じとみなさない
+				(run == len(delim) || run >= 3) &&
+				!(delim[0] == '_' && after < len(text) && isWordByte(text[after]))
+			if closes {
+				return j
+			}
+			j = after - 1
+		}
+	}
+
+	p.noCloser[delim] = start
+	return -1
+}
+
+// 単語を構成する文字かどうかを返す。日本語は語の区切りに空白を使わないため、ASCII以外は単語に含めない
+func isWordByte(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,308 @@
+// Package markdownは、TODOのメモに書かれたMarkdownを安全なHTMLに変換する。
+//
+// 対応する記法は、見出し・段落・強調・インラインコード・コードブロック・引用・リスト・区切り線・リンクに限る。
+// 入力に含まれるHTMLはすべてエスケープし、リンクはhttp・https・mailtoと相対URLのみ許可する。
+// 画像は外部への読み込みを避けるため、リンクとして出力する。
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// リンクに付与するrel属性
+const linkRel = "nofollow noopener noreferrer"
+
+// リストの入れ子の上限。これより深い入れ子は段落として扱う
+const maxDepth = 10
+
+var (
+	headingPattern   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	hrPattern        = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fencePattern     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^`]*)$")
+	bulletPattern    = regexp.MustCompile(`^( {0,3})([-*+])(?:[ \t]+(.*))?$`)
+	orderedPattern   = regexp.MustCompile(`^( {0,3})(\d{1,9})([.)])(?:[ \t]+(.*))?$`)
+	quotePattern     = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	languagePattern  = regexp.MustCompile(`^[A-Za-z0-9_+-]+$`)
+	schemePattern    = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]*):`)
+	allowedSchemes   = map[string]bool{"http": true, "https": true, "mailto": true}
+	autolinkPattern  = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]*:[^<>\s]*)>`)
+	punctuationChars = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+)
+
+// Renderは、MarkdownをサニタイズしたHTMLに変換する
+func Render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\x00", "�")
+
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"), 0)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// 行の並びをブロック要素として出力する
+func renderBlocks(b *strings.Builder, lines []string, depth int) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+
+		case fencePattern.MatchString(line):
+			i = renderFence(b, lines, i)
+
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			level := string('0' + rune(len(m[1])))
+			b.WriteString("<h" + level + ">")
+			renderInline(b, strings.TrimSpace(m[2]))
+			b.WriteString("</h" + level + ">\n")
+			i++
+
+		case hrPattern.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case quotePattern.MatchString(line):
+			var quoted []string
+			for ; i < len(lines) && !isBlank(lines[i]); i++ {
+				if m := quotePattern.FindStringSubmatch(lines[i]); m != nil {
+					quoted = append(quoted, m[1])
+				} else {
+					// 引用の段落の続きとして扱う
+					quoted = append(quoted, lines[i])
+				}
+			}
+			b.WriteString("<blockquote>\n")
+			if depth < maxDepth {
+				renderBlocks(b, quoted, depth+1)
+			} else {
+				renderParagraph(b, quoted)
+			}
+			b.WriteString("</blockquote>\n")
+
+		case listItem(line) != nil:
+			i = renderList(b, lines, i, depth)
+
+		default:
+			start := i
+			for i++; i < len(lines) && !startsBlock(lines[i]); i++ {
+			}
+			renderParagraph(b, lines[start:i])
+		}
+	}
+}
+
+// 段落を中断するブロックの開始行かどうかを返す
+func startsBlock(line string) bool {
+	if isBlank(line) || fencePattern.MatchString(line) || headingPattern.MatchString(line) ||
+		hrPattern.MatchString(line) || quotePattern.MatchString(line) {
+		return true
+	}
+	item := listItem(line)
+	return item != nil && item.text != ""
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func renderParagraph(b *strings.Builder, lines []string) {
+	for i := range lines {
+		lines[i] = strings.TrimLeft(lines[i], " \t")
+	}
+	b.WriteString("<p>")
+	renderInline(b, strings.Join(lines, "\n"))
+	b.WriteString("</p>\n")
+}
+
+// コードブロックを出力し、次に処理する行の位置を返す
+func renderFence(b *strings.Builder, lines []string, start int) int {
+	m := fencePattern.FindStringSubmatch(lines[start])
+	fence := m[1]
+	language, _, _ := strings.Cut(strings.TrimSpace(m[2]), " ")
+
+	if languagePattern.MatchString(language) {
+		b.WriteString(`<pre><code class="language-` + language + `">`)
+	} else {
+		b.WriteString("<pre><code>")
+	}
+
+	i := start + 1
+	for ; i < len(lines); i++ {
+		closing := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(closing, fence) && strings.Trim(closing, fence[:1]) == "" {
+			i++
+			break
+		}
+		b.WriteString(html.EscapeString(lines[i]))
+		b.WriteString("\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+// listItemLineは、リストの項目の開始行
+type listItemLine struct {
+	ordered bool
+	marker  string
+	start   string
+	text    string
+	// 項目の本文の開始位置。続きの行はこれ以上字下げする
+	indent int
+}
+
+func listItem(line string) *listItemLine {
+	if hrPattern.MatchString(line) {
+		return nil
+	}
+	if m := bulletPattern.FindStringSubmatch(line); m != nil {
+		return &listItemLine{marker: m[2], text: m[3], indent: len(m[1]) + len(m[2]) + 1}
+	}
+	if m := orderedPattern.FindStringSubmatch(line); m != nil {
+		return &listItemLine{ordered: true, marker: m[3], start: m[2], text: m[4], indent: len(m[1]) + len(m[2]) + len(m[3]) + 1}
+	}
+	return nil
+}
+
+// リストを出力し、次に処理する行の位置を返す
+func renderList(b *strings.Builder, lines []string, start, depth int) int {
+	first := listItem(lines[start])
+	if first.ordered {
+		if n := strings.TrimLeft(first.start, "0"); n != "" && n != "1" {
+			b.WriteString(`<ol start="` + n + `">` + "\n")
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	i := start
+	for i < len(lines) {
+		item := listItem(lines[i])
+		if item == nil || item.ordered != first.ordered || item.marker != first.marker {
+			break
+		}
+
+		body := []string{item.text}
+		loose := false
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if isBlank(line) {
+				// 空行の後も字下げが続く場合は、同じ項目の続きとして扱う
+				if i+1 < len(lines) && indentation(lines[i+1]) >= item.indent {
+					body = append(body, "")
+					loose = true
+					continue
+				}
+				break
+			}
+			if indentation(line) >= item.indent {
+				body = append(body, stripIndent(line, item.indent))
+				continue
+			}
+			if startsBlock(line) {
+				break
+			}
+			// 字下げのない行は、段落の続きとして扱う
+			body = append(body, line)
+		}
+
+		b.WriteString("<li>")
+		renderItem(b, body, loose, depth)
+		b.WriteString("</li>\n")
+
+		// 項目の間の空行は読み飛ばす
+		if i < len(lines) && isBlank(lines[i]) && i+1 < len(lines) {
+			if next := listItem(lines[i+1]); next != nil && next.ordered == first.ordered && next.marker == first.marker {
+				i++
+			}
+		}
+	}
+
+	if first.ordered {
+		b.WriteString("</ol>\n")
+	} else {
+		b.WriteString("</ul>\n")
+	}
+	return i
+}
+
+// リストの項目の本文を出力する。段落が1つだけの場合は<p>で囲まない
+func renderItem(b *strings.Builder, body []string, loose bool, depth int) {
+	if depth >= maxDepth {
+		renderInline(b, strings.TrimSpace(strings.Join(body, "\n")))
+		return
+	}
+
+	var inner strings.Builder
+	renderBlocks(&inner, body, depth+1)
+	out := inner.String()
+	if !loose && strings.HasPrefix(out, "<p>") {
+		if end := strings.Index(out, "</p>\n"); end >= 0 {
+			out = out[len("<p>"):end] + out[end+len("</p>"):]
+		}
+	}
+	b.WriteString(strings.TrimSuffix(out, "\n"))
+}
+
+// 行頭の字下げの幅を返す。タブは4文字として数える
+func indentation(line string) int {
+	n := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// 行頭から幅nまでの字下げを取り除く
+func stripIndent(line string, n int) string {
+	width := 0
+	for i := 0; i < len(line); i++ {
+		if width >= n {
+			return line[i:]
+		}
+		switch line[i] {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return line[i:]
+		}
+	}
+	return ""
+}
+
+// リンク先として許可するURLかどうかを返す。スキームのないURLは相対URLとして許可する
+func safeURL(u string) bool {
+	if u == "" {
+		return false
+	}
+	for _, r := range u {
+		// 制御文字や空白を挟んだスキームは、ブラウザによって解釈が異なるため許可しない
+		if r <= ' ' || r == 0x7f {
+			return false
+		}
+	}
+	// パスやクエリより前に:がある場合は、スキームとして扱う
+	path := u
+	if k := strings.IndexAny(u, "/?#"); k >= 0 {
+		path = u[:k]
+	}
+	if !strings.Contains(path, ":") {
+		return true
+	}
+	m := schemePattern.FindStringSubmatch(u)
+	return m != nil && allowedSchemes[strings.ToLower(m[1])]
+}
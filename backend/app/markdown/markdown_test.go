@@ -0,0 +1,147 @@
+package markdown_test
+
+import (
+	"backend/app/markdown"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	cases := map[string]struct {
+		src  string
+		want string
+	}{
+		"段落":        {"買い物\nリスト", "<p>買い物\nリスト</p>"},
+		"空行で段落を分ける": {"1つ目\n\n2つ目", "<p>1つ目</p>\n<p>2つ目</p>"},
+		"見出し":       {"## 手順 ##", "<h2>手順</h2>"},
+		"強調":        {"**必ず**_確認_する", "<p><strong>必ず</strong><em>確認</em>する</p>"},
+		"単語中の_":     {"snake_case_name", "<p>snake_case_name</p>"},
+		"閉じない強調":    {"*a **b", "<p>*a **b</p>"},
+		"インラインコード":  {"`a < b` と ``x`y``", "<p><code>a &lt; b</code> と <code>x`y</code></p>"},
+		"コードブロック": {
+			"```go\nfmt.Println(\"<b>\")\n```",
+			"<pre><code class=\"language-go\">fmt.Println(&#34;&lt;b&gt;&#34;)\n</code></pre>",
+		},
+		"引用":      {"> 引用\n> 続き", "<blockquote>\n<p>引用\n続き</p>\n</blockquote>"},
+		"区切り線":    {"a\n\n---", "<p>a</p>\n<hr>"},
+		"箇条書き":    {"- 牛乳\n- 卵", "<ul>\n<li>牛乳</li>\n<li>卵</li>\n</ul>"},
+		"番号付きリスト": {"3. 三\n4. 四", "<ol start=\"3\">\n<li>三</li>\n<li>四</li>\n</ol>"},
+		"入れ子のリスト": {
+			"- 親\n  - 子",
+			"<ul>\n<li>親\n<ul>\n<li>子</li>\n</ul></li>\n</ul>",
+		},
+		"改行": {"1行目  \n2行目", "<p>1行目<br>\n2行目</p>"},
+		"リンク": {
+			`[資料](https://example.com/a?b=1&c=2 "タイトル")`,
+			`<p><a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener noreferrer">資料</a></p>`,
+		},
+		"相対リンク": {"[一覧](/todos)", `<p><a href="/todos" rel="nofollow noopener noreferrer">一覧</a></p>`},
+		"自動リンク": {
+			"<mailto:a@example.com>",
+			`<p><a href="mailto:a@example.com" rel="nofollow noopener noreferrer">mailto:a@example.com</a></p>`,
+		},
+		"画像はリンクにする": {
+			"![図](https://example.com/a.png)",
+			`<p><a href="https://example.com/a.png" rel="nofollow noopener noreferrer">図</a></p>`,
+		},
+		"エスケープ": {`\*そのまま\*`, "<p>*そのまま*</p>"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := markdown.Render(c.src); got != c.want {
+				t.Errorf("want: %q, got: %q", c.want, got)
+			}
+		})
+	}
+}
+
+// 危険なHTMLやURLが出力されないことを確認する
+func TestRenderSanitizes(t *testing.T) {
+	cases := map[string]struct {
+		src  string
+		want string
+	}{
+		"scriptタグ":       {"<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		"イベント属性":         {`<img src=x onerror="alert(1)">`, "<p>&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</p>"},
+		"javascriptのリンク": {"[押す](javascript:alert(1))", "<p>押す)</p>"},
+		"大文字のスキーム":       {"[押す](JaVaScRiPt:alert)", "<p>押す</p>"},
+		"dataのリンク":       {"[押す](data:text/html,x)", "<p>押す</p>"},
+		"vbscriptの自動リンク": {"<vbscript:msgbox>", "<p>&lt;vbscript:msgbox&gt;</p>"},
+		"制御文字を挟んだスキーム":   {"[押す](java\tscript:alert)", "<p>[押す](java\tscript:alert)</p>"},
+		"属性の引用符を閉じる": {
+			`[a](https://example.com/"onmouseover="alert)`,
+			`<p><a href="https://example.com/&#34;onmouseover=&#34;alert" rel="nofollow noopener noreferrer">a</a></p>`,
+		},
+		"言語名による属性の注入": {"```\"onclick=x\n```", "<pre><code></code></pre>"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := markdown.Render(c.src); got != c.want {
+				t.Errorf("want: %q, got: %q", c.want, got)
+			}
+		})
+	}
+}
+
+// 閉じない記号が大量に続く入力でも、すぐに処理が終わることを確認する
+func TestRenderLargeInput(t *testing.T) {
+	inputs := []string{
+		strings.Repeat("*a", 32*1024),
+		strings.Repeat("[a](", 16*1024),
+		strings.Repeat("`a``", 16*1024),
+		strings.Repeat("[", 64*1024),
+		strings.Repeat("> ", 32*1024),
+		strings.Repeat("- ", 32*1024),
+	}
+
+	for _, src := range inputs {
+		start := time.Now()
+		markdown.Render(src)
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("処理に時間がかかりすぎています: %s (%q...)", elapsed, src[:8])
+		}
+	}
+}
+
+var (
+	// 出力されるタグ。属性はリンクのhrefとrel、コードブロックのclassのみ
+	tagPattern     = regexp.MustCompile(`<[^>]*>`)
+	allowedTag     = regexp.MustCompile(`^</?(?:p|h[1-6]|strong|em|code|pre|blockquote|ul|ol|li|hr|br)>$|^<ol start="\d+">$|^<code class="language-[A-Za-z0-9_+-]+">$|^<a href="[^"<>]*" rel="nofollow noopener noreferrer">$|^</a>$`)
+	hrefPattern    = regexp.MustCompile(`href="([^"]*)"`)
+	allowedHrefURL = regexp.MustCompile(`^(?i:https?:|mailto:|[^:]*$|[^:]*[/?#])`)
+)
+
+func FuzzRender(f *testing.F) {
+	for _, seed := range []string{
+		"# 見出し\n\n- [リンク](https://example.com) **強調**\n  1. `code`",
+		"<script>alert(1)</script>",
+		"[a](javascript:alert(1)) ![b](data:x) <vbscript:x>",
+		"> 引用\n> ```\n> <b>\n> ```",
+		"*a _b* c_ [d](</e f> \"g\")",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, src string) {
+		out := markdown.Render(src)
+
+		// 許可したタグ以外は出力されない
+		for _, tag := range tagPattern.FindAllString(out, -1) {
+			if !allowedTag.MatchString(tag) {
+				t.Fatalf("許可していないタグが出力されました: %q -> %q", src, tag)
+			}
+		}
+
+		// リンク先は許可したスキームか相対URLのみ
+		for _, m := range hrefPattern.FindAllStringSubmatch(out, -1) {
+			href := strings.ReplaceAll(m[1], "&amp;", "&")
+			if !allowedHrefURL.MatchString(href) {
+				t.Fatalf("許可していないURLが出力されました: %q -> %q", src, href)
+			}
+		}
+	})
+}
@@ -0,0 +1,73 @@
+// mentionは、コメントの本文から@で始まるユーザー名の言及を取り出すパッケージ
+package mention
+
+import "strings"
+
+const (
+	// ユーザー名の最大長
+	maxNameLength = 32
+	// 1つの本文から取り出す言及の上限。これを超えた分は無視する
+	MaxMentions = 20
+)
+
+// Parseは、本文中の言及（@ユーザー名）を現れた順に重複なく返す。
+// メールアドレスのように直前が英数字の@や、コードスパン（`...`）の中の@は言及として扱わない。
+// ユーザー名は英数字と_.-からなり、末尾の.と-は文の区切りとみなして含めない。
+// 大文字と小文字は区別しない
+func Parse(body string) []string {
+	var names []string
+	seen := map[string]bool{}
+	inCode := false
+
+	for i := 0; i < len(body) && len(names) < MaxMentions; i++ {
+		c := body[i]
+		if c == '`' {
+			inCode = !inCode
+			continue
+		}
+		if c != '@' || inCode || (i > 0 && isNameByte(body[i-1])) || (i > 0 && body[i-1] == '@') {
+			continue
+		}
+
+		end := i + 1
+		for end < len(body) && isNameByte(body[end]) {
+			end++
+		}
+		name := strings.TrimRight(body[i+1:end], ".-")
+		i = end - 1
+		if name == "" || len(name) > maxNameLength || !isNameStart(name[0]) {
+			continue
+		}
+
+		key := strings.ToLower(name)
+		if !seen[key] {
+			seen[key] = true
+			names = append(names, key)
+		}
+	}
+	return names
+}
+
+// Addedは、beforeになくafterにある言及を返す。編集で新たに言及されたユーザーのみ通知するために使う
+func Added(before, after []string) []string {
+	existing := map[string]bool{}
+	for _, name := range before {
+		existing[name] = true
+	}
+
+	var added []string
+	for _, name := range after {
+		if !existing[name] {
+			added = append(added, name)
+		}
+	}
+	return added
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isNameByte(c byte) bool {
+	return isNameStart(c) || c == '.' || c == '-'
+}
@@ -0,0 +1,57 @@
+package mention_test
+
+import (
+	"backend/app/mention"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]struct {
+		body string
+		want []string
+	}{
+		"言及なし":           {"今日中に確認します", nil},
+		"文頭と文中":          {"@alice と @bob_2 に確認", []string{"alice", "bob_2"}},
+		"日本語の直後":         {"確認は@aliceさんへ", []string{"alice"}},
+		"重複と大文字小文字":      {"@Alice @alice @ALICE", []string{"alice"}},
+		"文末の記号は含めない":     {"@alice. @bob-, (@carol)", []string{"alice", "bob", "carol"}},
+		"ドットを含む名前":       {"@taro.yamada お願いします", []string{"taro.yamada"}},
+		"メールアドレス":        {"alice@example.com に送付", nil},
+		"コードスパンの中":       {"`@alice` は例です。@bob", []string{"bob"}},
+		"@のみ":            {"@ @@ @.", nil},
+		"長すぎる名前":         {"@" + strings.Repeat("a", 33), nil},
+		"連続した@":          {"@@alice", nil},
+		"名前の先頭は英数字か下線のみ": {"@-alice", nil},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := mention.Parse(c.body); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want: %v, got: %v", c.want, got)
+			}
+		})
+	}
+}
+
+// 言及の上限を超えた分は無視することを確認する
+func TestParseLimit(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < mention.MaxMentions+5; i++ {
+		b.WriteString("@user")
+		b.WriteByte(byte('a' + i))
+		b.WriteByte(' ')
+	}
+
+	if got := mention.Parse(b.String()); len(got) != mention.MaxMentions {
+		t.Errorf("want: %d, got: %d", mention.MaxMentions, len(got))
+	}
+}
+
+func TestAdded(t *testing.T) {
+	got := mention.Added([]string{"alice", "bob"}, []string{"bob", "carol"})
+	if want := []string{"carol"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
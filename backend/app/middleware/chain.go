@@ -6,6 +6,9 @@ import (
 
 // ミドルウェアを連結する
 func Chain(next http.Handler, rl *RateLimiter) http.Handler {
+	next = Workspace(next)
+	next = User(next)
+	next = RequestID(next)
 	next = CORS(next)
 	next = JSONContentType(next)
 	next = LimitRequestBody(next)
@@ -3,14 +3,29 @@ package middleware
 import (
 	"backend/app/model"
 	"backend/app/response"
+	"mime"
 	"net/http"
 )
 
+// パスごとに受け付けるContent-Type。設定のないパスはJSON形式のみ受け付ける
+var contentTypes = newRouteTable[[]string]()
+
+// SetContentTypesは、パターンに一致するパスでJSON形式の代わりに受け付けるメディアタイプを設定する
+func SetContentTypes(pattern string, mediaTypes ...string) {
+	contentTypes.set(pattern, mediaTypes)
+}
+
 // JSONContentTypeは、POST/PUTリクエストのContent-TypeがJSON形式であることを確認するミドルウェア。
 func JSONContentType(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost || r.Method == http.MethodPut {
-			if r.Header.Get("Content-Type") != "application/json" {
+			if allowed, ok := contentTypes.lookup(r); ok {
+				if !hasMediaType(r, allowed) {
+					const m = "送信できないデータの形式です。"
+					response.WriteTodosResponse(w, []model.Todo{}, http.StatusUnsupportedMediaType, m)
+					return
+				}
+			} else if r.Header.Get("Content-Type") != "application/json" {
 				const m = "JSON形式のデータを送信してください。"
 				response.WriteTodosResponse(w, []model.Todo{}, http.StatusRequestEntityTooLarge, m)
 				return
@@ -19,3 +34,17 @@ func JSONContentType(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// リクエストのContent-Typeが、パラメーターを除いて指定のメディアタイプのいずれかと一致するかどうかを返す
+func hasMediaType(r *http.Request, allowed []string) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, t := range allowed {
+		if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
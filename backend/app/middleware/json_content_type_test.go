@@ -0,0 +1,40 @@
+package middleware_test
+
+import (
+	"backend/app/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// パスごとに設定したContent-Typeのみ受け付けることを確認する
+func TestJSONContentType(t *testing.T) {
+	middleware.SetContentTypes("/content-type-test/{id}/upload", "multipart/form-data")
+
+	cases := map[string]struct {
+		path        string
+		contentType string
+		wantCode    int
+	}{
+		"JSON形式":      {"/content-type-test/1", "application/json", http.StatusOK},
+		"JSON以外":      {"/content-type-test/1", "text/plain", http.StatusRequestEntityTooLarge},
+		"設定したメディアタイプ": {"/content-type-test/1/upload", "multipart/form-data; boundary=abc", http.StatusOK},
+		"設定したパスではJSON形式も受け付けない": {"/content-type-test/1/upload", "application/json", http.StatusUnsupportedMediaType},
+		"Content-Typeの形式が不正":    {"/content-type-test/1/upload", "multipart/form-data; boundary", http.StatusUnsupportedMediaType},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, c.path, nil)
+			req.Header.Set("Content-Type", c.contentType)
+			middleware.JSONContentType(next).ServeHTTP(rec, req)
+
+			if rec.Code != c.wantCode {
+				t.Errorf("want: %d, got: %d", c.wantCode, rec.Code)
+			}
+		})
+	}
+}
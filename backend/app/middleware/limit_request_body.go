@@ -8,12 +8,35 @@ import (
 	"net/http"
 )
 
+// 既定のリクエストボディの上限。1024 bytes = 1KB
+const DefaultMaxBodySize = 1024
+
+// パスごとのリクエストボディの上限
+var bodyLimits = newRouteTable[int64]()
+
+// SetBodyLimitは、パターンに一致するパスのリクエストボディの上限を設定する。
+// 負の値を指定した場合は、このミドルウェアでは読み取らず、ハンドラー側で制限する
+func SetBodyLimit(pattern string, limit int64) {
+	bodyLimits.set(pattern, limit)
+}
+
+// リクエストのパスに対応するリクエストボディの上限を返す
+func bodyLimit(r *http.Request) int64 {
+	if limit, ok := bodyLimits.lookup(r); ok {
+		return limit
+	}
+	return DefaultMaxBodySize
+}
+
 // リクエストボディのサイズを制限するミドルウェア
 func LimitRequestBody(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost || r.Method == http.MethodPut {
-			// 1KB までのリクエストボディのみ受け付ける
-			const maxBodySize = 1024 // 1024 bytes = 1KB
+			maxBodySize := bodyLimit(r)
+			if maxBodySize < 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
 			r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
 
 			// リクエストボディを読み取る
@@ -0,0 +1,49 @@
+package middleware_test
+
+import (
+	"backend/app/middleware"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// パスごとに設定したリクエストボディの上限が適用されることを確認する
+func TestLimitRequestBody(t *testing.T) {
+	middleware.SetBodyLimit("/limit-test/large", 4096)
+	middleware.SetBodyLimit("/limit-test/stream/{id}", -1)
+
+	cases := map[string]struct {
+		path     string
+		size     int
+		wantCode int
+	}{
+		"既定の上限以内":    {"/limit-test/other", 1024, http.StatusOK},
+		"既定の上限を超える":  {"/limit-test/other", 1025, http.StatusRequestEntityTooLarge},
+		"設定した上限以内":   {"/limit-test/large", 4096, http.StatusOK},
+		"設定した上限を超える": {"/limit-test/large", 4097, http.StatusRequestEntityTooLarge},
+		"制限しないパス":    {"/limit-test/stream/1", 1 << 20, http.StatusOK},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var read int
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				read = len(body)
+			})
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, c.path, strings.NewReader(strings.Repeat("a", c.size)))
+			middleware.LimitRequestBody(next).ServeHTTP(rec, req)
+
+			if rec.Code != c.wantCode {
+				t.Errorf("want: %d, got: %d", c.wantCode, rec.Code)
+			}
+			if c.wantCode == http.StatusOK && read != c.size {
+				t.Errorf("ハンドラーが読み取ったサイズ want: %d, got: %d", c.size, read)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"backend/app/requestctx"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	// リクエストIDを受け渡すヘッダー
+	RequestIDHeader = "X-Request-ID"
+	// クライアントから受け付けるリクエストIDの最大長
+	maxRequestIDLength = 64
+)
+
+// RequestIDは、リクエストごとにIDを割り当ててcontextとレスポンスヘッダーに設定するミドルウェア。
+// クライアントが妥当なIDを送信した場合はそれを引き継ぐ。
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		ctx := requestctx.WithRequestID(r.Context(), id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	// crypto/randの読み取りは失敗しない
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ログやDBに保存しても安全な文字のみで構成されているか確認する
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+)
+
+// routeTableは、ServeMuxのパターンでリクエストのパスを照合し、パターンごとの設定値を返す
+type routeTable[T any] struct {
+	mu     sync.RWMutex
+	mux    *http.ServeMux
+	values map[string]T
+}
+
+func newRouteTable[T any]() *routeTable[T] {
+	return &routeTable[T]{mux: http.NewServeMux(), values: map[string]T{}}
+}
+
+// パターンに設定値を登録する。登録済みのパターンは設定値を置き換える
+func (t *routeTable[T]) set(pattern string, value T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.values[pattern]; !ok {
+		t.mux.Handle(pattern, http.NotFoundHandler())
+	}
+	t.values[pattern] = value
+}
+
+// リクエストのパスに最も具体的に一致するパターンの設定値を返す
+func (t *routeTable[T]) lookup(r *http.Request) (T, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	_, pattern := t.mux.Handler(r)
+	value, ok := t.values[pattern]
+	return value, ok
+}
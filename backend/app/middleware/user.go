@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"backend/app/auth"
+	"backend/app/constant"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/websocket"
+	"net/http"
+	"strings"
+)
+
+const (
+	// 認証トークンを指定するヘッダーの接頭辞
+	bearerPrefix = "Bearer "
+	// WebSocketの接続で、認証トークンをサブプロトコルとして指定する場合の接頭辞。
+	// ブラウザはWebSocketの接続にAuthorizationヘッダーを指定できないため、この形式で受け付ける
+	BearerProtocolPrefix = "bearer."
+)
+
+// Userは、リクエストを行ったユーザーを認証してcontextに設定するミドルウェア。
+// ユーザーは認証トークンをauth.Default()で検証して求め、
+// トークンの指定がない場合は匿名ユーザー（0）として扱う。トークンが不正な場合は401を返す。
+func User(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, given := requestToken(r)
+		if !given {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, ok := verifyToken(token)
+		if !ok {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusUnauthorized, constant.USER_ERR_INVALID_TOKEN)
+			return
+		}
+
+		ctx := requestctx.WithUserID(r.Context(), userID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// リクエストから認証トークンを取り出す。AuthorizationヘッダーのBearerトークンを優先し、
+// ない場合はWebSocketのハンドシェイクで提示された「bearer.トークン」のサブプロトコルを使用する。
+// Bearer以外の形式のAuthorizationヘッダーは、検証できない空のトークンとして扱う
+func requestToken(r *http.Request) (string, bool) {
+	if v := r.Header.Get("Authorization"); v != "" {
+		token, _ := strings.CutPrefix(v, bearerPrefix)
+		if token == v {
+			return "", true
+		}
+		return token, true
+	}
+	if websocket.IsUpgrade(r) {
+		for _, p := range websocket.Subprotocols(r) {
+			if token, ok := strings.CutPrefix(p, BearerProtocolPrefix); ok {
+				return token, true
+			}
+		}
+	}
+	return "", false
+}
+
+// トークンを検証してユーザーIDを返す。トークンを検証できない場合はfalseを返す
+func verifyToken(token string) (int, bool) {
+	signer := auth.Default()
+	if signer == nil {
+		return 0, false
+	}
+	userID, err := signer.Verify(token)
+	if err != nil {
+		return 0, false
+	}
+	return userID, true
+}
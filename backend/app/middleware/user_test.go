@@ -0,0 +1,72 @@
+package middleware_test
+
+import (
+	"backend/app/auth"
+	"backend/app/middleware"
+	"backend/app/requestctx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// 署名を検証したトークンのユーザーのみcontextに設定されることを確認する
+func TestUser(t *testing.T) {
+	signer := auth.NewSigner([]byte("test-secret"))
+	auth.SetDefault(signer)
+	defer auth.SetDefault(nil)
+
+	forged := auth.NewSigner([]byte("forged-secret"))
+
+	cases := map[string]struct {
+		header         string
+		protocols      string
+		wantStatusCode int
+		wantUserID     int
+	}{
+		"トークンを指定":     {header: "Bearer " + signer.Issue(7, time.Hour), wantStatusCode: http.StatusOK, wantUserID: 7},
+		"指定なしは匿名":     {header: "", wantStatusCode: http.StatusOK, wantUserID: 0},
+		"有効期限切れ":      {header: "Bearer " + signer.Issue(7, -time.Second), wantStatusCode: http.StatusUnauthorized},
+		"別の鍵で署名":      {header: "Bearer " + forged.Issue(7, time.Hour), wantStatusCode: http.StatusUnauthorized},
+		"ユーザーIDのみを指定": {header: "Bearer 7", wantStatusCode: http.StatusUnauthorized},
+		"Bearer以外の形式": {header: "Basic dXNlcjpwYXNz", wantStatusCode: http.StatusUnauthorized},
+		"WebSocketのサブプロトコルで指定": {
+			protocols:      "todo, " + middleware.BearerProtocolPrefix + signer.Issue(7, time.Hour),
+			wantStatusCode: http.StatusOK,
+			wantUserID:     7,
+		},
+		"WebSocketのサブプロトコルが不正": {
+			protocols:      "todo, " + middleware.BearerProtocolPrefix + "7",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var gotUserID int
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUserID = requestctx.UserID(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			if c.header != "" {
+				req.Header.Set("Authorization", c.header)
+			}
+			if c.protocols != "" {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", "websocket")
+				req.Header.Set("Sec-WebSocket-Protocol", c.protocols)
+			}
+			rec := httptest.NewRecorder()
+
+			middleware.User(next).ServeHTTP(rec, req)
+
+			if rec.Code != c.wantStatusCode {
+				t.Errorf("期待したステータスコード: %d, 実際のステータスコード: %d", c.wantStatusCode, rec.Code)
+			}
+			if gotUserID != c.wantUserID {
+				t.Errorf("期待したユーザーID: %d, 実際のユーザーID: %d", c.wantUserID, gotUserID)
+			}
+		})
+	}
+}
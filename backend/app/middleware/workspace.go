@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"backend/app/constant"
+	"backend/app/database"
+	"backend/app/model"
+	"backend/app/requestctx"
+	"backend/app/response"
+	"backend/app/websocket"
+	"database/sql"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ワークスペースを指定するヘッダー
+const WorkspaceHeader = "X-Workspace-ID"
+
+// WebSocketの接続でワークスペースを指定するクエリパラメータ。
+// ブラウザはWebSocketの接続にヘッダーを指定できないため、ヘッダーの代わりに受け付ける
+const WorkspaceQuery = "workspace_id"
+
+// ワークスペースを解決しないパス（共有リンクなど、ハンドラー側で解決するもの）
+var publicRoutes = newRouteTable[bool]()
+
+// SetPublicは、パターンに一致するパスを認証とワークスペースの解決の対象外にする
+func SetPublic(pattern string) {
+	publicRoutes.set(pattern, true)
+}
+
+// Workspaceは、リクエストのワークスペースを解決してcontextに設定するミドルウェア。
+// ヘッダー（WebSocketの接続ではクエリパラメータも可）、サブドメインの順に解決し、どちらもなければユーザーが所属する最初のワークスペースを使用する。
+// 認証していない場合は401、ユーザーがワークスペースのメンバーでない場合は403を返す。
+func Workspace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if public, _ := publicRoutes.lookup(r); public {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID := requestctx.UserID(r.Context())
+		if userID == 0 {
+			response.WriteTodosResponse(w, []model.Todo{}, http.StatusUnauthorized, constant.USER_ERR_UNAUTHORIZED)
+			return
+		}
+
+		workspaceID, code, errMessage := resolveWorkspace(r, userID)
+		if errMessage != "" {
+			response.WriteTodosResponse(w, []model.Todo{}, code, errMessage)
+			return
+		}
+
+		ctx := requestctx.WithWorkspaceID(r.Context(), workspaceID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func resolveWorkspace(r *http.Request, userID int) (int, int, string) {
+	db := database.GetDB()
+
+	v := r.Header.Get(WorkspaceHeader)
+	if v == "" && websocket.IsUpgrade(r) {
+		v = r.URL.Query().Get(WorkspaceQuery)
+	}
+
+	var workspaceID int
+	if v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return 0, http.StatusBadRequest, constant.WORKSPACE_ERR_INVALID_WORKSPACE
+		}
+		workspaceID = id
+	} else if slug := subdomain(r.Host); slug != "" {
+		if err := db.QueryRow("SELECT id FROM workspaces WHERE slug = ?", slug).Scan(&workspaceID); err != nil {
+			if err == sql.ErrNoRows {
+				return 0, http.StatusNotFound, constant.WORKSPACE_ERR_NOT_FOUND_WORKSPACE
+			}
+			return 0, http.StatusInternalServerError, constant.WORKSPACE_ERR_FAILED_GET_WORKSPACE
+		}
+	} else {
+		query := "SELECT workspace_id FROM workspace_members WHERE user_id = ? ORDER BY workspace_id LIMIT 1"
+		if err := db.QueryRow(query, userID).Scan(&workspaceID); err != nil {
+			if err == sql.ErrNoRows {
+				return 0, http.StatusForbidden, constant.WORKSPACE_ERR_NOT_MEMBER
+			}
+			return 0, http.StatusInternalServerError, constant.WORKSPACE_ERR_FAILED_GET_WORKSPACE
+		}
+		return workspaceID, 0, ""
+	}
+
+	// 指定されたワークスペースは、メンバーの場合のみ使用できる
+	var memberID int
+	query := "SELECT user_id FROM workspace_members WHERE workspace_id = ? AND user_id = ?"
+	if err := db.QueryRow(query, workspaceID, userID).Scan(&memberID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, http.StatusForbidden, constant.WORKSPACE_ERR_NOT_MEMBER
+		}
+		return 0, http.StatusInternalServerError, constant.WORKSPACE_ERR_FAILED_GET_WORKSPACE
+	}
+	return workspaceID, 0, ""
+}
+
+// subdomainは、ホスト名の先頭ラベルをワークスペースのスラッグとして返す。
+// localhostやIPアドレスなど、サブドメインを持たないホストの場合は空文字を返す。
+func subdomain(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) < 3 || labels[0] == "www" {
+		return ""
+	}
+	return labels[0]
+}
@@ -0,0 +1,176 @@
+package middleware_test
+
+import (
+	"backend/app/database"
+	"backend/app/middleware"
+	"backend/app/requestctx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const testUserID = 5
+
+// expectMemberは、ワークスペースのメンバーかどうかの確認を期待値として設定します。
+func expectMember(mock sqlmock.Sqlmock, workspaceID int, member bool) {
+	rows := sqlmock.NewRows([]string{"user_id"})
+	if member {
+		rows.AddRow(testUserID)
+	}
+	mock.ExpectQuery(`^SELECT user_id FROM workspace_members WHERE workspace_id = \? AND user_id = \?$`).
+		WithArgs(workspaceID, testUserID).
+		WillReturnRows(rows)
+}
+
+func TestWorkspace(t *testing.T) {
+	middleware.SetPublic("/workspace-test/shared/{token}")
+
+	cases := map[string]struct {
+		path            string
+		host            string
+		header          string
+		websocket       bool
+		anonymous       bool
+		mockSetup       func(mock sqlmock.Sqlmock)
+		wantStatusCode  int
+		wantWorkspaceID int
+	}{
+		"ヘッダーで指定": {
+			host:   "localhost:8080",
+			header: "3",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				expectMember(mock, 3, true)
+			},
+			wantStatusCode:  http.StatusOK,
+			wantWorkspaceID: 3,
+		},
+		"WebSocketの接続でクエリパラメータで指定": {
+			path:      "/todos?workspace_id=3",
+			host:      "localhost:8080",
+			websocket: true,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				expectMember(mock, 3, true)
+			},
+			wantStatusCode:  http.StatusOK,
+			wantWorkspaceID: 3,
+		},
+		"ヘッダーが不正": {
+			host:           "localhost:8080",
+			header:         "abc",
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		"メンバーではないワークスペースを指定": {
+			host:   "localhost:8080",
+			header: "4",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				expectMember(mock, 4, false)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+		"サブドメインで指定": {
+			host: "team-a.todo.example.com",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT id FROM workspaces WHERE slug = \?`).
+					WithArgs("team-a").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				expectMember(mock, 7, true)
+			},
+			wantStatusCode:  http.StatusOK,
+			wantWorkspaceID: 7,
+		},
+		"存在しないサブドメイン": {
+			host: "unknown.todo.example.com",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT id FROM workspaces WHERE slug = \?`).
+					WithArgs("unknown").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		"指定なし": {
+			host: "localhost:8080",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT workspace_id FROM workspace_members WHERE user_id = \? ORDER BY workspace_id LIMIT 1$`).
+					WithArgs(testUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"workspace_id"}).AddRow(2))
+			},
+			wantStatusCode:  http.StatusOK,
+			wantWorkspaceID: 2,
+		},
+		"所属するワークスペースがない": {
+			host: "localhost:8080",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT workspace_id FROM workspace_members`).
+					WithArgs(testUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"workspace_id"}))
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+		"未認証": {
+			host:           "localhost:8080",
+			header:         "3",
+			anonymous:      true,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		"公開ルートは解決しない": {
+			path:           "/workspace-test/shared/abc",
+			host:           "localhost:8080",
+			header:         "3",
+			anonymous:      true,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			wantStatusCode: http.StatusOK,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("モックDBの作成に失敗しました: %s", err)
+			}
+			defer db.Close()
+			database.SetDB(db)
+
+			c.mockSetup(mock)
+
+			var gotWorkspaceID int
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotWorkspaceID = requestctx.WorkspaceID(r.Context())
+			})
+
+			path := c.path
+			if path == "" {
+				path = "/todos"
+			}
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Host = c.host
+			if c.header != "" {
+				req.Header.Set(middleware.WorkspaceHeader, c.header)
+			}
+			if c.websocket {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", "websocket")
+			}
+			if !c.anonymous {
+				req = req.WithContext(requestctx.WithUserID(req.Context(), testUserID))
+			}
+			rec := httptest.NewRecorder()
+
+			middleware.Workspace(next).ServeHTTP(rec, req)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("満たされていない期待値があります: %s", err)
+			}
+			if rec.Code != c.wantStatusCode {
+				t.Errorf("期待したステータスコード: %d, 実際のステータスコード: %d", c.wantStatusCode, rec.Code)
+			}
+			if gotWorkspaceID != c.wantWorkspaceID {
+				t.Errorf("期待したワークスペースID: %d, 実際のワークスペースID: %d", c.wantWorkspaceID, gotWorkspaceID)
+			}
+		})
+	}
+}
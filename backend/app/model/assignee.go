@@ -0,0 +1,6 @@
+package model
+
+// TodoAssigneesは、Todoの担当者のユーザーIDの一覧
+type TodoAssignees struct {
+	UserIDs []int `json:"user_ids"`
+}
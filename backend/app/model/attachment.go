@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Attachmentは、Todoに添付したファイル
+type Attachment struct {
+	ID       int    `json:"id"`
+	TodoID   int    `json:"todo_id"`
+	Filename string `json:"filename"`
+	// 内容から判定したメディアタイプ。アップロード時に指定されたContent-Typeは使わない
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	// 内容のSHA-256。同じ内容のファイルは保存先を共有する
+	SHA256 string `json:"sha256"`
+	// 縮小画像の作成状態（pending, ready, failed）。画像以外は空
+	ThumbnailStatus string `json:"thumbnail_status,omitempty"`
+	// 縮小画像のURL。内容ごとに固定のため、長期間キャッシュできる
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
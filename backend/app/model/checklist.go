@@ -0,0 +1,29 @@
+package model
+
+// ChecklistItemは、Todoの中の小さな手順
+type ChecklistItem struct {
+	ID        int    `json:"id"`
+	Text      string `json:"text"`
+	IsChecked bool   `json:"is_checked"`
+	// 表示順。0から始まる
+	Position int `json:"position"`
+}
+
+// Checklistは、Todoのチェックリストと完了の割合
+type Checklist struct {
+	Items    []ChecklistItem   `json:"items"`
+	Progress ChecklistProgress `json:"progress"`
+}
+
+// ChecklistProgressは、チェックリストの完了の割合
+type ChecklistProgress struct {
+	Checked int `json:"checked"`
+	Total   int `json:"total"`
+	// 完了した項目の割合（0〜1）。項目がない場合は0
+	Ratio float64 `json:"ratio"`
+}
+
+// ChecklistOrderは、並べ替え後の項目のIDの並び
+type ChecklistOrder struct {
+	ItemIDs []int `json:"item_ids"`
+}
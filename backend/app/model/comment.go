@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Commentは、Todoに付けたコメント
+type Comment struct {
+	ID       int `json:"id"`
+	TodoID   int `json:"todo_id"`
+	AuthorID int `json:"author_id"`
+	// 本文。@ユーザー名で言及したユーザーに通知する
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Activityは、Todoのコメントと変更を時系列に並べた項目
+type Activity struct {
+	// comment, created, updated, completed, reopened, reverted, deletedのいずれか
+	Type string `json:"type"`
+	// 実行したユーザー。匿名の場合はnull
+	ActorID *int `json:"actor_id"`
+	// コメントの場合のみ返す
+	Comment *Comment `json:"comment,omitempty"`
+	// 変更の場合に、値が変わった項目の名前
+	Changes   []string  `json:"changes,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
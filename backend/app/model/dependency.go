@@ -0,0 +1,6 @@
+package model
+
+// TodoDependenciesは、Todoの完了を妨げている（先に完了すべき）TodoのIDの一覧
+type TodoDependencies struct {
+	BlockerIDs []int `json:"blocker_ids"`
+}
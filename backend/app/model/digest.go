@@ -0,0 +1,14 @@
+package model
+
+// DigestPreferenceは、ユーザーごとのダイジェストメールの配信設定
+type DigestPreference struct {
+	WorkspaceID int `json:"-"`
+	UserID      int `json:"-"`
+	// falseの場合は配信しない
+	Enabled bool `json:"enabled"`
+	// 配信先。自分の確認済みのアドレスのみ指定でき、省略した場合も確認済みのアドレスに配信する
+	Email string `json:"email"`
+	// 配信する時刻（HH:MM）
+	SendTime string `json:"send_time"`
+	TimeZone string `json:"time_zone"`
+}
@@ -0,0 +1,26 @@
+package model
+
+// Milestoneは、期間を区切ってTodoをまとめる単位（スプリントなど）
+type Milestone struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// 開始日と終了日（YYYY-MM-DD）。どちらの日も期間に含む
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// Burndownは、マイルストーンの残りの見積もりを日ごとに並べたもの
+type Burndown struct {
+	MilestoneID int `json:"milestone_id"`
+	// 開始日から、終了日と今日のうち早い方までの各日
+	Points []BurndownPoint `json:"points"`
+}
+
+// BurndownPointは、ある日の終わり（UTC）時点のマイルストーンの見積もり
+type BurndownPoint struct {
+	Date string `json:"date"`
+	// 未完了のTodoの見積もりの合計
+	RemainingPoints int `json:"remaining_points"`
+	// 完了したTodoも含めた見積もりの合計。途中でTodoを追加・削除した場合に変わる
+	TotalPoints int `json:"total_points"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Reminderは、Todoのリマインダー
+type Reminder struct {
+	ID       int       `json:"id"`
+	TodoID   int       `json:"todo_id"`
+	RemindAt time.Time `json:"remind_at"`
+	// 通知の方法（push, webhook, email）
+	Channel string `json:"channel"`
+	// 通知先。メールの場合は宛先のアドレスで、自分の確認済みのアドレスのみ指定できる
+	Target    string     `json:"target,omitempty"`
+	Status    string     `json:"status"`
+	SentAt    *time.Time `json:"sent_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
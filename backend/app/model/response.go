@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type TodoResponse struct {
 	Data   *Todo      `json:"data"`
 	Status StatusInfo `json:"status"`
@@ -9,3 +11,168 @@ type TodosResponse struct {
 	Data   []Todo     `json:"data"`
 	Status StatusInfo `json:"status"`
 }
+
+type ShareLinkResponse struct {
+	Data   *ShareLink `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type AuditLogsResponse struct {
+	Data   []AuditLog `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type TodoRevisionsResponse struct {
+	Data   []TodoRevision `json:"data"`
+	Status StatusInfo     `json:"status"`
+}
+
+type WebhookResponse struct {
+	Data   *Webhook   `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type WebhooksResponse struct {
+	Data   []Webhook  `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Data   []WebhookDelivery `json:"data"`
+	Status StatusInfo        `json:"status"`
+}
+
+type SyncChangesResponse struct {
+	Data   *SyncChanges `json:"data"`
+	Status StatusInfo   `json:"status"`
+}
+
+type SyncResultsResponse struct {
+	Data   []SyncResult `json:"data"`
+	Status StatusInfo   `json:"status"`
+}
+
+type OccurrencesResponse struct {
+	Data   []time.Time `json:"data"`
+	Status StatusInfo  `json:"status"`
+}
+
+type ReminderResponse struct {
+	Data   *Reminder  `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type RemindersResponse struct {
+	Data   []Reminder `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type DigestPreferenceResponse struct {
+	Data   *DigestPreference `json:"data"`
+	Status StatusInfo        `json:"status"`
+}
+
+type TodoSearchResponse struct {
+	Data   []TodoSearchResult `json:"data"`
+	Status StatusInfo         `json:"status"`
+}
+
+type SmartListResponse struct {
+	Data   *SmartList `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type SmartListsResponse struct {
+	Data   []SmartList `json:"data"`
+	Status StatusInfo  `json:"status"`
+}
+
+type TodoTagsResponse struct {
+	Data   *TodoTags  `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type TodoAssigneesResponse struct {
+	Data   *TodoAssignees `json:"data"`
+	Status StatusInfo     `json:"status"`
+}
+
+type TodoDependenciesResponse struct {
+	Data   *TodoDependencies `json:"data"`
+	Status StatusInfo        `json:"status"`
+}
+
+type WorkflowResponse struct {
+	Data   *Workflow  `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type BoardResponse struct {
+	Data   *Board     `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type TimeEntryResponse struct {
+	Data   *TimeEntry `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type TimeEntriesResponse struct {
+	Data   *TimeEntries `json:"data"`
+	Status StatusInfo   `json:"status"`
+}
+
+type TimeReportResponse struct {
+	Data   *TimeReport `json:"data"`
+	Status StatusInfo  `json:"status"`
+}
+
+type MilestoneResponse struct {
+	Data   *Milestone `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type MilestonesResponse struct {
+	Data   []Milestone `json:"data"`
+	Status StatusInfo  `json:"status"`
+}
+
+type BurndownResponse struct {
+	Data   *Burndown  `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type ChecklistResponse struct {
+	Data   *Checklist `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type AttachmentResponse struct {
+	Data   *Attachment `json:"data"`
+	Status StatusInfo  `json:"status"`
+}
+
+type AttachmentsResponse struct {
+	Data   []Attachment `json:"data"`
+	Status StatusInfo   `json:"status"`
+}
+
+type CommentResponse struct {
+	Data   *Comment   `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type CommentsResponse struct {
+	Data   []Comment  `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type ActivitiesResponse struct {
+	Data   []Activity `json:"data"`
+	Status StatusInfo `json:"status"`
+}
+
+type StatsResponse struct {
+	Data   *Stats     `json:"data"`
+	Status StatusInfo `json:"status"`
+}
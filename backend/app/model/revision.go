@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// TodoRevisionは、ある時点のTodoの状態と直前のリビジョンからの変更点
+type TodoRevision struct {
+	Revision  int           `json:"revision"`
+	Todo      Todo          `json:"todo"`
+	ActorID   *int          `json:"actor_id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+// FieldChangeは、フィールド単位の変更内容
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
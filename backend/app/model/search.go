@@ -0,0 +1,10 @@
+package model
+
+// TodoSearchResultは、全文検索で一致したTodo
+type TodoSearchResult struct {
+	Todo Todo `json:"todo"`
+	// 関連度。大きいほど検索語に関連する
+	Score float64 `json:"score"`
+	// 一致箇所を<mark>で囲んだタイトルの抜粋（HTMLエスケープ済み）
+	Snippet string `json:"snippet"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+type ShareLink struct {
+	ID        int        `json:"id"`
+	ListID    int        `json:"list_id"`
+	Token     string     `json:"token,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ShareLinkInputは共有リンク作成時のリクエストボディ
+type ShareLinkInput struct {
+	// 有効期限（時間単位）。0の場合は無期限
+	ExpiresInHours int `json:"expires_in_hours"`
+}
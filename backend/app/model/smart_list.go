@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// SmartListは、フィルターに名前を付けて保存したユーザーごとのリスト
+type SmartList struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// 問い合わせ言語のフィルター（例: is:open tag:work due:<7d）
+	Filter    string    `json:"filter"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TodoTagsは、Todoに付けるタグの一覧
+type TodoTags struct {
+	Tags []string `json:"tags"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Statsは、ワークスペースのTodoの集計
+type Stats struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Open      int `json:"open"`
+	// 期限を過ぎた未完了のTodoの数
+	Overdue  int           `json:"overdue"`
+	ByStatus []StatusCount `json:"by_status"`
+	// 完了したTodoの割合（0から1）。Todoがない場合は0
+	CompletionRate float64 `json:"completion_rate"`
+	// 期間内の各日（UTC）の推移
+	Days []StatsDay `json:"days"`
+	// 期間内に完了したTodoの、作成から完了までの平均（秒）。完了したTodoがない場合はnull
+	AverageSecondsToComplete *int `json:"average_seconds_to_complete"`
+	// 完了したTodoがある日の連続日数。今日か昨日まで続いている場合のみ数える。
+	// いずれも直近366日の完了から求める
+	CurrentStreak int `json:"current_streak"`
+	LongestStreak int `json:"longest_streak"`
+	// 集計した日時。キャッシュした集計を返す場合は過去の日時になる
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// StatusCountは、ステータスごとのTodoの数
+type StatusCount struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+// StatsDayは、ある日（UTC）のTodoの作成と完了の数
+type StatsDay struct {
+	Date      string `json:"date"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+	// その日の終わり時点で完了していたTodoの割合。Todoがなかった場合はnull
+	CompletionRate *float64 `json:"completion_rate"`
+}
@@ -0,0 +1,44 @@
+package model
+
+// SyncChangesは、変更トークン以降に変更されたTodoと削除されたTodoのID
+type SyncChanges struct {
+	Todos   []Todo `json:"todos"`
+	Deleted []int  `json:"deleted"`
+	// 次回の同期で指定する変更トークン
+	Token string `json:"token"`
+}
+
+// SyncMutationは、オフライン中にクライアントで行った変更1件
+type SyncMutation struct {
+	// クライアントが採番した変更のID。結果との対応付けに使用する
+	ClientID string `json:"client_id"`
+	Op       string `json:"op"`
+	ID       int    `json:"id"`
+	// クライアントが変更の元にしたリビジョン
+	BaseRevision int `json:"base_revision"`
+	// 更新の場合、省略した項目は変更しない
+	Todo TodoUpdate `json:"todo"`
+	// 更新の場合、未完了のTodoに依存していても完了できるようにする
+	AllowBlocked bool `json:"allow_blocked"`
+}
+
+type SyncRequest struct {
+	Mutations []SyncMutation `json:"mutations"`
+}
+
+// SyncConflictは、サーバーとクライアントが同じフィールドを異なる値に変更した競合
+type SyncConflict struct {
+	Field       string `json:"field"`
+	ClientValue any    `json:"client_value"`
+	ServerValue any    `json:"server_value"`
+}
+
+// SyncResultは、変更1件の適用結果
+type SyncResult struct {
+	ClientID string `json:"client_id"`
+	Status   string `json:"status"`
+	// 適用後のTodo。削除した場合と適用できなかった場合はnull
+	Todo      *Todo          `json:"todo"`
+	Conflicts []SyncConflict `json:"conflicts,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
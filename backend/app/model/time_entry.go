@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// TimeEntryは、Todoの作業時間の記録
+type TimeEntry struct {
+	ID     int `json:"id"`
+	TodoID int `json:"todo_id"`
+	UserID int `json:"user_id"`
+	// 作業の開始日時と終了日時。タイマーの実行中は終了日時がnull
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	// 作業時間（秒）。タイマーの実行中は0
+	DurationSeconds int `json:"duration_seconds"`
+	// 手動で記録する場合のメモ
+	Note string `json:"note,omitempty"`
+}
+
+// TimeEntriesは、Todoの作業時間の記録と合計
+type TimeEntries struct {
+	Entries []TimeEntry `json:"entries"`
+	// 終了した記録の作業時間の合計（秒）。実行中のタイマーは含まない
+	TotalSeconds int `json:"total_seconds"`
+}
+
+// TimeReportは、期間内の作業時間をgroup_byで指定した単位で集計したもの
+type TimeReport struct {
+	// 集計の期間（YYYY-MM-DD）。どちらの日も含む
+	From string `json:"from"`
+	To   string `json:"to"`
+	// list, tag, dayのいずれか
+	GroupBy string          `json:"group_by"`
+	Rows    []TimeReportRow `json:"rows"`
+	// 期間内の作業時間の合計（秒）。タグごとの集計では、複数のタグを付けたTodoを各行に含めるため行の合計とは一致しない
+	TotalSeconds int `json:"total_seconds"`
+}
+
+// TimeReportRowは、作業時間の集計の1行
+type TimeReportRow struct {
+	// リストのID、タグ、日付（YYYY-MM-DD）のいずれか。リストやタグがない場合は空文字
+	Key     string `json:"key"`
+	Seconds int    `json:"seconds"`
+}
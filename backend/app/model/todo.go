@@ -1,7 +1,62 @@
 package model
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type Todo struct {
 	ID         int    `json:"id"`
 	Title      string `json:"title"`
 	IsComplete bool   `json:"is_complete"`
+	// ワークフローのステータス。is_completeはステータスが完了として扱われるかどうかから決まる。
+	// 更新時に省略した場合は、is_completeの変更からステータスを決める
+	Status string `json:"status,omitempty"`
+	// 見積もり（ストーリーポイント）。見積もっていない場合は省略する
+	Estimate *int `json:"estimate,omitempty"`
+	// 楽観的ロック用のリビジョン番号。更新時に指定すると、一致しない場合は競合として扱う
+	Revision int `json:"revision"`
+	// 所属するリスト。どのリストにも属さない場合はnull
+	ListID *int `json:"list_id"`
+	// 所属するマイルストーン。どのマイルストーンにも属さない場合は省略する
+	MilestoneID *int `json:"milestone_id,omitempty"`
+	// 期限。指定しない場合はnull
+	DueAt *time.Time `json:"due_at"`
+	// 繰り返しのルール（RRULE）。期限を起点に繰り返す
+	Recurrence string `json:"recurrence,omitempty"`
+	// 繰り返しの日時を求めるタイムゾーン（IANA）。省略した場合はUTC
+	TimeZone string `json:"time_zone,omitempty"`
+	// メモ（Markdown）。保存時は入力をそのまま保持する
+	Notes string `json:"notes,omitempty"`
+	// render=htmlを指定した場合に返す、メモをサニタイズ済みのHTMLに変換したもの
+	NotesHTML string `json:"notes_html,omitempty"`
+	// include=checklistを指定した場合に返す、チェックリストと完了の割合
+	Checklist *Checklist `json:"checklist,omitempty"`
+	// include=assigneesを指定した場合に返す、担当者のユーザーID
+	Assignees []int `json:"assignees,omitempty"`
+	// include=time_spentを指定した場合に返す、記録した作業時間の合計（秒）
+	TimeSpent *int `json:"time_spent,omitempty"`
+}
+
+// TodoUpdateは、更新で指定されたTodoと、JSONで指定された項目。
+// 省略された項目は変更しないため、nullを指定した場合と区別する
+type TodoUpdate struct {
+	Todo
+	// 指定された項目のJSONのキー。nilの場合は、すべての項目が指定されたものとして扱う
+	Fields map[string]bool `json:"-"`
+}
+
+func (u *TodoUpdate) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &u.Todo); err != nil {
+		return err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	u.Fields = make(map[string]bool, len(raw))
+	for key := range raw {
+		u.Fields[key] = true
+	}
+	return nil
 }
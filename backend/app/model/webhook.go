@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+type Webhook struct {
+	ID         int      `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	// 署名用のシークレット。作成時のレスポンスでのみ返却する
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryは、Webhookの配信1件の状態
+type WebhookDelivery struct {
+	ID             int       `json:"id"`
+	WebhookID      int       `json:"webhook_id"`
+	EventType      string    `json:"event_type"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	LastStatusCode *int      `json:"last_status_code"`
+	LastError      string    `json:"last_error"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
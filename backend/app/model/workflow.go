@@ -0,0 +1,35 @@
+package model
+
+// Workflowは、リストのTodoが取りうるステータスと、ステータスの変更の規則
+type Workflow struct {
+	// ステータスを表示する順に並べたもの。最初の未完了のステータスを新しいTodoのステータスとする
+	States []WorkflowState `json:"states"`
+	// 許可するステータスの変更。空の場合はどのステータスにも変更できる
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+// WorkflowStateは、ワークフローのステータス
+type WorkflowState struct {
+	Name string `json:"name"`
+	// このステータスのTodoを完了として扱うかどうか
+	Done bool `json:"done"`
+}
+
+// WorkflowTransitionは、許可するステータスの変更
+type WorkflowTransition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Boardは、リストのTodoをステータスごとに並べたもの
+type Board struct {
+	ListID  int           `json:"list_id"`
+	Columns []BoardColumn `json:"columns"`
+}
+
+// BoardColumnは、ボードの1つのステータスの列
+type BoardColumn struct {
+	Status string `json:"status"`
+	Done   bool   `json:"done"`
+	Todos  []Todo `json:"todos"`
+}
@@ -0,0 +1,26 @@
+package model
+
+// WSClientMessageは、WebSocketでクライアントから受信するメッセージ
+type WSClientMessage struct {
+	// subscribe, unsubscribe, create, update, delete のいずれか
+	Type string `json:"type"`
+	// 応答を対応付けるためにクライアントが指定する任意の値
+	Ref    string `json:"ref"`
+	ListID int    `json:"list_id"`
+	TodoID int    `json:"todo_id"`
+	// 更新の場合、省略した項目は変更しない
+	Todo *TodoUpdate `json:"todo"`
+	// 更新の場合、未完了のTodoに依存していても完了できるようにする
+	AllowBlocked bool `json:"allow_blocked"`
+}
+
+// WSServerMessageは、WebSocketでクライアントへ送信するメッセージ
+type WSServerMessage struct {
+	// ack, error, event のいずれか
+	Type    string `json:"type"`
+	Ref     string `json:"ref,omitempty"`
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+	Todo    *Todo  `json:"todo,omitempty"`
+	Event   any    `json:"event,omitempty"`
+}
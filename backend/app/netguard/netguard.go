@@ -0,0 +1,116 @@
+// netguardは、利用者が指定したURLへの接続を公開されたアドレスに限定するパッケージ。
+// Webhookの送信先などを経由して、内部のサービスやクラウドのメタデータにアクセスされるのを防ぐ
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddressは、接続先が公開されたアドレスでない場合のエラー
+var ErrForbiddenAddress = errors.New("netguard: forbidden address")
+
+// 標準ライブラリの判定に含まれない、公開されていないアドレスの範囲
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	// キャリアグレードNAT
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	// ベンチマーク用
+	netip.MustParsePrefix("198.18.0.0/15"),
+	// 将来の予約とブロードキャスト
+	netip.MustParsePrefix("240.0.0.0/4"),
+	// NAT64。IPv4の内部アドレスに変換される可能性がある
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// IsPublicは、アドレスが公開されたユニキャストアドレスかを返す。
+// ループバック、プライベート、リンクローカル（169.254.169.254のメタデータを含む）、マルチキャストなどはfalseを返す
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range reservedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsForbiddenHostは、名前解決をせずに判定できる範囲で、ホストが公開されていないかを返す。
+// localhostと、公開されていないIPアドレスのリテラルをtrueとする
+func IsForbiddenHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return !IsPublic(addr)
+	}
+	return false
+}
+
+// Resolverは、ホスト名をIPアドレスに解決する
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+var resolver Resolver = net.DefaultResolver
+
+// SetResolverは、CheckHostで使用するResolverを設定し、元に戻す関数を返す（テスト用）
+func SetResolver(r Resolver) (restore func()) {
+	prev := resolver
+	resolver = r
+	return func() { resolver = prev }
+}
+
+// CheckHostは、ホストを名前解決し、すべてのアドレスが公開されたアドレスであることを確認する。
+// 公開されていないアドレスを含む場合はErrForbiddenAddressを返す
+func CheckHost(ctx context.Context, host string) error {
+	if IsForbiddenHost(host) {
+		return ErrForbiddenAddress
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return nil
+	}
+
+	addrs, err := resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !IsPublic(addr) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// Controlは、net.DialerのControlに指定し、公開されていないアドレスへの接続を拒否する。
+// 名前解決の後、接続の直前に呼び出されるため、検証後に解決結果を変えるDNSリバインディングも防げる
+func Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil || !IsPublic(addrPort.Addr()) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// NewClientは、公開されたアドレスにのみ接続するHTTPクライアントを返す。
+// プロキシを経由すると接続先を検証できないため、環境変数のプロキシ設定は使用しない。
+// リダイレクト先への接続も同じ検証を通る
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: Control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,89 @@
+package netguard_test
+
+import (
+	"backend/app/netguard"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsForbiddenHost(t *testing.T) {
+	cases := map[string]struct {
+		host string
+		want bool
+	}{
+		"公開されたIPv4":          {host: "93.184.216.34", want: false},
+		"公開されたIPv6":          {host: "2606:2800:220:1:248:1893:25c8:1946", want: false},
+		"ホスト名":               {host: "example.com", want: false},
+		"localhost":          {host: "localhost", want: true},
+		"localhostのサブドメイン":   {host: "api.localhost.", want: true},
+		"ループバック":             {host: "127.0.0.1", want: true},
+		"IPv6のループバック":        {host: "::1", want: true},
+		"プライベート（10/8）":       {host: "10.1.2.3", want: true},
+		"プライベート（172.16/12）":  {host: "172.16.0.1", want: true},
+		"プライベート（192.168/16）": {host: "192.168.1.1", want: true},
+		"メタデータ":              {host: "169.254.169.254", want: true},
+		"未指定":                {host: "0.0.0.0", want: true},
+		"キャリアグレードNAT":        {host: "100.64.0.1", want: true},
+		"IPv4射影アドレス":         {host: "::ffff:127.0.0.1", want: true},
+		"ユニークローカル":           {host: "fd00::1", want: true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := netguard.IsForbiddenHost(c.host); got != c.want {
+				t.Errorf("期待した結果: %v, 実際の結果: %v", c.want, got)
+			}
+		})
+	}
+}
+
+// stubResolverは、固定のアドレスを返すResolverです。
+type stubResolver map[string][]netip.Addr
+
+func (r stubResolver) LookupNetIP(_ context.Context, _, host string) ([]netip.Addr, error) {
+	return r[host], nil
+}
+
+func TestCheckHost(t *testing.T) {
+	restore := netguard.SetResolver(stubResolver{
+		"public.example":  {netip.MustParseAddr("93.184.216.34")},
+		"private.example": {netip.MustParseAddr("93.184.216.34"), netip.MustParseAddr("10.0.0.5")},
+	})
+	defer restore()
+
+	cases := map[string]struct {
+		host    string
+		wantErr error
+	}{
+		"公開されたアドレスに解決":    {host: "public.example"},
+		"公開されていないアドレスを含む": {host: "private.example", wantErr: netguard.ErrForbiddenAddress},
+		"メタデータのアドレスを直接指定": {host: "169.254.169.254", wantErr: netguard.ErrForbiddenAddress},
+		"公開されたアドレスを直接指定":  {host: "93.184.216.34"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := netguard.CheckHost(context.Background(), c.host); !errors.Is(err, c.wantErr) {
+				t.Errorf("期待したエラー: %v, 実際のエラー: %v", c.wantErr, err)
+			}
+		})
+	}
+}
+
+// 接続時に、解決したアドレスが公開されていない場合は拒否することを確認する
+func TestNewClientRejectsPrivateAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	_, err := netguard.NewClient(time.Second).Get(srv.URL)
+	if !errors.Is(err, netguard.ErrForbiddenAddress) {
+		t.Errorf("ループバックへの接続が拒否されていません: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+// outboxは、Todoの変更イベントを変更と同じトランザクションでアウトボックスに記録し、
+// バックグラウンドでプロセス内の購読者に中継するパッケージ。
+// プロセスが変更のコミット直後に停止しても、未中継のイベントは再起動後に配信される。
+package outbox
+
+import (
+	"backend/app/event"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Recordは、イベントに重複排除用IDを採番してアウトボックスに記録し、記録したイベントを返す。
+// 変更と同じトランザクションで記録することで、コミットされた変更のイベントが失われないようにする。
+func Record(tx *sql.Tx, e event.Event) (event.Event, error) {
+	dedupID, err := newDedupID()
+	if err != nil {
+		return e, err
+	}
+	e.DedupID = dedupID
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return e, err
+	}
+
+	query := "INSERT INTO outbox (workspace_id, dedup_id, event_type, payload, created_at) VALUES (?, ?, ?, ?, ?)"
+	_, err = tx.Exec(query, e.WorkspaceID, e.DedupID, string(e.Type), string(payload), e.OccurredAt)
+	return e, err
+}
+
+func newDedupID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Handlerは、アウトボックスのイベントを受け取る処理。
+// エラーを返した場合、イベントは中継済みにならず次回に再び渡されるため、重複排除用IDなどで冪等に処理すること。
+type Handler func(e event.Event) error
+
+// Relayは、未中継のイベントをアウトボックスから読み出し、Handlersに渡してからBrokerに配信する。
+// すべてのHandlerが成功した後に中継済みとして記録するため、Handlersには少なくとも1回は届く（at-least-once）。
+// Brokerの購読者（SSEやWebSocketの接続）への配信はベストエフォートで、取りこぼした場合は再接続時の再送で追いつく。
+// 再配信されたイベントは重複排除用IDで識別できる。
+type Relay struct {
+	db     *sql.DB
+	broker *event.Broker
+
+	// 取りこぼしが許されない処理（Webhookの配信キューへの登録など）
+	Handlers []Handler
+	// 1回の処理で中継する最大件数
+	BatchSize int
+	// 中継済みのイベントを保持する期間
+	Retention time.Duration
+	// 現在時刻を返す関数（テスト用に差し替え可能）
+	Now func() time.Time
+}
+
+// Relayのコンストラクタ
+func NewRelay(db *sql.DB, broker *event.Broker) *Relay {
+	return &Relay{
+		db:        db,
+		broker:    broker,
+		BatchSize: 100,
+		Retention: 7 * 24 * time.Hour,
+		Now:       time.Now,
+	}
+}
+
+// Runは、一定間隔でアウトボックスを中継し続ける。ctxがキャンセルされると戻る。
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.RunOnce(ctx); err != nil {
+				log.Printf("failed to relay outbox: %v", err)
+			}
+			if err := r.Purge(ctx); err != nil {
+				log.Printf("failed to purge outbox: %v", err)
+			}
+		}
+	}
+}
+
+// RunOnceは、未中継のイベントを古い順に中継し、中継した件数を返す
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	query := "SELECT id, workspace_id, payload FROM outbox WHERE published_at IS NULL ORDER BY id LIMIT ?"
+	rows, err := r.db.QueryContext(ctx, query, r.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	type pending struct {
+		id    int64
+		event event.Event
+	}
+	var events []pending
+	for rows.Next() {
+		var (
+			p       pending
+			payload []byte
+		)
+		if err := rows.Scan(&p.id, &p.event.WorkspaceID, &payload); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if err := json.Unmarshal(payload, &p.event); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, p)
+	}
+	// 途中で読み込みに失敗した場合は、バッチの終わりとして扱わずにエラーを返す
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, err
+	}
+	rows.Close()
+
+	for i, p := range events {
+		// イベントIDはBrokerが採番し直す
+		p.event.ID = 0
+		for _, h := range r.Handlers {
+			if err := h(p.event); err != nil {
+				return i, err
+			}
+		}
+		r.broker.Publish(p.event)
+		if _, err := r.db.ExecContext(ctx, "UPDATE outbox SET published_at = ? WHERE id = ?", r.Now(), p.id); err != nil {
+			return i, err
+		}
+	}
+	return len(events), nil
+}
+
+// Purgeは、保持期間を過ぎた中継済みのイベントを削除する
+func (r *Relay) Purge(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM outbox WHERE published_at IS NOT NULL AND published_at < ?", r.Now().Add(-r.Retention))
+	return err
+}
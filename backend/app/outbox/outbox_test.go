@@ -0,0 +1,222 @@
+package outbox_test
+
+import (
+	"backend/app/event"
+	"backend/app/outbox"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var now = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// newRelayは、モックDBと固定時刻を使用するRelayを作成します。
+func newRelay(t *testing.T, broker *event.Broker) (*outbox.Relay, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("モックDBの作成に失敗しました: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	r := outbox.NewRelay(db, broker)
+	r.Now = func() time.Time { return now }
+
+	return r, mock
+}
+
+func TestRecord(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("モックDBの作成に失敗しました: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`^INSERT INTO outbox \(workspace_id, dedup_id, event_type, payload, created_at\) VALUES \(\?, \?, \?, \?, \?\)$`).
+		WithArgs(3, sqlmock.AnyArg(), "todo.created", sqlmock.AnyArg(), now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := outbox.Record(tx, event.Event{Type: event.TodoCreated, WorkspaceID: 3, TodoID: 1, OccurredAt: now})
+	if err != nil {
+		t.Fatalf("記録に失敗しました: %s", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(e.DedupID) != 32 {
+		t.Errorf("重複排除用IDが採番されていません: %q", e.DedupID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+func TestRelayRunOnce(t *testing.T) {
+	broker := event.NewBroker(10)
+	sub, _, _ := broker.Subscribe(3, 0)
+	defer sub.Close()
+
+	// 変更時に配信済みのイベントは、中継しても重複して配信されない
+	published := broker.Publish(event.Event{Type: event.TodoCreated, WorkspaceID: 3, TodoID: 1, DedupID: "d1"})
+	<-sub.Events()
+
+	payload := func(e event.Event) string {
+		b, _ := json.Marshal(e)
+		return string(b)
+	}
+
+	r, mock := newRelay(t, broker)
+	var handled []string
+	r.Handlers = []outbox.Handler{func(e event.Event) error {
+		handled = append(handled, e.DedupID)
+		return nil
+	}}
+	mock.ExpectQuery(`^SELECT id, workspace_id, payload FROM outbox WHERE published_at IS NULL ORDER BY id LIMIT \?$`).
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workspace_id", "payload"}).
+			AddRow(1, 3, payload(published)).
+			AddRow(2, 3, payload(event.Event{Type: event.TodoDeleted, TodoID: 2, DedupID: "d2"})))
+	mock.ExpectExec(`^UPDATE outbox SET published_at = \? WHERE id = \?$`).
+		WithArgs(now, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^UPDATE outbox SET published_at = \? WHERE id = \?$`).
+		WithArgs(now, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := r.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("中継に失敗しました: %s", err)
+	}
+	if n != 2 {
+		t.Errorf("期待した中継件数: 2, 実際: %d", n)
+	}
+	// Handlerには、Brokerで配信済みのイベントも含めてすべて渡す
+	if len(handled) != 2 || handled[0] != "d1" || handled[1] != "d2" {
+		t.Errorf("Handlerに渡されたイベントが不正です: %v", handled)
+	}
+
+	select {
+	case e := <-sub.Events():
+		if e.DedupID != "d2" || e.TodoID != 2 || e.WorkspaceID != 3 {
+			t.Errorf("想定外のイベントです: %+v", e)
+		}
+	default:
+		t.Fatal("未配信のイベントが中継されていません")
+	}
+	if n := len(sub.Events()); n != 0 {
+		t.Errorf("配信済みのイベントが重複して配信されました: %d件", n)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+// 中継済みの記録に失敗した場合は、次回に同じイベントを再度中継する
+func TestRelayRunOnceMarkFailure(t *testing.T) {
+	r, mock := newRelay(t, event.NewBroker(10))
+	mock.ExpectQuery(`^SELECT id, workspace_id, payload FROM outbox`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workspace_id", "payload"}).
+			AddRow(1, 3, `{"type":"todo.created","todo_id":1,"dedup_id":"d1"}`))
+	mock.ExpectExec(`^UPDATE outbox SET published_at`).
+		WillReturnError(sqlmock.ErrCancelled)
+
+	if _, err := r.RunOnce(context.Background()); err == nil {
+		t.Error("エラーが返却されていません")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+// 途中で読み込みに失敗した場合は、読み込んだイベントも中継せずにエラーを返す
+func TestRelayRunOnceRowError(t *testing.T) {
+	broker := event.NewBroker(10)
+	sub, _, _ := broker.Subscribe(3, 0)
+	defer sub.Close()
+
+	r, mock := newRelay(t, broker)
+	mock.ExpectQuery(`^SELECT id, workspace_id, payload FROM outbox`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workspace_id", "payload"}).
+			AddRow(1, 3, `{"type":"todo.created","todo_id":1,"dedup_id":"d1"}`).
+			AddRow(2, 3, `{"type":"todo.created","todo_id":2,"dedup_id":"d2"}`).
+			RowError(1, sqlmock.ErrCancelled))
+
+	if n, err := r.RunOnce(context.Background()); err == nil || n != 0 {
+		t.Fatalf("読み込みの失敗が返却されていません: n=%d, err=%v", n, err)
+	}
+	if n := len(sub.Events()); n != 0 {
+		t.Errorf("読み込みに失敗したバッチのイベントが配信されました: %d件", n)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+// Handlerが失敗した場合は中継済みにせず、次回に同じイベントを再度渡す
+func TestRelayRunOnceHandlerFailure(t *testing.T) {
+	broker := event.NewBroker(10)
+	sub, _, _ := broker.Subscribe(3, 0)
+	defer sub.Close()
+
+	r, mock := newRelay(t, broker)
+	attempts := 0
+	r.Handlers = []outbox.Handler{func(e event.Event) error {
+		attempts++
+		if attempts == 1 {
+			return sqlmock.ErrCancelled
+		}
+		return nil
+	}}
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(`^SELECT id, workspace_id, payload FROM outbox`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "workspace_id", "payload"}).
+				AddRow(1, 3, `{"type":"todo.created","todo_id":1,"dedup_id":"d1"}`))
+	}
+	mock.ExpectExec(`^UPDATE outbox SET published_at = \? WHERE id = \?$`).
+		WithArgs(now, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if n, err := r.RunOnce(context.Background()); err == nil || n != 0 {
+		t.Fatalf("Handlerの失敗が返却されていません: n=%d, err=%v", n, err)
+	}
+	if n := len(sub.Events()); n != 0 {
+		t.Errorf("Handlerが失敗したイベントが配信されました: %d件", n)
+	}
+
+	if n, err := r.RunOnce(context.Background()); err != nil || n != 1 {
+		t.Fatalf("再度の中継に失敗しました: n=%d, err=%v", n, err)
+	}
+	if attempts != 2 {
+		t.Errorf("期待したHandlerの呼び出し回数: 2, 実際: %d", attempts)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+func TestRelayPurge(t *testing.T) {
+	r, mock := newRelay(t, event.NewBroker(10))
+	mock.ExpectExec(`^DELETE FROM outbox WHERE published_at IS NOT NULL AND published_at < \?$`).
+		WithArgs(now.Add(-7 * 24 * time.Hour)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	if err := r.Purge(context.Background()); err != nil {
+		t.Fatalf("削除に失敗しました: %s", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
@@ -0,0 +1,331 @@
+// recurrenceは、iCalendar（RFC 5545）のRRULEの一部を解釈し、繰り返しの日時を求めるパッケージ。
+// FREQはDAILY・WEEKLY・MONTHLY、パラメーターはINTERVAL・BYDAY・COUNT・UNTILに対応する。
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// 実行環境にタイムゾーンのデータベースがない場合でも、IANAのタイムゾーンを解決できるようにする
+	_ "time/tzdata"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// 繰り返しの日時を探す期間数の上限。条件に一致する日が存在しないルールで無限に探さないようにする
+const maxPeriods = 10000
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNumは、BYDAYの1要素。Nは月の第N曜日（負の場合は末尾から）を表し、0の場合はすべての該当曜日
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Ruleは、解釈済みのRRULE
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	// 開始日時を含めた繰り返しの回数。0の場合は制限しない
+	Count int
+	// 繰り返しの終了日時（この日時を含む）。ゼロ値の場合は制限しない
+	Until time.Time
+}
+
+// Parseは、"FREQ=WEEKLY;BYDAY=MO,WE"の形式のRRULEを解釈する。先頭の"RRULE:"は省略できる。
+func Parse(s string) (Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	rule := Rule{Interval: 1}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" || seen[name] {
+			return Rule{}, ErrInvalidRule
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			switch f := Frequency(value); f {
+			case Daily, Weekly, Monthly:
+				rule.Freq = f
+			default:
+				return Rule{}, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRule, value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return Rule{}, ErrInvalidRule
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return Rule{}, ErrInvalidRule
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return Rule{}, ErrInvalidRule
+			}
+			rule.Until = until
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(v)
+				if err != nil {
+					return Rule{}, err
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+		default:
+			return Rule{}, fmt.Errorf("%w: unsupported parameter %q", ErrInvalidRule, name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	// RFC 5545ではCOUNTとUNTILを同時に指定できない
+	if rule.Count != 0 && !rule.Until.IsZero() {
+		return Rule{}, fmt.Errorf("%w: COUNT and UNTIL are exclusive", ErrInvalidRule)
+	}
+	for _, wd := range rule.ByDay {
+		// 第N曜日の指定は月単位の繰り返しでのみ意味を持つ
+		if wd.N != 0 && rule.Freq != Monthly {
+			return Rule{}, fmt.Errorf("%w: ordinal BYDAY requires FREQ=MONTHLY", ErrInvalidRule)
+		}
+	}
+	return rule, nil
+}
+
+// UNTILは日付（20240131）またはUTCの日時（20240131T090000Z）で指定する
+func parseUntil(value string) (time.Time, error) {
+	if len(value) == len("20060102") {
+		t, err := time.Parse("20060102", value)
+		if err != nil {
+			return time.Time{}, err
+		}
+		// 日付の指定はその日の終わりまでを含む
+		return t.Add(24*time.Hour - time.Nanosecond), nil
+	}
+	return time.Parse("20060102T150405Z", value)
+}
+
+func parseWeekdayNum(v string) (WeekdayNum, error) {
+	if len(v) < 2 {
+		return WeekdayNum{}, ErrInvalidRule
+	}
+	wd, ok := weekdays[v[len(v)-2:]]
+	if !ok {
+		return WeekdayNum{}, ErrInvalidRule
+	}
+	result := WeekdayNum{Weekday: wd}
+	if prefix := v[:len(v)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, ErrInvalidRule
+		}
+		result.N = n
+	}
+	return result, nil
+}
+
+// Stringは、RRULEの文字列に戻す
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = wd.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (wd WeekdayNum) String() string {
+	for name, d := range weekdays {
+		if d == wd.Weekday {
+			if wd.N != 0 {
+				return strconv.Itoa(wd.N) + name
+			}
+			return name
+		}
+	}
+	return ""
+}
+
+// Occurrencesは、dtstartから始まる繰り返しの日時を最大n件返す。dtstartは常に最初の日時に含める。
+// 各日時はdtstartのタイムゾーンでの時刻を保つため、夏時間の切り替えをまたいでも同じ時刻になる。
+func (r Rule) Occurrences(dtstart time.Time, n int) []time.Time {
+	if n <= 0 {
+		return nil
+	}
+	limit := n
+	if r.Count > 0 && r.Count < limit {
+		limit = r.Count
+	}
+
+	result := []time.Time{dtstart}
+	for period := 0; period < maxPeriods && len(result) < limit; period++ {
+		for _, t := range r.candidates(dtstart, period) {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return result
+			}
+			result = append(result, t)
+			if len(result) == limit {
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Nextは、dtstartの次の繰り返しの日時と、その日時から始まる残りの繰り返しのルールを返す。
+// 次の日時がない場合、okはfalseになる。
+func (r Rule) Next(dtstart time.Time) (next time.Time, rest Rule, ok bool) {
+	occurrences := r.Occurrences(dtstart, 2)
+	if len(occurrences) < 2 {
+		return time.Time{}, Rule{}, false
+	}
+
+	rest = r
+	if rest.Count > 0 {
+		// 回数の指定は、次の日時を開始日時として数え直す
+		rest.Count--
+	}
+	return occurrences[1], rest, true
+}
+
+// period番目の期間に含まれる日時の候補を、古い順に返す
+func (r Rule) candidates(dtstart time.Time, period int) []time.Time {
+	loc := dtstart.Location()
+	hour, min, sec := dtstart.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, dtstart.Nanosecond(), loc)
+	}
+	step := period * r.Interval
+
+	var result []time.Time
+	switch r.Freq {
+	case Daily:
+		t := at(dtstart.Year(), dtstart.Month(), dtstart.Day()+step)
+		if r.matchesWeekday(t.Weekday()) {
+			result = append(result, t)
+		}
+	case Weekly:
+		// 週の始まりは月曜日とする（WKST=MO）
+		offset := (int(dtstart.Weekday()) + 6) % 7
+		monday := dtstart.Day() - offset + 7*step
+		if len(r.ByDay) == 0 {
+			result = append(result, at(dtstart.Year(), dtstart.Month(), monday+offset))
+			break
+		}
+		for i := 0; i < 7; i++ {
+			t := at(dtstart.Year(), dtstart.Month(), monday+i)
+			if r.matchesWeekday(t.Weekday()) {
+				result = append(result, t)
+			}
+		}
+	case Monthly:
+		first := time.Date(dtstart.Year(), dtstart.Month()+time.Month(step), 1, 0, 0, 0, 0, loc)
+		year, month := first.Year(), first.Month()
+		daysInMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+		if len(r.ByDay) == 0 {
+			// 該当する日がない月（31日など）は飛ばす
+			if dtstart.Day() <= daysInMonth {
+				result = append(result, at(year, month, dtstart.Day()))
+			}
+			break
+		}
+		days := map[int]bool{}
+		for _, wd := range r.ByDay {
+			for _, day := range monthDays(year, month, daysInMonth, wd, loc) {
+				days[day] = true
+			}
+		}
+		sorted := make([]int, 0, len(days))
+		for day := range days {
+			sorted = append(sorted, day)
+		}
+		sort.Ints(sorted)
+		for _, day := range sorted {
+			result = append(result, at(year, month, day))
+		}
+	}
+	return result
+}
+
+// BYDAYの指定がない場合は、すべての曜日に一致する
+func (r Rule) matchesWeekday(wd time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, d := range r.ByDay {
+		if d.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// 月の中でBYDAYの1要素に一致する日を返す
+func monthDays(year int, month time.Month, daysInMonth int, wd WeekdayNum, loc *time.Location) []int {
+	firstWeekday := time.Date(year, month, 1, 0, 0, 0, 0, loc).Weekday()
+	firstDay := 1 + (int(wd.Weekday)-int(firstWeekday)+7)%7
+
+	var days []int
+	for day := firstDay; day <= daysInMonth; day += 7 {
+		days = append(days, day)
+	}
+
+	switch {
+	case wd.N > 0:
+		if wd.N > len(days) {
+			return nil
+		}
+		return days[wd.N-1 : wd.N]
+	case wd.N < 0:
+		if -wd.N > len(days) {
+			return nil
+		}
+		return days[len(days)+wd.N : len(days)+wd.N+1]
+	}
+	return days
+}
@@ -0,0 +1,227 @@
+package recurrence_test
+
+import (
+	"backend/app/recurrence"
+	"errors"
+	"testing"
+	"time"
+)
+
+// mustLocationは、IANAのタイムゾーンを読み込みます。
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("タイムゾーンの読み込みに失敗しました: %s", err)
+	}
+	return loc
+}
+
+// formatAllは、比較しやすいように日時をRFC 3339の文字列に変換します。
+func formatAll(times []time.Time) []string {
+	result := make([]string, len(times))
+	for i, t := range times {
+		result[i] = t.Format(time.RFC3339)
+	}
+	return result
+}
+
+func checkTimes(t *testing.T, want []string, got []time.Time) {
+	t.Helper()
+
+	gotStrings := formatAll(got)
+	if len(gotStrings) != len(want) {
+		t.Fatalf("期待した日時: %v, 実際: %v", want, gotStrings)
+	}
+	for i := range want {
+		if gotStrings[i] != want[i] {
+			t.Errorf("期待した日時: %v, 実際: %v", want, gotStrings)
+			return
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := map[string]struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		"毎日":               {input: "FREQ=DAILY", want: "FREQ=DAILY"},
+		"RRULE:を省略しない":     {input: "RRULE:FREQ=WEEKLY;BYDAY=MO,FR", want: "FREQ=WEEKLY;BYDAY=MO,FR"},
+		"隔週":               {input: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU"},
+		"毎月最終金曜日":          {input: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", want: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3"},
+		"終了日時":             {input: "FREQ=DAILY;UNTIL=20240110T000000Z", want: "FREQ=DAILY;UNTIL=20240110T000000Z"},
+		"終了日":              {input: "FREQ=DAILY;UNTIL=20240110", want: "FREQ=DAILY;UNTIL=20240110T235959Z"},
+		"FREQがない":          {input: "BYDAY=MO", wantErr: true},
+		"未対応のFREQ":         {input: "FREQ=YEARLY", wantErr: true},
+		"未対応のパラメーター":       {input: "FREQ=DAILY;BYHOUR=9", wantErr: true},
+		"COUNTとUNTILの同時指定": {input: "FREQ=DAILY;COUNT=2;UNTIL=20240110", wantErr: true},
+		"週単位での第N曜日":        {input: "FREQ=WEEKLY;BYDAY=2MO", wantErr: true},
+		"曜日が不正":            {input: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		"INTERVALが0":       {input: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		"パラメーターの重複":        {input: "FREQ=DAILY;FREQ=WEEKLY", wantErr: true},
+		"空":                {input: "", wantErr: true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			rule, err := recurrence.Parse(c.input)
+			if c.wantErr {
+				if !errors.Is(err, recurrence.ErrInvalidRule) {
+					t.Errorf("期待したエラー: %v, 実際: %v", recurrence.ErrInvalidRule, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解釈に失敗しました: %s", err)
+			}
+			if got := rule.String(); got != c.want {
+				t.Errorf("期待したルール: %s, 実際: %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	utc := time.UTC
+
+	cases := map[string]struct {
+		rule    string
+		dtstart time.Time
+		n       int
+		want    []string
+	}{
+		"毎日": {
+			rule:    "FREQ=DAILY",
+			dtstart: time.Date(2024, 1, 30, 9, 0, 0, 0, utc),
+			n:       3,
+			want:    []string{"2024-01-30T09:00:00Z", "2024-01-31T09:00:00Z", "2024-02-01T09:00:00Z"},
+		},
+		"平日のみ": {
+			rule:    "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			dtstart: time.Date(2024, 1, 5, 9, 0, 0, 0, utc), // 金曜日
+			n:       3,
+			want:    []string{"2024-01-05T09:00:00Z", "2024-01-08T09:00:00Z", "2024-01-09T09:00:00Z"},
+		},
+		"毎週月・水": {
+			rule:    "FREQ=WEEKLY;BYDAY=MO,WE",
+			dtstart: time.Date(2024, 1, 3, 9, 0, 0, 0, utc), // 水曜日
+			n:       4,
+			want:    []string{"2024-01-03T09:00:00Z", "2024-01-08T09:00:00Z", "2024-01-10T09:00:00Z", "2024-01-15T09:00:00Z"},
+		},
+		"隔週の日曜日は週の最後の日": {
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU",
+			dtstart: time.Date(2024, 1, 1, 9, 0, 0, 0, utc), // 月曜日
+			n:       4,
+			want:    []string{"2024-01-01T09:00:00Z", "2024-01-07T09:00:00Z", "2024-01-15T09:00:00Z", "2024-01-21T09:00:00Z"},
+		},
+		"毎月31日は31日がない月を飛ばす": {
+			rule:    "FREQ=MONTHLY",
+			dtstart: time.Date(2024, 1, 31, 9, 0, 0, 0, utc),
+			n:       3,
+			want:    []string{"2024-01-31T09:00:00Z", "2024-03-31T09:00:00Z", "2024-05-31T09:00:00Z"},
+		},
+		"毎月最終金曜日": {
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR",
+			dtstart: time.Date(2024, 1, 26, 18, 0, 0, 0, utc),
+			n:       3,
+			want:    []string{"2024-01-26T18:00:00Z", "2024-02-23T18:00:00Z", "2024-03-29T18:00:00Z"},
+		},
+		"毎月第1・第3月曜日": {
+			rule:    "FREQ=MONTHLY;BYDAY=1MO,3MO",
+			dtstart: time.Date(2024, 1, 1, 9, 0, 0, 0, utc),
+			n:       4,
+			want:    []string{"2024-01-01T09:00:00Z", "2024-01-15T09:00:00Z", "2024-02-05T09:00:00Z", "2024-02-19T09:00:00Z"},
+		},
+		"回数の指定は開始日時を含む": {
+			rule:    "FREQ=DAILY;COUNT=2",
+			dtstart: time.Date(2024, 1, 1, 9, 0, 0, 0, utc),
+			n:       10,
+			want:    []string{"2024-01-01T09:00:00Z", "2024-01-02T09:00:00Z"},
+		},
+		"終了日時を含む": {
+			rule:    "FREQ=DAILY;UNTIL=20240103T090000Z",
+			dtstart: time.Date(2024, 1, 1, 9, 0, 0, 0, utc),
+			n:       10,
+			want:    []string{"2024-01-01T09:00:00Z", "2024-01-02T09:00:00Z", "2024-01-03T09:00:00Z"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			rule, err := recurrence.Parse(c.rule)
+			if err != nil {
+				t.Fatalf("解釈に失敗しました: %s", err)
+			}
+			checkTimes(t, c.want, rule.Occurrences(c.dtstart, c.n))
+		})
+	}
+}
+
+// 夏時間の切り替えをまたいでも、現地時刻は変わらない
+func TestOccurrencesAcrossDST(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	newYork := mustLocation(t, "America/New_York")
+
+	cases := map[string]struct {
+		rule    string
+		dtstart time.Time
+		n       int
+		want    []string
+	}{
+		"夏時間の開始（ベルリン）": {
+			rule:    "FREQ=DAILY",
+			dtstart: time.Date(2024, 3, 30, 9, 0, 0, 0, berlin),
+			n:       3,
+			want:    []string{"2024-03-30T09:00:00+01:00", "2024-03-31T09:00:00+02:00", "2024-04-01T09:00:00+02:00"},
+		},
+		"夏時間の終了（ニューヨーク）": {
+			rule:    "FREQ=WEEKLY;BYDAY=SU",
+			dtstart: time.Date(2024, 10, 27, 8, 30, 0, 0, newYork),
+			n:       2,
+			want:    []string{"2024-10-27T08:30:00-04:00", "2024-11-03T08:30:00-05:00"},
+		},
+		"存在しない時刻は切り替え後の時刻になる": {
+			rule:    "FREQ=DAILY",
+			dtstart: time.Date(2024, 3, 30, 2, 30, 0, 0, berlin),
+			n:       3,
+			want:    []string{"2024-03-30T02:30:00+01:00", "2024-03-31T03:30:00+02:00", "2024-04-01T02:30:00+02:00"},
+		},
+		"毎月の繰り返しも現地時刻を保つ": {
+			rule:    "FREQ=MONTHLY",
+			dtstart: time.Date(2024, 2, 15, 7, 0, 0, 0, berlin),
+			n:       2,
+			want:    []string{"2024-02-15T07:00:00+01:00", "2024-03-15T07:00:00+01:00"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			rule, err := recurrence.Parse(c.rule)
+			if err != nil {
+				t.Fatalf("解釈に失敗しました: %s", err)
+			}
+			checkTimes(t, c.want, rule.Occurrences(c.dtstart, c.n))
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	dtstart := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	rule, _ := recurrence.Parse("FREQ=DAILY;COUNT=2")
+	next, rest, ok := rule.Next(dtstart)
+	if !ok || !next.Equal(dtstart.AddDate(0, 0, 1)) {
+		t.Fatalf("次の日時が不正です: %v, %v", next, ok)
+	}
+	if rest.String() != "FREQ=DAILY;COUNT=1" {
+		t.Errorf("残りの回数が不正です: %s", rest)
+	}
+
+	// 最後の1回の次はない
+	if _, _, ok := rest.Next(next); ok {
+		t.Error("回数を超えて次の日時が返却されました")
+	}
+}
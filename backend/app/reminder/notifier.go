@@ -0,0 +1,99 @@
+package reminder
+
+import (
+	"backend/app/event"
+	"backend/app/mailer"
+	"backend/app/model"
+	"backend/app/webhook"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 通知の方法
+const (
+	ChannelPush    = "push"
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+)
+
+// Notificationは、リマインダー1件の通知内容
+type Notification struct {
+	ReminderID  int
+	WorkspaceID int
+	UserID      int
+	Todo        model.Todo
+	RemindAt    time.Time
+	// 通知先。メールの場合はユーザー自身の確認済みのアドレスで、確認済みのアドレスがない場合は空
+	Target string
+}
+
+// Keyは、通知の冪等キーを返す。同じリマインダーを再送した場合も同じ値になるため、受信側で重複を判別できる。
+func (n Notification) Key() string {
+	return "reminder-" + strconv.Itoa(n.ReminderID)
+}
+
+func (n Notification) event() event.Event {
+	todo := n.Todo
+	return event.Event{
+		Type:        event.TodoReminder,
+		DedupID:     n.Key(),
+		WorkspaceID: n.WorkspaceID,
+		ListID:      todo.ListID,
+		TodoID:      todo.ID,
+		Todo:        &todo,
+		UserID:      n.UserID,
+		OccurredAt:  n.RemindAt,
+	}
+}
+
+// Notifierは、リマインダーを通知する
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// PushNotifierは、Server-Sent EventsとWebSocketで接続中のクライアントに通知する
+type PushNotifier struct {
+	Broker *event.Broker
+}
+
+func (p *PushNotifier) Notify(ctx context.Context, n Notification) error {
+	p.Broker.Publish(n.event())
+	return nil
+}
+
+// WebhookNotifierは、リマインダーを追加したユーザーが登録した、todo.reminderを購読しているWebhookに通知する
+type WebhookNotifier struct {
+	Dispatcher *webhook.Dispatcher
+}
+
+func (wn *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	return wn.Dispatcher.Enqueue(n.event())
+}
+
+// EmailNotifierは、メールで通知する
+type EmailNotifier struct {
+	Sender mailer.Sender
+	From   string
+}
+
+func (en *EmailNotifier) Notify(ctx context.Context, n Notification) error {
+	if n.Target == "" {
+		return fmt.Errorf("user %d has no verified email address", n.UserID)
+	}
+
+	text := fmt.Sprintf("「%s」のリマインダーです。\n", n.Todo.Title)
+	if n.Todo.DueAt != nil {
+		text += fmt.Sprintf("期限: %s\n", n.Todo.DueAt.UTC().Format("2006-01-02 15:04 (UTC)"))
+	}
+
+	return en.Sender.Send(mailer.Message{
+		From:      en.From,
+		To:        n.Target,
+		Subject:   "リマインダー: " + n.Todo.Title,
+		Text:      text,
+		MessageID: n.Key() + "@todo",
+		Date:      n.RemindAt,
+	})
+}
@@ -0,0 +1,216 @@
+// reminderは、Todoのリマインダーを指定の日時に通知するパッケージ。
+// 配信状態をDBに記録し、リースを取得したスケジューラーだけが通知するため、
+// 複数のプロセスが動作していても、再起動をまたいでも同じリマインダーを重複して通知しない。
+package reminder
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+)
+
+// リマインダーの状態
+const (
+	StatusPending = "pending"
+	// スケジューラーが通知中。リースの期限が切れた場合は他のスケジューラーが引き継ぐ
+	StatusLeased   = "leased"
+	StatusSent     = "sent"
+	StatusFailed   = "failed"
+	StatusCanceled = "canceled"
+)
+
+// 記録するエラーメッセージの最大長
+const maxRecordedError = 255
+
+// Schedulerは、通知日時を迎えたリマインダーを通知する
+type Scheduler struct {
+	db        *sql.DB
+	notifiers map[string]Notifier
+
+	// リースの有効期間。通知にかかる時間より十分に長くすること
+	LeaseDuration time.Duration
+	// 1回の処理で通知する最大件数
+	BatchSize int
+	// 最大試行回数。超えた場合は失敗とする
+	MaxAttempts int
+	// 再試行までの間隔。試行回数に比例して長くする
+	RetryDelay time.Duration
+	// 現在時刻を返す関数（テスト用に差し替え可能）
+	Now func() time.Time
+}
+
+// Schedulerのコンストラクタ。notifiersは通知の方法ごとのNotifier
+func NewScheduler(db *sql.DB, notifiers map[string]Notifier) *Scheduler {
+	return &Scheduler{
+		db:            db,
+		notifiers:     notifiers,
+		LeaseDuration: 5 * time.Minute,
+		BatchSize:     20,
+		MaxAttempts:   5,
+		RetryDelay:    time.Minute,
+		Now:           time.Now,
+	}
+}
+
+// Runは、一定間隔でリマインダーを通知し続ける。ctxがキャンセルされると戻る。
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RunOnce(ctx); err != nil {
+				log.Printf("failed to send reminders: %v", err)
+			}
+		}
+	}
+}
+
+type leasedReminder struct {
+	notification Notification
+	channel      string
+	attempts     int
+	// Todoが削除済みの場合はfalse
+	todoExists bool
+}
+
+// RunOnceは、通知日時を迎えたリマインダーのリースを取得して通知し、処理した件数を返す。
+// リースの期限が切れたリマインダー（通知中に停止したスケジューラーのもの）も引き継いで通知する。
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	token, err := newLeaseToken()
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.Now()
+	claimQuery := "UPDATE reminders SET status = ?, lease_token = ?, lease_expires_at = ? " +
+		"WHERE (status = ? AND next_attempt_at <= ?) OR (status = ? AND lease_expires_at <= ?) ORDER BY next_attempt_at LIMIT ?"
+	result, err := s.db.ExecContext(ctx, claimQuery,
+		StatusLeased, token, now.Add(s.LeaseDuration),
+		StatusPending, now, StatusLeased, now, s.BatchSize,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return 0, err
+	}
+
+	reminders, err := s.leased(ctx, token)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, r := range reminders {
+		if err := s.process(ctx, token, r); err != nil {
+			return 0, err
+		}
+	}
+	return len(reminders), nil
+}
+
+// リースを取得したリマインダーを、対象のTodoとともに取得する
+func (s *Scheduler) leased(ctx context.Context, token string) ([]leasedReminder, error) {
+	// メールの宛先は登録時の値ではなく、通知時点のユーザー自身の確認済みのアドレスとする
+	query := "SELECT r.id, r.workspace_id, r.user_id, r.channel, e.email, r.remind_at, r.attempts, " +
+		"t.id, t.title, t.is_complete, t.list_id, t.due_at " +
+		"FROM reminders r LEFT JOIN todos t ON t.id = r.todo_id AND t.workspace_id = r.workspace_id " +
+		"LEFT JOIN user_emails e ON e.user_id = r.user_id AND e.verified_at IS NOT NULL " +
+		"WHERE r.lease_token = ? AND r.status = ?"
+	rows, err := s.db.QueryContext(ctx, query, token, StatusLeased)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []leasedReminder
+	for rows.Next() {
+		var (
+			r          leasedReminder
+			n          = &r.notification
+			todoID     sql.NullInt64
+			title      sql.NullString
+			email      sql.NullString
+			isComplete sql.NullBool
+		)
+		if err := rows.Scan(
+			&n.ReminderID, &n.WorkspaceID, &n.UserID, &r.channel, &email, &n.RemindAt, &r.attempts,
+			&todoID, &title, &isComplete, &n.Todo.ListID, &n.Todo.DueAt,
+		); err != nil {
+			return nil, err
+		}
+		r.todoExists = todoID.Valid
+		n.Target = email.String
+		n.Todo.ID = int(todoID.Int64)
+		n.Todo.Title = title.String
+		n.Todo.IsComplete = isComplete.Bool
+		reminders = append(reminders, r)
+	}
+	return reminders, rows.Err()
+}
+
+// リマインダー1件を通知し、結果を記録する
+func (s *Scheduler) process(ctx context.Context, token string, r leasedReminder) error {
+	// 完了済み・削除済みのTodoは通知しない
+	if !r.todoExists || r.notification.Todo.IsComplete {
+		return s.finish(token, r, StatusCanceled, nil)
+	}
+
+	notifier, ok := s.notifiers[r.channel]
+	if !ok {
+		return s.finish(token, r, StatusFailed, fmt.Errorf("unsupported channel %q", r.channel))
+	}
+
+	if err := notifier.Notify(ctx, r.notification); err != nil {
+		if r.attempts+1 >= s.MaxAttempts {
+			return s.finish(token, r, StatusFailed, err)
+		}
+		return s.finish(token, r, StatusPending, err)
+	}
+	return s.finish(token, r, StatusSent, nil)
+}
+
+// 通知の結果を記録し、リースを解放する。
+// リースの期限が切れて他のスケジューラーが引き継いでいる場合は、記録しない。
+func (s *Scheduler) finish(token string, r leasedReminder, status string, notifyErr error) error {
+	now := s.Now()
+	attempts := r.attempts
+	nextAttemptAt := now
+	lastError := ""
+	var sentAt any
+
+	switch status {
+	case StatusSent:
+		attempts++
+		sentAt = now
+	case StatusPending, StatusFailed:
+		attempts++
+		nextAttemptAt = now.Add(s.RetryDelay * time.Duration(attempts))
+	}
+	if notifyErr != nil {
+		lastError = notifyErr.Error()
+		if len(lastError) > maxRecordedError {
+			lastError = lastError[:maxRecordedError]
+		}
+	}
+
+	query := "UPDATE reminders SET status = ?, attempts = ?, next_attempt_at = ?, sent_at = ?, last_error = ?, lease_token = NULL, lease_expires_at = NULL " +
+		"WHERE id = ? AND lease_token = ?"
+	_, err := s.db.Exec(query, status, attempts, nextAttemptAt, sentAt, lastError, r.notification.ReminderID, token)
+	return err
+}
+
+func newLeaseToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,200 @@
+package reminder_test
+
+import (
+	"backend/app/event"
+	"backend/app/mailer"
+	"backend/app/mailer/mailertest"
+	"backend/app/reminder"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var now = time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// リースを取得したリマインダーのカラム
+var leasedColumns = []string{
+	"id", "workspace_id", "user_id", "channel", "email", "remind_at", "attempts",
+	"id", "title", "is_complete", "list_id", "due_at",
+}
+
+// newSchedulerは、モックDBと固定時刻を使用するSchedulerを作成します。
+func newScheduler(t *testing.T, notifiers map[string]reminder.Notifier) (*reminder.Scheduler, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("モックDBの作成に失敗しました: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s := reminder.NewScheduler(db, notifiers)
+	s.Now = func() time.Time { return now }
+	s.MaxAttempts = 3
+
+	return s, mock
+}
+
+// expectClaimは、リースの取得を期待値として設定します。
+func expectClaim(mock sqlmock.Sqlmock, claimed int64) {
+	mock.ExpectExec(`^UPDATE reminders SET status = \?, lease_token = \?, lease_expires_at = \? WHERE \(status = \? AND next_attempt_at <= \?\) OR \(status = \? AND lease_expires_at <= \?\) ORDER BY next_attempt_at LIMIT \?$`).
+		WithArgs("leased", sqlmock.AnyArg(), now.Add(5*time.Minute), "pending", now, "leased", now, 20).
+		WillReturnResult(sqlmock.NewResult(0, claimed))
+}
+
+// expectFinishは、通知結果の記録を期待値として設定します。
+func expectFinish(mock sqlmock.Sqlmock, id int, status string, attempts int, nextAttemptAt time.Time, sentAt any) {
+	mock.ExpectExec(`^UPDATE reminders SET status = \?, attempts = \?, next_attempt_at = \?, sent_at = \?, last_error = \?, lease_token = NULL, lease_expires_at = NULL WHERE id = \? AND lease_token = \?$`).
+		WithArgs(status, attempts, nextAttemptAt, sentAt, sqlmock.AnyArg(), id, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func checkExpectations(t *testing.T, mock sqlmock.Sqlmock) {
+	t.Helper()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	t.Run("メールで通知し、完了済みと削除済みのTodoは取り消す", func(t *testing.T) {
+		server := mailertest.NewServer(t)
+		s, mock := newScheduler(t, map[string]reminder.Notifier{
+			reminder.ChannelEmail: &reminder.EmailNotifier{Sender: &mailer.SMTPSender{Addr: server.Addr}, From: "todo@example.com"},
+		})
+
+		expectClaim(mock, 3)
+		mock.ExpectQuery(`^SELECT r\.id, .* FROM reminders r LEFT JOIN todos t ON t\.id = r\.todo_id AND t\.workspace_id = r\.workspace_id LEFT JOIN user_emails e ON e\.user_id = r\.user_id AND e\.verified_at IS NOT NULL WHERE r\.lease_token = \? AND r\.status = \?$`).
+			WithArgs(sqlmock.AnyArg(), "leased").
+			WillReturnRows(sqlmock.NewRows(leasedColumns).
+				AddRow(1, 1, 7, "email", "user@example.com", now, 0, 10, "牛乳を買う", false, nil, nil).
+				AddRow(2, 1, 7, "email", "user@example.com", now, 0, 11, "完了済み", true, nil, nil).
+				AddRow(3, 1, 7, "email", "user@example.com", now, 0, nil, nil, nil, nil, nil))
+		expectFinish(mock, 1, "sent", 1, now, now)
+		expectFinish(mock, 2, "canceled", 0, now, nil)
+		expectFinish(mock, 3, "canceled", 0, now, nil)
+
+		n, err := s.RunOnce(context.Background())
+		if err != nil {
+			t.Fatalf("エラーが発生しました: %s", err)
+		}
+		if n != 3 {
+			t.Errorf("処理件数が不正です: %d", n)
+		}
+		checkExpectations(t, mock)
+
+		mails := server.Mails()
+		if len(mails) != 1 {
+			t.Fatalf("送信件数が不正です: %d", len(mails))
+		}
+		if len(mails[0].To) != 1 || mails[0].To[0] != "user@example.com" {
+			t.Errorf("宛先が不正です: %v", mails[0].To)
+		}
+		// 再送時に受信側で重複を判別できるよう、リマインダーごとに固定のMessage-IDを付ける
+		if !strings.Contains(mails[0].Data, "Message-ID: <reminder-1@todo>") {
+			t.Errorf("Message-IDが不正です:\n%s", mails[0].Data)
+		}
+	})
+
+	t.Run("送信に失敗した場合は再試行し、上限に達したら失敗とする", func(t *testing.T) {
+		server := mailertest.NewServer(t)
+		server.FailData(true)
+		s, mock := newScheduler(t, map[string]reminder.Notifier{
+			reminder.ChannelEmail: &reminder.EmailNotifier{Sender: &mailer.SMTPSender{Addr: server.Addr}, From: "todo@example.com"},
+		})
+
+		expectClaim(mock, 2)
+		mock.ExpectQuery(`^SELECT r\.id`).
+			WithArgs(sqlmock.AnyArg(), "leased").
+			WillReturnRows(sqlmock.NewRows(leasedColumns).
+				AddRow(1, 1, 7, "email", "user@example.com", now, 0, 10, "牛乳を買う", false, nil, nil).
+				AddRow(2, 1, 7, "email", "user@example.com", now, 2, 10, "牛乳を買う", false, nil, nil))
+		expectFinish(mock, 1, "pending", 1, now.Add(time.Minute), nil)
+		expectFinish(mock, 2, "failed", 3, now.Add(3*time.Minute), nil)
+
+		if _, err := s.RunOnce(context.Background()); err != nil {
+			t.Fatalf("エラーが発生しました: %s", err)
+		}
+		checkExpectations(t, mock)
+	})
+
+	t.Run("確認済みのメールアドレスがない場合は送信しない", func(t *testing.T) {
+		server := mailertest.NewServer(t)
+		s, mock := newScheduler(t, map[string]reminder.Notifier{
+			reminder.ChannelEmail: &reminder.EmailNotifier{Sender: &mailer.SMTPSender{Addr: server.Addr}, From: "todo@example.com"},
+		})
+
+		expectClaim(mock, 1)
+		mock.ExpectQuery(`^SELECT r\.id`).
+			WithArgs(sqlmock.AnyArg(), "leased").
+			WillReturnRows(sqlmock.NewRows(leasedColumns).
+				AddRow(1, 1, 7, "email", nil, now, 0, 10, "牛乳を買う", false, nil, nil))
+		expectFinish(mock, 1, "pending", 1, now.Add(time.Minute), nil)
+
+		if _, err := s.RunOnce(context.Background()); err != nil {
+			t.Fatalf("エラーが発生しました: %s", err)
+		}
+		checkExpectations(t, mock)
+		if len(server.Mails()) != 0 {
+			t.Errorf("メールが送信されました: %v", server.Mails())
+		}
+	})
+
+	t.Run("通知の方法が未設定の場合は失敗とする", func(t *testing.T) {
+		s, mock := newScheduler(t, map[string]reminder.Notifier{})
+
+		expectClaim(mock, 1)
+		mock.ExpectQuery(`^SELECT r\.id`).
+			WithArgs(sqlmock.AnyArg(), "leased").
+			WillReturnRows(sqlmock.NewRows(leasedColumns).
+				AddRow(1, 1, 7, "email", "user@example.com", now, 0, 10, "牛乳を買う", false, nil, nil))
+		expectFinish(mock, 1, "failed", 1, now.Add(time.Minute), nil)
+
+		if _, err := s.RunOnce(context.Background()); err != nil {
+			t.Fatalf("エラーが発生しました: %s", err)
+		}
+		checkExpectations(t, mock)
+	})
+
+	t.Run("通知日時を迎えたリマインダーがない", func(t *testing.T) {
+		s, mock := newScheduler(t, map[string]reminder.Notifier{})
+
+		expectClaim(mock, 0)
+
+		n, err := s.RunOnce(context.Background())
+		if err != nil || n != 0 {
+			t.Errorf("want: 0, nil, got: %d, %v", n, err)
+		}
+		checkExpectations(t, mock)
+	})
+}
+
+func TestPushNotifier(t *testing.T) {
+	broker := event.NewBroker(10)
+	sub, _, _ := broker.Subscribe(1, 0)
+	defer sub.Close()
+
+	p := &reminder.PushNotifier{Broker: broker}
+	n := reminder.Notification{ReminderID: 4, WorkspaceID: 1, UserID: 7, RemindAt: now}
+	n.Todo.ID = 10
+	n.Todo.Title = "牛乳を買う"
+	if err := p.Notify(context.Background(), n); err != nil {
+		t.Fatalf("エラーが発生しました: %s", err)
+	}
+
+	select {
+	case e := <-sub.Events():
+		if e.Type != event.TodoReminder || e.UserID != 7 || e.TodoID != 10 || e.DedupID != "reminder-4" {
+			t.Errorf("イベントが不正です: %+v", e)
+		}
+		if e.VisibleTo(8) {
+			t.Error("他のユーザーに通知されます")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("イベントが配信されません")
+	}
+}
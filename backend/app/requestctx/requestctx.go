@@ -0,0 +1,47 @@
+// requestctxは、リクエストごとに解決した値をcontextで受け渡すためのパッケージ
+package requestctx
+
+import "context"
+
+type contextKey int
+
+const (
+	workspaceIDKey contextKey = iota
+	userIDKey
+	requestIDKey
+)
+
+// WithWorkspaceIDは、ワークスペースIDを設定したcontextを返す
+func WithWorkspaceID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, workspaceIDKey, id)
+}
+
+// WorkspaceIDは、contextに設定されたワークスペースIDを返す。
+// 未設定の場合は、どのワークスペースにも一致しない0を返す。
+func WorkspaceID(ctx context.Context) int {
+	id, _ := ctx.Value(workspaceIDKey).(int)
+	return id
+}
+
+// WithUserIDは、リクエストを行ったユーザーのIDを設定したcontextを返す
+func WithUserID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, userIDKey, id)
+}
+
+// UserIDは、contextに設定されたユーザーIDを返す。
+// 未設定（匿名）の場合は0を返す。
+func UserID(ctx context.Context) int {
+	id, _ := ctx.Value(userIDKey).(int)
+	return id
+}
+
+// WithRequestIDは、リクエストIDを設定したcontextを返す
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDは、contextに設定されたリクエストIDを返す
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
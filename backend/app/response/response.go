@@ -2,8 +2,12 @@ package response
 
 import (
 	"backend/app/model"
+	"encoding/csv"
 	"encoding/json"
+	"mime"
 	"net/http"
+	"strings"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -34,8 +38,134 @@ func WriteTodosResponse(w http.ResponseWriter, todo []model.Todo, code int, errM
 	WriteJSON(w, data, code, errMessage)
 }
 
+func WriteShareLinkResponse(w http.ResponseWriter, link *model.ShareLink, code int, errMessage string) {
+	data := model.ShareLinkResponse{
+		Data: link,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteAuditLogsResponse(w http.ResponseWriter, logs []model.AuditLog, code int, errMessage string) {
+	data := model.AuditLogsResponse{
+		Data: logs,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteTodoRevisionsResponse(w http.ResponseWriter, revisions []model.TodoRevision, code int, errMessage string) {
+	data := model.TodoRevisionsResponse{
+		Data: revisions,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteWebhookResponse(w http.ResponseWriter, webhook *model.Webhook, code int, errMessage string) {
+	data := model.WebhookResponse{
+		Data: webhook,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteWebhooksResponse(w http.ResponseWriter, webhooks []model.Webhook, code int, errMessage string) {
+	data := model.WebhooksResponse{
+		Data: webhooks,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteWebhookDeliveriesResponse(w http.ResponseWriter, deliveries []model.WebhookDelivery, code int, errMessage string) {
+	data := model.WebhookDeliveriesResponse{
+		Data: deliveries,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteSyncChangesResponse(w http.ResponseWriter, changes *model.SyncChanges, code int, errMessage string) {
+	data := model.SyncChangesResponse{
+		Data: changes,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteSyncResultsResponse(w http.ResponseWriter, results []model.SyncResult, code int, errMessage string) {
+	data := model.SyncResultsResponse{
+		Data: results,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteOccurrencesResponse(w http.ResponseWriter, occurrences []time.Time, code int, errMessage string) {
+	data := model.OccurrencesResponse{
+		Data: occurrences,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
 type Data interface {
-	model.TodoResponse | model.TodosResponse
+	model.TodoResponse | model.TodosResponse | model.ShareLinkResponse | model.AuditLogsResponse |
+		model.TodoRevisionsResponse | model.WebhookResponse | model.WebhooksResponse | model.WebhookDeliveriesResponse |
+		model.SyncChangesResponse | model.SyncResultsResponse | model.OccurrencesResponse |
+		model.ReminderResponse | model.RemindersResponse | model.DigestPreferenceResponse |
+		model.TodoSearchResponse | model.SmartListResponse | model.SmartListsResponse | model.TodoTagsResponse |
+		model.ChecklistResponse | model.AttachmentResponse | model.AttachmentsResponse |
+		model.CommentResponse | model.CommentsResponse | model.ActivitiesResponse | model.TodoAssigneesResponse |
+		model.TodoDependenciesResponse | model.WorkflowResponse | model.BoardResponse |
+		model.TimeEntryResponse | model.TimeEntriesResponse | model.TimeReportResponse |
+		model.MilestoneResponse | model.MilestonesResponse | model.BurndownResponse | model.StatsResponse
 }
 
 // レスポンスをJSON形式で返却する
@@ -47,3 +177,342 @@ func WriteJSON[T Data](w http.ResponseWriter, data T, code int, errMessage strin
 		http.Error(w, `{"data": null, "status": {"code": 500, "error": true, "error_message": "内部エラーが発生しました。"}}`, http.StatusInternalServerError)
 	}
 }
+
+func WriteReminderResponse(w http.ResponseWriter, reminder *model.Reminder, code int, errMessage string) {
+	data := model.ReminderResponse{
+		Data: reminder,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteRemindersResponse(w http.ResponseWriter, reminders []model.Reminder, code int, errMessage string) {
+	data := model.RemindersResponse{
+		Data: reminders,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteDigestPreferenceResponse(w http.ResponseWriter, pref *model.DigestPreference, code int, errMessage string) {
+	data := model.DigestPreferenceResponse{
+		Data: pref,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteTodoSearchResponse(w http.ResponseWriter, results []model.TodoSearchResult, code int, errMessage string) {
+	data := model.TodoSearchResponse{
+		Data: results,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteSmartListResponse(w http.ResponseWriter, list *model.SmartList, code int, errMessage string) {
+	data := model.SmartListResponse{
+		Data: list,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteSmartListsResponse(w http.ResponseWriter, lists []model.SmartList, code int, errMessage string) {
+	data := model.SmartListsResponse{
+		Data: lists,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteTodoAssigneesResponse(w http.ResponseWriter, assignees *model.TodoAssignees, code int, errMessage string) {
+	data := model.TodoAssigneesResponse{
+		Data: assignees,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteTodoDependenciesResponse(w http.ResponseWriter, dependencies *model.TodoDependencies, code int, errMessage string) {
+	data := model.TodoDependenciesResponse{
+		Data: dependencies,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteWorkflowResponse(w http.ResponseWriter, wf *model.Workflow, code int, errMessage string) {
+	data := model.WorkflowResponse{
+		Data: wf,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteBoardResponse(w http.ResponseWriter, board *model.Board, code int, errMessage string) {
+	data := model.BoardResponse{
+		Data: board,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteTodoTagsResponse(w http.ResponseWriter, tags *model.TodoTags, code int, errMessage string) {
+	data := model.TodoTagsResponse{
+		Data: tags,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteChecklistResponse(w http.ResponseWriter, checklist *model.Checklist, code int, errMessage string) {
+	data := model.ChecklistResponse{
+		Data: checklist,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteAttachmentResponse(w http.ResponseWriter, attachment *model.Attachment, code int, errMessage string) {
+	data := model.AttachmentResponse{
+		Data: attachment,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteAttachmentsResponse(w http.ResponseWriter, attachments []model.Attachment, code int, errMessage string) {
+	data := model.AttachmentsResponse{
+		Data: attachments,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteCommentResponse(w http.ResponseWriter, comment *model.Comment, code int, errMessage string) {
+	data := model.CommentResponse{
+		Data: comment,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteCommentsResponse(w http.ResponseWriter, comments []model.Comment, code int, errMessage string) {
+	data := model.CommentsResponse{
+		Data: comments,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteActivitiesResponse(w http.ResponseWriter, activities []model.Activity, code int, errMessage string) {
+	data := model.ActivitiesResponse{
+		Data: activities,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteTimeEntryResponse(w http.ResponseWriter, entry *model.TimeEntry, code int, errMessage string) {
+	data := model.TimeEntryResponse{
+		Data: entry,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteTimeEntriesResponse(w http.ResponseWriter, entries *model.TimeEntries, code int, errMessage string) {
+	data := model.TimeEntriesResponse{
+		Data: entries,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteTimeReportResponse(w http.ResponseWriter, report *model.TimeReport, code int, errMessage string) {
+	data := model.TimeReportResponse{
+		Data: report,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteMilestoneResponse(w http.ResponseWriter, milestone *model.Milestone, code int, errMessage string) {
+	data := model.MilestoneResponse{
+		Data: milestone,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteMilestonesResponse(w http.ResponseWriter, milestones []model.Milestone, code int, errMessage string) {
+	data := model.MilestonesResponse{
+		Data: milestones,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteBurndownResponse(w http.ResponseWriter, burndown *model.Burndown, code int, errMessage string) {
+	data := model.BurndownResponse{
+		Data: burndown,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+func WriteStatsResponse(w http.ResponseWriter, stats *model.Stats, code int, errMessage string) {
+	data := model.StatsResponse{
+		Data: stats,
+		Status: model.StatusInfo{
+			Code:         code,
+			Error:        errMessage != "",
+			ErrorMessage: errMessage,
+		},
+	}
+
+	WriteJSON(w, data, code, errMessage)
+}
+
+// レスポンスをCSV形式で返却する。filenameはダウンロード時のファイル名。
+// 表計算ソフトで開いた際に数式として実行されないよう、各項目はescapeCSVFieldで変換する
+func WriteCSV(w http.ResponseWriter, filename string, records [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+
+	escaped := make([][]string, len(records))
+	for i, record := range records {
+		escaped[i] = make([]string, len(record))
+		for j, field := range record {
+			escaped[i][j] = escapeCSVField(field)
+		}
+	}
+
+	// ヘッダーを送信した後のため、書き込みのエラーは返却できない
+	_ = csv.NewWriter(w).WriteAll(escaped)
+}
+
+// 数式として解釈される文字で始まる項目は、先頭にシングルクォートを付けて文字列として扱わせる
+func escapeCSVField(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
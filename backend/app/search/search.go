@@ -0,0 +1,213 @@
+// searchは、Todoの全文検索の検索語を解析し、MySQLのBOOLEAN MODEの検索式への変換、
+// スコア計算、一致箇所を強調した抜粋の作成を行うパッケージ。
+//
+// todosテーブルには、日本語を分かち書きせずに検索できるよう、タイトルとメモに ngram パーサーの FULLTEXT インデックス
+// ft_todos_title_notes を作成する（db/migrations/0007_create_todos.sql）。
+package search
+
+import (
+	"errors"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ngramパーサーのトークンの長さ（MySQLのngram_token_sizeの既定値）
+const ngramTokenSize = 2
+
+// 検索語の上限
+const (
+	MaxQueryLength = 100
+	MaxTerms       = 10
+)
+
+var (
+	ErrEmptyQuery   = errors.New("empty query")
+	ErrQueryTooLong = errors.New("query too long")
+	ErrTooManyTerms = errors.New("too many terms")
+)
+
+// BOOLEAN MODEで演算子として解釈される文字
+const booleanOperators = `+-<>()~*"@`
+
+// Termは、検索語の1語
+type Term struct {
+	Text string
+	// 1文字の語はngramのトークンより短いため、前方一致で検索する
+	Prefix bool
+}
+
+// Queryは、解析済みの検索語。すべての語を含むTodoに一致する
+type Query struct {
+	Terms []Term
+}
+
+// Parseは、検索語を空白で区切って解析する。全角英数字と全角空白は半角に揃え、英字は小文字にする。
+func Parse(q string) (Query, error) {
+	q = Normalize(q)
+	if utf8.RuneCountInString(q) > MaxQueryLength {
+		return Query{}, ErrQueryTooLong
+	}
+
+	var query Query
+	seen := map[string]bool{}
+	for _, field := range strings.Fields(q) {
+		text := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(booleanOperators, r) {
+				return -1
+			}
+			return r
+		}, field)
+		if text == "" || seen[text] {
+			continue
+		}
+		seen[text] = true
+		query.Terms = append(query.Terms, Term{Text: text, Prefix: utf8.RuneCountInString(text) < ngramTokenSize})
+	}
+
+	if len(query.Terms) == 0 {
+		return Query{}, ErrEmptyQuery
+	}
+	if len(query.Terms) > MaxTerms {
+		return Query{}, ErrTooManyTerms
+	}
+	return query, nil
+}
+
+// Normalizeは、全角英数字・記号と全角空白を半角に変換し、小文字にする
+func Normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '！' && r <= '～':
+			return unicode.ToLower(r - '！' + '!')
+		default:
+			return unicode.ToLower(r)
+		}
+	}, s)
+}
+
+// BooleanModeは、MATCH ... AGAINST (? IN BOOLEAN MODE) に渡す検索式を返す。
+// ngramパーサーでは語がトークンに分割されるため、各語をフレーズとして検索すると、語の途中や先頭からの一致になる。
+func (q Query) BooleanMode() string {
+	parts := make([]string, len(q.Terms))
+	for i, t := range q.Terms {
+		if t.Prefix {
+			parts[i] = "+" + t.Text + "*"
+		} else {
+			parts[i] = `+"` + t.Text + `"`
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Matchは、textがすべての語を含む場合にtrueを返す
+func (q Query) Match(text string) bool {
+	normalized := Normalize(text)
+	for _, t := range q.Terms {
+		if !strings.Contains(normalized, t.Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchAnyは、textがいずれかの語を含む場合にtrueを返す
+func (q Query) MatchAny(text string) bool {
+	normalized := Normalize(text)
+	for _, t := range q.Terms {
+		if strings.Contains(normalized, t.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scoreは、FULLTEXTインデックスを使用できない場合のスコアを返す。
+// 語の出現回数を数え、先頭で一致した場合と、text全体に対する一致箇所の割合が大きい場合に高くする。
+func (q Query) Score(text string) float64 {
+	normalized := Normalize(text)
+	length := utf8.RuneCountInString(normalized)
+	if length == 0 {
+		return 0
+	}
+
+	var score float64
+	for _, t := range q.Terms {
+		count := strings.Count(normalized, t.Text)
+		if count == 0 {
+			return 0
+		}
+		score += float64(count) * float64(utf8.RuneCountInString(t.Text)) / float64(length)
+		if strings.HasPrefix(normalized, t.Text) {
+			score += 0.5
+		}
+	}
+	return score
+}
+
+// Snippetは、最初の一致箇所の前後を最大width文字切り出し、一致箇所を<mark>で囲んだ抜粋を返す。
+// 一致箇所以外はHTMLエスケープする。
+func (q Query) Snippet(text string, width int) string {
+	runes := []rune(text)
+	marks := q.highlights(runes)
+
+	start, end := 0, len(runes)
+	if len(runes) > width {
+		first := 0
+		for i, m := range marks {
+			if m {
+				first = i
+				break
+			}
+		}
+		// 一致箇所が前後の中央付近になるよう切り出す
+		start = max(0, first-width/4)
+		end = min(len(runes), start+width)
+		start = max(0, end-width)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	inMark := false
+	for i := start; i < end; i++ {
+		if marks[i] != inMark {
+			if marks[i] {
+				b.WriteString("<mark>")
+			} else {
+				b.WriteString("</mark>")
+			}
+			inMark = marks[i]
+		}
+		b.WriteString(html.EscapeString(string(runes[i])))
+	}
+	if inMark {
+		b.WriteString("</mark>")
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// 一致箇所の文字にtrueを設定したスライスを返す。
+// 正規化で文字数は変わらないため、正規化後の位置をそのまま元の文字列に適用できる。
+func (q Query) highlights(runes []rune) []bool {
+	normalized := []rune(Normalize(string(runes)))
+	marks := make([]bool, len(runes))
+	for _, t := range q.Terms {
+		term := []rune(t.Text)
+		for i := 0; i+len(term) <= len(normalized); i++ {
+			if string(normalized[i:i+len(term)]) == t.Text {
+				for j := i; j < i+len(term); j++ {
+					marks[j] = true
+				}
+			}
+		}
+	}
+	return marks
+}
@@ -0,0 +1,99 @@
+package search_test
+
+import (
+	"backend/app/search"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]struct {
+		q           string
+		wantBoolean string
+		wantErr     error
+	}{
+		"日本語":  {"買い物", `+"買い物"`, nil},
+		"複数の語": {"牛乳　買う", `+"牛乳" +"買う"`, nil},
+		"全角英数字を半角の小文字に": {"ＴＯＤＯ　Ａｐｐ", `+"todo" +"app"`, nil},
+		"1文字は前方一致":      {"a 会", `+a* +会*`, nil},
+		"演算子を取り除く":      {`-"meeting" +(notes)*`, `+"meeting" +"notes"`, nil},
+		"重複した語":         {"会議 会議", `+"会議"`, nil},
+		"空":             {"  ", "", search.ErrEmptyQuery},
+		"演算子のみ":         {"+ - *", "", search.ErrEmptyQuery},
+		"101文字":         {strings.Repeat("あ", 101), "", search.ErrQueryTooLong},
+		"11語":           {"a1 a2 a3 a4 a5 a6 a7 a8 a9 a10 a11", "", search.ErrTooManyTerms},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			q, err := search.Parse(c.q)
+			if !errors.Is(err, c.wantErr) {
+				t.Fatalf("期待したエラー: %v, 実際: %v", c.wantErr, err)
+			}
+			if err == nil && q.BooleanMode() != c.wantBoolean {
+				t.Errorf("期待した検索式: %s, 実際: %s", c.wantBoolean, q.BooleanMode())
+			}
+		})
+	}
+}
+
+func TestQueryScore(t *testing.T) {
+	q, err := search.Parse("牛乳")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := q.Score("牛乳を買う")
+	middle := q.Score("スーパーで牛乳を買う")
+	if !(prefix > middle && middle > 0) {
+		t.Errorf("先頭で一致した方が高いスコアになっていません: %f, %f", prefix, middle)
+	}
+	if got := q.Score("パンを買う"); got != 0 {
+		t.Errorf("一致しないタイトルのスコアが0ではありません: %f", got)
+	}
+	if !q.Match("スーパーで牛乳を買う") || q.Match("パンを買う") {
+		t.Error("一致の判定が不正です")
+	}
+}
+
+func TestQueryMatchAny(t *testing.T) {
+	q, err := search.Parse("牛乳 買う")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q.Match("牛乳は2本") || !q.MatchAny("牛乳は2本") {
+		t.Error("いずれかの語を含む場合の判定が不正です")
+	}
+	if q.MatchAny("パンを焼く") {
+		t.Error("語を含まない場合に一致と判定されています")
+	}
+}
+
+func TestQuerySnippet(t *testing.T) {
+	cases := map[string]struct {
+		q     string
+		text  string
+		width int
+		want  string
+	}{
+		"一致箇所を強調":      {"牛乳", "牛乳を買う", 60, "<mark>牛乳</mark>を買う"},
+		"全角英字に一致":      {"todo", "ＴＯＤＯアプリ", 60, "<mark>ＴＯＤＯ</mark>アプリ"},
+		"複数の語":         {"牛乳 パン", "牛乳とパン", 60, "<mark>牛乳</mark>と<mark>パン</mark>"},
+		"HTMLをエスケープ":   {"b", "<b>&", 60, "&lt;<mark>b</mark>&gt;&amp;"},
+		"一致箇所の前後を切り出す": {"牛乳", "あいうえおかきくけこさしすせそ牛乳たちつてと", 8, "…せそ<mark>牛乳</mark>たちつて…"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			q, err := search.Parse(c.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := q.Snippet(c.text, c.width); got != c.want {
+				t.Errorf("want: %s, got: %s", c.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"backend/app/model"
+	"sync"
+	"time"
+)
+
+// Cacheは、集計を短い時間だけ保持するキャッシュ。
+// 同じキーの集計を同時に求める場合は、最初の1件の結果を共有する
+type Cache struct {
+	ttl time.Duration
+	// 現在時刻を返す関数（テスト用に差し替え可能）
+	Now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	stats     model.Stats
+	err       error
+	expiresAt time.Time
+	// 集計が終わると閉じる
+	done chan struct{}
+}
+
+// Cacheのコンストラクタ
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, Now: time.Now, entries: make(map[string]*entry)}
+}
+
+var defaultCache *Cache
+
+// Defaultは、アプリケーション全体で使用するCacheを返す。未設定の場合はnil
+func Default() *Cache {
+	return defaultCache
+}
+
+// SetDefaultは、アプリケーション全体で使用するCacheを設定する
+func SetDefault(c *Cache) {
+	defaultCache = c
+}
+
+// Getは、有効な集計があればそれを返し、なければloadで求めて保持する。
+// 集計中の場合は、その結果を待って返す。loadが失敗した場合は保持しない
+func (c *Cache) Get(key string, load func() (model.Stats, error)) (model.Stats, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		select {
+		case <-e.done:
+			if c.Now().Before(e.expiresAt) {
+				c.mu.Unlock()
+				return e.stats, nil
+			}
+		default:
+			c.mu.Unlock()
+			<-e.done
+			return e.stats, e.err
+		}
+	}
+	e := &entry{done: make(chan struct{})}
+	c.entries[key] = e
+	c.removeExpired()
+	c.mu.Unlock()
+
+	e.stats, e.err = load()
+
+	c.mu.Lock()
+	if e.err != nil {
+		delete(c.entries, key)
+	} else {
+		e.expiresAt = c.Now().Add(c.ttl)
+	}
+	c.mu.Unlock()
+	close(e.done)
+
+	return e.stats, e.err
+}
+
+// 期限切れの集計を削除する。c.muを保持して呼び出すこと
+func (c *Cache) removeExpired() {
+	now := c.Now()
+	for key, e := range c.entries {
+		select {
+		case <-e.done:
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, key)
+			}
+		default:
+		}
+	}
+}
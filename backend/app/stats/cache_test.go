@@ -0,0 +1,91 @@
+package stats_test
+
+import (
+	"backend/app/model"
+	"backend/app/stats"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheExpires(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	cache := stats.NewCache(30 * time.Second)
+	cache.Now = func() time.Time { return now }
+
+	loads := 0
+	load := func() (model.Stats, error) {
+		loads++
+		return model.Stats{Total: loads}, nil
+	}
+
+	for _, elapsed := range []time.Duration{0, 29 * time.Second, 30 * time.Second} {
+		now = now.Add(elapsed)
+		if _, err := cache.Get("1:30", load); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		now = now.Add(-elapsed)
+	}
+	if loads != 2 {
+		t.Errorf("期限内は集計を再利用し、期限後に求め直す必要があります: %d回", loads)
+	}
+
+	got, _ := cache.Get("2:30", load)
+	if got.Total != 3 {
+		t.Errorf("キーごとに集計を保持する必要があります: %+v", got)
+	}
+}
+
+// 失敗した集計は保持せず、次の呼び出しで求め直すことを確認する
+func TestCacheDoesNotKeepErrors(t *testing.T) {
+	cache := stats.NewCache(time.Minute)
+
+	if _, err := cache.Get("1:30", func() (model.Stats, error) { return model.Stats{}, errors.New("DBエラー") }); err == nil {
+		t.Fatal("エラーを返す必要があります")
+	}
+	got, err := cache.Get("1:30", func() (model.Stats, error) { return model.Stats{Total: 5}, nil })
+	if err != nil || got.Total != 5 {
+		t.Errorf("want: 5, got: %+v, %v", got, err)
+	}
+}
+
+// 同じキーの集計を同時に求める場合は、1回だけ求めることを確認する
+func TestCacheSharesInFlightLoad(t *testing.T) {
+	cache := stats.NewCache(time.Minute)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func() (model.Stats, error) {
+		loads.Add(1)
+		<-release
+		return model.Stats{Total: 7}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]model.Stats, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = cache.Get("1:30", load)
+		}()
+	}
+	// 最初の集計が始まってから残りの呼び出しを待たせる
+	for loads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Errorf("集計は1回だけ求める必要があります: %d回", n)
+	}
+	for _, got := range results {
+		if got.Total != 7 {
+			t.Errorf("want: 7, got: %+v", got)
+		}
+	}
+}
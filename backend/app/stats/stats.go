@@ -0,0 +1,61 @@
+// statsは、Todoの集計のうちSQLで求めた値から導く部分と、集計のキャッシュを扱うパッケージ
+package stats
+
+import (
+	"backend/app/model"
+	"time"
+)
+
+// Changesは、ある日（UTC）のTodoの変更の数
+type Changes struct {
+	Created int
+	Deleted int
+	// 未完了から完了になった数（完了した状態で作成した場合を含む）
+	Completed int
+	// 完了から未完了に戻った数（完了したTodoを削除した場合を含む）
+	Reopened int
+}
+
+// Seriesは、fromから今日までの各日の終わり時点の完了の割合を、現在の数から変更を遡って求める。
+// changesはYYYY-MM-DDをキーとし、変更がない日は省略できる
+func Series(from, today time.Time, total, completed int, changes map[string]Changes) []model.StatsDay {
+	days := []model.StatsDay{}
+	for day := from; !day.After(today); day = day.AddDate(0, 0, 1) {
+		c := changes[day.Format(time.DateOnly)]
+		days = append(days, model.StatsDay{Date: day.Format(time.DateOnly), Created: c.Created, Completed: c.Completed})
+	}
+
+	// 今日の終わりの状態は現在の状態とし、前日の状態はその日の変更を打ち消して求める
+	for i := len(days) - 1; i >= 0; i-- {
+		if total > 0 {
+			rate := float64(completed) / float64(total)
+			days[i].CompletionRate = &rate
+		}
+		c := changes[days[i].Date]
+		total -= c.Created - c.Deleted
+		completed -= c.Completed - c.Reopened
+	}
+	return days
+}
+
+// Streaksは、完了したTodoがある日の並び（昇順・重複なし）から、現在と最長の連続日数を求める。
+// 今日に完了がなくても、昨日まで続いていれば現在の連続として数える
+func Streaks(days []time.Time, today time.Time) (current, longest int) {
+	run := 0
+	for i, day := range days {
+		if i > 0 && days[i-1].AddDate(0, 0, 1).Equal(day) {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+	}
+
+	if len(days) > 0 {
+		last := days[len(days)-1]
+		if last.Equal(today) || last.AddDate(0, 0, 1).Equal(today) {
+			current = run
+		}
+	}
+	return current, longest
+}
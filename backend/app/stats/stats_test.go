@@ -0,0 +1,61 @@
+package stats_test
+
+import (
+	"backend/app/model"
+	"backend/app/stats"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC)
+}
+
+func rate(r float64) *float64 {
+	return &r
+}
+
+// 現在の数から変更を遡って、各日の終わりの完了の割合を求めることを確認する
+func TestSeries(t *testing.T) {
+	changes := map[string]stats.Changes{
+		// 4件作成し、1件完了
+		"2024-05-02": {Created: 4, Completed: 1},
+		// 1件完了し、完了した1件を削除
+		"2024-05-04": {Completed: 1, Deleted: 1, Reopened: 1},
+	}
+
+	got := stats.Series(day(1), day(4), 3, 1, changes)
+	want := []model.StatsDay{
+		{Date: "2024-05-01"},
+		{Date: "2024-05-02", Created: 4, Completed: 1, CompletionRate: rate(0.25)},
+		{Date: "2024-05-03", CompletionRate: rate(0.25)},
+		{Date: "2024-05-04", Completed: 1, CompletionRate: rate(1.0 / 3)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	cases := map[string]struct {
+		days        []time.Time
+		wantCurrent int
+		wantLongest int
+	}{
+		"完了なし":       {nil, 0, 0},
+		"今日まで続いている":  {[]time.Time{day(1), day(2), day(3), day(8), day(9), day(10)}, 3, 3},
+		"昨日まで続いている":  {[]time.Time{day(7), day(8), day(9)}, 3, 3},
+		"途切れている":     {[]time.Time{day(1), day(2), day(3), day(4), day(8)}, 0, 4},
+		"過去の連続の方が長い": {[]time.Time{day(1), day(2), day(3), day(4), day(10)}, 1, 4},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			current, longest := stats.Streaks(c.days, day(10))
+			if current != c.wantCurrent || longest != c.wantLongest {
+				t.Errorf("want: (%d, %d), got: (%d, %d)", c.wantCurrent, c.wantLongest, current, longest)
+			}
+		})
+	}
+}
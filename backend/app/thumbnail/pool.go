@@ -0,0 +1,265 @@
+package thumbnail
+
+import (
+	"backend/app/blob"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// 縮小画像の作成状態。縮小画像を作成しない添付ファイルは空文字とする
+const (
+	StatusPending = "pending"
+	StatusReady   = "ready"
+	StatusFailed  = "failed"
+)
+
+// 画像として読み込めなかったことを表す
+var errDecode = errors.New("thumbnail: invalid image")
+
+// Poolは、縮小画像を作成するワーカーの集まり。
+// アップロード時にEnqueueで依頼された添付ファイルをすぐに処理し、
+// 依頼が溢れた場合や再起動で失われた場合に備えて、作成待ちの添付ファイルを定期的に拾い直す
+type Pool struct {
+	db    *sql.DB
+	store blob.Store
+	queue chan int
+
+	mu sync.Mutex
+	// キューに入っているか処理中の添付ファイルのID
+	queued map[int]bool
+
+	// 同時に縮小画像を作成するワーカーの数
+	Workers int
+	// 縮小画像の長辺のピクセル数
+	Size int
+	// 1回の拾い直しで取得する最大件数
+	BatchSize int
+	// この時間を過ぎても作成待ちの添付ファイルを拾い直す
+	StaleAfter time.Duration
+	// 現在時刻を返す関数（テスト用に差し替え可能）
+	Now func() time.Time
+}
+
+// Poolのコンストラクタ。queueSizeは処理を待てる依頼の数
+func NewPool(db *sql.DB, store blob.Store, queueSize int) *Pool {
+	return &Pool{
+		db:         db,
+		store:      store,
+		queue:      make(chan int, queueSize),
+		queued:     map[int]bool{},
+		Workers:    2,
+		Size:       DefaultSize,
+		BatchSize:  50,
+		StaleAfter: time.Minute,
+		Now:        time.Now,
+	}
+}
+
+var defaultPool *Pool
+
+// Defaultは、アプリケーション全体で使用するPoolを返す。未設定の場合はnil
+func Default() *Pool {
+	return defaultPool
+}
+
+// SetDefaultは、アプリケーション全体で使用するPoolを設定する
+func SetDefault(p *Pool) {
+	defaultPool = p
+}
+
+// Enqueueは、添付ファイルの縮小画像の作成を依頼する。キューが一杯の場合はfalseを返し、
+// その添付ファイルは定期的な拾い直しで処理する
+func (p *Pool) Enqueue(attachmentID int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.queued[attachmentID] {
+		return true
+	}
+	select {
+	case p.queue <- attachmentID:
+		p.queued[attachmentID] = true
+		return true
+	default:
+		return false
+	}
+}
+
+// Runは、ワーカーを起動し、一定間隔で作成待ちの添付ファイルを拾い直し続ける。
+// ctxがキャンセルされると、処理中の縮小画像の作成が終わるのを待って戻る。
+func (p *Pool) Run(ctx context.Context, interval time.Duration) {
+	var wg sync.WaitGroup
+	for range p.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+	defer wg.Wait()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.RunOnce(ctx); err != nil {
+				log.Printf("failed to collect pending thumbnails: %v", err)
+			}
+		}
+	}
+}
+
+// RunOnceは、StaleAfterを過ぎても作成待ちの添付ファイルをキューに入れ、入れた件数を返す
+func (p *Pool) RunOnce(ctx context.Context) (int, error) {
+	query := "SELECT id FROM attachments WHERE thumbnail_status = ? AND created_at <= ? ORDER BY id LIMIT ?"
+	rows, err := p.db.QueryContext(ctx, query, StatusPending, p.Now().Add(-p.StaleAfter), p.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	enqueued := 0
+	for _, id := range ids {
+		if !p.Enqueue(id) {
+			break
+		}
+		enqueued++
+	}
+	return enqueued, nil
+}
+
+// キューから添付ファイルを取り出して縮小画像を作成し続ける
+func (p *Pool) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-p.queue:
+			if err := p.Process(ctx, id); err != nil {
+				log.Printf("failed to generate thumbnail for attachment %d: %v", id, err)
+			}
+			p.mu.Lock()
+			delete(p.queued, id)
+			p.mu.Unlock()
+		}
+	}
+}
+
+// Processは、添付ファイル1件の縮小画像を作成して記録する。
+// 同じ内容の縮小画像がすでにある場合は、作成せずにそれを使う。
+// 画像として読み込めない場合は失敗として記録し、再試行しない
+func (p *Pool) Process(ctx context.Context, attachmentID int) error {
+	var key string
+	query := "SELECT blob_key FROM attachments WHERE id = ? AND thumbnail_status = ?"
+	if err := p.db.QueryRowContext(ctx, query, attachmentID, StatusPending).Scan(&key); err != nil {
+		if err == sql.ErrNoRows {
+			// 削除済みか、作成済み
+			return nil
+		}
+		return err
+	}
+
+	if reused, err := p.reuse(ctx, attachmentID, key); err != nil || reused {
+		return err
+	}
+
+	staged, err := p.generate(ctx, key)
+	if errors.Is(err, ErrTooLarge) || errors.Is(err, errDecode) || errors.Is(err, blob.ErrNotFound) {
+		_, err := p.record(ctx, p.db, attachmentID, StatusFailed, "")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	// Commit後のDiscardは何もしない
+	defer staged.Discard()
+
+	// 縮小画像は記録してから配置し、同じ内容の参照を数えて削除する処理に消されないようにする。
+	// 作成中に添付ファイルが削除された場合は配置しない
+	updated, err := p.record(ctx, p.db, attachmentID, StatusReady, staged.Key())
+	if err != nil || !updated {
+		return err
+	}
+	return staged.Commit(ctx)
+}
+
+// 同じ内容の作成済みの縮小画像があれば、それを記録してtrueを返す。
+// 縮小画像を参照している添付ファイルをロックし、記録する前に縮小画像が削除されないようにする
+func (p *Pool) reuse(ctx context.Context, attachmentID int, key string) (bool, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	// Commit後のRollbackは何もしない
+	defer tx.Rollback()
+
+	var thumbnailKey string
+	cachedQuery := "SELECT thumbnail_key FROM attachments WHERE blob_key = ? AND thumbnail_status = ? LIMIT 1 FOR UPDATE"
+	if err := tx.QueryRowContext(ctx, cachedQuery, key, StatusReady).Scan(&thumbnailKey); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if _, err := p.record(ctx, tx, attachmentID, StatusReady, thumbnailKey); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// 元の内容から縮小画像を作成して一時的に保存する
+func (p *Pool) generate(ctx context.Context, key string) (blob.Staged, error) {
+	content, err := p.store.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	data, _, err := Generate(content, p.Size)
+	if err != nil {
+		if errors.Is(err, ErrTooLarge) {
+			return nil, err
+		}
+		return nil, errors.Join(errDecode, err)
+	}
+
+	return p.store.Stage(ctx, bytes.NewReader(data))
+}
+
+// 作成結果を記録する。作成中に添付ファイルが削除された場合は、記録せずにfalseを返す
+func (p *Pool) record(ctx context.Context, e execer, attachmentID int, status, thumbnailKey string) (bool, error) {
+	query := "UPDATE attachments SET thumbnail_status = ?, thumbnail_key = ? WHERE id = ? AND thumbnail_status = ?"
+	result, err := e.ExecContext(ctx, query, status, thumbnailKey, attachmentID, StatusPending)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// execerは、*sql.DBと*sql.Txに共通する更新のメソッド
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
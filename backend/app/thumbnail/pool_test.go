@@ -0,0 +1,218 @@
+package thumbnail_test
+
+import (
+	"backend/app/blob"
+	"backend/app/thumbnail"
+	"bytes"
+	"context"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var now = time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// newPoolは、モックDBと一時ディレクトリのストレージを使用するPoolを作成します。
+func newPool(t *testing.T) (*thumbnail.Pool, sqlmock.Sqlmock, *blob.LocalStore) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("モックDBの作成に失敗しました: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("ストレージの作成に失敗しました: %s", err)
+	}
+
+	p := thumbnail.NewPool(db, store, 2)
+	p.Now = func() time.Time { return now }
+	return p, mock, store
+}
+
+// expectPendingは、作成待ちの添付ファイルの取得を期待値として設定します。
+func expectPending(mock sqlmock.Sqlmock, id int, key string) {
+	mock.ExpectQuery(`^SELECT blob_key FROM attachments WHERE id = \? AND thumbnail_status = \?$`).
+		WithArgs(id, "pending").
+		WillReturnRows(sqlmock.NewRows([]string{"blob_key"}).AddRow(key))
+}
+
+// expectCachedは、同じ内容の作成済みの縮小画像の取得を期待値として設定します。
+func expectCached(mock sqlmock.Sqlmock, key string, rows *sqlmock.Rows) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT thumbnail_key FROM attachments WHERE blob_key = \? AND thumbnail_status = \? LIMIT 1 FOR UPDATE$`).
+		WithArgs(key, "ready").
+		WillReturnRows(rows)
+}
+
+// expectNotCachedは、同じ内容の作成済みの縮小画像がないことを期待値として設定します。
+func expectNotCached(mock sqlmock.Sqlmock, key string) {
+	expectCached(mock, key, sqlmock.NewRows([]string{"thumbnail_key"}))
+	mock.ExpectRollback()
+}
+
+// expectRecordは、作成結果の記録を期待値として設定します。
+func expectRecord(mock sqlmock.Sqlmock, id int, status string, thumbnailKey any) {
+	mock.ExpectExec(`^UPDATE attachments SET thumbnail_status = \?, thumbnail_key = \? WHERE id = \? AND thumbnail_status = \?$`).
+		WithArgs(status, thumbnailKey, id, "pending").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func checkExpectations(t *testing.T, mock sqlmock.Sqlmock) {
+	t.Helper()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("縮小画像を作成して保存", func(t *testing.T) {
+		p, mock, store := newPool(t)
+
+		var src bytes.Buffer
+		png.Encode(&src, newImage(1024, 512))
+		key, _, _ := store.Put(ctx, &src)
+
+		expectPending(mock, 5, key)
+		expectNotCached(mock, key)
+		mock.ExpectExec(`^UPDATE attachments SET thumbnail_status = \?, thumbnail_key = \?`).
+			WithArgs("ready", sqlmock.AnyArg(), 5, "pending").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := p.Process(ctx, 5); err != nil {
+			t.Fatalf("縮小画像の作成に失敗しました: %s", err)
+		}
+		checkExpectations(t, mock)
+
+		// 縮小画像は、元の内容とは別のキーで保存される
+		var thumbnailKey string
+		found := 0
+		for _, k := range listKeys(t, store.Dir) {
+			if k != key {
+				thumbnailKey = k
+				found++
+			}
+		}
+		if found != 1 {
+			t.Fatalf("縮小画像が1件保存されていません: %d", found)
+		}
+		content, err := store.Open(ctx, thumbnailKey)
+		if err != nil {
+			t.Fatalf("縮小画像を開けません: %s", err)
+		}
+		defer content.Close()
+		img, err := png.Decode(content)
+		if err != nil {
+			t.Fatalf("縮小画像を読み込めません: %s", err)
+		}
+		if b := img.Bounds(); b.Dx() != 256 || b.Dy() != 128 {
+			t.Errorf("want: 256x128, got: %dx%d", b.Dx(), b.Dy())
+		}
+	})
+
+	t.Run("同じ内容の縮小画像があれば作成しない", func(t *testing.T) {
+		p, mock, _ := newPool(t)
+
+		key := strings.Repeat("a", 64)
+		cached := strings.Repeat("b", 64)
+		expectPending(mock, 6, key)
+		expectCached(mock, key, sqlmock.NewRows([]string{"thumbnail_key"}).AddRow(cached))
+		expectRecord(mock, 6, "ready", cached)
+		mock.ExpectCommit()
+
+		if err := p.Process(ctx, 6); err != nil {
+			t.Fatalf("縮小画像の記録に失敗しました: %s", err)
+		}
+		checkExpectations(t, mock)
+	})
+
+	t.Run("画像として読み込めない場合は失敗として記録", func(t *testing.T) {
+		p, mock, store := newPool(t)
+
+		key, _, _ := store.Put(ctx, strings.NewReader("\x89PNG\r\n\x1a\nbroken"))
+		expectPending(mock, 7, key)
+		expectNotCached(mock, key)
+		expectRecord(mock, 7, "failed", "")
+
+		if err := p.Process(ctx, 7); err != nil {
+			t.Fatalf("失敗の記録に失敗しました: %s", err)
+		}
+		checkExpectations(t, mock)
+	})
+
+	t.Run("作成中に削除された場合は縮小画像を残さない", func(t *testing.T) {
+		p, mock, store := newPool(t)
+
+		var src bytes.Buffer
+		png.Encode(&src, newImage(512, 512))
+		key, _, _ := store.Put(ctx, &src)
+
+		expectPending(mock, 8, key)
+		expectNotCached(mock, key)
+		mock.ExpectExec(`^UPDATE attachments SET thumbnail_status = \?, thumbnail_key = \?`).
+			WithArgs("ready", sqlmock.AnyArg(), 8, "pending").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := p.Process(ctx, 8); err != nil {
+			t.Fatalf("縮小画像の作成に失敗しました: %s", err)
+		}
+		checkExpectations(t, mock)
+		if keys := listKeys(t, store.Dir); len(keys) != 1 || keys[0] != key {
+			t.Errorf("元の内容のみ残ること: %v", keys)
+		}
+		if tmp, _ := os.ReadDir(filepath.Join(store.Dir, "tmp")); len(tmp) != 0 {
+			t.Errorf("一時ファイルが残っています: %v", tmp)
+		}
+	})
+}
+
+func TestRunOnce(t *testing.T) {
+	p, mock, _ := newPool(t)
+
+	mock.ExpectQuery(`^SELECT id FROM attachments WHERE thumbnail_status = \? AND created_at <= \? ORDER BY id LIMIT \?$`).
+		WithArgs("pending", now.Add(-time.Minute), 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+
+	// キューの大きさは2のため、3件目は次の拾い直しで処理する
+	n, err := p.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("拾い直しに失敗しました: %s", err)
+	}
+	if n != 2 {
+		t.Errorf("want: 2, got: %d", n)
+	}
+	checkExpectations(t, mock)
+
+	// キューに入っている添付ファイルは重複して入れない
+	if !p.Enqueue(1) {
+		t.Errorf("キューに入っている添付ファイルの依頼は受け付けたものとすること")
+	}
+	if p.Enqueue(3) {
+		t.Errorf("キューが一杯の場合はfalseを返すこと")
+	}
+}
+
+// listKeysは、ストレージに保存されている内容のキーを返します。
+func listKeys(t *testing.T, dir string) []string {
+	t.Helper()
+
+	matches, err := filepath.Glob(filepath.Join(dir, "??", "*"))
+	if err != nil {
+		t.Fatalf("保存先の一覧の取得に失敗しました: %s", err)
+	}
+	keys := make([]string, 0, len(matches))
+	for _, m := range matches {
+		keys = append(keys, filepath.Base(m))
+	}
+	return keys
+}
@@ -0,0 +1,115 @@
+// thumbnailは、画像の添付ファイルから縮小画像を作成するパッケージ。
+// 縮小画像はワーカーが非同期に作成し、元の内容と同じストレージに保存する。
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	// GIFはimage.Decodeで展開できるよう登録のみ行う
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// 既定の縮小画像の長辺のピクセル数
+const DefaultSize = 256
+
+// 展開する画像の最大ピクセル数。小さなファイルから巨大な画像を展開させる攻撃を防ぐ
+const MaxPixels = 24_000_000
+
+// ErrTooLargeは、画像のピクセル数がMaxPixelsを超える場合に返す
+var ErrTooLarge = errors.New("thumbnail: image too large")
+
+// 縮小画像を作成できる添付ファイルのメディアタイプ
+var supportedTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+// Supportedは、メディアタイプの内容から縮小画像を作成できるかどうかを返す
+func Supported(contentType string) bool {
+	return supportedTypes[contentType]
+}
+
+// Generateは、画像を長辺がsizeピクセル以内になるよう縮小し、エンコードした内容とメディアタイプを返す。
+// JPEGはJPEGのまま、それ以外は透過を保つためPNGで出力する。元の画像がsize以内の場合は拡大しない。
+// アニメーションGIFは最初のフレームを使う
+func Generate(r io.Reader, size int) ([]byte, string, error) {
+	var head bytes.Buffer
+	config, format, err := image.DecodeConfig(io.TeeReader(r, &head))
+	if err != nil {
+		return nil, "", fmt.Errorf("thumbnail: decode config: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	src, _, err := image.Decode(io.MultiReader(&head, r))
+	if err != nil {
+		return nil, "", fmt.Errorf("thumbnail: decode: %w", err)
+	}
+	dst := resize(src, size)
+
+	var out bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&out, dst, &jpeg.Options{Quality: 85})
+		return out.Bytes(), "image/jpeg", err
+	}
+	err = png.Encode(&out, dst)
+	return out.Bytes(), "image/png", err
+}
+
+// resizeは、画像を長辺がsizeピクセル以内になるよう縮小する。
+// 縮小先の1ピクセルに対応する元の画像の範囲を平均する（面積平均法）
+func resize(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	// 平均はアルファを乗算済みの値で求める
+	rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, max(1, h*size/w)
+		} else {
+			tw, th = max(1, w*size/h), size
+		}
+	}
+	if tw == w && th == h {
+		return rgba
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := y*h/th, (y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := x*w/tw, (x+1)*w/tw
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride+x0*4 : sy*rgba.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += int(row[i])
+					g += int(row[i+1])
+					b += int(row[i+2])
+					a += int(row[i+3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,118 @@
+package thumbnail_test
+
+import (
+	"backend/app/thumbnail"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// newImageは、左半分が赤、右半分が透明な画像を作成します。
+func newImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w/2; x++ {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	return img
+}
+
+func TestGenerate(t *testing.T) {
+	var pngData, jpegData, gifData bytes.Buffer
+	png.Encode(&pngData, newImage(800, 400))
+	jpeg.Encode(&jpegData, newImage(300, 600), nil)
+	gif.Encode(&gifData, newImage(100, 50), nil)
+
+	cases := map[string]struct {
+		data       []byte
+		wantType   string
+		wantWidth  int
+		wantHeight int
+	}{
+		"横長のPNG":          {pngData.Bytes(), "image/png", 256, 128},
+		"縦長のJPEGはJPEGのまま": {jpegData.Bytes(), "image/jpeg", 128, 256},
+		"小さい画像は拡大しない":     {gifData.Bytes(), "image/png", 100, 50},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			data, contentType, err := thumbnail.Generate(bytes.NewReader(c.data), thumbnail.DefaultSize)
+			if err != nil {
+				t.Fatalf("縮小画像の作成に失敗しました: %s", err)
+			}
+			if contentType != c.wantType {
+				t.Errorf("want: %s, got: %s", c.wantType, contentType)
+			}
+
+			img, _, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("縮小画像を読み込めません: %s", err)
+			}
+			if b := img.Bounds(); b.Dx() != c.wantWidth || b.Dy() != c.wantHeight {
+				t.Errorf("want: %dx%d, got: %dx%d", c.wantWidth, c.wantHeight, b.Dx(), b.Dy())
+			}
+		})
+	}
+}
+
+// 縮小しても色と透過が保たれることを確認する
+func TestGenerateKeepsAlpha(t *testing.T) {
+	var src bytes.Buffer
+	png.Encode(&src, newImage(512, 512))
+
+	data, _, err := thumbnail.Generate(&src, 64)
+	if err != nil {
+		t.Fatalf("縮小画像の作成に失敗しました: %s", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("縮小画像を読み込めません: %s", err)
+	}
+
+	if got := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA); got != (color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("左端は赤のままであること: %v", got)
+	}
+	if _, _, _, a := img.At(63, 0).RGBA(); a != 0 {
+		t.Errorf("右端は透明のままであること: %d", a)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	t.Run("画像でない内容", func(t *testing.T) {
+		if _, _, err := thumbnail.Generate(strings.NewReader("not an image"), thumbnail.DefaultSize); err == nil {
+			t.Errorf("エラーを返すこと")
+		}
+	})
+
+	t.Run("展開すると巨大な画像", func(t *testing.T) {
+		_, _, err := thumbnail.Generate(bytes.NewReader(pngHeader(100000, 100000)), thumbnail.DefaultSize)
+		if !errors.Is(err, thumbnail.ErrTooLarge) {
+			t.Errorf("want: ErrTooLarge, got: %v", err)
+		}
+	})
+}
+
+// pngHeaderは、指定した大きさを宣言するだけのPNGのシグネチャとIHDRチャンクを返します。
+func pngHeader(w, h uint32) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], w)
+	binary.BigEndian.PutUint32(ihdr[8:], h)
+	ihdr[12], ihdr[13] = 8, 6 // 8bit RGBA
+
+	var b bytes.Buffer
+	b.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&b, binary.BigEndian, uint32(13))
+	b.Write(ihdr)
+	binary.Write(&b, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+	return b.Bytes()
+}
@@ -0,0 +1,24 @@
+package validator
+
+import (
+	"backend/app/model"
+	"fmt"
+)
+
+func TodoAssigneesInput(assignees model.TodoAssignees) error {
+	const (
+		errTooManyAssignees = "担当者は10人以内で指定してください。"
+		errInvalidUserID    = "担当者のユーザーIDが不正です。"
+	)
+
+	if len(assignees.UserIDs) > 10 {
+		return fmt.Errorf(errTooManyAssignees)
+	}
+	for _, userID := range assignees.UserIDs {
+		if userID <= 0 {
+			return fmt.Errorf(errInvalidUserID)
+		}
+	}
+
+	return nil
+}
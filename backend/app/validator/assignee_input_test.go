@@ -0,0 +1,34 @@
+package validator_test
+
+import (
+	"backend/app/model"
+	"backend/app/validator"
+	"testing"
+)
+
+func TestTodoAssigneesInput(t *testing.T) {
+	wantErr, noErr := true, false
+	cases := map[string]struct {
+		input      model.TodoAssignees
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし":    {model.TodoAssignees{UserIDs: []int{7, 11}}, "", noErr},
+		"担当者なし":    {model.TodoAssignees{}, "", noErr},
+		"11人":      {model.TodoAssignees{UserIDs: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}}, "担当者は10人以内で指定してください。", wantErr},
+		"ユーザーIDが0": {model.TodoAssignees{UserIDs: []int{7, 0}}, "担当者のユーザーIDが不正です。", wantErr},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.TodoAssigneesInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package validator
+
+import (
+	"backend/app/model"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+func ChecklistItemInput(item model.ChecklistItem) error {
+	const (
+		errRequiredText   = "項目の内容を入力してください。"
+		errOverLengthText = "項目の内容は200文字以内で入力してください。"
+	)
+
+	if strings.TrimSpace(item.Text) == "" {
+		return fmt.Errorf(errRequiredText)
+	}
+	if utf8.RuneCountInString(item.Text) > 200 {
+		return fmt.Errorf(errOverLengthText)
+	}
+
+	return nil
+}
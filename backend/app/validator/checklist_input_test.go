@@ -0,0 +1,35 @@
+package validator_test
+
+import (
+	"backend/app/model"
+	"backend/app/validator"
+	"strings"
+	"testing"
+)
+
+func TestChecklistItemInput(t *testing.T) {
+	wantErr, noErr := true, false
+	cases := map[string]struct {
+		input      model.ChecklistItem
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし":    {model.ChecklistItem{Text: "牛乳を買う"}, "", noErr},
+		"200文字":    {model.ChecklistItem{Text: strings.Repeat("あ", 200)}, "", noErr},
+		"内容が空":     {model.ChecklistItem{Text: "　 "}, "項目の内容を入力してください。", wantErr},
+		"内容が201文字": {model.ChecklistItem{Text: strings.Repeat("あ", 201)}, "項目の内容は200文字以内で入力してください。", wantErr},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.ChecklistItemInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
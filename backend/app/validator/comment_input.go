@@ -0,0 +1,24 @@
+package validator
+
+import (
+	"backend/app/model"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+func CommentInput(comment model.Comment) error {
+	const (
+		errRequiredBody   = "コメントを入力してください。"
+		errOverLengthBody = "コメントは2000文字以内で入力してください。"
+	)
+
+	if strings.TrimSpace(comment.Body) == "" {
+		return fmt.Errorf(errRequiredBody)
+	}
+	if utf8.RuneCountInString(comment.Body) > 2000 {
+		return fmt.Errorf(errOverLengthBody)
+	}
+
+	return nil
+}
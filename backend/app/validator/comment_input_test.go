@@ -0,0 +1,35 @@
+package validator_test
+
+import (
+	"backend/app/model"
+	"backend/app/validator"
+	"strings"
+	"testing"
+)
+
+func TestCommentInput(t *testing.T) {
+	wantErr, noErr := true, false
+	cases := map[string]struct {
+		input      model.Comment
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし":     {model.Comment{Body: "@alice 確認お願いします"}, "", noErr},
+		"2000文字":    {model.Comment{Body: strings.Repeat("あ", 2000)}, "", noErr},
+		"本文が空":      {model.Comment{Body: "\n "}, "コメントを入力してください。", wantErr},
+		"本文が2001文字": {model.Comment{Body: strings.Repeat("あ", 2001)}, "コメントは2000文字以内で入力してください。", wantErr},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.CommentInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package validator
+
+import (
+	"backend/app/model"
+	"fmt"
+)
+
+func TodoDependenciesInput(dependencies model.TodoDependencies) error {
+	const (
+		errTooManyBlockers = "依存するTODOは50件以内で指定してください。"
+		errInvalidBlocker  = "依存するTODOのIDが不正です。"
+	)
+
+	if len(dependencies.BlockerIDs) > 50 {
+		return fmt.Errorf(errTooManyBlockers)
+	}
+	for _, id := range dependencies.BlockerIDs {
+		if id <= 0 {
+			return fmt.Errorf(errInvalidBlocker)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package validator_test
+
+import (
+	"backend/app/model"
+	"backend/app/validator"
+	"testing"
+)
+
+func TestTodoDependenciesInput(t *testing.T) {
+	wantErr, noErr := true, false
+	tooMany := make([]int, 51)
+	for i := range tooMany {
+		tooMany[i] = i + 1
+	}
+	cases := map[string]struct {
+		input      model.TodoDependencies
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし":  {model.TodoDependencies{BlockerIDs: []int{2, 3}}, "", noErr},
+		"依存なし":   {model.TodoDependencies{}, "", noErr},
+		"51件":    {model.TodoDependencies{BlockerIDs: tooMany}, "依存するTODOは50件以内で指定してください。", wantErr},
+		"IDが負の値": {model.TodoDependencies{BlockerIDs: []int{-1}}, "依存するTODOのIDが不正です。", wantErr},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.TodoDependenciesInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package validator
+
+import (
+	"backend/app/model"
+	"fmt"
+	"net/mail"
+	"time"
+)
+
+func DigestPreferenceInput(pref model.DigestPreference) error {
+	const (
+		errInvalidEmail    = "メールアドレスが不正です。"
+		errOverLengthEmail = "メールアドレスは254文字以内で入力してください。"
+		errInvalidSendTime = "配信時刻はHH:MMの形式で入力してください。"
+		errInvalidTimeZone = "タイムゾーンが不正です。"
+	)
+
+	// アドレスは省略でき、その場合は自分の確認済みのアドレスに配信する
+	if pref.Email != "" {
+		if len(pref.Email) > 254 {
+			return fmt.Errorf(errOverLengthEmail)
+		}
+		// 表示名付きのアドレスは受け付けない
+		addr, err := mail.ParseAddress(pref.Email)
+		if err != nil || addr.Address != pref.Email {
+			return fmt.Errorf(errInvalidEmail)
+		}
+	}
+
+	if t, err := time.Parse("15:04", pref.SendTime); err != nil || t.Format("15:04") != pref.SendTime {
+		return fmt.Errorf(errInvalidSendTime)
+	}
+
+	if _, err := time.LoadLocation(pref.TimeZone); err != nil {
+		return fmt.Errorf(errInvalidTimeZone)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package validator_test
+
+import (
+	"backend/app/model"
+	"backend/app/validator"
+	"testing"
+)
+
+func TestDigestPreferenceInput(t *testing.T) {
+	wantErr, noErr := true, false
+	valid := func(f func(p *model.DigestPreference)) model.DigestPreference {
+		p := model.DigestPreference{Enabled: true, Email: "user@example.com", SendTime: "08:00", TimeZone: "Asia/Tokyo"}
+		f(&p)
+		return p
+	}
+	cases := map[string]struct {
+		input      model.DigestPreference
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし":         {valid(func(p *model.DigestPreference) {}), "", noErr},
+		"配信停止はアドレスなしで可": {valid(func(p *model.DigestPreference) { p.Enabled, p.Email = false, "" }), "", noErr},
+		"配信するがアドレスを省略":  {valid(func(p *model.DigestPreference) { p.Email = "" }), "", noErr},
+		"アドレスが不正":       {valid(func(p *model.DigestPreference) { p.Email = "user" }), "メールアドレスが不正です。", wantErr},
+		"配信時刻が不正":       {valid(func(p *model.DigestPreference) { p.SendTime = "25:00" }), "配信時刻はHH:MMの形式で入力してください。", wantErr},
+		"配信時刻の形式が不正":    {valid(func(p *model.DigestPreference) { p.SendTime = "8:00" }), "配信時刻はHH:MMの形式で入力してください。", wantErr},
+		"タイムゾーンが不正":     {valid(func(p *model.DigestPreference) { p.TimeZone = "Mars/Olympus" }), "タイムゾーンが不正です。", wantErr},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.DigestPreferenceInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
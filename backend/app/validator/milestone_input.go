@@ -0,0 +1,46 @@
+package validator
+
+import (
+	"backend/app/model"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// マイルストーンの期間の最大日数（開始日と終了日を含む）
+const MaxMilestoneDays = 366
+
+func MilestoneInput(milestone model.Milestone) error {
+	const (
+		errRequiredName   = "マイルストーン名は必須です。"
+		errOverLengthName = "マイルストーン名は100文字以内で入力してください。"
+		errInvalidDate    = "開始日と終了日はYYYY-MM-DD形式で入力してください。"
+		errInvalidRange   = "終了日は開始日以降にしてください。"
+		errOverLongRange  = "期間は366日以内にしてください。"
+	)
+
+	if len(strings.TrimSpace(milestone.Name)) == 0 {
+		return fmt.Errorf(errRequiredName)
+	}
+	if utf8.RuneCountInString(milestone.Name) > 100 {
+		return fmt.Errorf(errOverLengthName)
+	}
+
+	start, err := time.Parse(time.DateOnly, milestone.StartDate)
+	if err != nil {
+		return fmt.Errorf(errInvalidDate)
+	}
+	end, err := time.Parse(time.DateOnly, milestone.EndDate)
+	if err != nil {
+		return fmt.Errorf(errInvalidDate)
+	}
+	if end.Before(start) {
+		return fmt.Errorf(errInvalidRange)
+	}
+	if end.Sub(start) >= MaxMilestoneDays*24*time.Hour {
+		return fmt.Errorf(errOverLongRange)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package validator_test
+
+import (
+	"backend/app/model"
+	"backend/app/validator"
+	"strings"
+	"testing"
+)
+
+func TestMilestoneInput(t *testing.T) {
+	wantErr, noErr := true, false
+	milestone := func(name, start, end string) model.Milestone {
+		return model.Milestone{Name: name, StartDate: start, EndDate: end}
+	}
+
+	cases := map[string]struct {
+		input      model.Milestone
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし":      {milestone("スプリント1", "2024-05-01", "2024-05-14"), "", noErr},
+		"開始日と終了日が同じ": {milestone("リリース", "2024-05-01", "2024-05-01"), "", noErr},
+		"名前が100文字":   {milestone(strings.Repeat("あ", 100), "2024-05-01", "2024-05-14"), "", noErr},
+		"名前が空":       {milestone(" ", "2024-05-01", "2024-05-14"), "マイルストーン名は必須です。", wantErr},
+		"名前が101文字":   {milestone(strings.Repeat("あ", 101), "2024-05-01", "2024-05-14"), "マイルストーン名は100文字以内で入力してください。", wantErr},
+		"開始日がない":     {milestone("スプリント1", "", "2024-05-14"), "開始日と終了日はYYYY-MM-DD形式で入力してください。", wantErr},
+		"終了日の形式が不正":  {milestone("スプリント1", "2024-05-01", "2024/05/14"), "開始日と終了日はYYYY-MM-DD形式で入力してください。", wantErr},
+		"存在しない日付":    {milestone("スプリント1", "2024-02-30", "2024-05-14"), "開始日と終了日はYYYY-MM-DD形式で入力してください。", wantErr},
+		"終了日が開始日より前": {milestone("スプリント1", "2024-05-14", "2024-05-13"), "終了日は開始日以降にしてください。", wantErr},
+		"期間が366日":    {milestone("2024年", "2024-01-01", "2024-12-31"), "", noErr},
+		"期間が367日":    {milestone("2024年", "2024-01-01", "2025-01-01"), "期間は366日以内にしてください。", wantErr},
+		"開始日が遠い過去":   {milestone("スプリント1", "0001-01-01", "2024-05-14"), "期間は366日以内にしてください。", wantErr},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.MilestoneInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package validator
+
+import (
+	"backend/app/model"
+	"fmt"
+	"net/mail"
+)
+
+// リマインダーの通知の方法
+var reminderChannels = map[string]bool{
+	"push":    true,
+	"webhook": true,
+	"email":   true,
+}
+
+func ReminderInput(reminder model.Reminder) error {
+	const (
+		errRequiredRemindAt = "通知日時を入力してください。"
+		errInvalidChannel   = "通知の方法はpush、webhook、emailのいずれかを指定してください。"
+		errInvalidTarget    = "宛先のメールアドレスが不正です。"
+		errOverLengthTarget = "宛先は254文字以内で入力してください。"
+	)
+
+	if reminder.RemindAt.IsZero() {
+		return fmt.Errorf(errRequiredRemindAt)
+	}
+
+	if !reminderChannels[reminder.Channel] {
+		return fmt.Errorf(errInvalidChannel)
+	}
+
+	// 宛先は省略でき、その場合は自分の確認済みのアドレスに通知する
+	if reminder.Channel == "email" && reminder.Target != "" {
+		if len(reminder.Target) > 254 {
+			return fmt.Errorf(errOverLengthTarget)
+		}
+		// 表示名付きのアドレスは受け付けない
+		addr, err := mail.ParseAddress(reminder.Target)
+		if err != nil || addr.Address != reminder.Target {
+			return fmt.Errorf(errInvalidTarget)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package validator_test
+
+import (
+	"backend/app/model"
+	"backend/app/validator"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReminderInput(t *testing.T) {
+	wantErr, noErr := true, false
+	valid := func(f func(r *model.Reminder)) model.Reminder {
+		r := model.Reminder{RemindAt: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC), Channel: "push"}
+		f(&r)
+		return r
+	}
+	cases := map[string]struct {
+		input      model.Reminder
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし":        {valid(func(r *model.Reminder) {}), "", noErr},
+		"メールで通知":       {valid(func(r *model.Reminder) { r.Channel, r.Target = "email", "user@example.com" }), "", noErr},
+		"通知日時が空":       {valid(func(r *model.Reminder) { r.RemindAt = time.Time{} }), "通知日時を入力してください。", wantErr},
+		"通知の方法が不正":     {valid(func(r *model.Reminder) { r.Channel = "sms" }), "通知の方法はpush、webhook、emailのいずれかを指定してください。", wantErr},
+		"メールの宛先を省略":    {valid(func(r *model.Reminder) { r.Channel = "email" }), "", noErr},
+		"メールの宛先が不正":    {valid(func(r *model.Reminder) { r.Channel, r.Target = "email", "user" }), "宛先のメールアドレスが不正です。", wantErr},
+		"宛先に表示名":       {valid(func(r *model.Reminder) { r.Channel, r.Target = "email", "User <user@example.com>" }), "宛先のメールアドレスが不正です。", wantErr},
+		"宛先に改行":        {valid(func(r *model.Reminder) { r.Channel, r.Target = "email", "user@example.com\r\nBcc: x@example.com" }), "宛先のメールアドレスが不正です。", wantErr},
+		"メールの宛先が255文字": {valid(func(r *model.Reminder) { r.Channel, r.Target = "email", strings.Repeat("a", 243)+"@example.com" }), "宛先は254文字以内で入力してください。", wantErr},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.ReminderInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
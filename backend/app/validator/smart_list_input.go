@@ -0,0 +1,56 @@
+package validator
+
+import (
+	"backend/app/filter"
+	"backend/app/model"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+func SmartListInput(list model.SmartList) error {
+	const (
+		errRequiredName   = "名前を入力してください。"
+		errOverLengthName = "名前は50文字以内で入力してください。"
+		errRequiredFilter = "フィルターを入力してください。"
+		errInvalidFilter  = "フィルターが不正です。"
+	)
+
+	if strings.TrimSpace(list.Name) == "" {
+		return fmt.Errorf(errRequiredName)
+	}
+	if utf8.RuneCountInString(list.Name) > 50 {
+		return fmt.Errorf(errOverLengthName)
+	}
+
+	if strings.TrimSpace(list.Filter) == "" {
+		return fmt.Errorf(errRequiredFilter)
+	}
+	if _, err := filter.Parse(list.Filter); err != nil {
+		return fmt.Errorf("%s%s", errInvalidFilter, err.Error())
+	}
+
+	return nil
+}
+
+func TodoTagsInput(tags model.TodoTags) error {
+	const (
+		errTooManyTags   = "タグは20個以内で指定してください。"
+		errRequiredTag   = "空のタグは指定できません。"
+		errOverLengthTag = "タグは50文字以内で入力してください。"
+	)
+
+	if len(tags.Tags) > 20 {
+		return fmt.Errorf(errTooManyTags)
+	}
+	for _, tag := range tags.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return fmt.Errorf(errRequiredTag)
+		}
+		if utf8.RuneCountInString(tag) > 50 {
+			return fmt.Errorf(errOverLengthTag)
+		}
+	}
+
+	return nil
+}
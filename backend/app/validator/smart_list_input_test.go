@@ -0,0 +1,64 @@
+package validator_test
+
+import (
+	"backend/app/model"
+	"backend/app/validator"
+	"strings"
+	"testing"
+)
+
+func TestSmartListInput(t *testing.T) {
+	wantErr, noErr := true, false
+	cases := map[string]struct {
+		input      model.SmartList
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし":       {model.SmartList{Name: "今週の仕事", Filter: "is:open tag:work due:<7d"}, "", noErr},
+		"名前が空":        {model.SmartList{Name: " ", Filter: "is:open"}, "名前を入力してください。", wantErr},
+		"名前が51文字":     {model.SmartList{Name: strings.Repeat("あ", 51), Filter: "is:open"}, "名前は50文字以内で入力してください。", wantErr},
+		"フィルターが空":     {model.SmartList{Name: "a"}, "フィルターを入力してください。", wantErr},
+		"フィルターの構文が不正": {model.SmartList{Name: "a", Filter: "is:later"}, "フィルターが不正です。1文字目: isにはopen、done、overdue、recurringのいずれかを指定してください。", wantErr},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.SmartListInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
+
+func TestTodoTagsInput(t *testing.T) {
+	wantErr, noErr := true, false
+	cases := map[string]struct {
+		input      model.TodoTags
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし":   {model.TodoTags{Tags: []string{"work", "仕事"}}, "", noErr},
+		"タグなし":    {model.TodoTags{}, "", noErr},
+		"21個":     {model.TodoTags{Tags: strings.Split(strings.Repeat("a,", 20)+"a", ",")}, "タグは20個以内で指定してください。", wantErr},
+		"空のタグ":    {model.TodoTags{Tags: []string{" "}}, "空のタグは指定できません。", wantErr},
+		"タグが51文字": {model.TodoTags{Tags: []string{strings.Repeat("a", 51)}}, "タグは50文字以内で入力してください。", wantErr},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.TodoTagsInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package validator
+
+import (
+	"backend/app/model"
+	"fmt"
+)
+
+// 1回の同期で受け付ける変更の最大件数
+const MaxSyncMutations = 100
+
+// 同期で受け付ける変更の種類
+var syncOps = map[string]bool{
+	"create": true,
+	"update": true,
+	"delete": true,
+}
+
+func SyncInput(req model.SyncRequest) error {
+	const (
+		errOverMutations        = "1回に同期できる変更は100件までです。"
+		errRequiredClientID     = "変更のIDは必須です。"
+		errOverLengthClientID   = "変更のIDは64文字以内で入力してください。"
+		errDuplicateClientID    = "変更のIDが重複しています。"
+		errInvalidOp            = "変更の種類が不正です。"
+		errRequiredTodoID       = "更新・削除するTODOのIDは必須です。"
+		errRequiredBaseRevision = "更新の元にしたリビジョンは必須です。"
+	)
+
+	if len(req.Mutations) > MaxSyncMutations {
+		return fmt.Errorf(errOverMutations)
+	}
+
+	seen := map[string]bool{}
+	for _, m := range req.Mutations {
+		if m.ClientID == "" {
+			return fmt.Errorf(errRequiredClientID)
+		}
+		if len(m.ClientID) > 64 {
+			return fmt.Errorf(errOverLengthClientID)
+		}
+		if seen[m.ClientID] {
+			return fmt.Errorf(errDuplicateClientID)
+		}
+		seen[m.ClientID] = true
+
+		if !syncOps[m.Op] {
+			return fmt.Errorf(errInvalidOp)
+		}
+		if m.Op != "create" && m.ID <= 0 {
+			return fmt.Errorf(errRequiredTodoID)
+		}
+		if m.Op == "update" && m.BaseRevision <= 0 {
+			return fmt.Errorf(errRequiredBaseRevision)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package validator_test
+
+import (
+	"backend/app/model"
+	"backend/app/validator"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSyncInput(t *testing.T) {
+	wantErr, noErr := true, false
+	mutations := func(ms ...model.SyncMutation) model.SyncRequest {
+		return model.SyncRequest{Mutations: ms}
+	}
+	tooMany := make([]model.SyncMutation, 101)
+	for i := range tooMany {
+		tooMany[i] = model.SyncMutation{ClientID: strconv.Itoa(i), Op: "create"}
+	}
+
+	cases := map[string]struct {
+		input      model.SyncRequest
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし": {mutations(
+			model.SyncMutation{ClientID: "a", Op: "create"},
+			model.SyncMutation{ClientID: "b", Op: "update", ID: 1, BaseRevision: 2},
+			model.SyncMutation{ClientID: "c", Op: "delete", ID: 1},
+		), "", noErr},
+		"変更が101件":     {model.SyncRequest{Mutations: tooMany}, "1回に同期できる変更は100件までです。", wantErr},
+		"変更のIDが空":     {mutations(model.SyncMutation{Op: "create"}), "変更のIDは必須です。", wantErr},
+		"変更のIDが65文字":  {mutations(model.SyncMutation{ClientID: strings.Repeat("a", 65), Op: "create"}), "変更のIDは64文字以内で入力してください。", wantErr},
+		"変更のIDが重複":    {mutations(model.SyncMutation{ClientID: "a", Op: "create"}, model.SyncMutation{ClientID: "a", Op: "create"}), "変更のIDが重複しています。", wantErr},
+		"変更の種類が不正":    {mutations(model.SyncMutation{ClientID: "a", Op: "upsert"}), "変更の種類が不正です。", wantErr},
+		"削除するIDがない":   {mutations(model.SyncMutation{ClientID: "a", Op: "delete"}), "更新・削除するTODOのIDは必須です。", wantErr},
+		"更新のリビジョンがない": {mutations(model.SyncMutation{ClientID: "a", Op: "update", ID: 1}), "更新の元にしたリビジョンは必須です。", wantErr},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.SyncInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
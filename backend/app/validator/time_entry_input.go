@@ -0,0 +1,39 @@
+package validator
+
+import (
+	"backend/app/model"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// 1件の記録で扱う作業時間の上限
+const maxTimeEntryDuration = 24 * time.Hour
+
+func TimeEntryInput(entry model.TimeEntry) error {
+	const (
+		errRequiredStartedAt = "開始日時を入力してください。"
+		errRequiredEndedAt   = "終了日時を入力してください。"
+		errInvalidRange      = "終了日時は開始日時より後にしてください。"
+		errTooLong           = "1件の作業時間は24時間以内で入力してください。"
+		errOverLengthNote    = "メモは200文字以内で入力してください。"
+	)
+
+	if entry.StartedAt.IsZero() {
+		return fmt.Errorf(errRequiredStartedAt)
+	}
+	if entry.EndedAt == nil || entry.EndedAt.IsZero() {
+		return fmt.Errorf(errRequiredEndedAt)
+	}
+	if !entry.EndedAt.After(entry.StartedAt) {
+		return fmt.Errorf(errInvalidRange)
+	}
+	if entry.EndedAt.Sub(entry.StartedAt) > maxTimeEntryDuration {
+		return fmt.Errorf(errTooLong)
+	}
+	if utf8.RuneCountInString(entry.Note) > 200 {
+		return fmt.Errorf(errOverLengthNote)
+	}
+
+	return nil
+}
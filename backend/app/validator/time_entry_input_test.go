@@ -0,0 +1,46 @@
+package validator_test
+
+import (
+	"backend/app/model"
+	"backend/app/validator"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeEntryInput(t *testing.T) {
+	wantErr, noErr := true, false
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		end := start.Add(d)
+		return &end
+	}
+
+	cases := map[string]struct {
+		input      model.TimeEntry
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし":    {model.TimeEntry{StartedAt: start, EndedAt: at(90 * time.Minute), Note: "レビュー"}, "", noErr},
+		"24時間":     {model.TimeEntry{StartedAt: start, EndedAt: at(24 * time.Hour)}, "", noErr},
+		"開始日時がない":  {model.TimeEntry{EndedAt: at(time.Hour)}, "開始日時を入力してください。", wantErr},
+		"終了日時がない":  {model.TimeEntry{StartedAt: start}, "終了日時を入力してください。", wantErr},
+		"終了が開始と同じ": {model.TimeEntry{StartedAt: start, EndedAt: at(0)}, "終了日時は開始日時より後にしてください。", wantErr},
+		"終了が開始より前": {model.TimeEntry{StartedAt: start, EndedAt: at(-time.Minute)}, "終了日時は開始日時より後にしてください。", wantErr},
+		"24時間を超える": {model.TimeEntry{StartedAt: start, EndedAt: at(24*time.Hour + time.Second)}, "1件の作業時間は24時間以内で入力してください。", wantErr},
+		"メモが201文字": {model.TimeEntry{StartedAt: start, EndedAt: at(time.Hour), Note: strings.Repeat("あ", 201)}, "メモは200文字以内で入力してください。", wantErr},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.TimeEntryInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
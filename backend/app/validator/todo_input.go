@@ -2,14 +2,25 @@ package validator
 
 import (
 	"backend/app/model"
+	"backend/app/recurrence"
 	"fmt"
 	"strings"
+	"time"
 )
 
+// メモの最大サイズ（バイト）。起動時に設定で変更できる
+var MaxNotesSize = 64 * 1024
+
 func TodoInput(todo model.Todo) error {
 	const (
-		errRequiredTitle   = "タイトルは必須です。"
-		errOverLengthTitle = "タイトルは255文字以内で入力してください。"
+		errRequiredTitle     = "タイトルは必須です。"
+		errOverLengthTitle   = "タイトルは255文字以内で入力してください。"
+		errInvalidRecurrence = "繰り返しのルールが不正です。"
+		errOverLengthRecur   = "繰り返しのルールは255文字以内で入力してください。"
+		errRequiredDueAt     = "繰り返すTODOには期限が必要です。"
+		errInvalidTimeZone   = "タイムゾーンが不正です。"
+		errOverSizeNotes     = "メモは%dバイト以内で入力してください。"
+		errInvalidEstimate   = "見積もりは0から100のポイントで入力してください。"
 	)
 
 	if len(strings.TrimSpace(todo.Title)) == 0 {
@@ -19,5 +30,30 @@ func TodoInput(todo model.Todo) error {
 		return fmt.Errorf(errOverLengthTitle)
 	}
 
+	if todo.Recurrence != "" {
+		if len(todo.Recurrence) > 255 {
+			return fmt.Errorf(errOverLengthRecur)
+		}
+		if _, err := recurrence.Parse(todo.Recurrence); err != nil {
+			return fmt.Errorf(errInvalidRecurrence)
+		}
+		if todo.DueAt == nil {
+			return fmt.Errorf(errRequiredDueAt)
+		}
+	}
+	if todo.TimeZone != "" {
+		if _, err := time.LoadLocation(todo.TimeZone); err != nil {
+			return fmt.Errorf(errInvalidTimeZone)
+		}
+	}
+
+	if len(todo.Notes) > MaxNotesSize {
+		return fmt.Errorf(errOverSizeNotes, MaxNotesSize)
+	}
+
+	if todo.Estimate != nil && (*todo.Estimate < 0 || *todo.Estimate > 100) {
+		return fmt.Errorf(errInvalidEstimate)
+	}
+
 	return nil
 }
@@ -4,20 +4,33 @@ import (
 	"backend/app/model"
 	"backend/app/validator"
 	"strings"
+	"time"
 
 	"testing"
 )
 
 func TestTodoInput(t *testing.T) {
 	wantErr, noErr := true, false
+	dueAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	points := func(p int) *int { return &p }
 	cases := map[string]struct {
 		input      model.Todo
 		wantErrMsg string
 		expectErr  bool
 	}{
-		"エラーなし":      {model.Todo{ID: 1, Title: "タイトル", IsComplete: false}, "", noErr},
-		"タイトルが空":     {model.Todo{ID: 1, Title: "", IsComplete: false}, "タイトルは必須です。", wantErr},
-		"タイトルが256文字": {model.Todo{ID: 1, Title: strings.Repeat("あ", 256), IsComplete: false}, "タイトルは255文字以内で入力してください。", wantErr},
+		"エラーなし":       {model.Todo{ID: 1, Title: "タイトル", IsComplete: false}, "", noErr},
+		"タイトルが空":      {model.Todo{ID: 1, Title: "", IsComplete: false}, "タイトルは必須です。", wantErr},
+		"タイトルが256文字":  {model.Todo{ID: 1, Title: strings.Repeat("あ", 256), IsComplete: false}, "タイトルは255文字以内で入力してください。", wantErr},
+		"繰り返しあり":      {model.Todo{Title: "ゴミ出し", DueAt: &dueAt, Recurrence: "FREQ=WEEKLY;BYDAY=MO", TimeZone: "Asia/Tokyo"}, "", noErr},
+		"繰り返しのルールが不正": {model.Todo{Title: "ゴミ出し", DueAt: &dueAt, Recurrence: "FREQ=HOURLY"}, "繰り返しのルールが不正です。", wantErr},
+		"繰り返しに期限がない":  {model.Todo{Title: "ゴミ出し", Recurrence: "FREQ=DAILY"}, "繰り返すTODOには期限が必要です。", wantErr},
+		"タイムゾーンが不正":   {model.Todo{Title: "ゴミ出し", TimeZone: "Mars/Olympus"}, "タイムゾーンが不正です。", wantErr},
+		"メモが上限以内":     {model.Todo{Title: "メモあり", Notes: strings.Repeat("a", validator.MaxNotesSize)}, "", noErr},
+		"メモが上限を超える":   {model.Todo{Title: "メモあり", Notes: strings.Repeat("a", validator.MaxNotesSize+1)}, "メモは65536バイト以内で入力してください。", wantErr},
+		"見積もりが0":      {model.Todo{Title: "見積もり", Estimate: points(0)}, "", noErr},
+		"見積もりが100":    {model.Todo{Title: "見積もり", Estimate: points(100)}, "", noErr},
+		"見積もりが負":      {model.Todo{Title: "見積もり", Estimate: points(-1)}, "見積もりは0から100のポイントで入力してください。", wantErr},
+		"見積もりが101":    {model.Todo{Title: "見積もり", Estimate: points(101)}, "見積もりは0から100のポイントで入力してください。", wantErr},
 	}
 
 	for name, c := range cases {
@@ -0,0 +1,58 @@
+package validator
+
+import (
+	"backend/app/model"
+	"backend/app/netguard"
+	"fmt"
+	"net/url"
+)
+
+// Webhookで購読できるイベントの種類
+var webhookEventTypes = map[string]bool{
+	"todo.created":    true,
+	"todo.updated":    true,
+	"todo.deleted":    true,
+	"todo.completed":  true,
+	"todo.reminder":   true,
+	"todo.mentioned":  true,
+	"todo.assigned":   true,
+	"todo.unassigned": true,
+}
+
+func WebhookInput(webhook model.Webhook) error {
+	const (
+		errInvalidURL       = "URLはhttpまたはhttpsの絶対URLで入力してください。"
+		errOverLengthURL    = "URLは2048文字以内で入力してください。"
+		errForbiddenURL     = "URLに内部のアドレスは指定できません。"
+		errRequiredEvent    = "イベントの種類を1つ以上指定してください。"
+		errInvalidEvent     = "イベントの種類が不正です。"
+		errOverLengthSecret = "シークレットは255文字以内で入力してください。"
+	)
+
+	if len(webhook.URL) > 2048 {
+		return fmt.Errorf(errOverLengthURL)
+	}
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf(errInvalidURL)
+	}
+	// 名前解決が必要なホストは、登録時と配信時に検証する
+	if netguard.IsForbiddenHost(u.Hostname()) {
+		return fmt.Errorf(errForbiddenURL)
+	}
+
+	if len(webhook.EventTypes) == 0 {
+		return fmt.Errorf(errRequiredEvent)
+	}
+	for _, t := range webhook.EventTypes {
+		if !webhookEventTypes[t] {
+			return fmt.Errorf(errInvalidEvent)
+		}
+	}
+
+	if len(webhook.Secret) > 255 {
+		return fmt.Errorf(errOverLengthSecret)
+	}
+
+	return nil
+}
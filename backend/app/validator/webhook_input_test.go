@@ -0,0 +1,49 @@
+package validator_test
+
+import (
+	"backend/app/model"
+	"backend/app/validator"
+	"strings"
+
+	"testing"
+)
+
+func TestWebhookInput(t *testing.T) {
+	wantErr, noErr := true, false
+	valid := func(f func(w *model.Webhook)) model.Webhook {
+		w := model.Webhook{URL: "https://example.com/hook", EventTypes: []string{"todo.completed"}}
+		f(&w)
+		return w
+	}
+	cases := map[string]struct {
+		input      model.Webhook
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし":           {valid(func(w *model.Webhook) {}), "", noErr},
+		"URLが相対パス":        {valid(func(w *model.Webhook) { w.URL = "/hook" }), "URLはhttpまたはhttpsの絶対URLで入力してください。", wantErr},
+		"URLのスキームが不正":     {valid(func(w *model.Webhook) { w.URL = "ftp://example.com" }), "URLはhttpまたはhttpsの絶対URLで入力してください。", wantErr},
+		"URLがlocalhost":   {valid(func(w *model.Webhook) { w.URL = "http://localhost:8080/hook" }), "URLに内部のアドレスは指定できません。", wantErr},
+		"URLがループバック":      {valid(func(w *model.Webhook) { w.URL = "http://127.0.0.1/hook" }), "URLに内部のアドレスは指定できません。", wantErr},
+		"URLがプライベート":      {valid(func(w *model.Webhook) { w.URL = "http://10.0.0.5/hook" }), "URLに内部のアドレスは指定できません。", wantErr},
+		"URLがメタデータ":       {valid(func(w *model.Webhook) { w.URL = "http://169.254.169.254/latest/meta-data" }), "URLに内部のアドレスは指定できません。", wantErr},
+		"URLがIPv6のループバック": {valid(func(w *model.Webhook) { w.URL = "http://[::1]/hook" }), "URLに内部のアドレスは指定できません。", wantErr},
+		"URLが2049文字":      {valid(func(w *model.Webhook) { w.URL = "https://example.com/" + strings.Repeat("a", 2029) }), "URLは2048文字以内で入力してください。", wantErr},
+		"イベントが空":          {valid(func(w *model.Webhook) { w.EventTypes = nil }), "イベントの種類を1つ以上指定してください。", wantErr},
+		"イベントが不正":         {valid(func(w *model.Webhook) { w.EventTypes = []string{"todo.unknown"} }), "イベントの種類が不正です。", wantErr},
+		"シークレットが256文字":    {valid(func(w *model.Webhook) { w.Secret = strings.Repeat("s", 256) }), "シークレットは255文字以内で入力してください。", wantErr},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.WebhookInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package validator
+
+import (
+	"backend/app/model"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+func WorkflowInput(wf model.Workflow) error {
+	const (
+		errTooFewStates        = "ステータスは2個以上指定してください。"
+		errTooManyStates       = "ステータスは10個以内で指定してください。"
+		errRequiredStateName   = "ステータス名を入力してください。"
+		errOverLengthStateName = "ステータス名は30文字以内で入力してください。"
+		errDuplicateStateName  = "ステータス名が重複しています。"
+		errNoOpenState         = "未完了として扱うステータスを1個以上指定してください。"
+		errNoDoneState         = "完了として扱うステータスを1個以上指定してください。"
+		errTooManyTransitions  = "ステータスの変更の規則は100個以内で指定してください。"
+		errUnknownTransition   = "ステータスの変更の規則に、ワークフローにないステータスが含まれています。"
+		errSelfTransition      = "同じステータスへの変更は規則に指定できません。"
+		errDuplicateTransition = "ステータスの変更の規則が重複しています。"
+	)
+
+	if len(wf.States) < 2 {
+		return fmt.Errorf(errTooFewStates)
+	}
+	if len(wf.States) > 10 {
+		return fmt.Errorf(errTooManyStates)
+	}
+
+	names := map[string]bool{}
+	open, done := false, false
+	for _, state := range wf.States {
+		if strings.TrimSpace(state.Name) == "" {
+			return fmt.Errorf(errRequiredStateName)
+		}
+		if utf8.RuneCountInString(state.Name) > 30 {
+			return fmt.Errorf(errOverLengthStateName)
+		}
+		if names[state.Name] {
+			return fmt.Errorf(errDuplicateStateName)
+		}
+		names[state.Name] = true
+		if state.Done {
+			done = true
+		} else {
+			open = true
+		}
+	}
+	if !open {
+		return fmt.Errorf(errNoOpenState)
+	}
+	if !done {
+		return fmt.Errorf(errNoDoneState)
+	}
+
+	if len(wf.Transitions) > 100 {
+		return fmt.Errorf(errTooManyTransitions)
+	}
+	transitions := map[model.WorkflowTransition]bool{}
+	for _, t := range wf.Transitions {
+		if !names[t.From] || !names[t.To] {
+			return fmt.Errorf(errUnknownTransition)
+		}
+		if t.From == t.To {
+			return fmt.Errorf(errSelfTransition)
+		}
+		if transitions[t] {
+			return fmt.Errorf(errDuplicateTransition)
+		}
+		transitions[t] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package validator_test
+
+import (
+	"backend/app/model"
+	"backend/app/validator"
+	"strings"
+	"testing"
+)
+
+func TestWorkflowInput(t *testing.T) {
+	wantErr, noErr := true, false
+	states := []model.WorkflowState{{Name: "todo"}, {Name: "doing"}, {Name: "done", Done: true}}
+	withTransitions := func(transitions ...model.WorkflowTransition) model.Workflow {
+		return model.Workflow{States: states, Transitions: transitions}
+	}
+	cases := map[string]struct {
+		input      model.Workflow
+		wantErrMsg string
+		expectErr  bool
+	}{
+		"エラーなし":       {withTransitions(model.WorkflowTransition{From: "todo", To: "doing"}), "", noErr},
+		"規則なし":        {withTransitions(), "", noErr},
+		"ステータスが1個":    {model.Workflow{States: states[2:]}, "ステータスは2個以上指定してください。", wantErr},
+		"ステータス名が空":    {model.Workflow{States: []model.WorkflowState{{Name: " "}, {Name: "done", Done: true}}}, "ステータス名を入力してください。", wantErr},
+		"ステータス名が31文字": {model.Workflow{States: []model.WorkflowState{{Name: strings.Repeat("あ", 31)}, {Name: "done", Done: true}}}, "ステータス名は30文字以内で入力してください。", wantErr},
+		"ステータス名の重複":   {model.Workflow{States: []model.WorkflowState{{Name: "todo"}, {Name: "todo", Done: true}}}, "ステータス名が重複しています。", wantErr},
+		"完了のステータスなし":  {model.Workflow{States: []model.WorkflowState{{Name: "todo"}, {Name: "doing"}}}, "完了として扱うステータスを1個以上指定してください。", wantErr},
+		"未完了のステータスなし": {model.Workflow{States: []model.WorkflowState{{Name: "done", Done: true}, {Name: "closed", Done: true}}}, "未完了として扱うステータスを1個以上指定してください。", wantErr},
+		"規則にないステータス":  {withTransitions(model.WorkflowTransition{From: "todo", To: "blocked"}), "ステータスの変更の規則に、ワークフローにないステータスが含まれています。", wantErr},
+		"同じステータスへの規則": {withTransitions(model.WorkflowTransition{From: "todo", To: "todo"}), "同じステータスへの変更は規則に指定できません。", wantErr},
+		"規則の重複": {
+			withTransitions(model.WorkflowTransition{From: "todo", To: "done"}, model.WorkflowTransition{From: "todo", To: "done"}),
+			"ステータスの変更の規則が重複しています。", wantErr,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validator.WorkflowInput(c.input)
+			if c.expectErr {
+				if err == nil || err.Error() != c.wantErrMsg {
+					t.Errorf("want: %s, got: %v", c.wantErrMsg, err)
+				}
+			} else if err != nil {
+				t.Errorf("want: nil, got: %s", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+// webhookは、Todoの変更を外部のURLへ署名付きで配信するパッケージ。
+// 配信はDBのキューに記録し、失敗した場合は指数バックオフで再試行する。
+package webhook
+
+import (
+	"backend/app/event"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 配信の状態
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// 配信時に付与するヘッダー
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// 配信先のレスポンスボディのうち、記録する最大長
+const maxRecordedError = 255
+
+// Signは、タイムスタンプとボディを連結した文字列のHMAC-SHA256署名を返す。
+// 受信側は同じ方法で計算した値とhmac.Equalで比較して検証する。
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcherは、イベントを配信キューに登録し、キューの配信を実行する
+type Dispatcher struct {
+	db     *sql.DB
+	client *http.Client
+
+	// 最大試行回数。超えた場合は失敗として扱う
+	MaxAttempts int
+	// 再試行の間隔の初期値。試行ごとに2倍にする
+	BaseBackoff time.Duration
+	// 再試行の間隔の上限
+	MaxBackoff time.Duration
+	// 1回の処理で配信する最大件数
+	BatchSize int
+	// 確保した配信を他のプロセスが再び確保できるようになるまでの時間。
+	// 1回の処理で配信を終えられる時間より長くすること
+	ClaimTimeout time.Duration
+	// 現在時刻を返す関数（テスト用に差し替え可能）
+	Now func() time.Time
+}
+
+// Dispatcherのコンストラクタ
+func NewDispatcher(db *sql.DB, client *http.Client) *Dispatcher {
+	return &Dispatcher{
+		db:           db,
+		client:       client,
+		MaxAttempts:  8,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   time.Hour,
+		BatchSize:    20,
+		ClaimTimeout: 5 * time.Minute,
+		Now:          time.Now,
+	}
+}
+
+// Enqueueは、イベントを購読しているWebhookごとに配信をキューに登録する。
+// アウトボックスの中継（outbox.Handler）から呼び出し、同じイベントを再度登録しても重複しない
+func (d *Dispatcher) Enqueue(e event.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	// 同じイベントが再配信された場合は、(webhook_id, dedup_id)の一意制約により登録しない
+	now := d.Now()
+	query := "INSERT IGNORE INTO webhook_deliveries (workspace_id, webhook_id, dedup_id, event_type, payload, status, attempts, next_attempt_at, created_at) " +
+		"SELECT workspace_id, id, ?, ?, ?, ?, 0, ?, ? FROM webhooks WHERE workspace_id = ? AND deleted_at IS NULL AND FIND_IN_SET(?, event_types) > 0"
+	args := []any{e.DedupID, string(e.Type), string(payload), StatusPending, now, now, e.WorkspaceID, string(e.Type)}
+	// 特定のユーザー宛てのイベント（リマインダーなど）は、そのユーザーが登録したWebhookにのみ配信する
+	if e.UserID != 0 {
+		query += " AND user_id = ?"
+		args = append(args, e.UserID)
+	}
+	_, err = d.db.Exec(query, args...)
+	return err
+}
+
+// Runは、一定間隔で配信キューを処理し続ける。ctxがキャンセルされると戻る。
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.RunOnce(ctx); err != nil {
+				log.Printf("failed to dispatch webhooks: %v", err)
+			}
+		}
+	}
+}
+
+type pendingDelivery struct {
+	id        int
+	eventType string
+	payload   []byte
+	attempts  int
+	url       string
+	secret    string
+}
+
+// RunOnceは、配信時刻を迎えた配信を実行し、処理した件数を返す
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	deliveries, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range deliveries {
+		statusCode, deliverErr := d.deliver(ctx, p)
+		if err := d.recordAttempt(p, statusCode, deliverErr); err != nil {
+			return 0, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// 配信時刻を迎えた配信を、他のプロセスと重複しないように確保する。
+// 他のプロセスが確保中の行はFOR UPDATE SKIP LOCKEDで読み飛ばし、確保した行は次の試行時刻をClaimTimeout後にずらしてから確定する。
+// 配信中にプロセスが停止した場合は、ClaimTimeout後に再び配信の対象になる
+func (d *Dispatcher) claim(ctx context.Context) ([]pendingDelivery, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT d.id, d.event_type, d.payload, d.attempts, w.url, w.secret FROM webhook_deliveries d " +
+		"JOIN webhooks w ON w.id = d.webhook_id WHERE d.status = ? AND d.next_attempt_at <= ? ORDER BY d.next_attempt_at LIMIT ? " +
+		"FOR UPDATE OF d SKIP LOCKED"
+	rows, err := tx.QueryContext(ctx, query, StatusPending, d.Now(), d.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []pendingDelivery
+	for rows.Next() {
+		var p pendingDelivery
+		if err := rows.Scan(&p.id, &p.eventType, &p.payload, &p.attempts, &p.url, &p.secret); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		deliveries = append(deliveries, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(deliveries) > 0 {
+		placeholders := make([]string, len(deliveries))
+		args := []any{d.Now().Add(d.ClaimTimeout)}
+		for i, p := range deliveries {
+			placeholders[i] = "?"
+			args = append(args, p.id)
+		}
+		claimQuery := "UPDATE webhook_deliveries SET next_attempt_at = ? WHERE id IN (" + strings.Join(placeholders, ", ") + ")"
+		if _, err := tx.ExecContext(ctx, claimQuery, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// 配信先にPOSTし、レスポンスのステータスコードを返す。2xx以外はエラーとして扱う。
+func (d *Dispatcher) deliver(ctx context.Context, p pendingDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(p.payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := d.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, p.eventType)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(p.id))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(p.secret, timestamp, p.payload))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, maxRecordedError))
+		return res.StatusCode, fmt.Errorf("unexpected status %d: %s", res.StatusCode, body)
+	}
+	return res.StatusCode, nil
+}
+
+// 試行結果を記録する。失敗した場合は再試行の時刻を設定し、最大試行回数に達した場合は失敗とする。
+func (d *Dispatcher) recordAttempt(p pendingDelivery, statusCode int, deliverErr error) error {
+	attempts := p.attempts + 1
+	status := StatusSucceeded
+	lastError := ""
+	nextAttemptAt := d.Now()
+
+	if deliverErr != nil {
+		lastError = deliverErr.Error()
+		if len(lastError) > maxRecordedError {
+			lastError = lastError[:maxRecordedError]
+		}
+		if attempts >= d.MaxAttempts {
+			status = StatusFailed
+		} else {
+			status = StatusPending
+			nextAttemptAt = nextAttemptAt.Add(d.backoff(attempts))
+		}
+	}
+
+	var lastStatusCode any
+	if statusCode != 0 {
+		lastStatusCode = statusCode
+	}
+
+	query := "UPDATE webhook_deliveries SET status = ?, attempts = ?, last_status_code = ?, last_error = ?, next_attempt_at = ? WHERE id = ?"
+	_, err := d.db.Exec(query, status, attempts, lastStatusCode, lastError, nextAttemptAt, p.id)
+	return err
+}
+
+// 試行回数に応じた再試行までの間隔を返す
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= d.MaxBackoff {
+			return d.MaxBackoff
+		}
+	}
+	return wait
+}
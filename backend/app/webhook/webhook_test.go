@@ -0,0 +1,173 @@
+package webhook_test
+
+import (
+	"backend/app/event"
+	"backend/app/webhook"
+	"context"
+	"crypto/hmac"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var now = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// newDispatcherは、モックDBと固定時刻を使用するDispatcherを作成します。
+func newDispatcher(t *testing.T) (*webhook.Dispatcher, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("モックDBの作成に失敗しました: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	d := webhook.NewDispatcher(db, http.DefaultClient)
+	d.Now = func() time.Time { return now }
+	d.MaxAttempts = 3
+	d.BaseBackoff = time.Minute
+
+	return d, mock
+}
+
+// expectPendingは、配信待ちの配信1件を確保する期待値を設定します。
+func expectPending(mock sqlmock.Sqlmock, url string, attempts int) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT d.id, d.event_type, d.payload, d.attempts, w.url, w.secret FROM webhook_deliveries d JOIN webhooks w .* FOR UPDATE OF d SKIP LOCKED$`).
+		WithArgs(webhook.StatusPending, now, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "payload", "attempts", "url", "secret"}).
+			AddRow(7, "todo.completed", `{"type":"todo.completed","todo_id":1}`, attempts, url, "s3cret"))
+	mock.ExpectExec(`^UPDATE webhook_deliveries SET next_attempt_at = \? WHERE id IN \(\?\)$`).
+		WithArgs(now.Add(5*time.Minute), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestEnqueue(t *testing.T) {
+	d, mock := newDispatcher(t)
+
+	mock.ExpectExec(`^INSERT IGNORE INTO webhook_deliveries .* SELECT workspace_id, id, .* FROM webhooks WHERE workspace_id = \? AND deleted_at IS NULL AND FIND_IN_SET\(\?, event_types\) > 0$`).
+		WithArgs("d1", "todo.completed", sqlmock.AnyArg(), webhook.StatusPending, now, now, 3, "todo.completed").
+		WillReturnResult(sqlmock.NewResult(1, 2))
+
+	if err := d.Enqueue(event.Event{Type: event.TodoCompleted, WorkspaceID: 3, TodoID: 1, DedupID: "d1"}); err != nil {
+		t.Fatalf("キューへの登録に失敗しました: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+// 特定のユーザー宛てのイベントは、そのユーザーが登録したWebhookにのみ配信することを確認する
+func TestEnqueueUserEvent(t *testing.T) {
+	d, mock := newDispatcher(t)
+
+	mock.ExpectExec(`^INSERT IGNORE INTO webhook_deliveries .* FROM webhooks WHERE workspace_id = \? AND deleted_at IS NULL AND FIND_IN_SET\(\?, event_types\) > 0 AND user_id = \?$`).
+		WithArgs("reminder-1", "todo.reminder", sqlmock.AnyArg(), webhook.StatusPending, now, now, 3, "todo.reminder", 7).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := d.Enqueue(event.Event{Type: event.TodoReminder, WorkspaceID: 3, TodoID: 1, UserID: 7, DedupID: "reminder-1"}); err != nil {
+		t.Fatalf("キューへの登録に失敗しました: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+func TestRunOnceDeliversSignedPayload(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var receivedBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedBody, _ = io.ReadAll(r.Body)
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	d, mock := newDispatcher(t)
+	expectPending(mock, receiver.URL, 0)
+	mock.ExpectExec(`^UPDATE webhook_deliveries SET status = \?, attempts = \?, last_status_code = \?, last_error = \?, next_attempt_at = \? WHERE id = \?$`).
+		WithArgs(webhook.StatusSucceeded, 1, http.StatusNoContent, "", now, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := d.RunOnce(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("配信に失敗しました: n=%d, err=%v", n, err)
+	}
+
+	r := <-received
+	if r.Header.Get(webhook.EventHeader) != "todo.completed" || r.Header.Get(webhook.DeliveryHeader) != "7" {
+		t.Errorf("ヘッダーが不正です: %v", r.Header)
+	}
+	// 受信側と同じ方法で署名を検証する
+	timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.TimestampHeader), 10, 64)
+	want := webhook.Sign("s3cret", timestamp, receivedBody)
+	if !hmac.Equal([]byte(want), []byte(r.Header.Get(webhook.SignatureHeader))) {
+		t.Errorf("署名が一致しません: want=%s, got=%s", want, r.Header.Get(webhook.SignatureHeader))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+// 他のプロセスが確保中で配信待ちがない場合は、何も配信しない
+func TestRunOnceNothingToClaim(t *testing.T) {
+	d, mock := newDispatcher(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT d.id`).
+		WithArgs(webhook.StatusPending, now, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "payload", "attempts", "url", "secret"}))
+	mock.ExpectCommit()
+
+	n, err := d.RunOnce(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("想定外の結果です: n=%d, err=%v", n, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("満たされていない期待値があります: %s", err)
+	}
+}
+
+func TestRunOnceRetries(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	cases := map[string]struct {
+		attempts          int
+		wantStatus        string
+		wantNextAttemptAt time.Time
+	}{
+		"1回目の失敗は1分後に再試行": {attempts: 0, wantStatus: webhook.StatusPending, wantNextAttemptAt: now.Add(time.Minute)},
+		"2回目の失敗は2分後に再試行": {attempts: 1, wantStatus: webhook.StatusPending, wantNextAttemptAt: now.Add(2 * time.Minute)},
+		"最大試行回数で失敗":      {attempts: 2, wantStatus: webhook.StatusFailed, wantNextAttemptAt: now},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			d, mock := newDispatcher(t)
+			expectPending(mock, receiver.URL, c.attempts)
+			mock.ExpectExec(`^UPDATE webhook_deliveries SET`).
+				WithArgs(c.wantStatus, c.attempts+1, http.StatusServiceUnavailable, sqlmock.AnyArg(), c.wantNextAttemptAt, 7).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			if _, err := d.RunOnce(context.Background()); err != nil {
+				t.Fatalf("処理に失敗しました: %s", err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("満たされていない期待値があります: %s", err)
+			}
+		})
+	}
+}
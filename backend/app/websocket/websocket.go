@@ -0,0 +1,407 @@
+// websocketは、RFC 6455のWebSocketプロトコルのうち、本アプリケーションで必要な範囲を実装するパッケージ
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// フレームのオペコード
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xA
+)
+
+// クローズコード
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+)
+
+// Sec-WebSocket-Acceptの計算に使用するGUID
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// 制御フレームのペイロードの最大長
+const maxControlPayload = 125
+
+var (
+	ErrBadHandshake    = errors.New("websocket: bad handshake")
+	ErrMessageTooBig   = errors.New("websocket: message too big")
+	ErrProtocol        = errors.New("websocket: protocol error")
+	ErrUnsupportedHTTP = errors.New("websocket: response does not support hijacking")
+)
+
+// CloseErrorは、相手からクローズフレームを受信したことを表す
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Reason)
+}
+
+// Connは、WebSocketの接続
+type Conn struct {
+	conn     net.Conn
+	br       *bufio.Reader
+	isClient bool
+
+	// 書き込みは複数のゴルーチンから行われるため排他する
+	wmu sync.Mutex
+
+	// 受信するメッセージの最大長（バイト）。0の場合は無制限
+	MaxMessageSize int64
+	// Pongを受信した際に呼び出される関数
+	PongHandler func()
+	// ハンドシェイクで合意したサブプロトコル。合意していない場合は空
+	Subprotocol string
+}
+
+// IsUpgradeは、リクエストがWebSocketへの切り替えを求めているかを返す
+func IsUpgrade(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+// Subprotocolsは、クライアントが提示したサブプロトコルを提示順に返す
+func Subprotocols(r *http.Request) []string {
+	var protocols []string
+	for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				protocols = append(protocols, p)
+			}
+		}
+	}
+	return protocols
+}
+
+// Upgradeは、HTTPリクエストのハンドシェイクを検証し、WebSocketの接続に切り替える。
+// protocolには、クライアントが提示したサブプロトコルから選んだものを指定する。空の場合はサブプロトコルを応答しない。
+// ハンドシェイクが不正な場合はレスポンスを書き込まずにErrBadHandshakeを返すため、呼び出し側でエラーを返却すること。
+func Upgrade(w http.ResponseWriter, r *http.Request, protocol string) (*Conn, error) {
+	if r.Method != http.MethodGet || !IsUpgrade(r) || r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, ErrBadHandshake
+	}
+	if protocol != "" && !slices.Contains(Subprotocols(r), protocol) {
+		return nil, ErrBadHandshake
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, ErrBadHandshake
+	}
+
+	netConn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, ErrUnsupportedHTTP
+	}
+
+	res := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n"
+	if protocol != "" {
+		res += "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+	}
+	if _, err := netConn.Write([]byte(res + "\r\n")); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &Conn{conn: netConn, br: brw.Reader, Subprotocol: protocol}, nil
+}
+
+// Dialは、WebSocketサーバーに接続する。urlはws://形式で指定する。
+func Dial(url string, header http.Header) (*Conn, error) {
+	if !strings.HasPrefix(url, "ws://") {
+		return nil, ErrBadHandshake
+	}
+	hostPath := strings.TrimPrefix(url, "ws://")
+	host, path, _ := strings.Cut(hostPath, "/")
+	path = "/" + path
+
+	netConn, err := net.Dial("tcp", host)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+host+path, nil)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if err := req.Write(netConn); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(netConn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols || res.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		netConn.Close()
+		return nil, fmt.Errorf("%w: status %d", ErrBadHandshake, res.StatusCode)
+	}
+
+	return &Conn{conn: netConn, br: br, isClient: true, Subprotocol: res.Header.Get("Sec-WebSocket-Protocol")}, nil
+}
+
+// ReadMessageは、データメッセージを1件受信する。
+// 受信したPingには自動でPongを返し、クローズフレームを受信した場合は*CloseErrorを返す。
+func (c *Conn) ReadMessage() (opcode int, payload []byte, err error) {
+	var message []byte
+	messageOp := -1
+
+	for {
+		fin, op, data, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case OpPing:
+			if err := c.WriteControl(OpPong, data); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case OpPong:
+			if c.PongHandler != nil {
+				c.PongHandler()
+			}
+			continue
+		case OpClose:
+			closeErr := &CloseError{Code: CloseNormal}
+			if len(data) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(data))
+				closeErr.Reason = string(data[2:])
+			}
+			// 相手のクローズに応答してから終了する
+			_ = c.WriteControl(OpClose, closePayload(closeErr.Code, ""))
+			return 0, nil, closeErr
+		case OpText, OpBinary:
+			if messageOp != -1 {
+				return 0, nil, c.failProtocol()
+			}
+			messageOp = op
+		case OpContinuation:
+			if messageOp == -1 {
+				return 0, nil, c.failProtocol()
+			}
+		default:
+			return 0, nil, c.failProtocol()
+		}
+
+		if c.MaxMessageSize > 0 && int64(len(message)+len(data)) > c.MaxMessageSize {
+			_ = c.WriteControl(OpClose, closePayload(CloseMessageTooBig, ""))
+			return 0, nil, ErrMessageTooBig
+		}
+		message = append(message, data...)
+
+		if fin {
+			return messageOp, message, nil
+		}
+	}
+}
+
+// WriteMessageは、データメッセージを1フレームで送信する
+func (c *Conn) WriteMessage(opcode int, payload []byte) error {
+	return c.writeFrame(opcode, payload)
+}
+
+// WriteControlは、制御フレームを送信する
+func (c *Conn) WriteControl(opcode int, payload []byte) error {
+	if len(payload) > maxControlPayload {
+		return ErrProtocol
+	}
+	return c.writeFrame(opcode, payload)
+}
+
+// CloseWithReasonは、クローズフレームを送信してから接続を閉じる
+func (c *Conn) CloseWithReason(code int, reason string) error {
+	_ = c.WriteControl(OpClose, closePayload(code, reason))
+	return c.Close()
+}
+
+// Closeは、下位の接続を閉じる
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = header[0]&0x80 != 0
+	if header[0]&0x70 != 0 {
+		// 拡張は使用しないため、RSVビットは常に0でなければならない
+		return false, 0, nil, c.failProtocol()
+	}
+	opcode = int(header[0] & 0x0F)
+	masked := header[1]&0x80 != 0
+	length := int64(header[1] & 0x7F)
+
+	// クライアントからのフレームは必ずマスクされ、サーバーからのフレームはマスクされない
+	if masked == c.isClient {
+		return false, 0, nil, c.failProtocol()
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+
+	if opcode >= OpClose && (length > maxControlPayload || !fin) {
+		return false, 0, nil, c.failProtocol()
+	}
+	if length < 0 || (c.MaxMessageSize > 0 && length > c.MaxMessageSize) {
+		_ = c.WriteControl(OpClose, closePayload(CloseMessageTooBig, ""))
+		return false, 0, nil, ErrMessageTooBig
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|byte(opcode))
+
+	var maskBit byte
+	if c.isClient {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	if c.isClient {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+func (c *Conn) failProtocol() error {
+	_ = c.WriteControl(OpClose, closePayload(CloseProtocolError, ""))
+	return ErrProtocol
+}
+
+func closePayload(code int, reason string) []byte {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+	return append(payload, reason...)
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ヘッダーのカンマ区切りの値に、指定のトークンが含まれているか確認する
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package websocket_test
+
+import (
+	"backend/app/websocket"
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEchoServerは、受信したメッセージをそのまま返すテスト用サーバーを起動します。
+func newEchoServer(t *testing.T, maxMessageSize int64) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r, "")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer conn.Close()
+		conn.MaxMessageSize = maxMessageSize
+
+		for {
+			op, payload, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(op, payload); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return "ws://" + strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestHandshake(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// chatを提示された場合のみ、サブプロトコルとして合意する
+		protocol := ""
+		if slices.Contains(websocket.Subprotocols(r), "chat") {
+			protocol = "chat"
+		}
+		conn, err := websocket.Upgrade(w, r, protocol)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		conn.Close()
+	}))
+	defer srv.Close()
+
+	cases := map[string]struct {
+		header         map[string]string
+		wantStatusCode int
+		wantAccept     string
+		wantProtocol   string
+	}{
+		"正常系": {
+			header: map[string]string{
+				"Connection":            "keep-alive, Upgrade",
+				"Upgrade":               "websocket",
+				"Sec-WebSocket-Version": "13",
+				// RFC 6455 に記載の例
+				"Sec-WebSocket-Key": "dGhlIHNhbXBsZSBub25jZQ==",
+			},
+			wantStatusCode: http.StatusSwitchingProtocols,
+			wantAccept:     "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=",
+		},
+		"サブプロトコルを提示": {
+			header: map[string]string{
+				"Connection":             "Upgrade",
+				"Upgrade":                "websocket",
+				"Sec-WebSocket-Version":  "13",
+				"Sec-WebSocket-Key":      "dGhlIHNhbXBsZSBub25jZQ==",
+				"Sec-WebSocket-Protocol": "bearer.token, chat",
+			},
+			wantStatusCode: http.StatusSwitchingProtocols,
+			wantAccept:     "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=",
+			wantProtocol:   "chat",
+		},
+		"Upgradeヘッダーなし": {
+			header: map[string]string{
+				"Connection":            "Upgrade",
+				"Sec-WebSocket-Version": "13",
+				"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		"キーが不正": {
+			header: map[string]string{
+				"Connection":            "Upgrade",
+				"Upgrade":               "websocket",
+				"Sec-WebSocket-Version": "13",
+				"Sec-WebSocket-Key":     "short",
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+			if err != nil {
+				t.Fatalf("接続に失敗しました: %s", err)
+			}
+			defer conn.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			for k, v := range c.header {
+				req.Header.Set(k, v)
+			}
+			if err := req.Write(conn); err != nil {
+				t.Fatalf("リクエストの送信に失敗しました: %s", err)
+			}
+			res, err := http.ReadResponse(bufio.NewReader(conn), req)
+			if err != nil {
+				t.Fatalf("レスポンスの読み取りに失敗しました: %s", err)
+			}
+
+			if res.StatusCode != c.wantStatusCode {
+				t.Errorf("期待したステータスコード: %d, 実際のステータスコード: %d", c.wantStatusCode, res.StatusCode)
+			}
+			if got := res.Header.Get("Sec-WebSocket-Accept"); got != c.wantAccept {
+				t.Errorf("期待したSec-WebSocket-Accept: %q, 実際: %q", c.wantAccept, got)
+			}
+			if got := res.Header.Get("Sec-WebSocket-Protocol"); got != c.wantProtocol {
+				t.Errorf("期待したSec-WebSocket-Protocol: %q, 実際: %q", c.wantProtocol, got)
+			}
+		})
+	}
+}
+
+func TestEcho(t *testing.T) {
+	url := newEchoServer(t, 0)
+	conn, err := websocket.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("接続に失敗しました: %s", err)
+	}
+	defer conn.Close()
+
+	// 126バイト以上のメッセージは拡張長で送信される
+	for _, msg := range []string{"こんにちは", strings.Repeat("a", 300), strings.Repeat("b", 70000)} {
+		if err := conn.WriteMessage(websocket.OpText, []byte(msg)); err != nil {
+			t.Fatalf("送信に失敗しました: %s", err)
+		}
+		op, payload, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("受信に失敗しました: %s", err)
+		}
+		if op != websocket.OpText || string(payload) != msg {
+			t.Errorf("送信したメッセージと一致しません: op=%d, len=%d", op, len(payload))
+		}
+	}
+}
+
+func TestPingPong(t *testing.T) {
+	url := newEchoServer(t, 0)
+	conn, err := websocket.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("接続に失敗しました: %s", err)
+	}
+	defer conn.Close()
+
+	ponged := make(chan struct{}, 1)
+	conn.PongHandler = func() { ponged <- struct{}{} }
+
+	if err := conn.WriteControl(websocket.OpPing, []byte("ping")); err != nil {
+		t.Fatalf("Pingの送信に失敗しました: %s", err)
+	}
+	// Pongを処理させるため、続けてメッセージを往復させる
+	if err := conn.WriteMessage(websocket.OpText, []byte("x")); err != nil {
+		t.Fatalf("送信に失敗しました: %s", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("受信に失敗しました: %s", err)
+	}
+
+	select {
+	case <-ponged:
+	default:
+		t.Error("Pongを受信していません")
+	}
+}
+
+func TestMessageTooBig(t *testing.T) {
+	url := newEchoServer(t, 16)
+	conn, err := websocket.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("接続に失敗しました: %s", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.OpText, []byte(strings.Repeat("a", 17))); err != nil {
+		t.Fatalf("送信に失敗しました: %s", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseMessageTooBig {
+		t.Errorf("期待したクローズコード: %d, 実際のエラー: %v", websocket.CloseMessageTooBig, err)
+	}
+}
@@ -0,0 +1,146 @@
+// workflowは、Todoのステータスとステータスの変更の規則を扱うパッケージ
+package workflow
+
+import (
+	"backend/app/model"
+	"fmt"
+	"strings"
+)
+
+// 既定のワークフローのステータス
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusReview     = "review"
+	StatusDone       = "done"
+)
+
+// Defaultは、ワークフローを設定していないリストと、リストに属さないTodoのワークフローを返す。
+// どのステータスにも変更できる
+func Default() model.Workflow {
+	return model.Workflow{
+		States: []model.WorkflowState{
+			{Name: StatusTodo},
+			{Name: StatusInProgress},
+			{Name: StatusReview},
+			{Name: StatusDone, Done: true},
+		},
+		Transitions: []model.WorkflowTransition{},
+	}
+}
+
+// UnknownStatusErrorは、ワークフローにないステータスを指定したことを表す
+type UnknownStatusError struct {
+	Status string
+}
+
+func (e *UnknownStatusError) Error() string {
+	return fmt.Sprintf("ステータス「%s」はこのリストのワークフローにありません。", e.Status)
+}
+
+// TransitionErrorは、ワークフローで許可していないステータスの変更を表す
+type TransitionError struct {
+	From, To string
+	// Fromから変更できるステータス
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("ステータスを「%s」から「%s」に変更することはできません。「%s」から変更できるステータスはありません。", e.From, e.To, e.From)
+	}
+	return fmt.Sprintf("ステータスを「%s」から「%s」に変更することはできません。変更できるステータス: %s", e.From, e.To, strings.Join(e.Allowed, ", "))
+}
+
+// Stateは、名前を指定してワークフローのステータスを返す
+func State(wf model.Workflow, name string) (model.WorkflowState, bool) {
+	for _, state := range wf.States {
+		if state.Name == name {
+			return state, true
+		}
+	}
+	return model.WorkflowState{}, false
+}
+
+// Initialは、新しいTodoのステータス（最初の未完了のステータス）を返す
+func Initial(wf model.Workflow) string {
+	return first(wf, false)
+}
+
+// Completedは、完了したTodoのステータス（最初の完了のステータス）を返す
+func Completed(wf model.Workflow) string {
+	return first(wf, true)
+}
+
+func first(wf model.Workflow, done bool) string {
+	for _, state := range wf.States {
+		if state.Done == done {
+			return state.Name
+		}
+	}
+	return ""
+}
+
+// Allowedは、fromからtoへの変更を許可するかどうかを返す。
+// fromがワークフローにない場合は、どのステータスにも変更できる
+func Allowed(wf model.Workflow, from, to string) bool {
+	if from == to || len(wf.Transitions) == 0 {
+		return true
+	}
+	if _, ok := State(wf, from); !ok {
+		return true
+	}
+	for _, t := range wf.Transitions {
+		if t.From == from && t.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Targetsは、fromから変更できるステータスを、ワークフローの順に返す
+func Targets(wf model.Workflow, from string) []string {
+	var targets []string
+	for _, state := range wf.States {
+		if state.Name != from && Allowed(wf, from, state.Name) {
+			targets = append(targets, state.Name)
+		}
+	}
+	return targets
+}
+
+// Resolveは、ワークフローに従ってafterのステータスと完了状態を決める。
+// beforeは変更前のTodoで、作成の場合はnilとする。
+//
+// ステータスを省略した場合は、完了状態を変更していればそれに対応するステータスとし、
+// 変更していなければ変更前のステータスを引き継ぐ。ステータスを指定した場合は、完了状態をステータスから決める。
+// ステータスを変更する場合は、別のリストに移す場合も含めて、wf（移動先のリストのワークフロー）で許可した変更かどうかを確認する。
+// 変更前のステータスがwfにない場合は、どのステータスにも変更できる
+func Resolve(wf model.Workflow, before, after *model.Todo) error {
+	if after.Status == "" {
+		switch {
+		case before != nil && before.IsComplete == after.IsComplete && hasState(wf, before.Status):
+			after.Status = before.Status
+		case after.IsComplete:
+			after.Status = Completed(wf)
+		default:
+			after.Status = Initial(wf)
+		}
+	}
+
+	state, ok := State(wf, after.Status)
+	if !ok {
+		return &UnknownStatusError{Status: after.Status}
+	}
+	after.IsComplete = state.Done
+
+	if before != nil && !Allowed(wf, before.Status, after.Status) {
+		return &TransitionError{From: before.Status, To: after.Status, Allowed: Targets(wf, before.Status)}
+	}
+	return nil
+}
+
+func hasState(wf model.Workflow, name string) bool {
+	_, ok := State(wf, name)
+	return ok
+}
@@ -0,0 +1,133 @@
+package workflow_test
+
+import (
+	"backend/app/model"
+	"backend/app/workflow"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// todo → in_progress → review → done の順にのみ進め、reviewからはin_progressに戻せるワークフロー
+func newWorkflow() model.Workflow {
+	wf := workflow.Default()
+	wf.Transitions = []model.WorkflowTransition{
+		{From: "todo", To: "in_progress"},
+		{From: "in_progress", To: "review"},
+		{From: "review", To: "in_progress"},
+		{From: "review", To: "done"},
+	}
+	return wf
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestResolve(t *testing.T) {
+	cases := map[string]struct {
+		before       *model.Todo
+		after        model.Todo
+		wantStatus   string
+		wantComplete bool
+		wantErr      string
+	}{
+		"作成時は最初のステータス": {
+			after:      model.Todo{},
+			wantStatus: "todo",
+		},
+		"完了したTodoの作成": {
+			after:        model.Todo{IsComplete: true},
+			wantStatus:   "done",
+			wantComplete: true,
+		},
+		"ステータスの指定から完了状態を決める": {
+			before:       &model.Todo{Status: "review"},
+			after:        model.Todo{Status: "done"},
+			wantStatus:   "done",
+			wantComplete: true,
+		},
+		"省略した場合は変更前のステータスを引き継ぐ": {
+			before:     &model.Todo{Status: "review"},
+			after:      model.Todo{Title: "変更"},
+			wantStatus: "review",
+		},
+		"完了状態の変更からステータスを決める": {
+			before:     &model.Todo{Status: "done", IsComplete: true},
+			after:      model.Todo{},
+			wantStatus: "todo",
+			wantErr:    "ステータスを「done」から「todo」に変更することはできません。「done」から変更できるステータスはありません。",
+		},
+		"許可していない変更": {
+			before:     &model.Todo{Status: "todo"},
+			after:      model.Todo{IsComplete: true},
+			wantStatus: "done",
+			wantErr:    "ステータスを「todo」から「done」に変更することはできません。変更できるステータス: in_progress",
+		},
+		"ワークフローにないステータス": {
+			before:  &model.Todo{Status: "todo"},
+			after:   model.Todo{Status: "blocked"},
+			wantErr: "ステータス「blocked」はこのリストのワークフローにありません。",
+		},
+		"別のリストに移す場合も移動先の変更の規則を確認する": {
+			before:  &model.Todo{Status: "todo", ListID: intPtr(1)},
+			after:   model.Todo{Status: "done", ListID: intPtr(2)},
+			wantErr: "ステータスを「todo」から「done」に変更することはできません。変更できるステータス: in_progress",
+		},
+		"移動先のワークフローにないステータスから移す": {
+			before:       &model.Todo{Status: "doing", ListID: intPtr(1)},
+			after:        model.Todo{Status: "done", ListID: intPtr(2)},
+			wantStatus:   "done",
+			wantComplete: true,
+		},
+		"変更前のステータスがワークフローにない": {
+			before:     &model.Todo{Status: "legacy"},
+			after:      model.Todo{},
+			wantStatus: "todo",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			after := c.after
+			err := workflow.Resolve(newWorkflow(), c.before, &after)
+			if c.wantErr != "" {
+				if err == nil || err.Error() != c.wantErr {
+					t.Errorf("want: %s, got: %v", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want: nil, got: %v", err)
+			}
+			if after.Status != c.wantStatus || after.IsComplete != c.wantComplete {
+				t.Errorf("want: %s %v, got: %s %v", c.wantStatus, c.wantComplete, after.Status, after.IsComplete)
+			}
+		})
+	}
+}
+
+func TestResolveErrorTypes(t *testing.T) {
+	after := model.Todo{Status: "done"}
+	err := workflow.Resolve(newWorkflow(), &model.Todo{Status: "todo"}, &after)
+
+	var transitionErr *workflow.TransitionError
+	if !errors.As(err, &transitionErr) {
+		t.Fatalf("want: TransitionError, got: %v", err)
+	}
+	if transitionErr.From != "todo" || transitionErr.To != "done" {
+		t.Errorf("want: todo → done, got: %s → %s", transitionErr.From, transitionErr.To)
+	}
+}
+
+// 変更の規則を指定しない場合は、どのステータスにも変更できることを確認する
+func TestAllowedWithoutTransitions(t *testing.T) {
+	wf := workflow.Default()
+	if !workflow.Allowed(wf, "done", "todo") {
+		t.Error("規則がない場合はどのステータスにも変更できる必要があります")
+	}
+	want := []string{"in_progress", "review", "done"}
+	if got := workflow.Targets(wf, "todo"); !slices.Equal(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
@@ -0,0 +1,24 @@
+-- リストと、リストの読み取り専用の共有リンク
+CREATE TABLE lists (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    -- リストを所有するユーザー。共有リンクは所有者のみ作成・無効化できる
+    owner_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_lists_workspace (workspace_id, id)
+);
+
+CREATE TABLE share_links (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    list_id INT NOT NULL,
+    -- トークンそのものは保存せず、SHA-256のハッシュ値（16進数）のみを保存する
+    token_hash CHAR(64) NOT NULL,
+    expires_at DATETIME NULL,
+    revoked_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE KEY uq_share_links_token_hash (token_hash),
+    KEY idx_share_links_list (workspace_id, list_id),
+    CONSTRAINT fk_share_links_list FOREIGN KEY (list_id) REFERENCES lists (id) ON DELETE CASCADE
+);
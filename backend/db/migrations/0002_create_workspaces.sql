@@ -0,0 +1,21 @@
+-- ワークスペースと、その所属ユーザー。
+-- リクエストのワークスペースは、所属しているユーザーにのみ解決する
+CREATE TABLE workspaces (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    -- サブドメインで指定するための識別子
+    slug VARCHAR(63) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_workspaces_slug (slug)
+);
+
+CREATE TABLE workspace_members (
+    workspace_id INT NOT NULL,
+    user_id INT NOT NULL,
+    -- メンションで指定する名前
+    username VARCHAR(64) NOT NULL,
+    PRIMARY KEY (workspace_id, user_id),
+    UNIQUE KEY uq_workspace_members_username (workspace_id, username),
+    KEY idx_workspace_members_user (user_id),
+    CONSTRAINT fk_workspace_members_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces (id) ON DELETE CASCADE
+);
@@ -0,0 +1,18 @@
+-- 差分同期のためのTodoの変更の記録。
+-- seqはワークスペースごとにchange_sequencesで採番し、コミットの順序と一致する
+CREATE TABLE change_sequences (
+    workspace_id INT PRIMARY KEY,
+    seq BIGINT NOT NULL
+);
+
+CREATE TABLE todo_changes (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    seq BIGINT NOT NULL,
+    todo_id INT NOT NULL,
+    -- 削除した場合はトゥームストーンとして残す
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    changed_at DATETIME NOT NULL,
+    UNIQUE KEY uq_todo_changes_seq (workspace_id, seq),
+    KEY idx_todo_changes_todo (workspace_id, todo_id)
+);
@@ -0,0 +1,8 @@
+-- ユーザーのメールアドレス。
+-- メールによる通知は、確認済み（verified_atがNULLでない）のユーザー自身のアドレスにのみ送信する
+CREATE TABLE user_emails (
+    user_id INT PRIMARY KEY,
+    email VARCHAR(254) NOT NULL,
+    verified_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,17 @@
+-- Todoの作業時間の記録。ended_atがNULLの記録は実行中のタイマー。
+-- 実行中のタイマーはワークスペースをまたいでユーザーごとに1つまでとし、
+-- 実行中の間だけuser_idを持つrunning_user_idの一意制約で、同時に開始した場合も保証する
+CREATE TABLE time_entries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    todo_id INT NOT NULL,
+    user_id INT NOT NULL,
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME NULL,
+    duration_seconds INT NOT NULL DEFAULT 0,
+    note VARCHAR(200) NOT NULL DEFAULT '',
+    running_user_id INT AS (CASE WHEN ended_at IS NULL THEN user_id END) STORED,
+    UNIQUE KEY uq_time_entries_running (running_user_id),
+    KEY idx_time_entries_todo (workspace_id, todo_id, started_at),
+    KEY idx_time_entries_started (workspace_id, started_at)
+);
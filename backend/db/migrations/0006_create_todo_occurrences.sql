@@ -0,0 +1,9 @@
+-- 完了した繰り返すTodoと、その完了で作成した次の回。
+-- 完了した回ごとに次の回は1つまでとし、完了を取り消して再度完了しても作成し直さない
+CREATE TABLE todo_occurrences (
+    workspace_id INT NOT NULL,
+    todo_id INT NOT NULL,
+    next_todo_id INT NOT NULL,
+    PRIMARY KEY (todo_id),
+    KEY idx_todo_occurrences_workspace (workspace_id, todo_id)
+);
@@ -0,0 +1,55 @@
+-- Todoと、その変更の履歴・監査ログ。
+-- 履歴と監査ログは追記のみで、Todoを削除しても残す
+CREATE TABLE todos (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    is_complete BOOLEAN NOT NULL DEFAULT FALSE,
+    -- 楽観的ロック用のリビジョン番号。作成時は1から始める
+    revision INT NOT NULL DEFAULT 1,
+    list_id INT NULL,
+    due_at DATETIME NULL,
+    -- 繰り返しのルール（RRULE）。繰り返さない場合は空文字列
+    recurrence VARCHAR(255) NOT NULL DEFAULT '',
+    time_zone VARCHAR(64) NOT NULL DEFAULT '',
+    -- メモ（Markdown）。64KiBまで受け付けるため、TEXTでは足りない
+    notes MEDIUMTEXT NOT NULL,
+    -- ワークフローのステータス名。ワークフローと同じく大文字と小文字を区別する
+    status VARCHAR(30) COLLATE utf8mb4_bin NOT NULL DEFAULT '',
+    estimate INT NULL,
+    milestone_id INT NULL,
+    KEY idx_todos_list (workspace_id, list_id),
+    KEY idx_todos_milestone (workspace_id, milestone_id),
+    KEY idx_todos_due (workspace_id, is_complete, due_at),
+    -- 日本語を分かち書きせずに検索できるよう、ngramパーサーを使用する
+    FULLTEXT KEY ft_todos_title_notes (title, notes) WITH PARSER ngram
+);
+
+CREATE TABLE todo_revisions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    todo_id INT NOT NULL,
+    revision INT NOT NULL,
+    -- そのリビジョンのTodoの内容。todosの項目のみを記録する
+    snapshot_json JSON NOT NULL,
+    actor_id INT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE KEY uq_todo_revisions_revision (todo_id, revision),
+    KEY idx_todo_revisions_workspace (workspace_id, todo_id, revision)
+);
+
+CREATE TABLE audit_logs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    -- 匿名ユーザーの操作はNULL
+    actor_id INT NULL,
+    action VARCHAR(16) NOT NULL,
+    todo_id INT NOT NULL,
+    -- 作成時の変更前と削除時の変更後はNULL
+    before_json JSON NULL,
+    after_json JSON NULL,
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    KEY idx_audit_logs_todo (workspace_id, todo_id, id),
+    KEY idx_audit_logs_created (workspace_id, created_at)
+);
@@ -0,0 +1,68 @@
+-- Todoに属するチェックリスト・タグ・担当者・依存関係・コメント・添付ファイル。
+-- Todoを削除する際に、同じトランザクションでまとめて削除する
+CREATE TABLE checklist_items (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    todo_id INT NOT NULL,
+    text VARCHAR(200) NOT NULL,
+    is_checked BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Todoの中での並び順（0から）
+    position INT NOT NULL,
+    KEY idx_checklist_items_todo (workspace_id, todo_id, position)
+);
+
+CREATE TABLE todo_tags (
+    workspace_id INT NOT NULL,
+    todo_id INT NOT NULL,
+    -- 小文字に揃えて保存する。アクセントなどの違いを同じタグとみなさないよう、バイナリで比較する
+    tag VARCHAR(50) COLLATE utf8mb4_bin NOT NULL,
+    PRIMARY KEY (todo_id, tag),
+    KEY idx_todo_tags_tag (workspace_id, tag)
+);
+
+CREATE TABLE todo_assignees (
+    workspace_id INT NOT NULL,
+    todo_id INT NOT NULL,
+    user_id INT NOT NULL,
+    PRIMARY KEY (todo_id, user_id),
+    KEY idx_todo_assignees_user (workspace_id, user_id)
+);
+
+-- todo_idのTodoは、blocker_idのTodoが完了するまで完了できない
+CREATE TABLE todo_dependencies (
+    workspace_id INT NOT NULL,
+    todo_id INT NOT NULL,
+    blocker_id INT NOT NULL,
+    PRIMARY KEY (todo_id, blocker_id),
+    KEY idx_todo_dependencies_blocker (workspace_id, blocker_id)
+);
+
+CREATE TABLE comments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    todo_id INT NOT NULL,
+    author_id INT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    KEY idx_comments_todo (workspace_id, todo_id, id)
+);
+
+-- 内容はSHA-256のハッシュ値をキーとしてストレージに保存し、同じ内容は複数の添付ファイルで共有する
+CREATE TABLE attachments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    todo_id INT NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    blob_key CHAR(64) NOT NULL,
+    -- サムネイルを作成しない形式の場合は空文字列
+    thumbnail_status VARCHAR(16) NOT NULL DEFAULT '',
+    thumbnail_key VARCHAR(64) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_attachments_todo (workspace_id, todo_id),
+    KEY idx_attachments_blob (blob_key),
+    KEY idx_attachments_thumbnail (thumbnail_key),
+    KEY idx_attachments_thumbnail_status (thumbnail_status, created_at)
+);
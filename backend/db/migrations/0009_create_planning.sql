@@ -0,0 +1,41 @@
+-- マイルストーン、リストごとのワークフロー、ユーザーごとのスマートリスト
+CREATE TABLE milestones (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    KEY idx_milestones_workspace (workspace_id, start_date)
+);
+
+-- ワークフローを設定していないリストは、既定のワークフローを使用する
+CREATE TABLE workflow_states (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    list_id INT NOT NULL,
+    -- ステータス名は大文字と小文字を区別して検証するため、バイナリで比較する
+    name VARCHAR(30) COLLATE utf8mb4_bin NOT NULL,
+    is_done BOOLEAN NOT NULL,
+    position INT NOT NULL,
+    UNIQUE KEY uq_workflow_states_name (workspace_id, list_id, name)
+);
+
+CREATE TABLE workflow_transitions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    list_id INT NOT NULL,
+    from_status VARCHAR(30) COLLATE utf8mb4_bin NOT NULL,
+    to_status VARCHAR(30) COLLATE utf8mb4_bin NOT NULL,
+    UNIQUE KEY uq_workflow_transitions (workspace_id, list_id, from_status, to_status)
+);
+
+-- 名前はユーザーごとに一意で、重複した場合は登録時に409を返す
+CREATE TABLE smart_lists (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    user_id INT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    filter TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE KEY uq_smart_lists_name (workspace_id, user_id, name)
+);
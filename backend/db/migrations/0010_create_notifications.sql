@@ -0,0 +1,84 @@
+-- 変更のイベントの送信と、Webhook・リマインダー・ダイジェストによる通知
+
+-- 変更と同じトランザクションで記録し、コミット後に中継するイベント。
+-- dedup_idは購読者が重複を除くためのIDで、Webhookの配信の一意制約にも使う
+CREATE TABLE outbox (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    dedup_id CHAR(32) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    published_at DATETIME NULL,
+    UNIQUE KEY uq_outbox_dedup (dedup_id),
+    KEY idx_outbox_published (published_at, id)
+);
+
+-- Webhookは登録したユーザーのものとし、削除後も配信の記録のために論理削除で残す
+CREATE TABLE webhooks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    user_id INT NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    -- 配信するイベントの種類（カンマ区切り）
+    event_types VARCHAR(1024) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    created_at DATETIME NOT NULL,
+    deleted_at DATETIME NULL,
+    KEY idx_webhooks_workspace (workspace_id, deleted_at)
+);
+
+-- 同じイベントを再度中継しても、(webhook_id, dedup_id)の一意制約により重複して配信しない
+CREATE TABLE webhook_deliveries (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    webhook_id INT NOT NULL,
+    dedup_id CHAR(32) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_status_code INT NULL,
+    last_error VARCHAR(255) NOT NULL DEFAULT '',
+    next_attempt_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE KEY uq_webhook_deliveries_dedup (webhook_id, dedup_id),
+    KEY idx_webhook_deliveries_pending (status, next_attempt_at)
+);
+
+-- リマインダーは複数のプロセスで送信しても重複しないよう、リースを取得してから送信する
+CREATE TABLE reminders (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    todo_id INT NOT NULL,
+    user_id INT NOT NULL,
+    channel VARCHAR(16) NOT NULL,
+    target VARCHAR(254) NOT NULL DEFAULT '',
+    remind_at DATETIME NOT NULL,
+    next_attempt_at DATETIME NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    sent_at DATETIME NULL,
+    last_error VARCHAR(255) NOT NULL DEFAULT '',
+    lease_token CHAR(32) NULL,
+    lease_expires_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    KEY idx_reminders_todo (workspace_id, todo_id),
+    KEY idx_reminders_pending (status, next_attempt_at),
+    KEY idx_reminders_lease (lease_token)
+);
+
+-- ダイジェストの配信設定。送信時刻と送信済みの日付は、ユーザーのタイムゾーンでの文字列として保存する
+CREATE TABLE digest_preferences (
+    workspace_id INT NOT NULL,
+    user_id INT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    email VARCHAR(254) NOT NULL DEFAULT '',
+    -- HH:MM
+    send_time CHAR(5) NOT NULL,
+    time_zone VARCHAR(64) NOT NULL,
+    -- YYYY-MM-DD
+    last_sent_on CHAR(10) NULL,
+    PRIMARY KEY (workspace_id, user_id),
+    KEY idx_digest_preferences_enabled (enabled)
+);
@@ -1,7 +1,65 @@
+type ChecklistItem = {
+  id: number;
+  text: string;
+  is_checked: boolean;
+  position: number;
+};
+
+type Checklist = {
+  items: ChecklistItem[];
+  progress: {
+    checked: number;
+    total: number;
+    ratio: number;
+  };
+};
+
+type Attachment = {
+  id: number;
+  todo_id: number;
+  filename: string;
+  content_type: string;
+  size: number;
+  sha256: string;
+  thumbnail_status?: "pending" | "ready" | "failed";
+  thumbnail_url?: string;
+  created_at: string;
+};
+
+type Comment = {
+  id: number;
+  todo_id: number;
+  author_id: number;
+  body: string;
+  created_at: string;
+  updated_at: string;
+};
+
+type Activity = {
+  type: "comment" | "created" | "updated" | "completed" | "reopened" | "reverted" | "deleted";
+  actor_id: number | null;
+  comment?: Comment;
+  changes?: string[];
+  created_at: string;
+};
+
 type Data = {
   id: number;
   title: string;
   is_complete: boolean;
+  status?: string;
+  estimate?: number;
+  revision: number;
+  list_id: number | null;
+  milestone_id?: number;
+  due_at: string | null;
+  recurrence?: string;
+  time_zone?: string;
+  notes?: string;
+  notes_html?: string;
+  checklist?: Checklist;
+  assignees?: number[];
+  time_spent?: number;
 };
 
 type TodoResponse = {
@@ -22,4 +80,4 @@ type TodosResponse = {
   };
 };
 
-export type { Data, TodoResponse, TodosResponse };
+export type { Activity, Attachment, Checklist, ChecklistItem, Comment, Data, TodoResponse, TodosResponse };